@@ -0,0 +1,27 @@
+package annotate
+
+import (
+	"testing"
+
+	"github.com/lucasepe/kube/internal/metamap"
+)
+
+func TestValidate(t *testing.T) {
+	validate := func(o Opts) error {
+		return metamap.Validate(field, o.toMetamap())
+	}
+
+	// annotation values are free form and keys are case insensitive
+	if err := validate(Opts{Annotations: map[string]string{"example.com/Owner": "team a, on call"}}); err != nil {
+		t.Fatal(err)
+	}
+	for _, o := range []Opts{
+		{Annotations: map[string]string{"bad key": "x"}},
+		{Annotations: map[string]string{"owner": "x"}, Remove: []string{"owner"}},
+		{Remove: []string{"bad key"}},
+	} {
+		if err := validate(o); err == nil {
+			t.Errorf("expected an error for %+v", o)
+		}
+	}
+}
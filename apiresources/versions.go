@@ -0,0 +1,61 @@
+package apiresources
+
+import (
+	"sort"
+
+	kubeutil "github.com/lucasepe/kube/util"
+)
+
+// GroupVersion is a group/version served by the cluster.
+type GroupVersion struct {
+	Group   string
+	Version string
+	// GroupVersion is "group/version" (or just "version" for the core group).
+	GroupVersion string
+	// Preferred is true if this is the preferred version of the group.
+	Preferred bool
+}
+
+// Versions returns all the group/versions served by the cluster,
+// sorted by group/version (kubectl api-versions).
+func Versions(f kubeutil.Factory) ([]GroupVersion, error) {
+	discoveryClient, err := f.ToDiscoveryClient()
+	if err != nil {
+		return nil, err
+	}
+	// Always request fresh data from the server
+	discoveryClient.Invalidate()
+
+	groups, err := discoveryClient.ServerGroups()
+	if err != nil {
+		return nil, err
+	}
+
+	res := []GroupVersion{}
+	for _, g := range groups.Groups {
+		for _, v := range g.Versions {
+			res = append(res, GroupVersion{
+				Group:        g.Name,
+				Version:      v.Version,
+				GroupVersion: v.GroupVersion,
+				Preferred:    v.GroupVersion == g.PreferredVersion.GroupVersion,
+			})
+		}
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].GroupVersion < res[j].GroupVersion
+	})
+
+	return res, nil
+}
+
+// HasVersion returns true if the group/version (e.g. "apps/v1") is served.
+func HasVersion(versions []GroupVersion, groupVersion string) bool {
+	for _, gv := range versions {
+		if gv.GroupVersion == groupVersion {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,243 @@
+package apply
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	kubeutil "github.com/lucasepe/kube/util"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/cli-runtime/pkg/resource"
+)
+
+const (
+	// DefaultFieldManager is the field manager used when none is specified.
+	DefaultFieldManager = "kube"
+	// ApplySetLabel marks the objects applied as part of Opts.ApplySet.
+	ApplySetLabel = "applyset.kubernetes.io/part-of"
+)
+
+// Opts is a set of options that allows you to apply manifests using server-side apply.
+type Opts struct {
+	// Filenames are files, directories or URLs containing the manifests.
+	Filenames []string
+	Recursive bool
+	// Kustomize is a kustomization directory whose output is used as manifests.
+	Kustomize string
+	// Readers are streams of YAML or JSON manifests.
+	Readers []io.Reader
+	// Objects are applied as they are.
+	Objects []*unstructured.Unstructured
+
+	// Namespace is used for the namespaced objects without a namespace
+	// (defaults to the namespace of the current context).
+	Namespace string
+	// EnforceNamespace fails if an object declares a different namespace.
+	EnforceNamespace bool
+
+	// FieldManager is the name of the actor owning the applied fields (default "kube");
+	// it is required, distinct from the default, to prune.
+	FieldManager string
+	// Force takes the ownership of the fields managed by someone else, instead of failing with a conflict.
+	Force bool
+	// DryRun asks the server to process the request without persisting it.
+	DryRun bool
+
+	// ApplySet labels the applied objects with ApplySetLabel set to this
+	// name, and restricts pruning to the objects with the same label.
+	ApplySet string
+
+	// Prune deletes the objects previously applied by the same field manager
+	// that are missing from the current manifests. It requires FieldManager
+	// and PruneSelector or ApplySet.
+	Prune bool
+	// PruneSelector is the label selector of the objects candidates to be pruned.
+	PruneSelector string
+	// PruneAllowlist are the kinds candidates to be pruned (defaults to DefaultPruneAllowlist).
+	PruneAllowlist []schema.GroupVersionKind
+}
+
+// Result holds the outcome of Do.
+type Result struct {
+	// Applied are the objects as persisted by the server.
+	Applied []*unstructured.Unstructured
+	// Pruned are the deleted objects (when Opts.Prune is set).
+	Pruned []*unstructured.Unstructured
+}
+
+func (o *Opts) validate() error {
+	if !o.Prune {
+		return nil
+	}
+	if len(o.FieldManager) == 0 || o.FieldManager == DefaultFieldManager {
+		return fmt.Errorf("pruning requires a field manager other than %q", DefaultFieldManager)
+	}
+	if len(o.PruneSelector) == 0 && len(o.ApplySet) == 0 {
+		return fmt.Errorf("pruning requires a label selector or an apply set")
+	}
+	return nil
+}
+
+// Do applies the manifests with server-side apply. A failure does not stop
+// the other objects; all the errors are returned at the end. Pruning is
+// skipped if any object failed to be applied.
+func Do(f kubeutil.Factory, o Opts) (*Result, error) {
+	return DoContext(context.Background(), f, o)
+}
+
+// DoContext is like Do but stops when the context is cancelled.
+func DoContext(ctx context.Context, f kubeutil.Factory, o Opts) (*Result, error) {
+	if err := o.validate(); err != nil {
+		return nil, err
+	}
+	if len(o.FieldManager) == 0 {
+		o.FieldManager = DefaultFieldManager
+	}
+	if len(o.Namespace) == 0 {
+		ns, enforce, err := f.ToRawKubeConfigLoader().Namespace()
+		if err != nil {
+			return nil, err
+		}
+		o.Namespace, o.EnforceNamespace = ns, o.EnforceNamespace || enforce
+	}
+
+	infos, err := loadInfos(f, o)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &Result{
+		Applied: []*unstructured.Unstructured{},
+		Pruned:  []*unstructured.Unstructured{},
+	}
+	errs := []error{}
+	for _, info := range infos {
+		if err := ctx.Err(); err != nil {
+			errs = append(errs, err)
+			break
+		}
+		obj, err := applyOne(info, o)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		res.Applied = append(res.Applied, obj)
+	}
+	if len(errs) > 0 || !o.Prune {
+		return res, utilerrors.NewAggregate(errs)
+	}
+
+	res.Pruned, err = prune(ctx, f, o, infos, res.Applied)
+	return res, err
+}
+
+func applyOne(info *resource.Info, o Opts) (*unstructured.Unstructured, error) {
+	obj := info.Object
+	if len(o.ApplySet) > 0 {
+		obj = obj.DeepCopyObject()
+		acc, err := meta.Accessor(obj)
+		if err != nil {
+			return nil, err
+		}
+		labels := acc.GetLabels()
+		if labels == nil {
+			labels = map[string]string{}
+		}
+		labels[ApplySetLabel] = o.ApplySet
+		acc.SetLabels(labels)
+	}
+
+	data, err := runtime.Encode(unstructured.UnstructuredJSONScheme, obj)
+	if err != nil {
+		return nil, fmt.Errorf("unable to encode %s: %w", info.ObjectName(), err)
+	}
+
+	helper := resource.NewHelper(info.Client, info.Mapping).
+		WithFieldManager(o.FieldManager).
+		DryRun(o.DryRun)
+
+	out, err := helper.Patch(info.Namespace, info.Name, types.ApplyPatchType, data,
+		&metav1.PatchOptions{Force: &o.Force})
+	if err != nil {
+		return nil, fmt.Errorf("unable to apply %s: %w", info.ObjectName(), err)
+	}
+
+	res, ok := out.(*unstructured.Unstructured)
+	if !ok {
+		return nil, fmt.Errorf("unexpected object type %T applying %s", out, info.ObjectName())
+	}
+	return res, nil
+}
+
+func loadInfos(f kubeutil.Factory, o Opts) ([]*resource.Info, error) {
+	infos := []*resource.Info{}
+
+	if len(o.Filenames) > 0 || len(o.Readers) > 0 || len(o.Kustomize) > 0 {
+		b := f.NewBuilder().
+			Unstructured().
+			ContinueOnError().
+			NamespaceParam(o.Namespace).DefaultNamespace().
+			FilenameParam(o.EnforceNamespace, &resource.FilenameOptions{
+				Filenames: o.Filenames,
+				Recursive: o.Recursive,
+				Kustomize: o.Kustomize,
+			}).
+			Flatten()
+		for i, r := range o.Readers {
+			b = b.Stream(r, fmt.Sprintf("reader-%d", i))
+		}
+
+		list, err := b.Do().Infos()
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, list...)
+	}
+
+	if len(o.Objects) == 0 {
+		return infos, nil
+	}
+
+	mapper, err := f.ToRESTMapper()
+	if err != nil {
+		return nil, err
+	}
+	for _, obj := range o.Objects {
+		gvk := obj.GroupVersionKind()
+		mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+		if err != nil {
+			return nil, err
+		}
+		client, err := f.UnstructuredClientForMapping(mapping)
+		if err != nil {
+			return nil, err
+		}
+
+		obj = obj.DeepCopy()
+		if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+			switch ns := obj.GetNamespace(); {
+			case len(ns) == 0:
+				obj.SetNamespace(o.Namespace)
+			case o.EnforceNamespace && ns != o.Namespace:
+				return nil, fmt.Errorf("the namespace of %s/%s (%s) does not match the namespace %q",
+					gvk.Kind, obj.GetName(), ns, o.Namespace)
+			}
+		}
+
+		infos = append(infos, &resource.Info{
+			Client:    client,
+			Mapping:   mapping,
+			Namespace: obj.GetNamespace(),
+			Name:      obj.GetName(),
+			Object:    obj,
+		})
+	}
+
+	return infos, nil
+}
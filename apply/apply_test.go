@@ -0,0 +1,120 @@
+package apply
+
+import (
+	"context"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/cli-runtime/pkg/resource"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+var (
+	configMapGVK = schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}
+	namespaceGVK = schema.GroupVersionKind{Version: "v1", Kind: "Namespace"}
+)
+
+func TestOptsValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		o    Opts
+		ok   bool
+	}{
+		{"no prune", Opts{}, true},
+		{"default field manager", Opts{Prune: true, PruneSelector: "app=web"}, false},
+		{"explicit default field manager", Opts{Prune: true, FieldManager: DefaultFieldManager, ApplySet: "web"}, false},
+		{"no selector", Opts{Prune: true, FieldManager: "ci"}, false},
+		{"selector", Opts{Prune: true, FieldManager: "ci", PruneSelector: "app=web"}, true},
+		{"apply set", Opts{Prune: true, FieldManager: "ci", ApplySet: "web"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.o.validate(); (err == nil) != tt.ok {
+				t.Fatalf("unexpected validation result: %v", err)
+			}
+		})
+	}
+}
+
+func TestPruneSelector(t *testing.T) {
+	got, err := pruneSelector(Opts{PruneSelector: "app=web", ApplySet: "web"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "app=web," + ApplySetLabel + "=web"; got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+
+	if _, err := pruneSelector(Opts{PruneSelector: "app in ("}); err == nil {
+		t.Fatal("expected an error for an invalid selector")
+	}
+}
+
+func newObject(gvk schema.GroupVersionKind, namespace, name, manager string, labels map[string]string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(gvk)
+	obj.SetNamespace(namespace)
+	obj.SetName(name)
+	obj.SetUID(types.UID(name + "-uid"))
+	obj.SetLabels(labels)
+	obj.SetManagedFields([]metav1.ManagedFieldsEntry{
+		{Manager: manager, Operation: metav1.ManagedFieldsOperationApply},
+	})
+	return obj
+}
+
+func TestPrune(t *testing.T) {
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(configMapGVK, meta.RESTScopeNamespace)
+	mapper.Add(namespaceGVK, meta.RESTScopeRoot)
+
+	set := map[string]string{ApplySetLabel: "web"}
+	applied := newObject(configMapGVK, "team-a", "current", "ci", set)
+	objs := []runtime.Object{
+		applied,
+		newObject(configMapGVK, "team-a", "stale", "ci", set),
+		newObject(configMapGVK, "team-a", "foreign", "someone-else", set),
+		newObject(configMapGVK, "team-a", "unlabeled", "ci", nil),
+		newObject(configMapGVK, "team-b", "other-namespace", "ci", set),
+		newObject(namespaceGVK, "", "team-a", "ci", nil),
+	}
+	dyn := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{
+			{Version: "v1", Resource: "configmaps"}: "ConfigMapList",
+			{Version: "v1", Resource: "namespaces"}: "NamespaceList",
+		}, objs...)
+
+	mapping, err := mapper.RESTMapping(configMapGVK.GroupKind(), configMapGVK.Version)
+	if err != nil {
+		t.Fatal(err)
+	}
+	infos := []*resource.Info{{Namespace: "team-a", Name: "current", Mapping: mapping, Object: applied}}
+
+	o := Opts{Prune: true, FieldManager: "ci", ApplySet: "web", Namespace: "team-a"}
+	pruned, err := pruneWithClient(context.Background(), mapper, dyn, o, infos, []*unstructured.Unstructured{applied})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pruned) != 1 || pruned[0].GetName() != "stale" {
+		names := []string{}
+		for _, obj := range pruned {
+			names = append(names, obj.GetNamespace()+"/"+obj.GetName())
+		}
+		t.Fatalf("expected only the stale config map to be pruned, got %v", names)
+	}
+
+	deletes := 0
+	for _, action := range dyn.Actions() {
+		if action.GetVerb() == "delete" {
+			deletes++
+		}
+	}
+	if deletes != 1 {
+		t.Fatalf("expected a delete request, got %d", deletes)
+	}
+}
@@ -0,0 +1,162 @@
+package apply
+
+import (
+	"context"
+	"fmt"
+
+	kubeutil "github.com/lucasepe/kube/util"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/client-go/dynamic"
+)
+
+// DefaultPruneAllowlist are the kinds pruned by default (the same of kubectl).
+var DefaultPruneAllowlist = []schema.GroupVersionKind{
+	{Version: "v1", Kind: "ConfigMap"},
+	{Version: "v1", Kind: "Endpoints"},
+	{Version: "v1", Kind: "Namespace"},
+	{Version: "v1", Kind: "PersistentVolumeClaim"},
+	{Version: "v1", Kind: "PersistentVolume"},
+	{Version: "v1", Kind: "Pod"},
+	{Version: "v1", Kind: "ReplicationController"},
+	{Version: "v1", Kind: "Secret"},
+	{Version: "v1", Kind: "Service"},
+	{Group: "batch", Version: "v1", Kind: "Job"},
+	{Group: "batch", Version: "v1", Kind: "CronJob"},
+	{Group: "networking.k8s.io", Version: "v1", Kind: "Ingress"},
+	{Group: "apps", Version: "v1", Kind: "DaemonSet"},
+	{Group: "apps", Version: "v1", Kind: "Deployment"},
+	{Group: "apps", Version: "v1", Kind: "ReplicaSet"},
+	{Group: "apps", Version: "v1", Kind: "StatefulSet"},
+}
+
+// prune deletes the objects matching the prune selector (and the apply
+// set) that were applied by the same field manager and are not part of
+// the applied set. Only the namespaces touched by the applied objects
+// are visited.
+func prune(ctx context.Context, f kubeutil.Factory, o Opts, infos []*resource.Info, applied []*unstructured.Unstructured) ([]*unstructured.Unstructured, error) {
+	mapper, err := f.ToRESTMapper()
+	if err != nil {
+		return nil, err
+	}
+	dyn, err := f.DynamicClient()
+	if err != nil {
+		return nil, err
+	}
+	return pruneWithClient(ctx, mapper, dyn, o, infos, applied)
+}
+
+func pruneWithClient(ctx context.Context, mapper meta.RESTMapper, dyn dynamic.Interface, o Opts, infos []*resource.Info, applied []*unstructured.Unstructured) ([]*unstructured.Unstructured, error) {
+	allowlist := o.PruneAllowlist
+	if len(allowlist) == 0 {
+		allowlist = DefaultPruneAllowlist
+	}
+
+	selector, err := pruneSelector(o)
+	if err != nil {
+		return nil, err
+	}
+
+	keep := sets.NewString()
+	for _, obj := range applied {
+		keep.Insert(string(obj.GetUID()))
+	}
+
+	namespaces := sets.NewString()
+	for _, info := range infos {
+		if info.Namespaced() {
+			namespaces.Insert(info.Namespace)
+		}
+	}
+	if namespaces.Len() == 0 {
+		namespaces.Insert(o.Namespace)
+	}
+
+	deleteOptions := metav1.DeleteOptions{}
+	policy := metav1.DeletePropagationBackground
+	deleteOptions.PropagationPolicy = &policy
+	if o.DryRun {
+		deleteOptions.DryRun = []string{metav1.DryRunAll}
+	}
+
+	pruned := []*unstructured.Unstructured{}
+	for _, gvk := range allowlist {
+		mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+		if meta.IsNoMatchError(err) {
+			continue
+		}
+		if err != nil {
+			return pruned, err
+		}
+
+		scopes := []string{metav1.NamespaceNone}
+		if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+			scopes = namespaces.List()
+		}
+
+		for _, ns := range scopes {
+			list, err := dyn.Resource(mapping.Resource).Namespace(ns).List(ctx, metav1.ListOptions{
+				LabelSelector: selector,
+			})
+			if err != nil {
+				return pruned, fmt.Errorf("unable to list %s: %w", mapping.Resource.GroupResource(), err)
+			}
+
+			for i := range list.Items {
+				obj := &list.Items[i]
+				if keep.Has(string(obj.GetUID())) || obj.GetDeletionTimestamp() != nil ||
+					!appliedBy(obj, o.FieldManager) {
+					continue
+				}
+
+				opts := deleteOptions
+				opts.Preconditions = &metav1.Preconditions{UID: uidPtr(obj.GetUID())}
+				err := dyn.Resource(mapping.Resource).Namespace(ns).Delete(ctx, obj.GetName(), opts)
+				if err != nil {
+					return pruned, fmt.Errorf("unable to prune %s %s: %w", mapping.Resource.GroupResource(), obj.GetName(), err)
+				}
+				pruned = append(pruned, obj)
+			}
+		}
+	}
+
+	return pruned, nil
+}
+
+// pruneSelector combines the prune selector with the apply set label.
+func pruneSelector(o Opts) (string, error) {
+	selector, err := labels.Parse(o.PruneSelector)
+	if err != nil {
+		return "", fmt.Errorf("invalid prune selector: %w", err)
+	}
+	if len(o.ApplySet) > 0 {
+		req, err := labels.NewRequirement(ApplySetLabel, selection.Equals, []string{o.ApplySet})
+		if err != nil {
+			return "", fmt.Errorf("invalid apply set: %w", err)
+		}
+		selector = selector.Add(*req)
+	}
+	return selector.String(), nil
+}
+
+// appliedBy returns true if the field manager owns fields of the object
+// through an apply operation.
+func appliedBy(obj *unstructured.Unstructured, manager string) bool {
+	for _, mf := range obj.GetManagedFields() {
+		if mf.Manager == manager && mf.Operation == metav1.ManagedFieldsOperationApply {
+			return true
+		}
+	}
+	return false
+}
+
+func uidPtr(uid types.UID) *types.UID {
+	return &uid
+}
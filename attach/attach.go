@@ -0,0 +1,160 @@
+package attach
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/lucasepe/kube/scheme"
+	kubeutil "github.com/lucasepe/kube/util"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+const (
+	defaultPodAttachTimeout = 60 * time.Second
+)
+
+// Opts is a set of options that allows you to attach to a running container.
+type Opts struct {
+	Namespace string
+	PodName   string
+	// Object is a pod or a workload (the first of its pods is used);
+	// when set, PodName is ignored.
+	Object runtime.Object
+	// Container defaults to the one named by the default-container
+	// annotation or the first container of the pod.
+	Container string
+
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+	// TTY attaches to the container terminal (if the container has one);
+	// a Stdin that is a terminal is put in raw mode for the whole session.
+	TTY bool
+	// TerminalSizeQueue feeds the terminal resize events; when nil and
+	// Stdout is a terminal, its size changes are monitored automatically.
+	TerminalSizeQueue remotecommand.TerminalSizeQueue
+
+	GetPodTimeout time.Duration
+}
+
+// Do attaches to the standard streams of a running container.
+// Stdin and TTY are disabled if the container does not support them.
+func Do(f kubeutil.Factory, o Opts) error {
+	return DoContext(context.Background(), f, o)
+}
+
+// DoContext is like Do but stops when the context is cancelled.
+func DoContext(ctx context.Context, f kubeutil.Factory, o Opts) error {
+	if o.GetPodTimeout <= 0 {
+		o.GetPodTimeout = defaultPodAttachTimeout
+	}
+	if len(o.Namespace) == 0 {
+		ns, _, err := f.ToRawKubeConfigLoader().Namespace()
+		if err != nil {
+			return err
+		}
+		o.Namespace = ns
+	}
+
+	cli, err := f.KubernetesClientSet()
+	if err != nil {
+		return err
+	}
+
+	pod, attachOptions, err := target(ctx, cli.CoreV1(), &o)
+	if err != nil {
+		return err
+	}
+
+	req := cli.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Name(pod.Name).
+		Namespace(pod.Namespace).
+		SubResource("attach").
+		VersionedParams(attachOptions, scheme.ParameterCodec)
+
+	cfg, err := f.ToRESTConfig()
+	if err != nil {
+		return err
+	}
+
+	executor, err := remotecommand.NewSPDYExecutor(cfg, "POST", req.URL())
+	if err != nil {
+		return err
+	}
+
+	streamOptions := remotecommand.StreamOptions{
+		Stdin:  o.Stdin,
+		Stdout: o.Stdout,
+		Stderr: o.Stderr,
+	}
+	if !o.TTY {
+		return executor.Stream(streamOptions)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	streamOptions.Tty = true
+	streamOptions.Stderr = nil
+	streamOptions.TerminalSizeQueue = o.TerminalSizeQueue
+	if streamOptions.TerminalSizeQueue == nil {
+		streamOptions.TerminalSizeQueue = kubeutil.NewTerminalSizeQueue(ctx, o.Stdout)
+	}
+
+	if o.Stdin != nil {
+		restore, err := kubeutil.RawTerminal(o.Stdin)
+		if err != nil {
+			return err
+		}
+		defer restore()
+	}
+
+	return executor.Stream(streamOptions)
+}
+
+// target resolves the pod and the container to attach to, disabling
+// Stdin and TTY in the options if the container does not support them.
+func target(ctx context.Context, pods corev1client.PodsGetter, o *Opts) (*corev1.Pod, *corev1.PodAttachOptions, error) {
+	obj := o.Object
+	if obj == nil {
+		var err error
+		obj, err = pods.Pods(o.Namespace).Get(ctx, o.PodName, metav1.GetOptions{})
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	pod, err := kubeutil.AttachablePodForObjectContext(ctx, pods, obj, o.GetPodTimeout)
+	if err != nil {
+		return nil, nil, err
+	}
+	if kubeutil.IsPodTerminated(pod) {
+		return nil, nil, fmt.Errorf("cannot attach a container in a completed pod; current phase is %s", pod.Status.Phase)
+	}
+
+	container, err := kubeutil.ContainerToAttach(pod, o.Container)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !container.Stdin {
+		o.Stdin = nil
+	}
+	if !container.TTY {
+		o.TTY = false
+	}
+
+	return pod, &corev1.PodAttachOptions{
+		Container: container.Name,
+		Stdin:     o.Stdin != nil,
+		Stdout:    o.Stdout != nil,
+		Stderr:    o.Stderr != nil && !o.TTY,
+		TTY:       o.TTY,
+	}, nil
+}
@@ -0,0 +1,75 @@
+package attach
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func pod(name string, phase corev1.PodPhase, containers ...corev1.Container) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec:       corev1.PodSpec{Containers: containers},
+		Status:     corev1.PodStatus{Phase: phase},
+	}
+}
+
+func TestTarget(t *testing.T) {
+	cli := fake.NewSimpleClientset(pod("web-0", corev1.PodRunning,
+		corev1.Container{Name: "app", Stdin: true, TTY: true}))
+
+	o := &Opts{
+		Namespace: "default",
+		PodName:   "web-0",
+		Stdin:     strings.NewReader(""),
+		Stdout:    io.Discard,
+		Stderr:    io.Discard,
+		TTY:       true,
+	}
+	_, opts, err := target(context.Background(), cli.CoreV1(), o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.Container != "app" || !opts.Stdin || !opts.Stdout || opts.Stderr || !opts.TTY {
+		t.Fatalf("unexpected options %+v", opts)
+	}
+	if o.Stdin == nil || !o.TTY {
+		t.Fatalf("expected stdin and tty to be kept, got %+v", o)
+	}
+}
+
+func TestTargetNoStdin(t *testing.T) {
+	cli := fake.NewSimpleClientset(pod("web-0", corev1.PodRunning, corev1.Container{Name: "app"}))
+
+	o := &Opts{
+		Namespace: "default",
+		PodName:   "web-0",
+		Stdin:     strings.NewReader(""),
+		Stdout:    io.Discard,
+		Stderr:    io.Discard,
+		TTY:       true,
+	}
+	_, opts, err := target(context.Background(), cli.CoreV1(), o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.Stdin || opts.TTY || !opts.Stderr {
+		t.Fatalf("expected stdin and tty to be disabled, got %+v", opts)
+	}
+	if o.Stdin != nil || o.TTY {
+		t.Fatalf("expected the options to be updated, got %+v", o)
+	}
+}
+
+func TestTargetCompleted(t *testing.T) {
+	cli := fake.NewSimpleClientset(pod("job-0", corev1.PodFailed, corev1.Container{Name: "app"}))
+
+	if _, _, err := target(context.Background(), cli.CoreV1(), &Opts{Namespace: "default", PodName: "job-0"}); err == nil {
+		t.Fatal("expected an error for a completed pod")
+	}
+}
@@ -0,0 +1,187 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+
+	kubeutil "github.com/lucasepe/kube/util"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Opts is a set of options that allows you to check
+// if the current user can perform an action.
+type Opts struct {
+	// Verb is the action (e.g. "get", "list", "create", "delete" or "*").
+	Verb string
+	// Resource is the resource type, optionally qualified by group
+	// (e.g. "pods", "deployments.apps"); short names are expanded.
+	Resource    string
+	Subresource string
+	// Name restricts the check to a single object.
+	Name string
+	// NonResourceURL is checked instead of Resource (e.g. "/healthz").
+	NonResourceURL string
+
+	// Namespace defaults to the namespace of the current context.
+	Namespace     string
+	AllNamespaces bool
+}
+
+// Access is the outcome of an access review.
+type Access struct {
+	Allowed bool
+	// Denied is true if the action is explicitly denied; when both Allowed
+	// and Denied are false no authorizer has an opinion on the action.
+	Denied bool
+	// Reason is the (optional) explanation of the decision.
+	Reason string
+	// EvaluationError is set when the authorizer failed to evaluate
+	// some rule; the decision could then be incomplete.
+	EvaluationError string
+}
+
+// CanI checks, using a SelfSubjectAccessReview, if the current
+// user can perform the action (kubectl auth can-i).
+func CanI(f kubeutil.Factory, o Opts) (*Access, error) {
+	return CanIContext(context.Background(), f, o)
+}
+
+// CanIContext is like CanI but stops when the context is cancelled.
+func CanIContext(ctx context.Context, f kubeutil.Factory, o Opts) (*Access, error) {
+	if len(o.Verb) == 0 {
+		return nil, fmt.Errorf("you must specify a verb")
+	}
+	if len(o.Resource) == 0 && len(o.NonResourceURL) == 0 {
+		return nil, fmt.Errorf("you must specify a resource or a non resource URL")
+	}
+
+	if !o.AllNamespaces && len(o.Namespace) == 0 {
+		ns, _, err := f.ToRawKubeConfigLoader().Namespace()
+		if err != nil {
+			return nil, err
+		}
+		o.Namespace = ns
+	}
+	if o.AllNamespaces {
+		o.Namespace = ""
+	}
+
+	review := &authorizationv1.SelfSubjectAccessReview{}
+	if len(o.NonResourceURL) > 0 {
+		review.Spec.NonResourceAttributes = &authorizationv1.NonResourceAttributes{
+			Verb: o.Verb,
+			Path: o.NonResourceURL,
+		}
+	} else {
+		mapper, err := f.ToRESTMapper()
+		if err != nil {
+			return nil, err
+		}
+		gvr := resolveResource(mapper, o.Resource)
+		review.Spec.ResourceAttributes = &authorizationv1.ResourceAttributes{
+			Namespace:   o.Namespace,
+			Verb:        o.Verb,
+			Group:       gvr.Group,
+			Resource:    gvr.Resource,
+			Subresource: o.Subresource,
+			Name:        o.Name,
+		}
+	}
+
+	cli, err := f.KubernetesClientSet()
+	if err != nil {
+		return nil, err
+	}
+
+	return accessReview(ctx, cli, review)
+}
+
+func accessReview(ctx context.Context, cli kubernetes.Interface, review *authorizationv1.SelfSubjectAccessReview) (*Access, error) {
+	res, err := cli.AuthorizationV1().SelfSubjectAccessReviews().
+		Create(ctx, review, metav1.CreateOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Access{
+		Allowed:         res.Status.Allowed,
+		Denied:          res.Status.Denied,
+		Reason:          res.Status.Reason,
+		EvaluationError: res.Status.EvaluationError,
+	}, nil
+}
+
+// Rules are the actions the current user can perform in a namespace.
+type Rules struct {
+	Namespace        string
+	ResourceRules    []authorizationv1.ResourceRule
+	NonResourceRules []authorizationv1.NonResourceRule
+	// Incomplete is true if the authorizer does not support
+	// listing the rules or failed to evaluate some of them.
+	Incomplete      bool
+	EvaluationError string
+}
+
+// ListRules returns, using a SelfSubjectRulesReview, the rules the current
+// user is allowed in the namespace (kubectl auth can-i --list).
+// An empty namespace means the namespace of the current context.
+func ListRules(f kubeutil.Factory, namespace string) (*Rules, error) {
+	return ListRulesContext(context.Background(), f, namespace)
+}
+
+// ListRulesContext is like ListRules but stops when the context is cancelled.
+func ListRulesContext(ctx context.Context, f kubeutil.Factory, namespace string) (*Rules, error) {
+	if len(namespace) == 0 {
+		ns, _, err := f.ToRawKubeConfigLoader().Namespace()
+		if err != nil {
+			return nil, err
+		}
+		namespace = ns
+	}
+
+	cli, err := f.KubernetesClientSet()
+	if err != nil {
+		return nil, err
+	}
+
+	return rulesReview(ctx, cli, namespace)
+}
+
+func rulesReview(ctx context.Context, cli kubernetes.Interface, namespace string) (*Rules, error) {
+	review := &authorizationv1.SelfSubjectRulesReview{
+		Spec: authorizationv1.SelfSubjectRulesReviewSpec{Namespace: namespace},
+	}
+	res, err := cli.AuthorizationV1().SelfSubjectRulesReviews().
+		Create(ctx, review, metav1.CreateOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Rules{
+		Namespace:        namespace,
+		ResourceRules:    res.Status.ResourceRules,
+		NonResourceRules: res.Status.NonResourceRules,
+		Incomplete:       res.Status.Incomplete,
+		EvaluationError:  res.Status.EvaluationError,
+	}, nil
+}
+
+// resolveResource expands the resource (plural, singular, short name,
+// optionally with the group) to its group resource. Wildcards and
+// unknown resources are passed as they are.
+func resolveResource(mapper meta.RESTMapper, resource string) schema.GroupVersionResource {
+	gr := schema.ParseGroupResource(resource)
+	if gr.Resource == "*" {
+		return gr.WithVersion("")
+	}
+
+	gvr, err := mapper.ResourceFor(gr.WithVersion(""))
+	if err != nil {
+		return gr.WithVersion("")
+	}
+	return gvr
+}
@@ -0,0 +1,105 @@
+package auth
+
+import (
+	"context"
+	"testing"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+func TestResolveResource(t *testing.T) {
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Pod"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}, meta.RESTScopeNamespace)
+
+	tests := map[string]schema.GroupVersionResource{
+		"pods":                {Version: "v1", Resource: "pods"},
+		"pod":                 {Version: "v1", Resource: "pods"},
+		"deployments.apps":    {Group: "apps", Version: "v1", Resource: "deployments"},
+		"*":                   {Resource: "*"},
+		"widgets.example.com": {Group: "example.com", Resource: "widgets"},
+	}
+	for resource, want := range tests {
+		if got := resolveResource(mapper, resource); got != want {
+			t.Errorf("%s: expected %v, got %v", resource, want, got)
+		}
+	}
+}
+
+func TestAccessReview(t *testing.T) {
+	cli := fake.NewSimpleClientset()
+	cli.PrependReactor("create", "selfsubjectaccessreviews", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		review := action.(clienttesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview)
+		ra := review.Spec.ResourceAttributes
+		switch {
+		case ra.Verb == "get" && ra.Namespace == "default":
+			review.Status.Allowed = true
+		case ra.Verb == "delete":
+			review.Status.Denied = true
+			review.Status.Reason = "forbidden by policy"
+		}
+		return true, review, nil
+	})
+
+	tests := []struct {
+		attrs   authorizationv1.ResourceAttributes
+		allowed bool
+		denied  bool
+	}{
+		{authorizationv1.ResourceAttributes{Verb: "get", Resource: "pods", Namespace: "default"}, true, false},
+		{authorizationv1.ResourceAttributes{Verb: "get", Resource: "pods", Namespace: "kube-system"}, false, false},
+		{authorizationv1.ResourceAttributes{Verb: "delete", Resource: "pods", Namespace: "default"}, false, true},
+	}
+	for _, tt := range tests {
+		attrs := tt.attrs
+		review := &authorizationv1.SelfSubjectAccessReview{
+			Spec: authorizationv1.SelfSubjectAccessReviewSpec{ResourceAttributes: &attrs},
+		}
+		access, err := accessReview(context.Background(), cli, review)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if access.Allowed != tt.allowed || access.Denied != tt.denied {
+			t.Errorf("%+v: expected allowed=%t denied=%t, got %+v", tt.attrs, tt.allowed, tt.denied, access)
+		}
+		if access.Denied && access.Reason != "forbidden by policy" {
+			t.Errorf("expected the reason, got %q", access.Reason)
+		}
+	}
+}
+
+func TestRulesReview(t *testing.T) {
+	cli := fake.NewSimpleClientset()
+	cli.PrependReactor("create", "selfsubjectrulesreviews", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		review := action.(clienttesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectRulesReview)
+		if review.Spec.Namespace != "team-a" {
+			t.Errorf("unexpected namespace %q", review.Spec.Namespace)
+		}
+		review.Status.ResourceRules = []authorizationv1.ResourceRule{
+			{Verbs: []string{"get", "list"}, Resources: []string{"pods"}},
+		}
+		review.Status.Incomplete = true
+		return true, review, nil
+	})
+
+	rules, err := rulesReview(context.Background(), cli, "team-a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rules.Namespace != "team-a" || len(rules.ResourceRules) != 1 || !rules.Incomplete {
+		t.Fatalf("unexpected rules %+v", rules)
+	}
+}
+
+func TestCanIRequiredArgs(t *testing.T) {
+	for _, o := range []Opts{{Resource: "pods"}, {Verb: "get"}} {
+		if _, err := CanI(nil, o); err == nil {
+			t.Errorf("expected an error for %+v", o)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package auth
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"strings"
+
+	kubeutil "github.com/lucasepe/kube/util"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	restclient "k8s.io/client-go/rest"
+)
+
+const (
+	// SourceSelfSubjectReview means the user info was returned by the API server.
+	SourceSelfSubjectReview = "SelfSubjectReview"
+	// SourceKubeconfig means the user info was guessed from the credentials.
+	SourceKubeconfig = "kubeconfig"
+)
+
+// selfSubjectReviewVersions are tried in order; the API is
+// not served by the clusters older than v1.26.
+var selfSubjectReviewVersions = []string{"v1", "v1beta1", "v1alpha1"}
+
+// UserInfo describes the user of the active credentials.
+type UserInfo struct {
+	Username string
+	UID      string
+	Groups   []string
+	Extra    map[string][]string
+	// Source tells where the info comes from: SourceSelfSubjectReview
+	// or, on older clusters, SourceKubeconfig (best effort, it may be
+	// partial or empty, e.g. for exec or auth provider plugins).
+	Source string
+}
+
+// WhoAmI returns the user the API server authenticates with the active
+// credentials, using the authentication.k8s.io SelfSubjectReview API.
+// On clusters not serving it, the user is inferred from the credentials
+// (basic auth username, client certificate subject or token claims).
+func WhoAmI(f kubeutil.Factory) (*UserInfo, error) {
+	return WhoAmIContext(context.Background(), f)
+}
+
+// WhoAmIContext is like WhoAmI but stops when the context is cancelled.
+func WhoAmIContext(ctx context.Context, f kubeutil.Factory) (*UserInfo, error) {
+	cli, err := f.KubernetesClientSet()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, version := range selfSubjectReviewVersions {
+		body := fmt.Sprintf(`{"kind":"SelfSubjectReview","apiVersion":"authentication.k8s.io/%s"}`, version)
+		data, err := cli.Discovery().RESTClient().Post().
+			AbsPath("/apis/authentication.k8s.io", version, "selfsubjectreviews").
+			SetHeader("Content-Type", "application/json").
+			Body([]byte(body)).
+			Do(ctx).
+			Raw()
+		if apierrors.IsNotFound(err) || apierrors.IsMethodNotSupported(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		review := struct {
+			Status struct {
+				UserInfo struct {
+					Username string              `json:"username"`
+					UID      string              `json:"uid"`
+					Groups   []string            `json:"groups"`
+					Extra    map[string][]string `json:"extra"`
+				} `json:"userInfo"`
+			} `json:"status"`
+		}{}
+		if err := json.Unmarshal(data, &review); err != nil {
+			return nil, fmt.Errorf("unable to decode the SelfSubjectReview: %w", err)
+		}
+
+		ui := review.Status.UserInfo
+		return &UserInfo{
+			Username: ui.Username,
+			UID:      ui.UID,
+			Groups:   ui.Groups,
+			Extra:    ui.Extra,
+			Source:   SourceSelfSubjectReview,
+		}, nil
+	}
+
+	cfg, err := f.ToRESTConfig()
+	if err != nil {
+		return nil, err
+	}
+	return userFromConfig(cfg)
+}
+
+// userFromConfig infers the user from the credentials of the config.
+func userFromConfig(cfg *restclient.Config) (*UserInfo, error) {
+	res := &UserInfo{Source: SourceKubeconfig}
+
+	if len(cfg.Impersonate.UserName) > 0 {
+		res.Username = cfg.Impersonate.UserName
+		res.UID = cfg.Impersonate.UID
+		res.Groups = cfg.Impersonate.Groups
+		res.Extra = cfg.Impersonate.Extra
+		return res, nil
+	}
+
+	if len(cfg.Username) > 0 {
+		res.Username = cfg.Username
+		return res, nil
+	}
+
+	certData := cfg.CertData
+	if len(certData) == 0 && len(cfg.CertFile) > 0 {
+		data, err := os.ReadFile(cfg.CertFile)
+		if err != nil {
+			return nil, err
+		}
+		certData = data
+	}
+	if len(certData) > 0 {
+		block, _ := pem.Decode(certData)
+		if block == nil {
+			return nil, fmt.Errorf("unable to decode the client certificate")
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse the client certificate: %w", err)
+		}
+		// the same mapping of the API server x509 authenticator
+		res.Username = cert.Subject.CommonName
+		res.Groups = cert.Subject.Organization
+		return res, nil
+	}
+
+	token := cfg.BearerToken
+	if len(token) == 0 && len(cfg.BearerTokenFile) > 0 {
+		data, err := os.ReadFile(cfg.BearerTokenFile)
+		if err != nil {
+			return nil, err
+		}
+		token = strings.TrimSpace(string(data))
+	}
+	if len(token) > 0 {
+		claims, ok := tokenClaims(token)
+		if !ok {
+			// opaque token (e.g. static or bootstrap token)
+			return res, nil
+		}
+		if sa := claims.Kubernetes.ServiceAccount; len(sa.Name) > 0 {
+			ns := claims.Kubernetes.Namespace
+			res.Username = fmt.Sprintf("system:serviceaccount:%s:%s", ns, sa.Name)
+			res.UID = sa.UID
+			res.Groups = sets.NewString("system:serviceaccounts", "system:serviceaccounts:"+ns, "system:authenticated").List()
+			return res, nil
+		}
+		res.Username = claims.Subject
+		return res, nil
+	}
+
+	return res, nil
+}
+
+type jwtClaims struct {
+	Subject    string `json:"sub"`
+	Kubernetes struct {
+		Namespace      string `json:"namespace"`
+		ServiceAccount struct {
+			Name string `json:"name"`
+			UID  string `json:"uid"`
+		} `json:"serviceaccount"`
+	} `json:"kubernetes.io"`
+}
+
+// tokenClaims decodes (without verifying it) the payload of a JWT.
+func tokenClaims(token string) (*jwtClaims, bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return nil, false
+	}
+	claims := &jwtClaims{}
+	if err := json.Unmarshal(payload, claims); err != nil {
+		return nil, false
+	}
+	return claims, true
+}
@@ -0,0 +1,111 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+
+	restclient "k8s.io/client-go/rest"
+)
+
+func clientCert(t *testing.T, cn string, orgs ...string) []byte {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: cn, Organization: orgs},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func jwt(payload string) string {
+	enc := base64.RawURLEncoding.EncodeToString
+	return enc([]byte(`{"alg":"RS256"}`)) + "." + enc([]byte(payload)) + ".signature"
+}
+
+func TestUserFromConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  *restclient.Config
+		want UserInfo
+	}{
+		{
+			name: "impersonation",
+			cfg: &restclient.Config{
+				Username:    "admin",
+				Impersonate: restclient.ImpersonationConfig{UserName: "jane", Groups: []string{"dev"}},
+			},
+			want: UserInfo{Username: "jane", Groups: []string{"dev"}},
+		},
+		{
+			name: "basic auth",
+			cfg:  &restclient.Config{Username: "admin"},
+			want: UserInfo{Username: "admin"},
+		},
+		{
+			name: "client certificate",
+			cfg: &restclient.Config{TLSClientConfig: restclient.TLSClientConfig{
+				CertData: clientCert(t, "jane", "dev", "ops"),
+			}},
+			want: UserInfo{Username: "jane", Groups: []string{"dev", "ops"}},
+		},
+		{
+			name: "service account token",
+			cfg: &restclient.Config{BearerToken: jwt(
+				`{"sub":"system:serviceaccount:ci:deployer","kubernetes.io":{"namespace":"ci","serviceaccount":{"name":"deployer","uid":"1234"}}}`)},
+			want: UserInfo{
+				Username: "system:serviceaccount:ci:deployer",
+				UID:      "1234",
+				Groups:   []string{"system:authenticated", "system:serviceaccounts", "system:serviceaccounts:ci"},
+			},
+		},
+		{
+			name: "oidc token",
+			cfg:  &restclient.Config{BearerToken: jwt(`{"sub":"jane@example.com"}`)},
+			want: UserInfo{Username: "jane@example.com"},
+		},
+		{
+			name: "opaque token",
+			cfg:  &restclient.Config{BearerToken: "abcdef.0123456789abcdef"},
+			want: UserInfo{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := userFromConfig(tt.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.want.Source = SourceKubeconfig
+			if !reflect.DeepEqual(*got, tt.want) {
+				t.Fatalf("expected %+v, got %+v", tt.want, *got)
+			}
+		})
+	}
+}
+
+func TestUserFromConfigInvalidCert(t *testing.T) {
+	cfg := &restclient.Config{TLSClientConfig: restclient.TLSClientConfig{CertData: []byte("not a certificate")}}
+	if _, err := userFromConfig(cfg); err == nil {
+		t.Fatal("expected an error for an invalid certificate")
+	}
+}
@@ -0,0 +1,204 @@
+package bulk
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	kubeutil "github.com/lucasepe/kube/util"
+	"golang.org/x/sync/errgroup"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/util/flowcontrol"
+)
+
+const (
+	defaultConcurrency = 10
+	defaultMaxRetries  = 5
+	initialBackoff     = 200 * time.Millisecond
+	maxBackoff         = 30 * time.Second
+)
+
+// Operation is a single unit of work of a bulk execution.
+type Operation struct {
+	// Name identifies the operation in the report (e.g. "delete pods/web-0").
+	Name string
+	// Run executes the operation. It may be called more than once
+	// when it fails with a conflict or throttling error.
+	Run func(ctx context.Context, f kubeutil.Factory) error
+}
+
+// Result is the outcome of a single operation.
+type Result struct {
+	Name     string
+	Attempts int
+	Duration time.Duration
+	Err      error
+	// Skipped is true if the operation was never attempted
+	// because the execution was aborted.
+	Skipped bool
+}
+
+// Progress is notified after every completed operation.
+type Progress struct {
+	Total     int
+	Completed int
+	Failed    int
+	Last      Result
+}
+
+// Opts is a set of options that allows you to execute many operations.
+type Opts struct {
+	// Concurrency is the max number of operations running at the same time (default 10).
+	Concurrency int
+	// QPS limits the rate of attempts across all the workers; zero means no limit.
+	QPS float32
+	// Burst is the max burst of attempts allowed when QPS is set (default 1).
+	Burst int
+	// MaxRetries is the max number of retries of an operation failing
+	// with a conflict or throttling error (default 5, negative means none).
+	MaxRetries int
+	// StopOnError aborts the pending operations at the first failure.
+	StopOnError bool
+	// OnProgress, if not nil, is invoked (serially) after every operation.
+	OnProgress func(Progress)
+}
+
+// Report is the aggregate outcome of a bulk execution.
+type Report struct {
+	Total     int
+	Succeeded int
+	Failed    int
+	Skipped   int
+	Duration  time.Duration
+	// Results are in the same order of the operations.
+	Results []Result
+}
+
+// Err collects the errors of the failed operations in a
+// single error, or returns nil if no operation failed.
+func (r *Report) Err() error {
+	errs := []error{}
+	for _, res := range r.Results {
+		if res.Err != nil && !res.Skipped {
+			errs = append(errs, fmt.Errorf("%s: %w", res.Name, res.Err))
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// Do executes the operations using a bounded pool of workers, retrying
+// the ones failing with conflict (409) or throttling (429) errors.
+func Do(ctx context.Context, f kubeutil.Factory, ops []Operation, o Opts) *Report {
+	if o.Concurrency <= 0 {
+		o.Concurrency = defaultConcurrency
+	}
+	if o.MaxRetries == 0 {
+		o.MaxRetries = defaultMaxRetries
+	}
+	if o.Burst <= 0 {
+		o.Burst = 1
+	}
+
+	var limiter flowcontrol.RateLimiter
+	if o.QPS > 0 {
+		limiter = flowcontrol.NewTokenBucketRateLimiter(o.QPS, o.Burst)
+		defer limiter.Stop()
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	start := time.Now()
+	report := &Report{Total: len(ops), Results: make([]Result, len(ops))}
+
+	var mu sync.Mutex
+	completed := func(i int, res Result) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		report.Results[i] = res
+		switch {
+		case res.Skipped:
+			report.Skipped++
+			return
+		case res.Err != nil:
+			report.Failed++
+			if o.StopOnError {
+				cancel()
+			}
+		default:
+			report.Succeeded++
+		}
+
+		if o.OnProgress != nil {
+			o.OnProgress(Progress{
+				Total:     report.Total,
+				Completed: report.Succeeded + report.Failed,
+				Failed:    report.Failed,
+				Last:      res,
+			})
+		}
+	}
+
+	g := new(errgroup.Group)
+	g.SetLimit(o.Concurrency)
+	for i, op := range ops {
+		i, op := i, op
+		g.Go(func() error {
+			if err := ctx.Err(); err != nil {
+				completed(i, Result{Name: op.Name, Err: err, Skipped: true})
+				return nil
+			}
+			completed(i, run(ctx, f, op, limiter, o.MaxRetries))
+			return nil
+		})
+	}
+	g.Wait()
+
+	report.Duration = time.Since(start)
+	return report
+}
+
+func run(ctx context.Context, f kubeutil.Factory, op Operation, limiter flowcontrol.RateLimiter, maxRetries int) (res Result) {
+	res.Name = op.Name
+	start := time.Now()
+	defer func() { res.Duration = time.Since(start) }()
+
+	backoff := initialBackoff
+	for {
+		if limiter != nil {
+			if err := limiter.Wait(ctx); err != nil {
+				res.Err = err
+				return res
+			}
+		}
+
+		res.Attempts++
+		res.Err = op.Run(ctx, f)
+		if res.Err == nil || !isRetriable(res.Err) || res.Attempts > maxRetries {
+			return res
+		}
+
+		delay := backoff
+		if seconds, ok := apierrors.SuggestsClientDelay(res.Err); ok {
+			delay = time.Duration(seconds) * time.Second
+		}
+		backoff *= 2
+		if backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+
+		select {
+		case <-ctx.Done():
+			return res
+		case <-time.After(delay):
+		}
+	}
+}
+
+func isRetriable(err error) bool {
+	return apierrors.IsConflict(err) || apierrors.IsTooManyRequests(err) ||
+		apierrors.IsServerTimeout(err) || apierrors.IsTimeout(err)
+}
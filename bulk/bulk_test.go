@@ -0,0 +1,63 @@
+package bulk
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	kubeutil "github.com/lucasepe/kube/util"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestDoRetriesConflicts(t *testing.T) {
+	var calls int32
+	conflicting := Operation{
+		Name: "conflicting",
+		Run: func(context.Context, kubeutil.Factory) error {
+			if atomic.AddInt32(&calls, 1) < 3 {
+				return apierrors.NewConflict(schema.GroupResource{Resource: "pods"}, "web", errors.New("stale"))
+			}
+			return nil
+		},
+	}
+	failing := Operation{
+		Name: "failing",
+		Run: func(context.Context, kubeutil.Factory) error {
+			return errors.New("boom")
+		},
+	}
+
+	progress := 0
+	report := Do(context.Background(), nil, []Operation{conflicting, failing}, Opts{
+		OnProgress: func(Progress) { progress++ },
+	})
+
+	if report.Succeeded != 1 || report.Failed != 1 || progress != 2 {
+		t.Fatalf("unexpected report: %+v (progress %d)", report, progress)
+	}
+	if got := report.Results[0].Attempts; got != 3 {
+		t.Fatalf("expected 3 attempts, got %d", got)
+	}
+	if got := report.Results[1].Attempts; got != 1 {
+		t.Fatalf("expected 1 attempt for a non retriable error, got %d", got)
+	}
+	if report.Err() == nil {
+		t.Fatal("expected an aggregate error")
+	}
+}
+
+func TestDoStopOnError(t *testing.T) {
+	ops := make([]Operation, 5)
+	for i := range ops {
+		ops[i] = Operation{Name: "op", Run: func(context.Context, kubeutil.Factory) error {
+			return errors.New("boom")
+		}}
+	}
+
+	report := Do(context.Background(), nil, ops, Opts{Concurrency: 1, StopOnError: true})
+	if report.Failed != 1 || report.Skipped != 4 {
+		t.Fatalf("expected 1 failed and 4 skipped, got %+v", report)
+	}
+}
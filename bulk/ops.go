@@ -0,0 +1,106 @@
+package bulk
+
+import (
+	"context"
+	"fmt"
+
+	kubeutil "github.com/lucasepe/kube/util"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+)
+
+// Delete returns an operation that deletes the named object.
+// An object that does not exist is not considered a failure.
+func Delete(gvr schema.GroupVersionResource, namespace, name string) Operation {
+	return Operation{
+		Name: fmt.Sprintf("delete %s %s", gvr.GroupResource(), objectKey(namespace, name)),
+		Run: func(ctx context.Context, f kubeutil.Factory) error {
+			dyn, err := f.DynamicClient()
+			if err != nil {
+				return err
+			}
+			err = resourceInterface(dyn, gvr, namespace).Delete(ctx, name, metav1.DeleteOptions{})
+			if apierrors.IsNotFound(err) {
+				return nil
+			}
+			return err
+		},
+	}
+}
+
+// Patch returns an operation that patches the named object.
+func Patch(gvr schema.GroupVersionResource, namespace, name string, pt types.PatchType, data []byte) Operation {
+	return Operation{
+		Name: fmt.Sprintf("patch %s %s", gvr.GroupResource(), objectKey(namespace, name)),
+		Run: func(ctx context.Context, f kubeutil.Factory) error {
+			dyn, err := f.DynamicClient()
+			if err != nil {
+				return err
+			}
+			_, err = resourceInterface(dyn, gvr, namespace).Patch(ctx, name, pt, data, metav1.PatchOptions{})
+			return err
+		},
+	}
+}
+
+// Apply returns an operation that applies the object server-side using
+// the field manager. Namespaced objects without a namespace go in the
+// namespace of the current context.
+func Apply(obj *unstructured.Unstructured, fieldManager string, force bool) Operation {
+	gvk := obj.GroupVersionKind()
+	return Operation{
+		Name: fmt.Sprintf("apply %s %s", gvk.Kind, objectKey(obj.GetNamespace(), obj.GetName())),
+		Run: func(ctx context.Context, f kubeutil.Factory) error {
+			mapper, err := f.ToRESTMapper()
+			if err != nil {
+				return err
+			}
+			mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+			if err != nil {
+				return err
+			}
+
+			namespace := ""
+			if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+				namespace = obj.GetNamespace()
+				if len(namespace) == 0 {
+					if namespace, _, err = f.ToRawKubeConfigLoader().Namespace(); err != nil {
+						return err
+					}
+				}
+			}
+
+			data, err := obj.MarshalJSON()
+			if err != nil {
+				return err
+			}
+
+			dyn, err := f.DynamicClient()
+			if err != nil {
+				return err
+			}
+			_, err = resourceInterface(dyn, mapping.Resource, namespace).Patch(ctx, obj.GetName(),
+				types.ApplyPatchType, data, metav1.PatchOptions{FieldManager: fieldManager, Force: &force})
+			return err
+		},
+	}
+}
+
+func resourceInterface(dyn dynamic.Interface, gvr schema.GroupVersionResource, namespace string) dynamic.ResourceInterface {
+	if len(namespace) == 0 {
+		return dyn.Resource(gvr)
+	}
+	return dyn.Resource(gvr).Namespace(namespace)
+}
+
+func objectKey(namespace, name string) string {
+	if len(namespace) == 0 {
+		return name
+	}
+	return namespace + "/" + name
+}
@@ -0,0 +1,168 @@
+package clusterinfo
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	kubeutil "github.com/lucasepe/kube/util"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/version"
+)
+
+const (
+	// ClusterServiceLabel marks the services reported by kubectl cluster-info.
+	ClusterServiceLabel = "kubernetes.io/cluster-service"
+
+	metricsGroupVersion = "metrics.k8s.io/v1beta1"
+)
+
+// Service is a cluster service reachable through the API server proxy.
+type Service struct {
+	Namespace string
+	Name      string
+	// URL is the API server proxy URL of the service.
+	URL string
+}
+
+// Check is the outcome of a single health check.
+type Check struct {
+	Name string
+	OK   bool
+	// Message is the failure reason, if reported.
+	Message string
+}
+
+// Health is the outcome of a health endpoint (/readyz or /livez).
+type Health struct {
+	OK     bool
+	Checks []Check
+	// Err is set if the endpoint could not be queried.
+	Err error
+}
+
+// Report describes the cluster.
+type Report struct {
+	// Host is the API server endpoint.
+	Host    string
+	Version *version.Info
+	// Services are the kube-system services labeled as cluster services.
+	Services []Service
+	// DNS is the cluster DNS service, nil if not found.
+	DNS *Service
+	// MetricsAvailable is true if the metrics.k8s.io API is served.
+	MetricsAvailable bool
+	Readyz           Health
+	Livez            Health
+}
+
+// Do returns the API server endpoint and version, the core services
+// and the health of the /readyz and /livez endpoints. Health failures
+// are reported in the result and are not returned as errors.
+func Do(ctx context.Context, f kubeutil.Factory) (*Report, error) {
+	cfg, err := f.ToRESTConfig()
+	if err != nil {
+		return nil, err
+	}
+	res := &Report{Host: cfg.Host}
+
+	cli, err := f.KubernetesClientSet()
+	if err != nil {
+		return nil, err
+	}
+
+	res.Version, err = cli.Discovery().ServerVersion()
+	if err != nil {
+		return nil, err
+	}
+
+	services, err := cli.CoreV1().Services(metav1.NamespaceSystem).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, svc := range services.Items {
+		if svc.Labels[ClusterServiceLabel] == "true" {
+			res.Services = append(res.Services, Service{
+				Namespace: svc.Namespace,
+				Name:      svc.Name,
+				URL:       proxyURL(cfg.Host, &svc),
+			})
+		}
+		if res.DNS == nil && (svc.Labels["k8s-app"] == "kube-dns" || svc.Name == "kube-dns") {
+			res.DNS = &Service{Namespace: svc.Namespace, Name: svc.Name, URL: proxyURL(cfg.Host, &svc)}
+		}
+	}
+
+	if _, err := cli.Discovery().ServerResourcesForGroupVersion(metricsGroupVersion); err == nil {
+		res.MetricsAvailable = true
+	}
+
+	client, err := f.RESTClient()
+	if err != nil {
+		return nil, err
+	}
+	for _, h := range []struct {
+		path string
+		into *Health
+	}{{"/readyz", &res.Readyz}, {"/livez", &res.Livez}} {
+		body, err := client.Get().AbsPath(h.path).Param("verbose", "").Do(ctx).Raw()
+		*h.into = parseHealth(string(body), err)
+	}
+
+	return res, nil
+}
+
+// proxyURL returns the API server proxy URL of the service
+// (the same reported by kubectl cluster-info).
+func proxyURL(host string, svc *corev1.Service) string {
+	name := svc.Name
+	if len(svc.Spec.Ports) > 0 {
+		port := svc.Spec.Ports[0]
+		p := port.Name
+		if len(p) == 0 {
+			p = fmt.Sprint(port.Port)
+		}
+		// the scheme is guessed from the port, as kubectl does
+		if port.Port == 443 || strings.Contains(p, "https") {
+			name = "https:" + name
+		}
+		name += ":" + p
+	}
+	return strings.TrimSuffix(host, "/") + "/api/v1/namespaces/" + svc.Namespace +
+		"/services/" + url.PathEscape(name) + "/proxy"
+}
+
+// parseHealth parses the verbose output of a health endpoint,
+// made by lines like "[+]ping ok" or "[-]etcd failed: reason withheld".
+func parseHealth(body string, err error) Health {
+	res := Health{}
+	for _, line := range strings.Split(body, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "[+]"):
+			res.Checks = append(res.Checks, Check{
+				Name: strings.TrimSuffix(strings.TrimPrefix(line, "[+]"), " ok"),
+				OK:   true,
+			})
+		case strings.HasPrefix(line, "[-]"):
+			name, msg := strings.TrimPrefix(line, "[-]"), ""
+			if i := strings.Index(name, " failed"); i >= 0 {
+				name, msg = name[:i], strings.TrimPrefix(strings.TrimPrefix(name[i:], " failed"), ": ")
+			}
+			res.Checks = append(res.Checks, Check{Name: name, Message: msg})
+		}
+	}
+
+	if err != nil && len(res.Checks) == 0 {
+		res.Err = err
+		return res
+	}
+
+	res.OK = err == nil
+	for _, c := range res.Checks {
+		res.OK = res.OK && c.OK
+	}
+	return res
+}
@@ -0,0 +1,34 @@
+package clusterinfo
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseHealth(t *testing.T) {
+	body := "[+]ping ok\n[+]log ok\n[-]etcd failed: reason withheld\nreadyz check failed\n"
+
+	res := parseHealth(body, errors.New("the server is currently unable to handle the request"))
+	if res.OK || res.Err != nil {
+		t.Fatalf("expected a failed health without errors, got %+v", res)
+	}
+	if len(res.Checks) != 3 {
+		t.Fatalf("expected 3 checks, got %d", len(res.Checks))
+	}
+	if c := res.Checks[0]; c.Name != "ping" || !c.OK {
+		t.Fatalf("unexpected first check: %+v", c)
+	}
+	if c := res.Checks[2]; c.Name != "etcd" || c.OK || c.Message != "reason withheld" {
+		t.Fatalf("unexpected etcd check: %+v", c)
+	}
+
+	res = parseHealth("[+]ping ok\nlivez check passed\n", nil)
+	if !res.OK {
+		t.Fatalf("expected a healthy endpoint, got %+v", res)
+	}
+
+	res = parseHealth("", errors.New("connection refused"))
+	if res.OK || res.Err == nil {
+		t.Fatalf("expected the request error, got %+v", res)
+	}
+}
@@ -0,0 +1,61 @@
+package config
+
+import (
+	"fmt"
+
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// Merge loads the kubeconfig files and merges them into a single config,
+// with the same rules of kubectl and the KUBECONFIG variable: the first
+// file to set a value (e.g. a context or the current context) wins.
+// Missing files are ignored. The certificates and keys referenced by
+// path are embedded, so that the config does not depend on other files.
+func Merge(paths ...string) (*clientcmdapi.Config, error) {
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("you must specify at least one kubeconfig file")
+	}
+
+	rules := &clientcmd.ClientConfigLoadingRules{Precedence: paths}
+	cfg, err := rules.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := clientcmdapi.FlattenConfig(cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Minify returns a copy of the config holding only the named context
+// (the current context if empty), with its cluster and user, and with
+// the certificates and keys embedded.
+func Minify(cfg *clientcmdapi.Config, context string) (*clientcmdapi.Config, error) {
+	res := cfg.DeepCopy()
+	if len(context) > 0 {
+		if _, ok := res.Contexts[context]; !ok {
+			return nil, fmt.Errorf("context %q not found", context)
+		}
+		res.CurrentContext = context
+	}
+
+	if err := clientcmdapi.MinifyConfig(res); err != nil {
+		return nil, err
+	}
+	if err := clientcmdapi.FlattenConfig(res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// ToYAML serializes the config as a kubeconfig file.
+func ToYAML(cfg *clientcmdapi.Config) ([]byte, error) {
+	return clientcmd.Write(*cfg)
+}
+
+// WriteFile writes the config to the file, only readable by the owner.
+func WriteFile(cfg *clientcmdapi.Config, filename string) error {
+	return clientcmd.WriteToFile(*cfg, filename)
+}
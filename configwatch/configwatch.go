@@ -0,0 +1,248 @@
+package configwatch
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	kubeutil "github.com/lucasepe/kube/util"
+	"golang.org/x/sync/errgroup"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	watchtools "k8s.io/client-go/tools/watch"
+)
+
+// Kind is the kind of a watched object.
+type Kind string
+
+const (
+	ConfigMap Kind = "ConfigMap"
+	Secret    Kind = "Secret"
+)
+
+// Target identifies a watched object.
+type Target struct {
+	Kind      Kind
+	Namespace string
+	Name      string
+}
+
+func (t Target) String() string {
+	return fmt.Sprintf("%s %s/%s", t.Kind, t.Namespace, t.Name)
+}
+
+// Modification holds the old and the new value of a changed key.
+type Modification struct {
+	Old []byte
+	New []byte
+}
+
+// Change is the key-level diff between two versions of a watched object.
+type Change struct {
+	Target          Target
+	ResourceVersion string
+
+	Added    map[string][]byte
+	Removed  map[string][]byte
+	Modified map[string]Modification
+
+	// Created is true for the first observed version when EmitInitial is set
+	// or when the object is created after the watch started.
+	Created bool
+	// Deleted is true when the object has been deleted.
+	Deleted bool
+}
+
+// Empty returns true if no key was added, removed or modified.
+func (c Change) Empty() bool {
+	return len(c.Added) == 0 && len(c.Removed) == 0 && len(c.Modified) == 0 && !c.Created && !c.Deleted
+}
+
+// Opts is a set of options that allows you to watch ConfigMaps and Secrets.
+type Opts struct {
+	Namespace  string
+	ConfigMaps []string
+	Secrets    []string
+
+	// EmitInitial invokes OnChange with the current data of each object
+	// (as all keys added) when the watch starts.
+	EmitInitial bool
+
+	// OnChange is invoked every time the data of a watched object changes;
+	// returning an error stops the watch.
+	OnChange func(Change) error
+}
+
+// Watch monitors the named ConfigMaps and Secrets until ctx is done or
+// OnChange returns an error. Expired watches are transparently re-listed.
+func Watch(ctx context.Context, f kubeutil.Factory, o Opts) error {
+	if o.OnChange == nil {
+		return fmt.Errorf("an OnChange callback is required")
+	}
+	if len(o.ConfigMaps) == 0 && len(o.Secrets) == 0 {
+		return fmt.Errorf("at least one ConfigMap or Secret name is required")
+	}
+
+	var err error
+	if len(o.Namespace) == 0 {
+		o.Namespace, _, err = f.ToRawKubeConfigLoader().Namespace()
+		if err != nil {
+			return err
+		}
+	}
+
+	cli, err := f.KubernetesClientSet()
+	if err != nil {
+		return err
+	}
+
+	g, ctx := errgroup.WithContext(ctx)
+	for _, name := range o.ConfigMaps {
+		t := Target{Kind: ConfigMap, Namespace: o.Namespace, Name: name}
+		g.Go(func() error { return watchTarget(ctx, cli, t, o) })
+	}
+	for _, name := range o.Secrets {
+		t := Target{Kind: Secret, Namespace: o.Namespace, Name: name}
+		g.Go(func() error { return watchTarget(ctx, cli, t, o) })
+	}
+
+	err = g.Wait()
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) || errors.Is(err, watchtools.ErrWatchClosed) {
+		return nil
+	}
+	return err
+}
+
+func watchTarget(ctx context.Context, cli kubernetes.Interface, t Target, o Opts) error {
+	selector := fields.OneTermEqualSelector("metadata.name", t.Name).String()
+
+	var lw *cache.ListWatch
+	var objType runtime.Object
+	switch t.Kind {
+	case ConfigMap:
+		objType = &corev1.ConfigMap{}
+		lw = &cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				options.FieldSelector = selector
+				return cli.CoreV1().ConfigMaps(t.Namespace).List(ctx, options)
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				options.FieldSelector = selector
+				return cli.CoreV1().ConfigMaps(t.Namespace).Watch(ctx, options)
+			},
+		}
+	case Secret:
+		objType = &corev1.Secret{}
+		lw = &cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				options.FieldSelector = selector
+				return cli.CoreV1().Secrets(t.Namespace).List(ctx, options)
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				options.FieldSelector = selector
+				return cli.CoreV1().Secrets(t.Namespace).Watch(ctx, options)
+			},
+		}
+	}
+
+	var (
+		last   map[string][]byte
+		lastRV string
+	)
+
+	precondition := func(store cache.Store) (bool, error) {
+		item, exists, err := store.GetByKey(t.Namespace + "/" + t.Name)
+		if err != nil || !exists {
+			return false, err
+		}
+		last, lastRV = dataOf(item.(runtime.Object))
+		if o.EmitInitial {
+			ch := diff(t, nil, last)
+			ch.Created, ch.ResourceVersion = true, lastRV
+			return false, o.OnChange(ch)
+		}
+		return false, nil
+	}
+
+	_, err := watchtools.UntilWithSync(ctx, lw, objType, precondition, func(ev watch.Event) (bool, error) {
+		cur, rv := dataOf(ev.Object)
+		if rv == lastRV {
+			return false, nil
+		}
+
+		var ch Change
+		switch ev.Type {
+		case watch.Added:
+			ch = diff(t, last, cur)
+			ch.Created = last == nil
+		case watch.Modified:
+			ch = diff(t, last, cur)
+		case watch.Deleted:
+			ch = diff(t, last, nil)
+			ch.Deleted = true
+			cur = nil
+		default:
+			return false, nil
+		}
+		ch.ResourceVersion = rv
+		last, lastRV = cur, rv
+
+		if ch.Empty() {
+			return false, nil
+		}
+		return false, o.OnChange(ch)
+	})
+
+	return err
+}
+
+func dataOf(obj runtime.Object) (map[string][]byte, string) {
+	res := map[string][]byte{}
+	switch t := obj.(type) {
+	case *corev1.ConfigMap:
+		for k, v := range t.Data {
+			res[k] = []byte(v)
+		}
+		for k, v := range t.BinaryData {
+			res[k] = v
+		}
+		return res, t.ResourceVersion
+	case *corev1.Secret:
+		for k, v := range t.Data {
+			res[k] = v
+		}
+		return res, t.ResourceVersion
+	}
+	return res, ""
+}
+
+func diff(t Target, old, cur map[string][]byte) Change {
+	ch := Change{
+		Target:   t,
+		Added:    map[string][]byte{},
+		Removed:  map[string][]byte{},
+		Modified: map[string]Modification{},
+	}
+	for k, v := range cur {
+		ov, ok := old[k]
+		if !ok {
+			ch.Added[k] = v
+			continue
+		}
+		if !bytes.Equal(ov, v) {
+			ch.Modified[k] = Modification{Old: ov, New: v}
+		}
+	}
+	for k, v := range old {
+		if _, ok := cur[k]; !ok {
+			ch.Removed[k] = v
+		}
+	}
+	return ch
+}
@@ -0,0 +1,161 @@
+package configwatch
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+func configMap(rv string, data map[string]string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "settings", Namespace: "default", ResourceVersion: rv},
+		Data:       data,
+	}
+}
+
+func TestDiff(t *testing.T) {
+	old := map[string][]byte{"a": []byte("1"), "b": []byte("2"), "c": []byte("3")}
+	cur := map[string][]byte{"a": []byte("1"), "b": []byte("20"), "d": []byte("4")}
+
+	ch := diff(Target{Kind: ConfigMap, Namespace: "default", Name: "settings"}, old, cur)
+	if !reflect.DeepEqual(ch.Added, map[string][]byte{"d": []byte("4")}) {
+		t.Fatalf("unexpected added keys %v", ch.Added)
+	}
+	if !reflect.DeepEqual(ch.Removed, map[string][]byte{"c": []byte("3")}) {
+		t.Fatalf("unexpected removed keys %v", ch.Removed)
+	}
+	if !reflect.DeepEqual(ch.Modified, map[string]Modification{"b": {Old: []byte("2"), New: []byte("20")}}) {
+		t.Fatalf("unexpected modified keys %v", ch.Modified)
+	}
+
+	if !diff(ch.Target, old, old).Empty() {
+		t.Fatal("expected no changes between equal data")
+	}
+}
+
+func TestDataOf(t *testing.T) {
+	cm := configMap("7", map[string]string{"mode": "debug"})
+	cm.BinaryData = map[string][]byte{"logo": {0x89, 0x50}}
+	data, rv := dataOf(cm)
+	if rv != "7" || string(data["mode"]) != "debug" || len(data["logo"]) != 2 {
+		t.Fatalf("unexpected data %v at %q", data, rv)
+	}
+
+	sec := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{ResourceVersion: "8"},
+		Data:       map[string][]byte{"password": []byte("s3cret")},
+	}
+	data, rv = dataOf(sec)
+	if rv != "8" || string(data["password"]) != "s3cret" {
+		t.Fatalf("unexpected data %v at %q", data, rv)
+	}
+}
+
+func TestWatchTarget(t *testing.T) {
+	cli := fake.NewSimpleClientset(configMap("1", map[string]string{"mode": "debug"}))
+	w := watch.NewFake()
+	cli.PrependWatchReactor("configmaps", func(action clienttesting.Action) (bool, watch.Interface, error) {
+		return true, w, nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	changes := make(chan Change, 10)
+	o := Opts{
+		EmitInitial: true,
+		OnChange: func(ch Change) error {
+			changes <- ch
+			return nil
+		},
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- watchTarget(ctx, cli, Target{Kind: ConfigMap, Namespace: "default", Name: "settings"}, o)
+	}()
+
+	next := func() Change {
+		t.Helper()
+		select {
+		case ch := <-changes:
+			return ch
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for a change")
+			return Change{}
+		}
+	}
+
+	ch := next()
+	if !ch.Created || ch.ResourceVersion != "1" || string(ch.Added["mode"]) != "debug" {
+		t.Fatalf("expected the initial data as added, got %+v", ch)
+	}
+
+	// The same version delivered again by the watch is not a change.
+	w.Modify(configMap("1", map[string]string{"mode": "debug"}))
+	w.Modify(configMap("2", map[string]string{"mode": "production", "replicas": "3"}))
+	ch = next()
+	if ch.Created || ch.Deleted || ch.ResourceVersion != "2" {
+		t.Fatalf("expected a modification, got %+v", ch)
+	}
+	if string(ch.Modified["mode"].Old) != "debug" || string(ch.Modified["mode"].New) != "production" || string(ch.Added["replicas"]) != "3" {
+		t.Fatalf("unexpected change %+v", ch)
+	}
+
+	w.Delete(configMap("3", map[string]string{"mode": "production", "replicas": "3"}))
+	ch = next()
+	if !ch.Deleted || len(ch.Removed) != 2 {
+		t.Fatalf("expected a deletion removing every key, got %+v", ch)
+	}
+
+	select {
+	case ch := <-changes:
+		t.Fatalf("unexpected change %+v", ch)
+	default:
+	}
+
+	cancel()
+	<-done
+}
+
+func TestWatchTargetStopsOnError(t *testing.T) {
+	cli := fake.NewSimpleClientset(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "creds", Namespace: "default", ResourceVersion: "1"},
+		Data:       map[string][]byte{"password": []byte("s3cret")},
+	})
+	cli.PrependWatchReactor("secrets", func(action clienttesting.Action) (bool, watch.Interface, error) {
+		return true, watch.NewFake(), nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	stop := errors.New("stop")
+	o := Opts{
+		EmitInitial: true,
+		OnChange:    func(Change) error { return stop },
+	}
+	err := watchTarget(ctx, cli, Target{Kind: Secret, Namespace: "default", Name: "creds"}, o)
+	if !errors.Is(err, stop) {
+		t.Fatalf("expected the callback error, got %v", err)
+	}
+}
+
+func TestWatchRequiredArgs(t *testing.T) {
+	for _, o := range []Opts{
+		{ConfigMaps: []string{"settings"}},
+		{OnChange: func(Change) error { return nil }},
+	} {
+		if err := Watch(context.Background(), nil, o); err == nil {
+			t.Errorf("expected an error for %+v", o)
+		}
+	}
+}
@@ -0,0 +1,262 @@
+package cp
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/lucasepe/kube/exec"
+	kubeutil "github.com/lucasepe/kube/util"
+)
+
+// Opts is a set of options that allows you to copy files to and from a pod container.
+// The container must have the tar binary.
+type Opts struct {
+	Namespace string
+	PodName   string
+	Container string
+
+	// Include are glob patterns (see path.Match) of the files to copy, matched
+	// against the path relative to the source or the file name; empty means all.
+	Include []string
+	// Exclude are glob patterns of the files and directories to skip.
+	Exclude []string
+	// NoPreserve does not preserve the permissions (and the ownership in the container).
+	NoPreserve bool
+}
+
+// ToPod copies the local file or directory to the path in the container.
+func ToPod(f kubeutil.Factory, o Opts, localPath, remotePath string) error {
+	return ToPodContext(context.Background(), f, o, localPath, remotePath)
+}
+
+// ToPodContext is like ToPod but stops when the context is cancelled.
+func ToPodContext(ctx context.Context, f kubeutil.Factory, o Opts, localPath, remotePath string) error {
+	if _, err := os.Stat(localPath); err != nil {
+		return err
+	}
+	remotePath = path.Clean(remotePath)
+
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(makeTar(localPath, path.Base(remotePath), o, pw))
+	}()
+
+	cmd := []string{"tar", "-xmf", "-", "-C", path.Dir(remotePath)}
+	if o.NoPreserve {
+		cmd = []string{"tar", "--no-same-permissions", "--no-same-owner", "-xmf", "-", "-C", path.Dir(remotePath)}
+	}
+
+	stderr := &bytes.Buffer{}
+	err := exec.DoContext(ctx, f, exec.Opts{
+		Namespace: o.Namespace,
+		PodName:   o.PodName,
+		Container: o.Container,
+		Command:   cmd,
+		Stdin:     pr,
+		Stdout:    io.Discard,
+		Stderr:    stderr,
+	})
+	pr.Close()
+	if err != nil {
+		return withStderr(err, stderr)
+	}
+	return nil
+}
+
+// FromPod copies the file or directory in the container to the local path.
+// Symbolic links and entries escaping the local path are skipped.
+func FromPod(f kubeutil.Factory, o Opts, remotePath, localPath string) error {
+	return FromPodContext(context.Background(), f, o, remotePath, localPath)
+}
+
+// FromPodContext is like FromPod but stops when the context is cancelled.
+func FromPodContext(ctx context.Context, f kubeutil.Factory, o Opts, remotePath, localPath string) error {
+	remotePath = path.Clean(remotePath)
+
+	pr, pw := io.Pipe()
+	stderr := &bytes.Buffer{}
+	go func() {
+		err := exec.DoContext(ctx, f, exec.Opts{
+			Namespace: o.Namespace,
+			PodName:   o.PodName,
+			Container: o.Container,
+			Command:   []string{"tar", "cf", "-", "-C", path.Dir(remotePath), path.Base(remotePath)},
+			Stdout:    pw,
+			Stderr:    stderr,
+		})
+		pw.CloseWithError(withStderr(err, stderr))
+	}()
+
+	err := untar(pr, path.Base(remotePath), localPath, o)
+	pr.CloseWithError(err)
+	return err
+}
+
+// makeTar writes the tar of src, with entries rooted at prefix.
+func makeTar(src, prefix string, o Opts, w io.Writer) error {
+	tw := tar.NewWriter(w)
+
+	err := filepath.Walk(src, func(file string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, file)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		if rel != "." && matchesAny(o.Exclude, rel) {
+			if fi.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !fi.IsDir() && len(o.Include) > 0 && !matchesAny(o.Include, rel) {
+			return nil
+		}
+
+		link := ""
+		if fi.Mode()&os.ModeSymlink != 0 {
+			if link, err = os.Readlink(file); err != nil {
+				return err
+			}
+		}
+		hdr, err := tar.FileInfoHeader(fi, link)
+		if err != nil {
+			return err
+		}
+		hdr.Name = prefix
+		if rel != "." {
+			hdr.Name = path.Join(prefix, rel)
+		}
+		if fi.IsDir() {
+			hdr.Name += "/"
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+
+		if !fi.Mode().IsRegular() {
+			return nil
+		}
+		fp, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer fp.Close()
+
+		_, err = io.Copy(tw, fp)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	return tw.Close()
+}
+
+// untar extracts the entries rooted at prefix into dest.
+func untar(r io.Reader, prefix, dest string, o Opts) error {
+	dest, err := filepath.Abs(dest)
+	if err != nil {
+		return err
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		name := strings.TrimSuffix(path.Clean(hdr.Name), "/")
+		rel := strings.TrimPrefix(strings.TrimPrefix(name, prefix), "/")
+		if name != prefix && !strings.HasPrefix(name, prefix+"/") {
+			continue
+		}
+
+		target := filepath.Join(dest, filepath.FromSlash(rel))
+		if target != dest && !strings.HasPrefix(target, dest+string(filepath.Separator)) {
+			continue
+		}
+
+		if len(rel) > 0 && matchesAny(o.Exclude, rel) {
+			continue
+		}
+
+		mode := os.FileMode(hdr.Mode).Perm()
+		if o.NoPreserve {
+			mode = 0644
+			if hdr.Typeflag == tar.TypeDir {
+				mode = 0755
+			}
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, mode|0700); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if len(o.Include) > 0 && !matchesAny(o.Include, rel) {
+				continue
+			}
+			if err := writeFile(target, tr, mode); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func writeFile(target string, r io.Reader, mode os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	fp, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(fp, r); err != nil {
+		fp.Close()
+		return err
+	}
+	if err := fp.Close(); err != nil {
+		return err
+	}
+	return os.Chmod(target, mode)
+}
+
+// matchesAny returns true if a pattern matches the relative path or its base name.
+func matchesAny(patterns []string, rel string) bool {
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, rel); ok {
+			return true
+		}
+		if ok, _ := path.Match(p, path.Base(rel)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func withStderr(err error, stderr *bytes.Buffer) error {
+	if err == nil {
+		return nil
+	}
+	if msg := strings.TrimSpace(stderr.String()); len(msg) > 0 {
+		return fmt.Errorf("%w: %s", err, msg)
+	}
+	return err
+}
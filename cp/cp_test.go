@@ -0,0 +1,60 @@
+package cp
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTarRoundTrip(t *testing.T) {
+	src := t.TempDir()
+	files := map[string]string{
+		"a.txt":         "a",
+		"b.log":         "b",
+		"sub/c.txt":     "c",
+		"skip/d.txt":    "d",
+		"sub/deep/e.sh": "e",
+	}
+	for name, content := range files {
+		file := filepath.Join(src, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte(content), 0640); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Chmod(filepath.Join(src, "sub", "deep", "e.sh"), 0750); err != nil {
+		t.Fatal(err)
+	}
+
+	o := Opts{Include: []string{"*.txt", "*.sh"}, Exclude: []string{"skip"}}
+
+	buf := &bytes.Buffer{}
+	if err := makeTar(src, "data", o, buf); err != nil {
+		t.Fatal(err)
+	}
+
+	dest := filepath.Join(t.TempDir(), "out")
+	if err := untar(buf, "data", dest, Opts{}); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, exists := range map[string]bool{
+		"a.txt": true, "b.log": false, "sub/c.txt": true, "skip/d.txt": false, "sub/deep/e.sh": true,
+	} {
+		_, err := os.Stat(filepath.Join(dest, filepath.FromSlash(name)))
+		if exists != (err == nil) {
+			t.Errorf("%s: expected exists=%v, got err=%v", name, exists, err)
+		}
+	}
+
+	fi, err := os.Stat(filepath.Join(dest, "sub", "deep", "e.sh"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Mode().Perm() != 0750 {
+		t.Errorf("expected mode 0750, got %o", fi.Mode().Perm())
+	}
+}
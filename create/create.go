@@ -0,0 +1,110 @@
+package create
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	kubeutil "github.com/lucasepe/kube/util"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/cli-runtime/pkg/resource"
+)
+
+// Opts is a set of options that allows you to create resources from manifests.
+type Opts struct {
+	// Filenames are files, directories or URLs containing the manifests.
+	Filenames []string
+	Recursive bool
+	// Kustomize is a kustomization directory whose output is used as manifests.
+	Kustomize string
+	// Readers are streams of YAML or JSON manifests (e.g. os.Stdin).
+	Readers []io.Reader
+
+	// Namespace is used for the namespaced objects without a namespace
+	// (defaults to the namespace of the current context).
+	Namespace string
+	// EnforceNamespace fails if an object declares a different namespace.
+	EnforceNamespace bool
+
+	// FieldManager is the name of the actor creating the objects.
+	FieldManager string
+	// DryRun asks the server to process the request without persisting it.
+	DryRun bool
+}
+
+// Do creates the objects read from the manifests, returning them with the
+// metadata assigned by the server. A failure does not stop the other
+// objects; all the errors are returned at the end.
+func Do(f kubeutil.Factory, o Opts) ([]*unstructured.Unstructured, error) {
+	return DoContext(context.Background(), f, o)
+}
+
+// DoContext is like Do but stops when the context is cancelled.
+func DoContext(ctx context.Context, f kubeutil.Factory, o Opts) ([]*unstructured.Unstructured, error) {
+	if len(o.Filenames) == 0 && len(o.Readers) == 0 && len(o.Kustomize) == 0 {
+		return nil, fmt.Errorf("you must specify at least one filename, reader or kustomization directory")
+	}
+	if len(o.Namespace) == 0 {
+		ns, enforce, err := f.ToRawKubeConfigLoader().Namespace()
+		if err != nil {
+			return nil, err
+		}
+		o.Namespace, o.EnforceNamespace = ns, o.EnforceNamespace || enforce
+	}
+
+	b := f.NewBuilder().
+		Unstructured().
+		ContinueOnError().
+		NamespaceParam(o.Namespace).DefaultNamespace().
+		FilenameParam(o.EnforceNamespace, &resource.FilenameOptions{
+			Filenames: o.Filenames,
+			Recursive: o.Recursive,
+			Kustomize: o.Kustomize,
+		}).
+		Flatten()
+	for i, r := range o.Readers {
+		b = b.Stream(r, fmt.Sprintf("reader-%d", i))
+	}
+
+	r := b.Do()
+	if err := r.Err(); err != nil {
+		return nil, err
+	}
+
+	return createAll(ctx, r, o)
+}
+
+// createAll creates the objects of the visitor.
+func createAll(ctx context.Context, v resource.Visitor, o Opts) ([]*unstructured.Unstructured, error) {
+	objs := []*unstructured.Unstructured{}
+	errs := []error{}
+	err := v.Visit(func(info *resource.Info, err error) error {
+		if err != nil {
+			errs = append(errs, err)
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		obj, err := resource.NewHelper(info.Client, info.Mapping).
+			WithFieldManager(o.FieldManager).
+			DryRun(o.DryRun).
+			Create(info.Namespace, true, info.Object)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("unable to create %s: %w", info.ObjectName(), err))
+			return nil
+		}
+
+		if u, ok := obj.(*unstructured.Unstructured); ok {
+			objs = append(objs, u)
+		}
+		return nil
+	})
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	return objs, utilerrors.NewAggregate(errs)
+}
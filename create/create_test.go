@@ -0,0 +1,145 @@
+package create
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/client-go/rest/fake"
+)
+
+// configMapInfo returns the info of a config map whose create requests
+// are answered with the given status code and recorded in reqs.
+func configMapInfo(t *testing.T, name string, code int, reqs *[]*http.Request) *resource.Info {
+	t.Helper()
+
+	client := &fake.RESTClient{
+		GroupVersion:         corev1.SchemeGroupVersion,
+		NegotiatedSerializer: resource.UnstructuredPlusDefaultContentConfig().NegotiatedSerializer,
+		Client: fake.CreateHTTPClient(func(req *http.Request) (*http.Response, error) {
+			if req.Method != http.MethodPost {
+				t.Fatalf("unexpected request: %s %s", req.Method, req.URL)
+			}
+			*reqs = append(*reqs, req)
+
+			body, err := io.ReadAll(req.Body)
+			if err != nil {
+				return nil, err
+			}
+			if code != http.StatusCreated {
+				body = []byte(`{"apiVersion":"v1","kind":"Status","status":"Failure","reason":"AlreadyExists","code":409}`)
+			} else {
+				u := &unstructured.Unstructured{}
+				if err := u.UnmarshalJSON(body); err != nil {
+					return nil, err
+				}
+				u.SetUID(types.UID("uid-" + u.GetName()))
+				if body, err = u.MarshalJSON(); err != nil {
+					return nil, err
+				}
+			}
+			return &http.Response{
+				StatusCode: code,
+				Header:     http.Header{"Content-Type": []string{runtime.ContentTypeJSON}},
+				Body:       io.NopCloser(bytes.NewReader(body)),
+			}, nil
+		}),
+	}
+
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion("v1")
+	obj.SetKind("ConfigMap")
+	obj.SetNamespace("default")
+	obj.SetName(name)
+	obj.SetResourceVersion("42")
+
+	return &resource.Info{
+		Client:    client,
+		Namespace: "default",
+		Name:      name,
+		Object:    obj,
+		Mapping: &meta.RESTMapping{
+			Resource: corev1.SchemeGroupVersion.WithResource("configmaps"),
+			Scope:    meta.RESTScopeNamespace,
+		},
+	}
+}
+
+func TestCreateAll(t *testing.T) {
+	var reqs []*http.Request
+	v := resource.InfoListVisitor{
+		configMapInfo(t, "settings", http.StatusCreated, &reqs),
+	}
+
+	objs, err := createAll(context.Background(), v, Opts{FieldManager: "tests", DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objs) != 1 || objs[0].GetUID() != "uid-settings" {
+		t.Fatalf("expected the object returned by the server, got %v", objs)
+	}
+	if len(objs[0].GetResourceVersion()) > 0 {
+		t.Fatalf("expected the resource version to be cleared, got %q", objs[0].GetResourceVersion())
+	}
+
+	if len(reqs) != 1 {
+		t.Fatalf("expected a create request, got %d", len(reqs))
+	}
+	if got := reqs[0].URL.Path; got != "/namespaces/default/configmaps" {
+		t.Fatalf("unexpected path %q", got)
+	}
+	q := reqs[0].URL.Query()
+	if q.Get("fieldManager") != "tests" || q.Get("dryRun") != "All" {
+		t.Fatalf("expected the field manager and the dry run, got %v", q)
+	}
+}
+
+func TestCreateAllContinueOnError(t *testing.T) {
+	var reqs []*http.Request
+	v := resource.InfoListVisitor{
+		configMapInfo(t, "existing", http.StatusConflict, &reqs),
+		configMapInfo(t, "settings", http.StatusCreated, &reqs),
+	}
+
+	objs, err := createAll(context.Background(), v, Opts{})
+	if err == nil {
+		t.Fatal("expected the already exists error")
+	}
+	if len(reqs) != 2 {
+		t.Fatalf("expected both the create requests, got %d", len(reqs))
+	}
+	if len(objs) != 1 || objs[0].GetName() != "settings" {
+		t.Fatalf("expected only the created object, got %v", objs)
+	}
+	if q := reqs[1].URL.Query(); q.Has("dryRun") || q.Has("fieldManager") {
+		t.Fatalf("unexpected query %v", q)
+	}
+}
+
+func TestDoNoInput(t *testing.T) {
+	if _, err := Do(nil, Opts{}); err == nil {
+		t.Fatal("expected an error without manifests")
+	}
+}
+
+func TestCreateAllCancelled(t *testing.T) {
+	var reqs []*http.Request
+	v := resource.InfoListVisitor{configMapInfo(t, "settings", http.StatusCreated, &reqs)}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := createAll(ctx, v, Opts{}); err == nil {
+		t.Fatal("expected the context error")
+	}
+	if len(reqs) != 0 {
+		t.Fatalf("expected no create requests, got %d", len(reqs))
+	}
+}
@@ -0,0 +1,241 @@
+package customresources
+
+import (
+	"context"
+	"sort"
+
+	kubeutil "github.com/lucasepe/kube/util"
+	"golang.org/x/sync/errgroup"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	runtimeresource "k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/metadata"
+)
+
+const (
+	defaultConcurrency = 5
+)
+
+var crdResource = schema.GroupVersionResource{
+	Group:    "apiextensions.k8s.io",
+	Version:  "v1",
+	Resource: "customresourcedefinitions",
+}
+
+// Opts is a set of options that allows you to list the custom resources.
+type Opts struct {
+	// Namespace restricts the namespaced resources to a namespace
+	// (defaults to the namespace of the current context).
+	Namespace     string
+	AllNamespaces bool
+	LabelSelector string
+	FieldSelector string
+	ChunkSize     int64
+	// Groups restricts the inventory to the CRDs of these API groups.
+	Groups []string
+	// MetadataOnly fetches only the objects metadata (no spec and status).
+	MetadataOnly bool
+	// SkipEmpty drops the CRDs without instances from the result.
+	SkipEmpty bool
+	// Concurrency is the max number of CRDs listed at the same time (default 5).
+	Concurrency int
+}
+
+// Inventory holds the instances of a CustomResourceDefinition.
+type Inventory struct {
+	// CRD is the name of the CustomResourceDefinition (e.g. "widgets.example.com").
+	CRD        string
+	Resource   schema.GroupVersionResource
+	Kind       string
+	Namespaced bool
+	Items      []*unstructured.Unstructured
+	// Err is set if the instances could not be listed.
+	Err error
+}
+
+// Do discovers all the installed CRDs and lists their instances,
+// returning an entry per CRD sorted by name. A failure listing the
+// instances of a CRD is reported in its entry and does not stop the others.
+func Do(f kubeutil.Factory, o Opts) ([]Inventory, error) {
+	return DoContext(context.Background(), f, o)
+}
+
+// DoContext is like Do but stops when the context is cancelled.
+func DoContext(ctx context.Context, f kubeutil.Factory, o Opts) ([]Inventory, error) {
+	if o.ChunkSize <= 0 {
+		o.ChunkSize = kubeutil.DefaultChunkSize
+	}
+	if o.Concurrency <= 0 {
+		o.Concurrency = defaultConcurrency
+	}
+
+	if !o.AllNamespaces && len(o.Namespace) == 0 {
+		ns, _, err := f.ToRawKubeConfigLoader().Namespace()
+		if err != nil {
+			return nil, err
+		}
+		o.Namespace = ns
+	}
+	if o.AllNamespaces {
+		o.Namespace = metav1.NamespaceAll
+	}
+
+	dyn, err := f.DynamicClient()
+	if err != nil {
+		return nil, err
+	}
+
+	var meta metadata.Interface
+	if o.MetadataOnly {
+		cfg, err := f.ToRESTConfig()
+		if err != nil {
+			return nil, err
+		}
+		if meta, err = metadata.NewForConfig(cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	crds, err := dyn.Resource(crdResource).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	res := []Inventory{}
+	for _, crd := range crds.Items {
+		inv, ok := inventoryFor(&crd, o.Groups)
+		if ok {
+			res = append(res, inv)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].CRD < res[j].CRD })
+
+	g := new(errgroup.Group)
+	g.SetLimit(o.Concurrency)
+	for i := range res {
+		inv := &res[i]
+		g.Go(func() error {
+			namespace := o.Namespace
+			if !inv.Namespaced {
+				namespace = metav1.NamespaceAll
+			}
+
+			listOptions := metav1.ListOptions{
+				Limit:         o.ChunkSize,
+				LabelSelector: o.LabelSelector,
+				FieldSelector: o.FieldSelector,
+			}
+			inv.Err = runtimeresource.FollowContinue(&listOptions,
+				func(options metav1.ListOptions) (runtime.Object, error) {
+					var (
+						list runtime.Object
+						err  error
+					)
+					if meta != nil {
+						list, err = listMetadata(ctx, meta, inv, namespace, options)
+					} else {
+						list, err = listObjects(ctx, dyn.Resource(inv.Resource).Namespace(namespace), inv, options)
+					}
+					if err != nil {
+						return nil, runtimeresource.EnhanceListError(err, options, inv.Resource.Resource)
+					}
+					return list, nil
+				})
+			return nil
+		})
+	}
+	g.Wait()
+
+	if o.SkipEmpty {
+		all := res
+		res = res[:0]
+		for _, inv := range all {
+			if len(inv.Items) > 0 || inv.Err != nil {
+				res = append(res, inv)
+			}
+		}
+	}
+
+	return res, nil
+}
+
+func listObjects(ctx context.Context, ri dynamic.ResourceInterface, inv *Inventory, options metav1.ListOptions) (runtime.Object, error) {
+	list, err := ri.List(ctx, options)
+	if err != nil {
+		return nil, err
+	}
+	for i := range list.Items {
+		inv.Items = append(inv.Items, &list.Items[i])
+	}
+	return list, nil
+}
+
+func listMetadata(ctx context.Context, meta metadata.Interface, inv *Inventory, namespace string, options metav1.ListOptions) (runtime.Object, error) {
+	list, err := meta.Resource(inv.Resource).Namespace(namespace).List(ctx, options)
+	if err != nil {
+		return nil, err
+	}
+	for i := range list.Items {
+		obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&list.Items[i])
+		if err != nil {
+			return nil, err
+		}
+		u := &unstructured.Unstructured{Object: obj}
+		u.SetAPIVersion(inv.Resource.GroupVersion().String())
+		u.SetKind(inv.Kind)
+		inv.Items = append(inv.Items, u)
+	}
+	return list, nil
+}
+
+// inventoryFor returns the (empty) entry for the CRD using the storage
+// version if it is served, otherwise the first served version.
+func inventoryFor(crd *unstructured.Unstructured, groups []string) (Inventory, bool) {
+	group, _, _ := unstructured.NestedString(crd.Object, "spec", "group")
+	if len(groups) > 0 && !contains(groups, group) {
+		return Inventory{}, false
+	}
+
+	plural, _, _ := unstructured.NestedString(crd.Object, "spec", "names", "plural")
+	kind, _, _ := unstructured.NestedString(crd.Object, "spec", "names", "kind")
+	scope, _, _ := unstructured.NestedString(crd.Object, "spec", "scope")
+	versions, _, _ := unstructured.NestedSlice(crd.Object, "spec", "versions")
+
+	version := ""
+	for _, v := range versions {
+		vm, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if served, _ := vm["served"].(bool); !served {
+			continue
+		}
+		name, _ := vm["name"].(string)
+		if storage, _ := vm["storage"].(bool); storage || len(version) == 0 {
+			version = name
+		}
+	}
+	if len(version) == 0 {
+		return Inventory{}, false
+	}
+
+	return Inventory{
+		CRD:        crd.GetName(),
+		Resource:   schema.GroupVersionResource{Group: group, Version: version, Resource: plural},
+		Kind:       kind,
+		Namespaced: scope != "Cluster",
+		Items:      []*unstructured.Unstructured{},
+	}, true
+}
+
+func contains(list []string, s string) bool {
+	for _, it := range list {
+		if it == s {
+			return true
+		}
+	}
+	return false
+}
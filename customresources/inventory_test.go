@@ -0,0 +1,90 @@
+package customresources
+
+import (
+	"context"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+func crd(group, plural, kind, scope string, versions ...interface{}) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apiextensions.k8s.io/v1",
+		"kind":       "CustomResourceDefinition",
+		"metadata":   map[string]interface{}{"name": plural + "." + group},
+		"spec": map[string]interface{}{
+			"group":    group,
+			"scope":    scope,
+			"names":    map[string]interface{}{"plural": plural, "kind": kind},
+			"versions": versions,
+		},
+	}}
+}
+
+func version(name string, served, storage bool) interface{} {
+	return map[string]interface{}{"name": name, "served": served, "storage": storage}
+}
+
+func TestInventoryFor(t *testing.T) {
+	tests := []struct {
+		name    string
+		crd     *unstructured.Unstructured
+		groups  []string
+		ok      bool
+		version string
+	}{
+		{"storage version", crd("example.com", "widgets", "Widget", "Namespaced", version("v1alpha1", true, false), version("v1", true, true)), nil, true, "v1"},
+		{"storage version not served", crd("example.com", "widgets", "Widget", "Namespaced", version("v1beta1", true, false), version("v1", false, true)), nil, true, "v1beta1"},
+		{"no served versions", crd("example.com", "widgets", "Widget", "Namespaced", version("v1", false, true)), nil, false, ""},
+		{"group filtered out", crd("example.com", "widgets", "Widget", "Namespaced", version("v1", true, true)), []string{"other.io"}, false, ""},
+		{"group selected", crd("example.com", "widgets", "Widget", "Namespaced", version("v1", true, true)), []string{"other.io", "example.com"}, true, "v1"},
+	}
+	for _, tt := range tests {
+		inv, ok := inventoryFor(tt.crd, tt.groups)
+		if ok != tt.ok {
+			t.Errorf("%s: expected ok=%t, got %t", tt.name, tt.ok, ok)
+			continue
+		}
+		if ok && inv.Resource.Version != tt.version {
+			t.Errorf("%s: expected version %s, got %s", tt.name, tt.version, inv.Resource.Version)
+		}
+	}
+
+	inv, _ := inventoryFor(crd("example.com", "gadgets", "Gadget", "Cluster", version("v1", true, true)), nil)
+	want := schema.GroupVersionResource{Group: "example.com", Version: "v1", Resource: "gadgets"}
+	if inv.CRD != "gadgets.example.com" || inv.Resource != want || inv.Kind != "Gadget" || inv.Namespaced {
+		t.Fatalf("unexpected inventory %+v", inv)
+	}
+}
+
+func TestListObjects(t *testing.T) {
+	widget := func(ns, name string) runtime.Object {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "example.com/v1",
+			"kind":       "Widget",
+			"metadata":   map[string]interface{}{"name": name, "namespace": ns},
+		}}
+	}
+
+	gvr := schema.GroupVersionResource{Group: "example.com", Version: "v1", Resource: "widgets"}
+	dyn := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{gvr: "WidgetList"},
+		widget("default", "a"), widget("default", "b"), widget("other", "c"))
+
+	inv := &Inventory{Resource: gvr, Kind: "Widget", Namespaced: true}
+	if _, err := listObjects(context.Background(), dyn.Resource(gvr).Namespace("default"), inv, metav1.ListOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if len(inv.Items) != 2 {
+		t.Fatalf("expected the widgets of the namespace, got %d", len(inv.Items))
+	}
+	for _, it := range inv.Items {
+		if it.GetNamespace() != "default" {
+			t.Fatalf("unexpected widget %s/%s", it.GetNamespace(), it.GetName())
+		}
+	}
+}
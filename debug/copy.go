@@ -0,0 +1,164 @@
+package debug
+
+import (
+	"context"
+	"fmt"
+
+	kubeutil "github.com/lucasepe/kube/util"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// Copy creates a modified copy of the pod, named CopyTo (default
+// "<pod>-debug"), in the same namespace and waits for it to be ready
+// (kubectl debug --copy-to).
+//
+// If Container names a container of the pod, it is modified with the
+// given Image, Command, Args and Env; otherwise, if Image is set, a
+// debug container is added to the copy. The copy is not managed by any
+// controller and, unless KeepLabels is set, has no labels.
+func Copy(f kubeutil.Factory, o Opts) (*Result, error) {
+	return CopyContext(context.Background(), f, o)
+}
+
+// CopyContext is like Copy but stops when the context is cancelled.
+func CopyContext(ctx context.Context, f kubeutil.Factory, o Opts) (*Result, error) {
+	if err := complete(f, &o); err != nil {
+		return nil, err
+	}
+	if len(o.CopyTo) == 0 {
+		o.CopyTo = o.PodName + "-debug"
+	}
+
+	cli, err := f.KubernetesClientSet()
+	if err != nil {
+		return nil, err
+	}
+
+	pod, err := cli.CoreV1().Pods(o.Namespace).Get(ctx, o.PodName, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	copied, container, err := podCopy(pod, o)
+	if err != nil {
+		return nil, err
+	}
+
+	created, err := cli.CoreV1().Pods(o.Namespace).Create(ctx, copied, metav1.CreateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("unable to create the copy of pod %s/%s: %w", o.Namespace, o.PodName, err)
+	}
+
+	res := &Result{Pod: created, Container: container}
+	if o.Replace {
+		err := cli.CoreV1().Pods(o.Namespace).Delete(ctx, o.PodName, metav1.DeleteOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return res, err
+		}
+	}
+
+	err = wait.PollImmediateWithContext(ctx, defaultPollInterval, o.Timeout, func(ctx context.Context) (bool, error) {
+		pod, err := cli.CoreV1().Pods(o.Namespace).Get(ctx, o.CopyTo, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		res.Pod = pod
+		if kubeutil.IsPodTerminated(pod) {
+			return false, fmt.Errorf("pod %s/%s terminated with phase %s", pod.Namespace, pod.Name, pod.Status.Phase)
+		}
+		return kubeutil.IsPodReady(pod), nil
+	})
+	if err != nil && ctx.Err() != nil {
+		err = ctx.Err()
+	}
+	if err != nil {
+		return res, fmt.Errorf("waiting for the pod %s/%s to be ready: %w", o.Namespace, o.CopyTo, err)
+	}
+
+	if o.Attach && len(res.Container) > 0 {
+		return res, attachTo(ctx, f, res, o)
+	}
+	return res, nil
+}
+
+// podCopy returns the modified copy of the pod and the
+// name of the modified (or added) container, if any.
+func podCopy(pod *corev1.Pod, o Opts) (*corev1.Pod, string, error) {
+	for name := range o.SetImages {
+		if c, _ := kubeutil.FindContainerByName(pod, name); name != "*" && c == nil {
+			return nil, "", fmt.Errorf("container %q not found in pod %s/%s", name, pod.Namespace, pod.Name)
+		}
+	}
+
+	copied := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        o.CopyTo,
+			Namespace:   pod.Namespace,
+			Annotations: pod.Annotations,
+		},
+		Spec: *pod.Spec.DeepCopy(),
+	}
+	if o.KeepLabels {
+		copied.Labels = pod.Labels
+	}
+
+	spec := &copied.Spec
+	// let the scheduler choose a node
+	spec.NodeName = ""
+	// ephemeral containers cannot be created with the pod
+	spec.EphemeralContainers = nil
+	if o.ShareProcesses {
+		share := true
+		spec.ShareProcessNamespace = &share
+	}
+
+	for i := range spec.Containers {
+		c := &spec.Containers[i]
+		if img, ok := o.SetImages[c.Name]; ok {
+			c.Image = img
+		} else if img, ok := o.SetImages["*"]; ok {
+			c.Image = img
+		}
+		if o.RemoveProbes {
+			c.LivenessProbe, c.ReadinessProbe, c.StartupProbe = nil, nil, nil
+		}
+	}
+
+	var target *corev1.Container
+	for i := range spec.Containers {
+		if spec.Containers[i].Name == o.Container {
+			target = &spec.Containers[i]
+			break
+		}
+	}
+	if target == nil {
+		if len(o.Image) == 0 {
+			return copied, "", nil
+		}
+		spec.Containers = append(spec.Containers, corev1.Container{
+			Name:                     o.Container,
+			TerminationMessagePolicy: corev1.TerminationMessageReadFile,
+		})
+		target = &spec.Containers[len(spec.Containers)-1]
+	}
+
+	if len(o.Image) > 0 {
+		target.Image = o.Image
+	}
+	if len(o.ImagePullPolicy) > 0 {
+		target.ImagePullPolicy = o.ImagePullPolicy
+	}
+	if len(o.Command) > 0 {
+		target.Command = o.Command
+	}
+	if len(o.Args) > 0 {
+		target.Args = o.Args
+	}
+	target.Env = append(target.Env, o.Env...)
+	target.Stdin, target.TTY = o.Stdin, o.TTY
+
+	return copied, target.Name, nil
+}
@@ -0,0 +1,63 @@
+package debug
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPodCopy(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "web-0",
+			Namespace: "demo",
+			Labels:    map[string]string{"app": "web"},
+		},
+		Spec: corev1.PodSpec{
+			NodeName: "node-1",
+			Containers: []corev1.Container{
+				{Name: "app", Image: "web:1", LivenessProbe: &corev1.Probe{}},
+				{Name: "proxy", Image: "envoy:1"},
+			},
+		},
+	}
+
+	copied, container, err := podCopy(pod, Opts{
+		CopyTo:         "web-0-debug",
+		Container:      "debugger",
+		Image:          "busybox",
+		SetImages:      map[string]string{"app": "web:debug"},
+		RemoveProbes:   true,
+		ShareProcesses: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if container != "debugger" {
+		t.Fatalf("expected the debugger container, got %q", container)
+	}
+	if copied.Name != "web-0-debug" || len(copied.Labels) != 0 || len(copied.Spec.NodeName) != 0 {
+		t.Fatalf("unexpected copy metadata: %s %v %q", copied.Name, copied.Labels, copied.Spec.NodeName)
+	}
+	if copied.Spec.ShareProcessNamespace == nil || !*copied.Spec.ShareProcessNamespace {
+		t.Fatal("expected process namespace sharing")
+	}
+	if len(copied.Spec.Containers) != 3 {
+		t.Fatalf("expected 3 containers, got %d", len(copied.Spec.Containers))
+	}
+	if got := copied.Spec.Containers[0]; got.Image != "web:debug" || got.LivenessProbe != nil {
+		t.Fatalf("unexpected app container: %+v", got)
+	}
+	if got := copied.Spec.Containers[1].Image; got != "envoy:1" {
+		t.Fatalf("expected the proxy image to be unchanged, got %s", got)
+	}
+	if pod.Spec.Containers[0].Image != "web:1" || pod.Spec.Containers[0].LivenessProbe == nil {
+		t.Fatal("expected the original pod to be unchanged")
+	}
+
+	if _, _, err := podCopy(pod, Opts{SetImages: map[string]string{"missing": "x"}}); err == nil {
+		t.Fatal("expected an error for an unknown container")
+	}
+}
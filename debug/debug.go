@@ -0,0 +1,218 @@
+package debug
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/lucasepe/kube/attach"
+	kubeutil "github.com/lucasepe/kube/util"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	utilrand "k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	// DefaultImage is the debug container image used when none is specified.
+	DefaultImage = "busybox"
+
+	defaultTimeout      = 60 * time.Second
+	defaultPollInterval = time.Second
+)
+
+// Opts is a set of options that allows you to debug a pod.
+type Opts struct {
+	Namespace string
+	PodName   string
+
+	// Image of the debug container (default "busybox" for ephemeral containers).
+	Image           string
+	ImagePullPolicy corev1.PullPolicy
+	// Container is the name of the debug container (default "debugger-<random>").
+	Container string
+	// Command replaces the image entrypoint; Args its arguments.
+	Command []string
+	Args    []string
+	Env     []corev1.EnvVar
+	// TargetContainer shares its process namespace with the debug
+	// container, when supported by the container runtime.
+	TargetContainer string
+
+	// Stdin keeps the debug container stdin open.
+	Stdin bool
+	// TTY allocates a terminal for the debug container.
+	TTY bool
+
+	// Attach attaches to the debug container once it is running,
+	// using the following streams.
+	Attach bool
+	In     io.Reader
+	Out    io.Writer
+	ErrOut io.Writer
+
+	// Timeout is the max time to wait for the debug container to be running (default 60s).
+	Timeout time.Duration
+
+	// CopyTo is the name of the pod copy (see Copy).
+	CopyTo string
+	// SetImages changes the images of the containers of the copy
+	// by container name ("*" for all the containers).
+	SetImages map[string]string
+	// RemoveProbes drops the liveness, readiness and startup probes from the copy.
+	RemoveProbes bool
+	// ShareProcesses enables the process namespace sharing in the copy.
+	ShareProcesses bool
+	// KeepLabels keeps the labels of the pod in the copy; they are
+	// removed by default so that the copy is not selected by services
+	// or controllers.
+	KeepLabels bool
+	// Replace deletes the original pod once the copy is created.
+	Replace bool
+
+	// NodeName is the node to debug (see Node).
+	NodeName string
+}
+
+// Result describes the debug container.
+type Result struct {
+	// Pod is the last observed state of the debugged pod.
+	Pod       *corev1.Pod
+	Container string
+}
+
+// Ephemeral injects an ephemeral debug container into the running pod,
+// waits for it to be running and, if Attach is set, attaches to it.
+func Ephemeral(f kubeutil.Factory, o Opts) (*Result, error) {
+	return EphemeralContext(context.Background(), f, o)
+}
+
+// EphemeralContext is like Ephemeral but stops when the context is cancelled.
+func EphemeralContext(ctx context.Context, f kubeutil.Factory, o Opts) (*Result, error) {
+	if err := complete(f, &o); err != nil {
+		return nil, err
+	}
+	if len(o.Image) == 0 {
+		o.Image = DefaultImage
+	}
+
+	cli, err := f.KubernetesClientSet()
+	if err != nil {
+		return nil, err
+	}
+
+	pod, err := cli.CoreV1().Pods(o.Namespace).Get(ctx, o.PodName, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	if kubeutil.IsPodTerminated(pod) {
+		return nil, fmt.Errorf("cannot debug a completed pod; current phase is %s", pod.Status.Phase)
+	}
+	if c, _ := kubeutil.FindContainerByName(pod, o.Container); c != nil {
+		return nil, fmt.Errorf("a container named %q already exists in pod %s/%s", o.Container, pod.Namespace, pod.Name)
+	}
+	if len(o.TargetContainer) > 0 {
+		if c, _ := kubeutil.FindContainerByName(pod, o.TargetContainer); c == nil {
+			return nil, fmt.Errorf("container %q not found in pod %s/%s", o.TargetContainer, pod.Namespace, pod.Name)
+		}
+	}
+
+	ec := corev1.EphemeralContainer{
+		EphemeralContainerCommon: corev1.EphemeralContainerCommon{
+			Name:                     o.Container,
+			Image:                    o.Image,
+			ImagePullPolicy:          o.ImagePullPolicy,
+			Command:                  o.Command,
+			Args:                     o.Args,
+			Env:                      o.Env,
+			Stdin:                    o.Stdin,
+			TTY:                      o.TTY,
+			TerminationMessagePolicy: corev1.TerminationMessageReadFile,
+		},
+		TargetContainerName: o.TargetContainer,
+	}
+
+	patch, err := json.Marshal(map[string]interface{}{
+		"spec": map[string]interface{}{
+			"ephemeralContainers": []corev1.EphemeralContainer{ec},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	pod, err = cli.CoreV1().Pods(o.Namespace).Patch(ctx, o.PodName,
+		types.StrategicMergePatchType, patch, metav1.PatchOptions{}, "ephemeralcontainers")
+	if err != nil {
+		return nil, fmt.Errorf("unable to add the debug container to pod %s/%s: %w", o.Namespace, o.PodName, err)
+	}
+
+	res := &Result{Pod: pod, Container: o.Container}
+	err = wait.PollImmediateWithContext(ctx, defaultPollInterval, o.Timeout, func(ctx context.Context) (bool, error) {
+		pod, err := cli.CoreV1().Pods(o.Namespace).Get(ctx, o.PodName, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		res.Pod = pod
+		for _, cs := range pod.Status.EphemeralContainerStatuses {
+			if cs.Name != o.Container {
+				continue
+			}
+			if t := cs.State.Terminated; t != nil {
+				if o.Attach {
+					return false, fmt.Errorf("debug container %s terminated (%s)", o.Container, t.Reason)
+				}
+				return true, nil
+			}
+			return cs.State.Running != nil, nil
+		}
+		return false, nil
+	})
+	if err != nil && ctx.Err() != nil {
+		err = ctx.Err()
+	}
+	if err != nil {
+		return res, fmt.Errorf("waiting for the debug container %s to be running: %w", o.Container, err)
+	}
+
+	if o.Attach {
+		return res, attachTo(ctx, f, res, o)
+	}
+	return res, nil
+}
+
+// complete sets the defaults of the options.
+func complete(f kubeutil.Factory, o *Opts) error {
+	if len(o.Namespace) == 0 {
+		ns, _, err := f.ToRawKubeConfigLoader().Namespace()
+		if err != nil {
+			return err
+		}
+		o.Namespace = ns
+	}
+	if len(o.Container) == 0 {
+		o.Container = "debugger-" + utilrand.String(5)
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = defaultTimeout
+	}
+	return nil
+}
+
+func attachTo(ctx context.Context, f kubeutil.Factory, res *Result, o Opts) error {
+	ao := attach.Opts{
+		Namespace: res.Pod.Namespace,
+		PodName:   res.Pod.Name,
+		Container: res.Container,
+		Stdout:    o.Out,
+		Stderr:    o.ErrOut,
+		TTY:       o.TTY,
+	}
+	if o.Stdin {
+		ao.Stdin = o.In
+	}
+	return attach.DoContext(ctx, f, ao)
+}
@@ -0,0 +1,162 @@
+package debug
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lucasepe/kube/exec"
+	kubeutil "github.com/lucasepe/kube/util"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilrand "k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	// HostRootMountPath is where the node filesystem is mounted in node debug pods.
+	HostRootMountPath = "/host"
+)
+
+// Node creates a privileged pod on the node named by NodeName, sharing
+// the host network, PID and IPC namespaces and with the node filesystem
+// mounted at /host, and waits for it to be running (kubectl debug node/...).
+// The pod tolerates every taint and is never restarted; delete it with
+// DeletePod when done.
+func Node(f kubeutil.Factory, o Opts) (*Result, error) {
+	return NodeContext(context.Background(), f, o)
+}
+
+// NodeContext is like Node but stops when the context is cancelled.
+func NodeContext(ctx context.Context, f kubeutil.Factory, o Opts) (*Result, error) {
+	if len(o.NodeName) == 0 {
+		return nil, fmt.Errorf("you must specify the node to debug")
+	}
+	if err := complete(f, &o); err != nil {
+		return nil, err
+	}
+	if len(o.Image) == 0 {
+		o.Image = DefaultImage
+	}
+
+	cli, err := f.KubernetesClientSet()
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := cli.CoreV1().Nodes().Get(ctx, o.NodeName, metav1.GetOptions{}); err != nil {
+		return nil, err
+	}
+
+	pod, err := cli.CoreV1().Pods(o.Namespace).Create(ctx, nodeDebugPod(o), metav1.CreateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("unable to create the debug pod on node %s: %w", o.NodeName, err)
+	}
+
+	name := pod.Name
+	res := &Result{Pod: pod, Container: o.Container}
+	err = wait.PollImmediateWithContext(ctx, defaultPollInterval, o.Timeout, func(ctx context.Context) (bool, error) {
+		pod, err := cli.CoreV1().Pods(o.Namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		res.Pod = pod
+		if kubeutil.IsPodTerminated(pod) {
+			if o.Attach {
+				return false, fmt.Errorf("pod %s/%s terminated with phase %s", pod.Namespace, pod.Name, pod.Status.Phase)
+			}
+			return true, nil
+		}
+		return pod.Status.Phase == corev1.PodRunning, nil
+	})
+	if err != nil && ctx.Err() != nil {
+		err = ctx.Err()
+	}
+	if err != nil {
+		return res, fmt.Errorf("waiting for the debug pod %s/%s to be running: %w", o.Namespace, name, err)
+	}
+
+	if o.Attach {
+		return res, attachTo(ctx, f, res, o)
+	}
+	return res, nil
+}
+
+// ExecOpts returns the options to run the command in the debug container.
+func (r *Result) ExecOpts(command ...string) exec.Opts {
+	return exec.Opts{
+		Namespace: r.Pod.Namespace,
+		PodName:   r.Pod.Name,
+		Container: r.Container,
+		Command:   command,
+	}
+}
+
+// DeletePod deletes the pod created by Copy or Node. It does
+// nothing if the pod is already gone.
+func DeletePod(f kubeutil.Factory, res *Result) error {
+	return DeletePodContext(context.Background(), f, res)
+}
+
+// DeletePodContext is like DeletePod but stops when the context is cancelled.
+func DeletePodContext(ctx context.Context, f kubeutil.Factory, res *Result) error {
+	cli, err := f.KubernetesClientSet()
+	if err != nil {
+		return err
+	}
+
+	err = cli.CoreV1().Pods(res.Pod.Namespace).Delete(ctx, res.Pod.Name, metav1.DeleteOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+func nodeDebugPod(o Opts) *corev1.Pod {
+	privileged := true
+	hostPathType := corev1.HostPathDirectory
+
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("node-debugger-%s-%s", o.NodeName, utilrand.String(5)),
+			Namespace: o.Namespace,
+		},
+		Spec: corev1.PodSpec{
+			NodeName:      o.NodeName,
+			HostNetwork:   true,
+			HostPID:       true,
+			HostIPC:       true,
+			RestartPolicy: corev1.RestartPolicyNever,
+			Tolerations: []corev1.Toleration{
+				{Operator: corev1.TolerationOpExists},
+			},
+			Containers: []corev1.Container{
+				{
+					Name:                     o.Container,
+					Image:                    o.Image,
+					ImagePullPolicy:          o.ImagePullPolicy,
+					Command:                  o.Command,
+					Args:                     o.Args,
+					Env:                      o.Env,
+					Stdin:                    o.Stdin,
+					TTY:                      o.TTY,
+					TerminationMessagePolicy: corev1.TerminationMessageReadFile,
+					SecurityContext: &corev1.SecurityContext{
+						Privileged: &privileged,
+					},
+					VolumeMounts: []corev1.VolumeMount{
+						{Name: "host-root", MountPath: HostRootMountPath},
+					},
+				},
+			},
+			Volumes: []corev1.Volume{
+				{
+					Name: "host-root",
+					VolumeSource: corev1.VolumeSource{
+						HostPath: &corev1.HostPathVolumeSource{Path: "/", Type: &hostPathType},
+					},
+				},
+			},
+		},
+	}
+}
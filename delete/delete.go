@@ -0,0 +1,174 @@
+package delete
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	kubeutil "github.com/lucasepe/kube/util"
+	"github.com/lucasepe/kube/wait"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/cli-runtime/pkg/resource"
+)
+
+const (
+	defaultWaitTimeout = 5 * time.Minute
+)
+
+// Opts is a set of options that allows you to delete resources.
+type Opts struct {
+	Resources     []string
+	LabelSelector string
+	FieldSelector string
+	AllNamespaces bool
+	Namespace     string
+	// All selects all the resources of the given types.
+	All bool
+
+	// Cascade is the propagation policy for the dependents (default Background).
+	Cascade metav1.DeletionPropagation
+	// GracePeriod in seconds; nil means the object default,
+	// zero deletes immediately.
+	GracePeriod *int64
+	// IgnoreNotFound treats not found resources as a successful delete.
+	IgnoreNotFound bool
+	DryRun         bool
+
+	// Wait watches each object until it is gone from the server.
+	Wait bool
+	// WaitTimeout is the max time to wait for all the deletions (default 5m).
+	WaitTimeout time.Duration
+}
+
+// Do deletes the selected resources returning the objects as they were
+// before the deletion. Failures do not stop the other deletions; all
+// the errors are returned at the end.
+func Do(f kubeutil.Factory, o Opts) ([]*unstructured.Unstructured, error) {
+	return DoContext(context.Background(), f, o)
+}
+
+// DoContext is like Do but stops when the context is cancelled.
+func DoContext(ctx context.Context, f kubeutil.Factory, o Opts) ([]*unstructured.Unstructured, error) {
+	if !o.AllNamespaces && len(o.Namespace) == 0 {
+		ns, _, err := f.ToRawKubeConfigLoader().Namespace()
+		if err != nil {
+			return nil, err
+		}
+		o.Namespace = ns
+	}
+
+	r := f.NewBuilder().
+		Unstructured().
+		ContinueOnError().
+		NamespaceParam(o.Namespace).DefaultNamespace().AllNamespaces(o.AllNamespaces).
+		LabelSelectorParam(o.LabelSelector).
+		FieldSelectorParam(o.FieldSelector).
+		SelectAllParam(o.All).
+		ResourceTypeOrNameArgs(false, o.Resources...).
+		Flatten().
+		Do()
+	if o.IgnoreNotFound {
+		r.IgnoreErrors(apierrors.IsNotFound)
+	}
+	if err := r.Err(); err != nil {
+		return nil, err
+	}
+
+	deleted, err := deleteAll(ctx, r, o)
+
+	objs := make([]*unstructured.Unstructured, 0, len(deleted))
+	for _, info := range deleted {
+		if obj, ok := info.Object.(*unstructured.Unstructured); ok {
+			objs = append(objs, obj)
+		}
+	}
+
+	if !o.Wait || o.DryRun || len(deleted) == 0 {
+		return objs, err
+	}
+
+	errs := []error{}
+	if err != nil {
+		errs = append(errs, err)
+	}
+	if err := waitForDeletion(ctx, f, deleted, o); err != nil {
+		errs = append(errs, err)
+	}
+
+	return objs, utilerrors.NewAggregate(errs)
+}
+
+// deleteAll deletes the objects of the visitor,
+// returning the ones actually deleted.
+func deleteAll(ctx context.Context, v resource.Visitor, o Opts) ([]*resource.Info, error) {
+	if len(o.Cascade) == 0 {
+		o.Cascade = metav1.DeletePropagationBackground
+	}
+
+	deleteOptions := &metav1.DeleteOptions{
+		PropagationPolicy:  &o.Cascade,
+		GracePeriodSeconds: o.GracePeriod,
+	}
+
+	deleted := []*resource.Info{}
+	err := v.Visit(func(info *resource.Info, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		_, err = resource.NewHelper(info.Client, info.Mapping).
+			DryRun(o.DryRun).
+			DeleteWithOptions(info.Namespace, info.Name, deleteOptions)
+		if err != nil {
+			if o.IgnoreNotFound && apierrors.IsNotFound(err) {
+				return nil
+			}
+			return fmt.Errorf("unable to delete %s: %w", info.ObjectName(), err)
+		}
+		deleted = append(deleted, info)
+		return nil
+	})
+
+	return deleted, err
+}
+
+// waitForDeletion waits, namespace by namespace, for
+// the deleted objects to be gone from the server.
+func waitForDeletion(ctx context.Context, f kubeutil.Factory, deleted []*resource.Info, o Opts) error {
+	if o.WaitTimeout <= 0 {
+		o.WaitTimeout = defaultWaitTimeout
+	}
+
+	namespaces := []string{}
+	names := map[string][]string{}
+	for _, info := range deleted {
+		if _, ok := names[info.Namespace]; !ok {
+			namespaces = append(namespaces, info.Namespace)
+		}
+		gr := info.Mapping.Resource.GroupResource()
+		names[info.Namespace] = append(names[info.Namespace], gr.String()+"/"+info.Name)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, o.WaitTimeout)
+	defer cancel()
+
+	errs := []error{}
+	for _, ns := range namespaces {
+		_, err := wait.ForDeletion(ctx, f, wait.Opts{
+			Namespace: ns,
+			Resources: names[ns],
+			Timeout:   o.WaitTimeout,
+		})
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return utilerrors.NewAggregate(errs)
+}
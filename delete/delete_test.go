@@ -0,0 +1,147 @@
+package delete
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest/fake"
+)
+
+// podInfo returns the info of a pod whose delete requests are
+// answered with the given status code and recorded in opts.
+func podInfo(t *testing.T, code int, opts *[]metav1.DeleteOptions) *resource.Info {
+	t.Helper()
+
+	client := &fake.RESTClient{
+		GroupVersion:         corev1.SchemeGroupVersion,
+		NegotiatedSerializer: scheme.Codecs.WithoutConversion(),
+		Client: fake.CreateHTTPClient(func(req *http.Request) (*http.Response, error) {
+			if req.Method != http.MethodDelete {
+				t.Fatalf("unexpected request: %s %s", req.Method, req.URL)
+			}
+			dat, err := io.ReadAll(req.Body)
+			if err != nil {
+				return nil, err
+			}
+			var do metav1.DeleteOptions
+			if err := json.Unmarshal(dat, &do); err != nil {
+				return nil, err
+			}
+			*opts = append(*opts, do)
+
+			status := &metav1.Status{Status: metav1.StatusSuccess, Code: int32(code)}
+			if code == http.StatusNotFound {
+				status = &metav1.Status{Status: metav1.StatusFailure, Code: int32(code), Reason: metav1.StatusReasonNotFound}
+			}
+			body := runtime.EncodeOrDie(scheme.Codecs.LegacyCodec(corev1.SchemeGroupVersion), status)
+			return &http.Response{
+				StatusCode: code,
+				Header:     http.Header{"Content-Type": []string{runtime.ContentTypeJSON}},
+				Body:       io.NopCloser(bytes.NewReader([]byte(body))),
+			}, nil
+		}),
+	}
+
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion("v1")
+	obj.SetKind("Pod")
+	obj.SetNamespace("default")
+	obj.SetName("web-0")
+
+	return &resource.Info{
+		Client:    client,
+		Namespace: "default",
+		Name:      "web-0",
+		Object:    obj,
+		Mapping: &meta.RESTMapping{
+			Resource: corev1.SchemeGroupVersion.WithResource("pods"),
+			Scope:    meta.RESTScopeNamespace,
+		},
+	}
+}
+
+func TestDeleteDefaults(t *testing.T) {
+	var sent []metav1.DeleteOptions
+	info := podInfo(t, http.StatusOK, &sent)
+
+	deleted, err := deleteAll(context.Background(), resource.InfoListVisitor{info}, Opts{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deleted) != 1 || deleted[0].Name != "web-0" {
+		t.Fatalf("unexpected deleted objects: %v", deleted)
+	}
+	if len(sent) != 1 {
+		t.Fatalf("expected a delete request, got %d", len(sent))
+	}
+	if sent[0].GracePeriodSeconds != nil {
+		t.Fatalf("expected the object grace period, got %d", *sent[0].GracePeriodSeconds)
+	}
+	if p := sent[0].PropagationPolicy; p == nil || *p != metav1.DeletePropagationBackground {
+		t.Fatalf("expected the background propagation, got %v", p)
+	}
+}
+
+func TestDeleteGracePeriod(t *testing.T) {
+	var sent []metav1.DeleteOptions
+	info := podInfo(t, http.StatusOK, &sent)
+
+	now := int64(0)
+	_, err := deleteAll(context.Background(), resource.InfoListVisitor{info}, Opts{
+		GracePeriod: &now,
+		Cascade:     metav1.DeletePropagationForeground,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sent) != 1 || sent[0].GracePeriodSeconds == nil || *sent[0].GracePeriodSeconds != 0 {
+		t.Fatalf("expected a zero grace period, got %+v", sent)
+	}
+	if p := sent[0].PropagationPolicy; p == nil || *p != metav1.DeletePropagationForeground {
+		t.Fatalf("expected the foreground propagation, got %v", p)
+	}
+}
+
+func TestDeleteIgnoreNotFound(t *testing.T) {
+	var sent []metav1.DeleteOptions
+
+	_, err := deleteAll(context.Background(), resource.InfoListVisitor{podInfo(t, http.StatusNotFound, &sent)}, Opts{})
+	if err == nil {
+		t.Fatal("expected a not found error")
+	}
+
+	deleted, err := deleteAll(context.Background(), resource.InfoListVisitor{podInfo(t, http.StatusNotFound, &sent)}, Opts{
+		IgnoreNotFound: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deleted) != 0 {
+		t.Fatalf("expected no deleted objects, got %v", deleted)
+	}
+}
+
+func TestDeleteCancelled(t *testing.T) {
+	var sent []metav1.DeleteOptions
+	info := podInfo(t, http.StatusOK, &sent)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := deleteAll(ctx, resource.InfoListVisitor{info}, Opts{}); err == nil {
+		t.Fatal("expected the context error")
+	}
+	if len(sent) != 0 {
+		t.Fatalf("expected no delete requests, got %d", len(sent))
+	}
+}
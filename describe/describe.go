@@ -0,0 +1,303 @@
+package describe
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lucasepe/kube/events"
+	kubeutil "github.com/lucasepe/kube/util"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/duration"
+)
+
+// Field is a key/value pair of a section.
+type Field struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// Section is a titled group of fields, optionally with nested sections.
+type Section struct {
+	Title    string    `json:"title"`
+	Fields   []Field   `json:"fields,omitempty"`
+	Sections []Section `json:"sections,omitempty"`
+}
+
+// Description is the structured description of an object.
+type Description struct {
+	APIVersion string                     `json:"apiVersion"`
+	Kind       string                     `json:"kind"`
+	Namespace  string                     `json:"namespace,omitempty"`
+	Name       string                     `json:"name"`
+	Sections   []Section                  `json:"sections"`
+	Events     []corev1.Event             `json:"events,omitempty"`
+	Object     *unstructured.Unstructured `json:"-"`
+}
+
+// Opts is a set of options that allows you to describe resources.
+type Opts struct {
+	Namespace     string
+	AllNamespaces bool
+	// Resources are in the form "type name..." or "type/name...".
+	Resources     []string
+	LabelSelector string
+	// NoEvents skips the lookup of the related events.
+	NoEvents bool
+}
+
+// Do returns the description of the selected resources: their metadata,
+// key spec and status fields, conditions and the related events.
+func Do(f kubeutil.Factory, o Opts) ([]Description, error) {
+	return DoContext(context.Background(), f, o)
+}
+
+// DoContext is like Do but stops when the context is cancelled.
+func DoContext(ctx context.Context, f kubeutil.Factory, o Opts) ([]Description, error) {
+	if !o.AllNamespaces && len(o.Namespace) == 0 {
+		ns, _, err := f.ToRawKubeConfigLoader().Namespace()
+		if err != nil {
+			return nil, err
+		}
+		o.Namespace = ns
+	}
+
+	infos, err := f.NewBuilder().
+		Unstructured().
+		ContinueOnError().
+		NamespaceParam(o.Namespace).DefaultNamespace().AllNamespaces(o.AllNamespaces).
+		LabelSelectorParam(o.LabelSelector).
+		ResourceTypeOrNameArgs(true, o.Resources...).
+		Flatten().
+		Do().
+		Infos()
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]Description, 0, len(infos))
+	for _, info := range infos {
+		if err := ctx.Err(); err != nil {
+			return res, err
+		}
+		obj, ok := info.Object.(*unstructured.Unstructured)
+		if !ok {
+			continue
+		}
+
+		desc := Describe(obj)
+		if !o.NoEvents {
+			if desc.Events, err = relatedEvents(ctx, f, obj); err != nil {
+				return res, err
+			}
+		}
+		res = append(res, desc)
+	}
+
+	return res, nil
+}
+
+// Describe returns the description of the object, without events.
+func Describe(obj *unstructured.Unstructured) Description {
+	desc := Description{
+		APIVersion: obj.GetAPIVersion(),
+		Kind:       obj.GetKind(),
+		Namespace:  obj.GetNamespace(),
+		Name:       obj.GetName(),
+		Object:     obj,
+	}
+
+	desc.Sections = append(desc.Sections, metadataSection(obj))
+	if s, ok := specSection(obj); ok {
+		desc.Sections = append(desc.Sections, s)
+	}
+	if s, ok := statusSection(obj); ok {
+		desc.Sections = append(desc.Sections, s)
+	}
+	if s, ok := containersSection(obj); ok {
+		desc.Sections = append(desc.Sections, s)
+	}
+	if s, ok := conditionsSection(obj); ok {
+		desc.Sections = append(desc.Sections, s)
+	}
+
+	return desc
+}
+
+func metadataSection(obj *unstructured.Unstructured) Section {
+	s := Section{Title: "Metadata"}
+	s.add("Name", obj.GetName())
+	s.add("Namespace", obj.GetNamespace())
+	s.add("Labels", formatMap(obj.GetLabels()))
+	s.add("Annotations", formatMap(obj.GetAnnotations()))
+	if ts := obj.GetCreationTimestamp(); !ts.IsZero() {
+		s.add("Created", fmt.Sprintf("%s (%s ago)", ts.UTC().Format(time.RFC1123Z), duration.HumanDuration(time.Since(ts.Time))))
+	}
+	if ts := obj.GetDeletionTimestamp(); ts != nil {
+		s.add("Deleting since", ts.UTC().Format(time.RFC1123Z))
+	}
+	owners := []string{}
+	for _, ref := range obj.GetOwnerReferences() {
+		owners = append(owners, ref.Kind+"/"+ref.Name)
+	}
+	s.add("Controlled by", strings.Join(owners, ", "))
+	s.add("UID", string(obj.GetUID()))
+	return s
+}
+
+// specFields are the spec fields worth showing, in order.
+var specFields = [][]string{
+	{"replicas"}, {"selector"}, {"type"}, {"clusterIP"}, {"nodeName"},
+	{"serviceAccountName"}, {"schedule"}, {"suspend"}, {"paused"},
+	{"strategy", "type"}, {"updateStrategy", "type"}, {"storageClassName"}, {"volumeName"},
+}
+
+func specSection(obj *unstructured.Unstructured) (Section, bool) {
+	s := Section{Title: "Spec"}
+	for _, fp := range specFields {
+		val, ok, _ := unstructured.NestedFieldNoCopy(obj.Object, append([]string{"spec"}, fp...)...)
+		if ok {
+			s.add(fieldName(fp), formatValue(val))
+		}
+	}
+
+	ports, _, _ := unstructured.NestedSlice(obj.Object, "spec", "ports")
+	for _, p := range ports {
+		pm, ok := p.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		desc := fmt.Sprintf("%v/%v", pm["port"], valueOr(pm["protocol"], "TCP"))
+		if tp, ok := pm["targetPort"]; ok {
+			desc = fmt.Sprintf("%s -> %v", desc, tp)
+		}
+		s.add(fmt.Sprintf("Port %v", valueOr(pm["name"], "<unset>")), desc)
+	}
+
+	return s, len(s.Fields) > 0
+}
+
+func statusSection(obj *unstructured.Unstructured) (Section, bool) {
+	s := Section{Title: "Status"}
+	status, ok, _ := unstructured.NestedMap(obj.Object, "status")
+	if !ok {
+		return s, false
+	}
+
+	keys := make([]string, 0, len(status))
+	for k, v := range status {
+		switch v.(type) {
+		case string, bool, int64, float64:
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		s.add(fieldName([]string{k}), formatValue(status[k]))
+	}
+	return s, len(s.Fields) > 0
+}
+
+func containersSection(obj *unstructured.Unstructured) (Section, bool) {
+	s := Section{Title: "Containers"}
+	if obj.GetKind() != "Pod" {
+		return s, false
+	}
+
+	pod := &corev1.Pod{}
+	if err := runtimeConverter(obj, pod); err != nil {
+		return s, false
+	}
+
+	statuses := map[string]corev1.ContainerStatus{}
+	for _, cs := range append(pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses...) {
+		statuses[cs.Name] = cs
+	}
+
+	add := func(c corev1.Container, init bool) {
+		cs := Section{Title: c.Name}
+		if init {
+			cs.Title += " (init)"
+		}
+		cs.add("Image", c.Image)
+		cs.add("Requests", formatResources(c.Resources.Requests))
+		cs.add("Limits", formatResources(c.Resources.Limits))
+		if st, ok := statuses[c.Name]; ok {
+			cs.add("State", containerState(st.State))
+			if st.LastTerminationState.Terminated != nil {
+				cs.add("Last State", containerState(st.LastTerminationState))
+			}
+			cs.add("Ready", fmt.Sprint(st.Ready))
+			cs.add("Restart Count", fmt.Sprint(st.RestartCount))
+		}
+		s.Sections = append(s.Sections, cs)
+	}
+	for _, c := range pod.Spec.InitContainers {
+		add(c, true)
+	}
+	for _, c := range pod.Spec.Containers {
+		add(c, false)
+	}
+
+	return s, len(s.Sections) > 0
+}
+
+func conditionsSection(obj *unstructured.Unstructured) (Section, bool) {
+	s := Section{Title: "Conditions"}
+	conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	for _, c := range conditions {
+		cm, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		val := fmt.Sprint(valueOr(cm["status"], "Unknown"))
+		if reason, ok := cm["reason"].(string); ok && len(reason) > 0 {
+			val = fmt.Sprintf("%s (%s)", val, reason)
+		}
+		if msg, ok := cm["message"].(string); ok && len(msg) > 0 {
+			val = fmt.Sprintf("%s: %s", val, msg)
+		}
+		s.add(fmt.Sprint(cm["type"]), val)
+	}
+	return s, len(s.Fields) > 0
+}
+
+// relatedEvents returns the events involving the object.
+func relatedEvents(ctx context.Context, f kubeutil.Factory, obj *unstructured.Unstructured) ([]corev1.Event, error) {
+	namespace := obj.GetNamespace()
+	if len(namespace) == 0 {
+		namespace = metav1.NamespaceDefault
+	}
+
+	list, err := events.DoContext(ctx, f, events.Opts{Namespace: namespace, ForName: obj.GetName()})
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "no events found") {
+			return []corev1.Event{}, nil
+		}
+		return nil, err
+	}
+
+	res := []corev1.Event{}
+	for _, ev := range list {
+		if ev.InvolvedObject.Kind != obj.GetKind() {
+			continue
+		}
+		if len(ev.InvolvedObject.UID) > 0 && ev.InvolvedObject.UID != obj.GetUID() {
+			continue
+		}
+		res = append(res, ev)
+	}
+	return res, nil
+}
+
+func (s *Section) add(key, value string) {
+	if len(value) == 0 {
+		return
+	}
+	s.Fields = append(s.Fields, Field{Key: key, Value: value})
+}
@@ -0,0 +1,162 @@
+package describe
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// field returns the value of the field of the titled section.
+func field(desc Description, title, key string) (string, bool) {
+	for _, s := range desc.Sections {
+		if s.Title != title {
+			continue
+		}
+		for _, f := range s.Fields {
+			if f.Key == key {
+				return f.Value, true
+			}
+		}
+	}
+	return "", false
+}
+
+func TestDescribeDeployment(t *testing.T) {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata": map[string]interface{}{
+			"name":      "web",
+			"namespace": "default",
+			"labels":    map[string]interface{}{"tier": "frontend", "app": "web"},
+		},
+		"spec": map[string]interface{}{
+			"replicas": int64(3),
+			"selector": map[string]interface{}{
+				"matchLabels": map[string]interface{}{"app": "web"},
+			},
+			"strategy": map[string]interface{}{"type": "RollingUpdate"},
+		},
+		"status": map[string]interface{}{
+			"readyReplicas": int64(2),
+			"conditions": []interface{}{
+				map[string]interface{}{"type": "Available", "status": "False", "reason": "MinimumReplicasUnavailable"},
+			},
+		},
+	}}
+
+	desc := Describe(obj)
+	if desc.Kind != "Deployment" || desc.Namespace != "default" || desc.Name != "web" {
+		t.Fatalf("unexpected description %+v", desc)
+	}
+
+	tests := []struct {
+		title, key, value string
+	}{
+		{"Metadata", "Labels", "app=web, tier=frontend"},
+		{"Spec", "Replicas", "3"},
+		{"Spec", "Selector", "app=web"},
+		{"Spec", "Strategy Type", "RollingUpdate"},
+		{"Status", "Ready Replicas", "2"},
+		{"Conditions", "Available", "False (MinimumReplicasUnavailable)"},
+	}
+	for _, tt := range tests {
+		got, ok := field(desc, tt.title, tt.key)
+		if !ok || got != tt.value {
+			t.Errorf("%s/%s: expected %q, got %q", tt.title, tt.key, tt.value, got)
+		}
+	}
+
+	if _, ok := field(desc, "Metadata", "Annotations"); ok {
+		t.Error("expected the empty fields to be omitted")
+	}
+	for _, s := range desc.Sections {
+		if s.Title == "Containers" {
+			t.Error("expected no containers section for a deployment")
+		}
+	}
+}
+
+func TestDescribePod(t *testing.T) {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Pod",
+		"metadata":   map[string]interface{}{"name": "web-0", "namespace": "default"},
+		"spec": map[string]interface{}{
+			"initContainers": []interface{}{
+				map[string]interface{}{"name": "setup", "image": "busybox"},
+			},
+			"containers": []interface{}{
+				map[string]interface{}{
+					"name":  "app",
+					"image": "nginx",
+					"resources": map[string]interface{}{
+						"requests": map[string]interface{}{"memory": "64Mi", "cpu": "100m"},
+					},
+				},
+			},
+		},
+		"status": map[string]interface{}{
+			"phase": "Running",
+			"containerStatuses": []interface{}{
+				map[string]interface{}{
+					"name":         "app",
+					"ready":        false,
+					"restartCount": int64(2),
+					"state": map[string]interface{}{
+						"waiting": map[string]interface{}{"reason": "CrashLoopBackOff"},
+					},
+					"lastState": map[string]interface{}{
+						"terminated": map[string]interface{}{"reason": "Error", "exitCode": int64(1)},
+					},
+				},
+			},
+		},
+	}}
+
+	desc := Describe(obj)
+	if phase, _ := field(desc, "Status", "Phase"); phase != "Running" {
+		t.Fatalf("expected the phase, got %q", phase)
+	}
+
+	var containers *Section
+	for i := range desc.Sections {
+		if desc.Sections[i].Title == "Containers" {
+			containers = &desc.Sections[i]
+		}
+	}
+	if containers == nil || len(containers.Sections) != 2 {
+		t.Fatalf("expected the init and the app containers, got %+v", containers)
+	}
+	if containers.Sections[0].Title != "setup (init)" {
+		t.Fatalf("expected the init container first, got %q", containers.Sections[0].Title)
+	}
+
+	app := Description{Sections: []Section{containers.Sections[1]}}
+	tests := map[string]string{
+		"Image":         "nginx",
+		"Requests":      "cpu=100m, memory=64Mi",
+		"State":         "Waiting (CrashLoopBackOff)",
+		"Last State":    "Terminated (Error, exit code 1)",
+		"Ready":         "false",
+		"Restart Count": "2",
+	}
+	for key, value := range tests {
+		if got, _ := field(app, "app", key); got != value {
+			t.Errorf("%s: expected %q, got %q", key, value, got)
+		}
+	}
+}
+
+func TestFieldName(t *testing.T) {
+	tests := map[string][]string{
+		"Replicas":             {"replicas"},
+		"Update Strategy Type": {"updateStrategy", "type"},
+		"Cluster IP":           {"clusterIP"},
+	}
+	for want, fp := range tests {
+		if got := fieldName(fp); got != want {
+			t.Errorf("expected %q, got %q", want, got)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package describe
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// fieldName turns a field path into a title (e.g. ["updateStrategy", "type"] -> "Update Strategy Type").
+func fieldName(fp []string) string {
+	words := []string{}
+	for _, f := range fp {
+		start := 0
+		for i, r := range f {
+			if i > 0 && unicode.IsUpper(r) && !unicode.IsUpper(rune(f[i-1])) {
+				words = append(words, f[start:i])
+				start = i
+			}
+		}
+		words = append(words, f[start:])
+	}
+	for i, w := range words {
+		words[i] = strings.ToUpper(w[:1]) + w[1:]
+	}
+	return strings.Join(words, " ")
+}
+
+func formatMap(m map[string]string) string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, k+"="+m[k])
+	}
+	return strings.Join(pairs, ", ")
+}
+
+func formatValue(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	case map[string]interface{}:
+		if ml, ok := t["matchLabels"].(map[string]interface{}); ok && len(t) == 1 {
+			labels := map[string]string{}
+			for k, v := range ml {
+				labels[k] = fmt.Sprint(v)
+			}
+			return formatMap(labels)
+		}
+		allStrings := map[string]string{}
+		for k, v := range t {
+			s, ok := v.(string)
+			if !ok {
+				data, _ := json.Marshal(t)
+				return string(data)
+			}
+			allStrings[k] = s
+		}
+		return formatMap(allStrings)
+	case []interface{}:
+		data, _ := json.Marshal(t)
+		return string(data)
+	default:
+		return fmt.Sprint(t)
+	}
+}
+
+func valueOr(v interface{}, def string) interface{} {
+	if v == nil {
+		return def
+	}
+	return v
+}
+
+func formatResources(rl corev1.ResourceList) string {
+	keys := make([]string, 0, len(rl))
+	for k := range rl {
+		keys = append(keys, string(k))
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		q := rl[corev1.ResourceName(k)]
+		pairs = append(pairs, k+"="+q.String())
+	}
+	return strings.Join(pairs, ", ")
+}
+
+func containerState(st corev1.ContainerState) string {
+	switch {
+	case st.Running != nil:
+		return fmt.Sprintf("Running (since %s)", st.Running.StartedAt.UTC().Format("2006-01-02 15:04:05"))
+	case st.Waiting != nil:
+		return fmt.Sprintf("Waiting (%s)", st.Waiting.Reason)
+	case st.Terminated != nil:
+		return fmt.Sprintf("Terminated (%s, exit code %d)", st.Terminated.Reason, st.Terminated.ExitCode)
+	}
+	return "Unknown"
+}
+
+func runtimeConverter(obj *unstructured.Unstructured, into interface{}) error {
+	return runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, into)
+}
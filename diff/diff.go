@@ -0,0 +1,253 @@
+package diff
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	kubeutil "github.com/lucasepe/kube/util"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/cli-runtime/pkg/resource"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// DefaultFieldManager is the field manager used when none is specified.
+	DefaultFieldManager = "kube"
+)
+
+// Opts is a set of options that allows you to compute the changes
+// a server-side apply of the manifests would make.
+type Opts struct {
+	// Filenames are files, directories or URLs containing the manifests.
+	Filenames []string
+	Recursive bool
+	// Readers are streams of YAML or JSON manifests.
+	Readers []io.Reader
+	// Objects are compared as they are.
+	Objects []*unstructured.Unstructured
+
+	// Namespace is used for the namespaced objects without a namespace
+	// (defaults to the namespace of the current context).
+	Namespace string
+	// EnforceNamespace fails if an object declares a different namespace.
+	EnforceNamespace bool
+
+	// FieldManager is the name of the actor owning the applied fields (default "kube").
+	FieldManager string
+	// Force takes the ownership of the fields managed by someone else, instead of failing with a conflict.
+	Force bool
+}
+
+// Result is the computed change for a single object.
+type Result struct {
+	Kind      string
+	Namespace string
+	Name      string
+	// Live is the current state of the object (nil if it does not exist yet).
+	Live *unstructured.Unstructured
+	// Merged is the state the object would have after the apply.
+	Merged *unstructured.Unstructured
+	// Diff is the unified diff between the YAML of Live and Merged
+	// (empty if nothing would change).
+	Diff string
+}
+
+// Changed reports whether applying the object would change it.
+func (r Result) Changed() bool {
+	return len(r.Diff) > 0
+}
+
+// Do submits the manifests with a server-side apply dry-run and returns,
+// for each object, the live and the merged state and their diff. Both
+// objects are stripped of the managed fields and of the metadata the
+// server updates on every write. A failure does not stop the other
+// objects; all the errors are returned at the end.
+func Do(f kubeutil.Factory, o Opts) ([]Result, error) {
+	return DoContext(context.Background(), f, o)
+}
+
+// DoContext is like Do but stops when the context is cancelled.
+func DoContext(ctx context.Context, f kubeutil.Factory, o Opts) ([]Result, error) {
+	if len(o.FieldManager) == 0 {
+		o.FieldManager = DefaultFieldManager
+	}
+	if len(o.Namespace) == 0 {
+		ns, enforce, err := f.ToRawKubeConfigLoader().Namespace()
+		if err != nil {
+			return nil, err
+		}
+		o.Namespace, o.EnforceNamespace = ns, o.EnforceNamespace || enforce
+	}
+
+	infos, err := loadInfos(f, o)
+	if err != nil {
+		return nil, err
+	}
+
+	results := []Result{}
+	errs := []error{}
+	for _, info := range infos {
+		if err := ctx.Err(); err != nil {
+			errs = append(errs, err)
+			break
+		}
+		res, err := diffOne(info, o)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		results = append(results, res)
+	}
+
+	return results, utilerrors.NewAggregate(errs)
+}
+
+func diffOne(info *resource.Info, o Opts) (Result, error) {
+	res := Result{
+		Kind:      info.Mapping.GroupVersionKind.Kind,
+		Namespace: info.Namespace,
+		Name:      info.Name,
+	}
+
+	data, err := runtime.Encode(unstructured.UnstructuredJSONScheme, info.Object)
+	if err != nil {
+		return res, fmt.Errorf("unable to encode %s: %w", info.ObjectName(), err)
+	}
+
+	helper := resource.NewHelper(info.Client, info.Mapping).
+		WithFieldManager(o.FieldManager).
+		DryRun(true)
+
+	live, err := helper.Get(info.Namespace, info.Name)
+	switch {
+	case apierrors.IsNotFound(err):
+	case err != nil:
+		return res, fmt.Errorf("unable to get %s: %w", info.ObjectName(), err)
+	default:
+		u, ok := live.(*unstructured.Unstructured)
+		if !ok {
+			return res, fmt.Errorf("unexpected object type %T getting %s", live, info.ObjectName())
+		}
+		res.Live = stripVolatile(u)
+	}
+
+	merged, err := helper.Patch(info.Namespace, info.Name, types.ApplyPatchType, data,
+		&metav1.PatchOptions{Force: &o.Force})
+	if err != nil {
+		return res, fmt.Errorf("unable to dry-run apply %s: %w", info.ObjectName(), err)
+	}
+	u, ok := merged.(*unstructured.Unstructured)
+	if !ok {
+		return res, fmt.Errorf("unexpected object type %T applying %s", merged, info.ObjectName())
+	}
+	res.Merged = stripVolatile(u)
+
+	from, err := toYAML(res.Live)
+	if err != nil {
+		return res, err
+	}
+	to, err := toYAML(res.Merged)
+	if err != nil {
+		return res, err
+	}
+	res.Diff = kubeutil.UnifiedDiff("live/"+info.ObjectName(), "merged/"+info.ObjectName(), from, to)
+
+	return res, nil
+}
+
+// stripVolatile returns a copy of the object without the managed fields
+// and the metadata that changes on every write (or dry-run create).
+func stripVolatile(obj *unstructured.Unstructured) *unstructured.Unstructured {
+	obj = obj.DeepCopy()
+	obj.SetManagedFields(nil)
+	obj.SetResourceVersion("")
+	unstructured.RemoveNestedField(obj.Object, "metadata", "generation")
+	obj.SetUID("")
+	obj.SetSelfLink("")
+	obj.SetCreationTimestamp(metav1.Time{})
+	return obj
+}
+
+func toYAML(obj *unstructured.Unstructured) (string, error) {
+	if obj == nil {
+		return "", nil
+	}
+	dat, err := yaml.Marshal(obj.Object)
+	if err != nil {
+		return "", err
+	}
+	return string(dat), nil
+}
+
+func loadInfos(f kubeutil.Factory, o Opts) ([]*resource.Info, error) {
+	infos := []*resource.Info{}
+
+	if len(o.Filenames) > 0 || len(o.Readers) > 0 {
+		b := f.NewBuilder().
+			Unstructured().
+			ContinueOnError().
+			NamespaceParam(o.Namespace).DefaultNamespace().
+			FilenameParam(o.EnforceNamespace, &resource.FilenameOptions{
+				Filenames: o.Filenames,
+				Recursive: o.Recursive,
+			}).
+			Flatten()
+		for i, r := range o.Readers {
+			b = b.Stream(r, fmt.Sprintf("reader-%d", i))
+		}
+
+		list, err := b.Do().Infos()
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, list...)
+	}
+
+	if len(o.Objects) == 0 {
+		return infos, nil
+	}
+
+	mapper, err := f.ToRESTMapper()
+	if err != nil {
+		return nil, err
+	}
+	for _, obj := range o.Objects {
+		gvk := obj.GroupVersionKind()
+		mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+		if err != nil {
+			return nil, err
+		}
+		client, err := f.UnstructuredClientForMapping(mapping)
+		if err != nil {
+			return nil, err
+		}
+
+		obj = obj.DeepCopy()
+		if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+			switch ns := obj.GetNamespace(); {
+			case len(ns) == 0:
+				obj.SetNamespace(o.Namespace)
+			case o.EnforceNamespace && ns != o.Namespace:
+				return nil, fmt.Errorf("the namespace of %s/%s (%s) does not match the namespace %q",
+					gvk.Kind, obj.GetName(), ns, o.Namespace)
+			}
+		}
+
+		infos = append(infos, &resource.Info{
+			Client:    client,
+			Mapping:   mapping,
+			Namespace: obj.GetNamespace(),
+			Name:      obj.GetName(),
+			Object:    obj,
+		})
+	}
+
+	return infos, nil
+}
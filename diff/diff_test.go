@@ -0,0 +1,156 @@
+package diff
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/client-go/rest/fake"
+)
+
+func configMap(data map[string]interface{}) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata":   map[string]interface{}{"name": "settings", "namespace": "default"},
+		"data":       data,
+	}}
+}
+
+// serverSide marks the object as returned by the server.
+func serverSide(obj *unstructured.Unstructured) *unstructured.Unstructured {
+	obj = obj.DeepCopy()
+	obj.SetUID("1234")
+	obj.SetResourceVersion("42")
+	obj.SetManagedFields(nil)
+	unstructured.SetNestedSlice(obj.Object, []interface{}{
+		map[string]interface{}{"manager": "kube", "operation": "Apply"},
+	}, "metadata", "managedFields")
+	return obj
+}
+
+// configMapInfo returns the info of the applied object served by a fake
+// client: the get returns live (or not found) and the patch merged.
+func configMapInfo(t *testing.T, applied, live, merged *unstructured.Unstructured, patches *[]*http.Request) *resource.Info {
+	t.Helper()
+
+	respond := func(code int, obj *unstructured.Unstructured) (*http.Response, error) {
+		body := []byte(`{"apiVersion":"v1","kind":"Status","status":"Failure","reason":"NotFound","code":404}`)
+		if obj != nil {
+			var err error
+			if body, err = obj.MarshalJSON(); err != nil {
+				return nil, err
+			}
+		}
+		return &http.Response{
+			StatusCode: code,
+			Header:     http.Header{"Content-Type": []string{runtime.ContentTypeJSON}},
+			Body:       io.NopCloser(bytes.NewReader(body)),
+		}, nil
+	}
+
+	client := &fake.RESTClient{
+		GroupVersion:         corev1.SchemeGroupVersion,
+		NegotiatedSerializer: resource.UnstructuredPlusDefaultContentConfig().NegotiatedSerializer,
+		Client: fake.CreateHTTPClient(func(req *http.Request) (*http.Response, error) {
+			switch req.Method {
+			case http.MethodGet:
+				if live == nil {
+					return respond(http.StatusNotFound, nil)
+				}
+				return respond(http.StatusOK, serverSide(live))
+			case http.MethodPatch:
+				*patches = append(*patches, req)
+				return respond(http.StatusOK, serverSide(merged))
+			}
+			t.Fatalf("unexpected request: %s %s", req.Method, req.URL)
+			return nil, nil
+		}),
+	}
+
+	return &resource.Info{
+		Client:    client,
+		Namespace: "default",
+		Name:      "settings",
+		Object:    applied,
+		Mapping: &meta.RESTMapping{
+			Resource:         corev1.SchemeGroupVersion.WithResource("configmaps"),
+			GroupVersionKind: corev1.SchemeGroupVersion.WithKind("ConfigMap"),
+			Scope:            meta.RESTScopeNamespace,
+		},
+	}
+}
+
+func TestDiffOneChanged(t *testing.T) {
+	var patches []*http.Request
+	applied := configMap(map[string]interface{}{"mode": "debug"})
+	live := configMap(map[string]interface{}{"mode": "production"})
+	info := configMapInfo(t, applied, live, applied, &patches)
+
+	res, err := diffOne(info, Opts{FieldManager: "tests", Force: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Changed() {
+		t.Fatal("expected a change")
+	}
+	if !strings.Contains(res.Diff, "-  mode: production") || !strings.Contains(res.Diff, "+  mode: debug") {
+		t.Fatalf("unexpected diff:\n%s", res.Diff)
+	}
+	for _, obj := range []*unstructured.Unstructured{res.Live, res.Merged} {
+		if len(obj.GetManagedFields()) > 0 || len(obj.GetResourceVersion()) > 0 || len(obj.GetUID()) > 0 {
+			t.Fatalf("expected the volatile metadata to be stripped, got %v", obj.Object["metadata"])
+		}
+	}
+
+	if len(patches) != 1 {
+		t.Fatalf("expected a patch request, got %d", len(patches))
+	}
+	req := patches[0]
+	if ct := req.Header.Get("Content-Type"); ct != string(types.ApplyPatchType) {
+		t.Fatalf("expected a server-side apply, got %q", ct)
+	}
+	q := req.URL.Query()
+	if q.Get("dryRun") != "All" || q.Get("fieldManager") != "tests" || q.Get("force") != "true" {
+		t.Fatalf("expected a forced dry-run, got %v", q)
+	}
+}
+
+func TestDiffOneNew(t *testing.T) {
+	var patches []*http.Request
+	applied := configMap(map[string]interface{}{"mode": "debug"})
+	info := configMapInfo(t, applied, nil, applied, &patches)
+
+	res, err := diffOne(info, Opts{FieldManager: DefaultFieldManager})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Live != nil || res.Merged == nil {
+		t.Fatalf("expected only the merged object, got %+v", res)
+	}
+	if !res.Changed() || strings.Contains(res.Diff, "\n-  ") {
+		t.Fatalf("expected only additions, got:\n%s", res.Diff)
+	}
+}
+
+func TestDiffOneUnchanged(t *testing.T) {
+	var patches []*http.Request
+	applied := configMap(map[string]interface{}{"mode": "debug"})
+	info := configMapInfo(t, applied, applied, applied, &patches)
+
+	res, err := diffOne(info, Opts{FieldManager: DefaultFieldManager})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Changed() {
+		t.Fatalf("expected no changes, got:\n%s", res.Diff)
+	}
+}
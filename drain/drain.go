@@ -0,0 +1,263 @@
+package drain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lucasepe/kube/node"
+	kubeutil "github.com/lucasepe/kube/util"
+	"golang.org/x/sync/errgroup"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	defaultConcurrency  = 10
+	defaultPollInterval = 2 * time.Second
+	initialBackoff      = 1 * time.Second
+	maxBackoff          = 30 * time.Second
+)
+
+// Opts is a set of options that allows you to drain a node.
+type Opts struct {
+	// DrainOpts selects which pods can be evicted (see node.ClassifyPod).
+	node.DrainOpts
+
+	// GracePeriod overrides the termination grace period of the
+	// evicted pods, rounded up to the second; zero means the pod's default.
+	GracePeriod time.Duration
+	// Timeout is the max time to wait for the drain to complete; zero means no limit.
+	Timeout time.Duration
+	// Concurrency is the max number of pods evicted at the same time (default 10).
+	Concurrency int
+	// SkipWaitForDeletion returns as soon as the evictions are accepted,
+	// without waiting for the pods to be gone.
+	SkipWaitForDeletion bool
+	// OnProgress, if not nil, is invoked (serially) after every pod
+	// has been evicted or has failed to be.
+	OnProgress func(PodResult)
+}
+
+// PodResult is the outcome of the drain for a single pod.
+type PodResult struct {
+	Namespace string
+	Name      string
+	// Reason explains why the pod was skipped or carries a
+	// warning for the evicted ones (e.g. local data loss).
+	Reason string
+	// Attempts is the number of eviction requests sent.
+	Attempts int
+	Duration time.Duration
+	Err      error
+}
+
+// Result is the outcome of a node drain.
+type Result struct {
+	Node string
+	// Cordoned is true if the node was marked unschedulable by this drain.
+	Cordoned bool
+	Evicted  []PodResult
+	Skipped  []PodResult
+	Failed   []PodResult
+}
+
+// Do cordons the node and evicts its pods using the Eviction API, so that
+// PodDisruptionBudgets are respected: evictions refused by a budget are
+// retried with backoff until the timeout expires. Mirror pods (and the
+// DaemonSet pods, if IgnoreDaemonSets is set) are left on the node.
+//
+// If any pod cannot be evicted with the given options nothing is evicted
+// and an error listing the blocking pods is returned; the node stays cordoned.
+func Do(ctx context.Context, f kubeutil.Factory, nodeName string, o Opts) (*Result, error) {
+	if o.Concurrency <= 0 {
+		o.Concurrency = defaultConcurrency
+	}
+	if o.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, o.Timeout)
+		defer cancel()
+	}
+
+	cordoned, err := Cordon(ctx, f, nodeName)
+	if err != nil {
+		return nil, err
+	}
+	res := &Result{Node: nodeName, Cordoned: cordoned}
+
+	pods, err := kubeutil.PodsOnNode(ctx, f, nodeName, kubeutil.PodsOnNodeOpts{
+		LabelSelector: o.PodSelector,
+	})
+	if err != nil {
+		return res, err
+	}
+
+	cli, err := f.KubernetesClientSet()
+	if err != nil {
+		return res, err
+	}
+
+	return res, drainPods(ctx, cli, res, pods, o)
+}
+
+// drainPods evicts the pods of the node, recording the outcomes in res.
+func drainPods(ctx context.Context, cli kubernetes.Interface, res *Result, pods []corev1.Pod, o Opts) error {
+	evict := []PodResult{}
+	blocked := []string{}
+	uids := map[string]types.UID{}
+	for _, pod := range pods {
+		action, reason := node.ClassifyPod(&pod, o.DrainOpts)
+		switch action {
+		case node.ActionEvict:
+			evict = append(evict, PodResult{Namespace: pod.Namespace, Name: pod.Name, Reason: reason})
+			uids[pod.Namespace+"/"+pod.Name] = pod.UID
+		case node.ActionSkip:
+			res.Skipped = append(res.Skipped, PodResult{Namespace: pod.Namespace, Name: pod.Name, Reason: reason})
+		case node.ActionBlock:
+			blocked = append(blocked, fmt.Sprintf("%s/%s: %s", pod.Namespace, pod.Name, reason))
+		}
+	}
+	if len(blocked) > 0 {
+		return fmt.Errorf("cannot drain node %s:\n  %s", res.Node, strings.Join(blocked, "\n  "))
+	}
+
+	var mu sync.Mutex
+	completed := func(pr PodResult) {
+		mu.Lock()
+		defer mu.Unlock()
+		if pr.Err != nil {
+			res.Failed = append(res.Failed, pr)
+		} else {
+			res.Evicted = append(res.Evicted, pr)
+		}
+		if o.OnProgress != nil {
+			o.OnProgress(pr)
+		}
+	}
+
+	g := new(errgroup.Group)
+	g.SetLimit(o.Concurrency)
+	for _, pr := range evict {
+		pr := pr
+		g.Go(func() error {
+			completed(evictPod(ctx, cli, pr, uids[pr.Namespace+"/"+pr.Name], o))
+			return nil
+		})
+	}
+	g.Wait()
+
+	errs := []error{}
+	for _, pr := range res.Failed {
+		errs = append(errs, pr.Err)
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// Cordon marks the node as unschedulable. It returns false
+// if the node was already unschedulable.
+func Cordon(ctx context.Context, f kubeutil.Factory, nodeName string) (bool, error) {
+	return setUnschedulable(ctx, f, nodeName, true)
+}
+
+// Uncordon marks the node as schedulable. It returns false
+// if the node was already schedulable.
+func Uncordon(ctx context.Context, f kubeutil.Factory, nodeName string) (bool, error) {
+	return setUnschedulable(ctx, f, nodeName, false)
+}
+
+func setUnschedulable(ctx context.Context, f kubeutil.Factory, nodeName string, unschedulable bool) (bool, error) {
+	cli, err := f.KubernetesClientSet()
+	if err != nil {
+		return false, err
+	}
+
+	n, err := cli.CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{})
+	if err != nil {
+		return false, err
+	}
+	if n.Spec.Unschedulable == unschedulable {
+		return false, nil
+	}
+
+	patch := fmt.Sprintf(`{"spec":{"unschedulable":%t}}`, unschedulable)
+	_, err = cli.CoreV1().Nodes().Patch(ctx, nodeName,
+		types.StrategicMergePatchType, []byte(patch), metav1.PatchOptions{})
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// evictPod evicts the pod, retrying while a PodDisruptionBudget blocks
+// the eviction, then waits for the pod to be gone.
+func evictPod(ctx context.Context, cli kubernetes.Interface, pod PodResult, uid types.UID, o Opts) (pr PodResult) {
+	pr = pod
+	start := time.Now()
+	defer func() { pr.Duration = time.Since(start) }()
+
+	gracePeriod := int64(-1)
+	if o.GracePeriod > 0 {
+		// rounded up, a sub-second value must not mean an immediate deletion
+		gracePeriod = int64((o.GracePeriod + time.Second - 1) / time.Second)
+	}
+
+	backoff := initialBackoff
+	for {
+		pr.Attempts++
+		err := kubeutil.EvictPodWithClient(ctx, cli, pr.Namespace, pr.Name, uid, gracePeriod)
+		if err == nil || apierrors.IsNotFound(err) || apierrors.IsConflict(err) {
+			// a conflict means the pod was replaced (the uid precondition failed)
+			break
+		}
+
+		var blocked *kubeutil.EvictionBlockedError
+		if !errors.As(err, &blocked) {
+			pr.Err = err
+			return pr
+		}
+
+		delay := backoff
+		if blocked.RetryAfter > 0 {
+			delay = blocked.RetryAfter
+		}
+		backoff *= 2
+		if backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+
+		select {
+		case <-ctx.Done():
+			pr.Err = err
+			return pr
+		case <-time.After(delay):
+		}
+	}
+
+	if o.SkipWaitForDeletion {
+		return pr
+	}
+
+	err := wait.PollImmediateUntilWithContext(ctx, defaultPollInterval, func(ctx context.Context) (bool, error) {
+		pod, err := cli.CoreV1().Pods(pr.Namespace).Get(ctx, pr.Name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return true, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		// a pod with the same name recreated (e.g. by a StatefulSet)
+		return pod.UID != uid, nil
+	})
+	if err != nil {
+		pr.Err = fmt.Errorf("waiting for the deletion of pod %s/%s: %w", pr.Namespace, pr.Name, err)
+	}
+	return pr
+}
@@ -0,0 +1,168 @@
+package drain
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lucasepe/kube/node"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func managedPod(name string) corev1.Pod {
+	owner := &appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{Name: "web", UID: "rs-uid"}}
+	return corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+			UID:       types.UID(name + "-uid"),
+			OwnerReferences: []metav1.OwnerReference{
+				*metav1.NewControllerRef(owner, appsv1.SchemeGroupVersion.WithKind("ReplicaSet")),
+			},
+		},
+		Spec: corev1.PodSpec{NodeName: "node-1"},
+	}
+}
+
+// evictions records the evictions and deletes the evicted pods,
+// refusing the first ones (as many as refusals) as a PodDisruptionBudget would.
+type evictions struct {
+	mu       sync.Mutex
+	refusals int
+	sent     []*policyv1.Eviction
+}
+
+func (e *evictions) install(cli *fake.Clientset) {
+	cli.PrependReactor("create", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() != "eviction" {
+			return false, nil, nil
+		}
+		eviction := action.(k8stesting.CreateAction).GetObject().(*policyv1.Eviction)
+
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		e.sent = append(e.sent, eviction)
+		if e.refusals > 0 {
+			e.refusals--
+			return true, nil, apierrors.NewTooManyRequests("disruption budget", 0)
+		}
+		err := cli.Tracker().Delete(corev1.SchemeGroupVersion.WithResource("pods"), eviction.Namespace, eviction.Name)
+		return true, nil, err
+	})
+}
+
+func newClient(pods []corev1.Pod) *fake.Clientset {
+	objs := []runtime.Object{}
+	for i := range pods {
+		objs = append(objs, &pods[i])
+	}
+	return fake.NewSimpleClientset(objs...)
+}
+
+func TestDrainPods(t *testing.T) {
+	mirror := managedPod("kube-proxy")
+	mirror.Annotations = map[string]string{"kubernetes.io/config.mirror": "hash"}
+	pods := []corev1.Pod{managedPod("web-1"), managedPod("web-2"), mirror}
+
+	cli := newClient(pods)
+	ev := &evictions{}
+	ev.install(cli)
+
+	res := &Result{Node: "node-1"}
+	err := drainPods(context.Background(), cli, res, pods, Opts{
+		Concurrency: 1,
+		GracePeriod: 500 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Evicted) != 2 || len(res.Skipped) != 1 || len(res.Failed) != 0 {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+
+	for _, eviction := range ev.sent {
+		opts := eviction.DeleteOptions
+		if opts.GracePeriodSeconds == nil || *opts.GracePeriodSeconds != 1 {
+			t.Fatalf("expected the grace period rounded up to 1s, got %v", opts.GracePeriodSeconds)
+		}
+		if opts.Preconditions == nil || opts.Preconditions.UID == nil ||
+			*opts.Preconditions.UID != types.UID(eviction.Name+"-uid") {
+			t.Fatalf("expected the uid precondition for %s, got %+v", eviction.Name, opts.Preconditions)
+		}
+	}
+}
+
+func TestDrainPodsBlocked(t *testing.T) {
+	unmanaged := managedPod("debug")
+	unmanaged.OwnerReferences = nil
+	pods := []corev1.Pod{managedPod("web-1"), unmanaged}
+
+	cli := newClient(pods)
+	ev := &evictions{}
+	ev.install(cli)
+
+	res := &Result{Node: "node-1"}
+	if err := drainPods(context.Background(), cli, res, pods, Opts{Concurrency: 1}); err == nil {
+		t.Fatal("expected an error for the unmanaged pod")
+	}
+	if len(ev.sent) != 0 {
+		t.Fatalf("expected no evictions, got %d", len(ev.sent))
+	}
+
+	res = &Result{Node: "node-1"}
+	err := drainPods(context.Background(), cli, res, pods, Opts{
+		DrainOpts:   node.DrainOpts{Force: true},
+		Concurrency: 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Evicted) != 2 {
+		t.Fatalf("expected both pods evicted with Force, got %+v", res)
+	}
+	if opts := ev.sent[0].DeleteOptions; opts.GracePeriodSeconds != nil {
+		t.Fatalf("expected the pod grace period, got %d", *opts.GracePeriodSeconds)
+	}
+}
+
+func TestEvictPodRetry(t *testing.T) {
+	pod := managedPod("web-1")
+	cli := newClient([]corev1.Pod{pod})
+	ev := &evictions{refusals: 1}
+	ev.install(cli)
+
+	pr := evictPod(context.Background(), cli, PodResult{Namespace: pod.Namespace, Name: pod.Name}, pod.UID, Opts{})
+	if pr.Err != nil {
+		t.Fatal(pr.Err)
+	}
+	if pr.Attempts != 2 {
+		t.Fatalf("expected a retry after the refusal, got %d attempts", pr.Attempts)
+	}
+}
+
+func TestEvictPodRetryTimeout(t *testing.T) {
+	pod := managedPod("web-1")
+	cli := newClient([]corev1.Pod{pod})
+	ev := &evictions{refusals: 100}
+	ev.install(cli)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	pr := evictPod(ctx, cli, PodResult{Namespace: pod.Namespace, Name: pod.Name}, pod.UID, Opts{})
+	if pr.Err == nil {
+		t.Fatal("expected the refusal once the context is done")
+	}
+	if pr.Attempts != 1 {
+		t.Fatalf("expected a single attempt, got %d", pr.Attempts)
+	}
+}
@@ -0,0 +1,335 @@
+// Package envtest boots a local etcd and kube-apiserver, so that the
+// packages of this module can be exercised by real integration tests.
+//
+// The binaries are looked up in Opts.BinaryAssetsDirectory, then in the
+// directory set by the KUBEBUILDER_ASSETS environment variable (the same
+// layout used by setup-envtest) and finally in the PATH.
+package envtest
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+
+	kubeutil "github.com/lucasepe/kube/util"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+const (
+	// AssetsEnvVar is the environment variable pointing to the directory
+	// that contains the etcd and kube-apiserver binaries.
+	AssetsEnvVar = "KUBEBUILDER_ASSETS"
+
+	contextName = "envtest"
+
+	defaultStartTimeout = 60 * time.Second
+	defaultStopTimeout  = 20 * time.Second
+)
+
+// ErrAssetsNotFound is returned by Start when the etcd or
+// kube-apiserver binaries cannot be found.
+var ErrAssetsNotFound = errors.New("envtest binaries not found")
+
+// Opts is a set of options that allows you to start a local control plane.
+type Opts struct {
+	// BinaryAssetsDirectory is the directory containing the etcd
+	// and kube-apiserver binaries (defaults to $KUBEBUILDER_ASSETS).
+	BinaryAssetsDirectory string
+	// StartTimeout is the time to wait for the control plane to be ready.
+	StartTimeout time.Duration
+	// StopTimeout is the time to wait for the processes to exit before killing them.
+	StopTimeout time.Duration
+	// APIServerFlags are additional flags for kube-apiserver (e.g. "--feature-gates=...").
+	APIServerFlags []string
+	// Output, when not nil, receives the output of the processes.
+	Output io.Writer
+}
+
+// Environment is a running control plane.
+type Environment struct {
+	// Factory is wired to the local kube-apiserver with cluster admin rights.
+	Factory kubeutil.Factory
+	// KubeConfig is the path of a kubeconfig file for the local kube-apiserver.
+	KubeConfig string
+	// Host is the URL of the local kube-apiserver.
+	Host string
+
+	dir       string
+	stopAfter time.Duration
+	etcd      *exec.Cmd
+	apiserver *exec.Cmd
+}
+
+// Setup starts a control plane for the test, skipping it when the
+// binaries are not available. The control plane is stopped when
+// the test and all its subtests complete.
+func Setup(t testing.TB, o Opts) *Environment {
+	t.Helper()
+
+	env, err := Start(o)
+	if errors.Is(err, ErrAssetsNotFound) {
+		t.Skipf("skipping integration test: %v", err)
+	}
+	if err != nil {
+		t.Fatalf("unable to start the control plane: %v", err)
+	}
+
+	t.Cleanup(func() {
+		if err := env.Stop(); err != nil {
+			t.Errorf("unable to stop the control plane: %v", err)
+		}
+	})
+
+	return env
+}
+
+// Start boots etcd and kube-apiserver and waits until the apiserver is ready.
+func Start(o Opts) (*Environment, error) {
+	if o.StartTimeout <= 0 {
+		o.StartTimeout = defaultStartTimeout
+	}
+	if o.StopTimeout <= 0 {
+		o.StopTimeout = defaultStopTimeout
+	}
+	if len(o.BinaryAssetsDirectory) == 0 {
+		o.BinaryAssetsDirectory = os.Getenv(AssetsEnvVar)
+	}
+	if o.Output == nil {
+		o.Output = io.Discard
+	}
+
+	etcdBin, err := lookupBinary(o.BinaryAssetsDirectory, "etcd")
+	if err != nil {
+		return nil, err
+	}
+	apiserverBin, err := lookupBinary(o.BinaryAssetsDirectory, "kube-apiserver")
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := os.MkdirTemp("", "kube-envtest-")
+	if err != nil {
+		return nil, err
+	}
+
+	env := &Environment{dir: dir, stopAfter: o.StopTimeout}
+	if err := env.start(o, etcdBin, apiserverBin); err != nil {
+		env.Stop()
+		return nil, err
+	}
+
+	return env, nil
+}
+
+func (e *Environment) start(o Opts, etcdBin, apiserverBin string) error {
+	ports, err := freePorts(3)
+	if err != nil {
+		return err
+	}
+	etcdURL := fmt.Sprintf("http://127.0.0.1:%d", ports[0])
+	peerURL := fmt.Sprintf("http://127.0.0.1:%d", ports[1])
+	e.Host = fmt.Sprintf("https://127.0.0.1:%d", ports[2])
+
+	ctx, cancel := context.WithTimeout(context.Background(), o.StartTimeout)
+	defer cancel()
+
+	e.etcd = exec.Command(etcdBin,
+		"--data-dir="+filepath.Join(e.dir, "etcd"),
+		"--listen-client-urls="+etcdURL,
+		"--advertise-client-urls="+etcdURL,
+		"--listen-peer-urls="+peerURL,
+		"--initial-advertise-peer-urls="+peerURL,
+		"--initial-cluster=default="+peerURL,
+	)
+	e.etcd.Stdout, e.etcd.Stderr = o.Output, o.Output
+	if err := e.etcd.Start(); err != nil {
+		return fmt.Errorf("unable to start etcd: %w", err)
+	}
+	if err := waitHealthy(ctx, http.DefaultClient, etcdURL+"/health", ""); err != nil {
+		return fmt.Errorf("etcd is not healthy: %w", err)
+	}
+
+	token, err := e.writeCredentials()
+	if err != nil {
+		return err
+	}
+
+	args := []string{
+		"--etcd-servers=" + etcdURL,
+		"--cert-dir=" + filepath.Join(e.dir, "certs"),
+		"--secure-port=" + strconv.Itoa(ports[2]),
+		"--advertise-address=127.0.0.1",
+		"--bind-address=127.0.0.1",
+		"--service-cluster-ip-range=10.0.0.0/24",
+		"--allow-privileged=true",
+		"--authorization-mode=RBAC",
+		"--token-auth-file=" + filepath.Join(e.dir, "tokens.csv"),
+		"--service-account-issuer=" + e.Host,
+		"--service-account-key-file=" + filepath.Join(e.dir, "sa.key"),
+		"--service-account-signing-key-file=" + filepath.Join(e.dir, "sa.key"),
+		"--disable-admission-plugins=ServiceAccount",
+	}
+	e.apiserver = exec.Command(apiserverBin, append(args, o.APIServerFlags...)...)
+	e.apiserver.Stdout, e.apiserver.Stderr = o.Output, o.Output
+	if err := e.apiserver.Start(); err != nil {
+		return fmt.Errorf("unable to start kube-apiserver: %w", err)
+	}
+
+	// the serving certificate is self-signed by kube-apiserver on startup
+	insecure := &http.Client{Transport: &http.Transport{
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+	}}
+	if err := waitHealthy(ctx, insecure, e.Host+"/readyz", token); err != nil {
+		return fmt.Errorf("kube-apiserver is not ready: %w", err)
+	}
+
+	if err := e.writeKubeConfig(token); err != nil {
+		return err
+	}
+	e.Factory = kubeutil.NewFactory(contextName, e.KubeConfig, kubeutil.WithMemoryDiscoveryCache())
+
+	return nil
+}
+
+// Stop terminates the processes and removes the temporary files.
+func (e *Environment) Stop() error {
+	errs := []error{}
+	for _, cmd := range []*exec.Cmd{e.apiserver, e.etcd} {
+		if err := stopProcess(cmd, e.stopAfter); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := os.RemoveAll(e.dir); err != nil {
+		errs = append(errs, err)
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// writeCredentials creates the service account signing key and
+// a static token file with a member of the system:masters group.
+func (e *Environment) writeCredentials() (string, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return "", err
+	}
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	if err := os.WriteFile(filepath.Join(e.dir, "sa.key"), keyPEM, 0600); err != nil {
+		return "", err
+	}
+
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(buf)
+
+	line := fmt.Sprintf("%s,envtest-admin,envtest-admin,\"system:masters\"\n", token)
+	if err := os.WriteFile(filepath.Join(e.dir, "tokens.csv"), []byte(line), 0600); err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+func (e *Environment) writeKubeConfig(token string) error {
+	cfg := clientcmdapi.NewConfig()
+	cfg.Clusters[contextName] = &clientcmdapi.Cluster{
+		Server:                e.Host,
+		InsecureSkipTLSVerify: true,
+	}
+	cfg.AuthInfos[contextName] = &clientcmdapi.AuthInfo{Token: token}
+	cfg.Contexts[contextName] = &clientcmdapi.Context{
+		Cluster:   contextName,
+		AuthInfo:  contextName,
+		Namespace: "default",
+	}
+	cfg.CurrentContext = contextName
+
+	e.KubeConfig = filepath.Join(e.dir, "kubeconfig")
+	return clientcmd.WriteToFile(*cfg, e.KubeConfig)
+}
+
+func lookupBinary(dir, name string) (string, error) {
+	if len(dir) > 0 {
+		bin := filepath.Join(dir, name)
+		if _, err := os.Stat(bin); err == nil {
+			return bin, nil
+		}
+	}
+	bin, err := exec.LookPath(name)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s (set %s or Opts.BinaryAssetsDirectory)", ErrAssetsNotFound, name, AssetsEnvVar)
+	}
+	return bin, nil
+}
+
+func freePorts(n int) ([]int, error) {
+	res := make([]int, 0, n)
+	for i := 0; i < n; i++ {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			return nil, err
+		}
+		defer l.Close()
+		res = append(res, l.Addr().(*net.TCPAddr).Port)
+	}
+	return res, nil
+}
+
+func waitHealthy(ctx context.Context, cli *http.Client, url, token string) error {
+	return wait.PollImmediateUntilWithContext(ctx, 250*time.Millisecond, func(ctx context.Context) (bool, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return false, err
+		}
+		if len(token) > 0 {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := cli.Do(req)
+		if err != nil {
+			return false, nil
+		}
+		resp.Body.Close()
+		return resp.StatusCode == http.StatusOK, nil
+	})
+}
+
+func stopProcess(cmd *exec.Cmd, timeout time.Duration) error {
+	if cmd == nil || cmd.Process == nil {
+		return nil
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+
+	cmd.Process.Signal(syscall.SIGTERM)
+	select {
+	case <-done:
+		return nil
+	case <-time.After(timeout):
+		if err := cmd.Process.Kill(); err != nil {
+			return fmt.Errorf("unable to kill %s: %w", filepath.Base(cmd.Path), err)
+		}
+		<-done
+		return nil
+	}
+}
@@ -0,0 +1,19 @@
+package envtest
+
+import (
+	"testing"
+
+	kube "github.com/lucasepe/kube/get"
+)
+
+func TestSetup(t *testing.T) {
+	env := Setup(t, Opts{})
+
+	objs, err := kube.Do(env.Factory, kube.Opts{Resources: []string{"namespaces"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objs) == 0 {
+		t.Fatal("expected at least the default namespaces")
+	}
+}
@@ -9,24 +9,51 @@ import (
 
 	kubeutil "github.com/lucasepe/kube/util"
 	corev1 "k8s.io/api/core/v1"
+	eventsv1 "k8s.io/api/events/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 	runtimeresource "k8s.io/cli-runtime/pkg/resource"
 )
 
+// API is the API used to list the events.
+type API string
+
+const (
+	// APIAuto uses events.k8s.io/v1 if served, otherwise core/v1.
+	APIAuto API = ""
+	// APICoreV1 uses the core/v1 events.
+	APICoreV1 API = "v1"
+	// APIEventsV1 uses the events.k8s.io/v1 events, converted to core/v1.
+	APIEventsV1 API = "events.k8s.io/v1"
+)
+
 // Opts is a set of options that allows you to list events.
 type Opts struct {
 	Namespace     string
 	AllNamespaces bool
 	FilterTypes   []string
+	// API is the events API to query (default APIAuto).
+	API API
 
+	// ForGVK, ForName and ForUID select the events about the objects
+	// matching all of them; with both ForGVK and ForName (or ForUID)
+	// the events are also matched by the namespace of the object.
 	ForGVK  schema.GroupVersionKind
 	ForName string
+	ForUID  types.UID
 }
 
 func Do(f kubeutil.Factory, o Opts) ([]corev1.Event, error) {
+	return DoContext(context.Background(), f, o)
+}
+
+// DoContext is like Do but stops when the context is cancelled.
+func DoContext(ctx context.Context, f kubeutil.Factory, o Opts) ([]corev1.Event, error) {
 	if err := o.complete(f); err != nil {
 		return nil, err
 	}
@@ -35,7 +62,7 @@ func Do(f kubeutil.Factory, o Opts) ([]corev1.Event, error) {
 		return nil, err
 	}
 
-	return o.run(f)
+	return o.run(ctx, f)
 }
 
 func (o *Opts) complete(f kubeutil.Factory) error {
@@ -51,6 +78,12 @@ func (o *Opts) complete(f kubeutil.Factory) error {
 }
 
 func (o *Opts) validate() error {
+	switch o.API {
+	case APIAuto, APICoreV1, APIEventsV1:
+	default:
+		return fmt.Errorf("valid APIs are %s or %s", APICoreV1, APIEventsV1)
+	}
+
 	for _, val := range o.FilterTypes {
 		if !strings.EqualFold(val, "Normal") && !strings.EqualFold(val, "Warning") {
 			return fmt.Errorf("valid types are Normal or Warning")
@@ -61,32 +94,28 @@ func (o *Opts) validate() error {
 }
 
 // run retrieves events
-func (o *Opts) run(f kubeutil.Factory) ([]corev1.Event, error) {
-	ctx := context.TODO()
+func (o *Opts) run(ctx context.Context, f kubeutil.Factory) ([]corev1.Event, error) {
 	namespace := o.Namespace
 	if o.AllNamespaces {
 		namespace = ""
 	}
 
-	listOptions := metav1.ListOptions{Limit: kubeutil.DefaultChunkSize}
-	if len(o.ForGVK.Kind) > 0 {
-		listOptions.FieldSelector = fields.AndSelectors(
-			fields.OneTermEqualSelector("involvedObject.apiVersion", o.ForGVK.GroupVersion().String()),
-			fields.OneTermEqualSelector("involvedObject.kind", o.ForGVK.Kind),
-		).String()
+	api, err := o.resolveAPI(f)
+	if err != nil {
+		return nil, err
 	}
 
-	if len(o.ForName) > 0 {
-		listOptions.FieldSelector = fields.OneTermEqualSelector("involvedObject.name", o.ForName).String()
+	listOptions := metav1.ListOptions{Limit: kubeutil.DefaultChunkSize}
+	listOptions.FieldSelector, namespace, err = o.fieldSelector(f, api, namespace)
+	if err != nil {
+		return nil, err
 	}
 
-	fmt.Println("==>", listOptions.FieldSelector)
 	cli, err := f.KubernetesClientSet()
 	if err != nil {
 		return nil, err
 	}
 
-	e := cli.CoreV1().Events(namespace)
 	el := &corev1.EventList{
 		TypeMeta: metav1.TypeMeta{
 			Kind:       "EventList",
@@ -95,7 +124,18 @@ func (o *Opts) run(f kubeutil.Factory) ([]corev1.Event, error) {
 	}
 	err = runtimeresource.FollowContinue(&listOptions,
 		func(options metav1.ListOptions) (runtime.Object, error) {
-			newEvents, err := e.List(ctx, options)
+			if api == APIEventsV1 {
+				newEvents, err := cli.EventsV1().Events(namespace).List(ctx, options)
+				if err != nil {
+					return nil, runtimeresource.EnhanceListError(err, options, "events")
+				}
+				for i := range newEvents.Items {
+					el.Items = append(el.Items, toCoreV1(&newEvents.Items[i]))
+				}
+				return newEvents, nil
+			}
+
+			newEvents, err := cli.CoreV1().Events(namespace).List(ctx, options)
 			if err != nil {
 				return nil, runtimeresource.EnhanceListError(err, options, "events")
 			}
@@ -135,6 +175,109 @@ func (o *Opts) run(f kubeutil.Factory) ([]corev1.Event, error) {
 	return el.Items, nil
 }
 
+// fieldSelector returns the selector of the events about the object and
+// the namespace to list; the events of a cluster scoped object are in any
+// namespace, e.g. the ones of the nodes are in the default namespace.
+func (o *Opts) fieldSelector(f kubeutil.Factory, api API, namespace string) (string, string, error) {
+	// the field of the object the events are about
+	regarding := "involvedObject"
+	if api == APIEventsV1 {
+		regarding = "regarding"
+	}
+
+	terms := fields.Set{}
+	if len(o.ForGVK.Kind) > 0 {
+		terms[regarding+".apiVersion"] = o.ForGVK.GroupVersion().String()
+		terms[regarding+".kind"] = o.ForGVK.Kind
+	}
+	if len(o.ForName) > 0 {
+		terms[regarding+".name"] = o.ForName
+	}
+	if len(o.ForUID) > 0 {
+		terms[regarding+".uid"] = string(o.ForUID)
+	}
+
+	if len(o.ForGVK.Kind) > 0 && (len(o.ForName) > 0 || len(o.ForUID) > 0) {
+		mapper, err := f.ToRESTMapper()
+		if err != nil {
+			return "", "", err
+		}
+		mapping, err := mapper.RESTMapping(o.ForGVK.GroupKind(), o.ForGVK.Version)
+		if err != nil {
+			return "", "", err
+		}
+		if mapping.Scope.Name() != meta.RESTScopeNameNamespace {
+			terms[regarding+".namespace"] = ""
+			namespace = metav1.NamespaceAll
+		} else if len(namespace) > 0 {
+			terms[regarding+".namespace"] = namespace
+		}
+	}
+
+	if len(terms) == 0 {
+		return "", namespace, nil
+	}
+	return fields.SelectorFromSet(terms).String(), namespace, nil
+}
+
+// resolveAPI returns the API to query: with APIAuto, events.k8s.io/v1
+// if the server has it.
+func (o *Opts) resolveAPI(f kubeutil.Factory) (API, error) {
+	if o.API != APIAuto {
+		return o.API, nil
+	}
+
+	dc, err := f.ToDiscoveryClient()
+	if err != nil {
+		return "", err
+	}
+	resources, err := dc.ServerResourcesForGroupVersion(eventsv1.SchemeGroupVersion.String())
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return APICoreV1, nil
+		}
+		return "", err
+	}
+	for _, r := range resources.APIResources {
+		if r.Name == "events" {
+			return APIEventsV1, nil
+		}
+	}
+	return APICoreV1, nil
+}
+
+// toCoreV1 converts an events.k8s.io/v1 event to core/v1,
+// as the API server does when serving the event with both APIs.
+func toCoreV1(ev *eventsv1.Event) corev1.Event {
+	res := corev1.Event{
+		ObjectMeta:          ev.ObjectMeta,
+		InvolvedObject:      ev.Regarding,
+		Related:             ev.Related,
+		Reason:              ev.Reason,
+		Message:             ev.Note,
+		Type:                ev.Type,
+		Action:              ev.Action,
+		EventTime:           ev.EventTime,
+		ReportingController: ev.ReportingController,
+		ReportingInstance:   ev.ReportingInstance,
+		FirstTimestamp:      ev.DeprecatedFirstTimestamp,
+		LastTimestamp:       ev.DeprecatedLastTimestamp,
+		Count:               ev.DeprecatedCount,
+		Source: corev1.EventSource{
+			Component: ev.DeprecatedSource.Component,
+			Host:      ev.DeprecatedSource.Host,
+		},
+	}
+	if ev.Series != nil {
+		res.Series = &corev1.EventSeries{
+			Count:            ev.Series.Count,
+			LastObservedTime: ev.Series.LastObservedTime,
+		}
+	}
+	res.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("Event"))
+	return res
+}
+
 // filteredEventType checks given event can be printed
 // by comparing it in filtered event flag.
 // If --event flag is not set by user, this function allows
@@ -0,0 +1,139 @@
+package exec
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/lucasepe/kube/scheme"
+	kubeutil "github.com/lucasepe/kube/util"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+const (
+	defaultPodExecTimeout = 60 * time.Second
+)
+
+// Opts is a set of options that allows you to run a command in a container.
+type Opts struct {
+	Namespace string
+	PodName   string
+	// Object is a pod or a workload (the first of its pods is used);
+	// when set, PodName is ignored.
+	Object runtime.Object
+	// Container defaults to the one named by the default-container
+	// annotation or the first container of the pod.
+	Container string
+	Command   []string
+
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+	// TTY allocates a terminal; stderr is merged into stdout.
+	TTY bool
+	// TerminalSizeQueue, if not nil, feeds the terminal resize events (TTY only).
+	TerminalSizeQueue remotecommand.TerminalSizeQueue
+
+	GetPodTimeout time.Duration
+}
+
+// Do runs the command in the container streaming its standard streams.
+func Do(f kubeutil.Factory, o Opts) error {
+	return DoContext(context.Background(), f, o)
+}
+
+// DoContext is like Do but stops when the context is cancelled.
+func DoContext(ctx context.Context, f kubeutil.Factory, o Opts) error {
+	if len(o.Command) == 0 {
+		return fmt.Errorf("you must specify at least one command for the container")
+	}
+	if o.GetPodTimeout <= 0 {
+		o.GetPodTimeout = defaultPodExecTimeout
+	}
+	if len(o.Namespace) == 0 {
+		ns, _, err := f.ToRawKubeConfigLoader().Namespace()
+		if err != nil {
+			return err
+		}
+		o.Namespace = ns
+	}
+
+	cli, err := f.KubernetesClientSet()
+	if err != nil {
+		return err
+	}
+
+	pod, execOptions, err := target(ctx, cli.CoreV1(), o)
+	if err != nil {
+		return err
+	}
+
+	req := cli.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Name(pod.Name).
+		Namespace(pod.Namespace).
+		SubResource("exec").
+		VersionedParams(execOptions, scheme.ParameterCodec)
+
+	cfg, err := f.ToRESTConfig()
+	if err != nil {
+		return err
+	}
+
+	executor, err := remotecommand.NewSPDYExecutor(cfg, "POST", req.URL())
+	if err != nil {
+		return err
+	}
+
+	streamOptions := remotecommand.StreamOptions{
+		Stdin:  o.Stdin,
+		Stdout: o.Stdout,
+		Stderr: o.Stderr,
+		Tty:    o.TTY,
+	}
+	if o.TTY {
+		streamOptions.Stderr = nil
+		streamOptions.TerminalSizeQueue = o.TerminalSizeQueue
+	}
+
+	return executor.Stream(streamOptions)
+}
+
+// target resolves the pod and the container to exec into.
+func target(ctx context.Context, pods corev1client.PodsGetter, o Opts) (*corev1.Pod, *corev1.PodExecOptions, error) {
+	obj := o.Object
+	if obj == nil {
+		var err error
+		obj, err = pods.Pods(o.Namespace).Get(ctx, o.PodName, metav1.GetOptions{})
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	pod, err := kubeutil.AttachablePodForObjectContext(ctx, pods, obj, o.GetPodTimeout)
+	if err != nil {
+		return nil, nil, err
+	}
+	if kubeutil.IsPodTerminated(pod) {
+		return nil, nil, fmt.Errorf("cannot exec into a container in a completed pod; current phase is %s", pod.Status.Phase)
+	}
+
+	container, err := kubeutil.ContainerToAttach(pod, o.Container)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return pod, &corev1.PodExecOptions{
+		Container: container.Name,
+		Command:   o.Command,
+		Stdin:     o.Stdin != nil,
+		Stdout:    o.Stdout != nil,
+		Stderr:    o.Stderr != nil && !o.TTY,
+		TTY:       o.TTY,
+	}, nil
+}
@@ -0,0 +1,129 @@
+package exec
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	kubeutil "github.com/lucasepe/kube/util"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func pod(name string, phase corev1.PodPhase, labels map[string]string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+			Labels:    labels,
+			Annotations: map[string]string{
+				kubeutil.DefaultContainerAnnotationName: "app",
+			},
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: "proxy"}, {Name: "app"}},
+		},
+		Status: corev1.PodStatus{Phase: phase},
+	}
+}
+
+func TestTarget(t *testing.T) {
+	cli := fake.NewSimpleClientset(pod("web-0", corev1.PodRunning, nil))
+
+	p, opts, err := target(context.Background(), cli.CoreV1(), Opts{
+		Namespace: "default",
+		PodName:   "web-0",
+		Command:   []string{"ls"},
+		Stdout:    io.Discard,
+		Stderr:    io.Discard,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Name != "web-0" {
+		t.Fatalf("unexpected pod %s", p.Name)
+	}
+	if opts.Container != "app" {
+		t.Fatalf("expected the default container, got %q", opts.Container)
+	}
+	if opts.Stdin || !opts.Stdout || !opts.Stderr || opts.TTY {
+		t.Fatalf("unexpected streams %+v", opts)
+	}
+}
+
+func TestTargetTTY(t *testing.T) {
+	cli := fake.NewSimpleClientset(pod("web-0", corev1.PodRunning, nil))
+
+	_, opts, err := target(context.Background(), cli.CoreV1(), Opts{
+		Namespace: "default",
+		PodName:   "web-0",
+		Container: "proxy",
+		Command:   []string{"sh"},
+		Stderr:    io.Discard,
+		TTY:       true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.Container != "proxy" {
+		t.Fatalf("expected the named container, got %q", opts.Container)
+	}
+	if opts.Stderr || !opts.TTY {
+		t.Fatalf("expected stderr to be merged into the terminal, got %+v", opts)
+	}
+}
+
+func TestTargetWorkload(t *testing.T) {
+	labels := map[string]string{"app": "web"}
+	cli := fake.NewSimpleClientset(
+		pod("web-pending", corev1.PodPending, labels),
+		pod("web-running", corev1.PodRunning, labels),
+		pod("other", corev1.PodRunning, map[string]string{"app": "other"}),
+	)
+
+	deploy := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+		Spec: appsv1.DeploymentSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+		},
+	}
+
+	p, _, err := target(context.Background(), cli.CoreV1(), Opts{
+		PodName:       "ignored",
+		Object:        deploy,
+		Command:       []string{"ls"},
+		GetPodTimeout: time.Second,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Name != "web-running" {
+		t.Fatalf("expected the running pod, got %s", p.Name)
+	}
+}
+
+func TestTargetErrors(t *testing.T) {
+	cli := fake.NewSimpleClientset(
+		pod("done", corev1.PodSucceeded, nil),
+		pod("web-0", corev1.PodRunning, nil),
+	)
+
+	for _, o := range []Opts{
+		{Namespace: "default", PodName: "missing"},
+		{Namespace: "default", PodName: "done"},
+		{Namespace: "default", PodName: "web-0", Container: "missing"},
+	} {
+		if _, _, err := target(context.Background(), cli.CoreV1(), o); err == nil {
+			t.Errorf("expected an error for %+v", o)
+		}
+	}
+}
+
+func TestDoNoCommand(t *testing.T) {
+	if err := Do(nil, Opts{PodName: "web-0"}); err == nil {
+		t.Fatal("expected an error without a command")
+	}
+}
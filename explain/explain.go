@@ -0,0 +1,313 @@
+package explain
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	kubeutil "github.com/lucasepe/kube/util"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Opts is a set of options that allows you to explain a resource field.
+type Opts struct {
+	// Path is the resource followed by the field path
+	// (e.g. "deployment.spec.strategy" or "pods").
+	Path string
+	// APIVersion selects the group and version of the resource
+	// (e.g. "apps/v1"); defaults to the preferred one.
+	APIVersion string
+	// Recursive returns the whole field tree instead of the direct children only.
+	Recursive bool
+}
+
+// Field is the documentation of a field and its children.
+type Field struct {
+	Name string
+	// Type is a Go-like type (e.g. "string", "[]Container", "map[string]string").
+	Type        string
+	Description string
+	Required    bool
+	Fields      []Field
+}
+
+// Explanation is the documentation of a resource field.
+type Explanation struct {
+	GroupVersionKind schema.GroupVersionKind
+	// Path is the field path, without the resource (empty for the resource itself).
+	Path string
+	Field
+}
+
+// Do fetches the OpenAPI v3 schema of the resource group version
+// from the cluster and returns the documentation of the field.
+func Do(f kubeutil.Factory, o Opts) (*Explanation, error) {
+	return DoContext(context.Background(), f, o)
+}
+
+// DoContext is like Do but stops when the context is cancelled.
+func DoContext(ctx context.Context, f kubeutil.Factory, o Opts) (*Explanation, error) {
+	parts := strings.Split(strings.TrimSpace(o.Path), ".")
+	if len(parts[0]) == 0 {
+		return nil, fmt.Errorf("you must specify the resource to explain")
+	}
+
+	gvk, err := resolveKind(f, parts[0], o.APIVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	schemas, err := fetchSchemas(ctx, f, gvk.GroupVersion())
+	if err != nil {
+		return nil, err
+	}
+
+	name, ok := findKind(schemas, gvk)
+	if !ok {
+		return nil, fmt.Errorf("no OpenAPI schema found for %s", gvk)
+	}
+
+	w := &walker{schemas: schemas, recursive: o.Recursive, visiting: map[string]bool{}}
+	s := schemas[name]
+	required := false
+	for _, field := range parts[1:] {
+		s = w.resolve(s)
+		if items := w.elem(s); items != nil {
+			s = w.resolve(items)
+		}
+		child, ok := s.Properties[field]
+		if !ok {
+			return nil, fmt.Errorf("field %q does not exist in %s", field, o.Path)
+		}
+		required = contains(s.Required, field)
+		s = child
+	}
+
+	res := &Explanation{
+		GroupVersionKind: gvk,
+		Path:             strings.Join(parts[1:], "."),
+		Field:            w.field(parts[len(parts)-1], s, required, 0),
+	}
+	if len(parts) == 1 {
+		res.Name = gvk.Kind
+	}
+	return res, nil
+}
+
+// resolveKind maps the resource (plural, singular or short name) to its kind.
+func resolveKind(f kubeutil.Factory, resource, apiVersion string) (schema.GroupVersionKind, error) {
+	mapper, err := f.ToRESTMapper()
+	if err != nil {
+		return schema.GroupVersionKind{}, err
+	}
+
+	gvr := schema.GroupVersionResource{Resource: strings.ToLower(resource)}
+	if len(apiVersion) > 0 {
+		gv, err := schema.ParseGroupVersion(apiVersion)
+		if err != nil {
+			return schema.GroupVersionKind{}, err
+		}
+		gvr.Group, gvr.Version = gv.Group, gv.Version
+	}
+
+	gvk, err := mapper.KindFor(gvr)
+	if err != nil {
+		return schema.GroupVersionKind{}, fmt.Errorf("unable to find the resource %q: %w", resource, err)
+	}
+	return gvk, nil
+}
+
+// fetchSchemas downloads the OpenAPI v3 document of the group version
+// and returns its component schemas.
+func fetchSchemas(ctx context.Context, f kubeutil.Factory, gv schema.GroupVersion) (map[string]*jsonSchema, error) {
+	dc, err := f.ToDiscoveryClient()
+	if err != nil {
+		return nil, err
+	}
+
+	paths, err := dc.OpenAPIV3().Paths()
+	if err != nil {
+		return nil, fmt.Errorf("unable to fetch the OpenAPI v3 paths: %w", err)
+	}
+
+	key := "apis/" + gv.String()
+	if len(gv.Group) == 0 {
+		key = "api/" + gv.Version
+	}
+	if _, ok := paths[key]; !ok {
+		return nil, fmt.Errorf("OpenAPI v3 schema not available for %s", gv)
+	}
+
+	// the discovery client decodes the document as protobuf,
+	// the components are easier to walk as JSON
+	data, err := dc.RESTClient().Get().
+		AbsPath("/openapi/v3", key).
+		SetHeader("Accept", "application/json").
+		Do(ctx).
+		Raw()
+	if err != nil {
+		return nil, fmt.Errorf("unable to fetch the OpenAPI v3 schema for %s: %w", gv, err)
+	}
+
+	doc := struct {
+		Components struct {
+			Schemas map[string]*jsonSchema `json:"schemas"`
+		} `json:"components"`
+	}{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("unable to parse the OpenAPI v3 schema for %s: %w", gv, err)
+	}
+	return doc.Components.Schemas, nil
+}
+
+func findKind(schemas map[string]*jsonSchema, gvk schema.GroupVersionKind) (string, bool) {
+	for name, s := range schemas {
+		for _, x := range s.GroupVersionKinds {
+			if x.Group == gvk.Group && x.Version == gvk.Version && x.Kind == gvk.Kind {
+				return name, true
+			}
+		}
+	}
+	return "", false
+}
+
+// jsonSchema is the subset of the OpenAPI v3 schema object used to explain fields.
+type jsonSchema struct {
+	Ref                  string                 `json:"$ref"`
+	Description          string                 `json:"description"`
+	Type                 string                 `json:"type"`
+	Format               string                 `json:"format"`
+	Required             []string               `json:"required"`
+	Properties           map[string]*jsonSchema `json:"properties"`
+	Items                *jsonSchema            `json:"items"`
+	AdditionalProperties json.RawMessage        `json:"additionalProperties"`
+	AllOf                []*jsonSchema          `json:"allOf"`
+	GroupVersionKinds    []struct {
+		Group   string `json:"group"`
+		Version string `json:"version"`
+		Kind    string `json:"kind"`
+	} `json:"x-kubernetes-group-version-kind"`
+}
+
+type walker struct {
+	schemas   map[string]*jsonSchema
+	recursive bool
+	// visiting are the schemas being expanded, to stop on recursive types
+	visiting map[string]bool
+}
+
+// resolve follows the references (also wrapped in allOf) keeping
+// the description of the referencing schema, if any.
+func (w *walker) resolve(s *jsonSchema) *jsonSchema {
+	for s != nil {
+		ref := refOf(s)
+		if len(ref) == 0 {
+			return s
+		}
+		target, ok := w.schemas[refName(ref)]
+		if !ok {
+			return s
+		}
+		if len(s.Description) > 0 && s.Description != target.Description {
+			c := *target
+			c.Description = s.Description
+			target = &c
+		}
+		s = target
+	}
+	return &jsonSchema{}
+}
+
+// elem returns the schema of the elements of arrays and maps, nil otherwise.
+func (w *walker) elem(s *jsonSchema) *jsonSchema {
+	if s.Items != nil {
+		return s.Items
+	}
+	if len(s.AdditionalProperties) > 0 {
+		ap := &jsonSchema{}
+		if err := json.Unmarshal(s.AdditionalProperties, ap); err == nil {
+			return ap
+		}
+	}
+	return nil
+}
+
+func (w *walker) typeName(s *jsonSchema) string {
+	if ref := refOf(s); len(ref) > 0 {
+		name := refName(ref)
+		if i := strings.LastIndex(name, "."); i >= 0 {
+			name = name[i+1:]
+		}
+		return name
+	}
+
+	switch {
+	case s.Items != nil:
+		return "[]" + w.typeName(s.Items)
+	case s.Type == "object" && len(s.AdditionalProperties) > 0:
+		if elem := w.elem(s); elem != nil {
+			return "map[string]" + w.typeName(elem)
+		}
+	case len(s.Type) == 0:
+		return "Object"
+	}
+	return s.Type
+}
+
+// field builds the documentation of the field; the children are
+// expanded one level deep, or all the way down when recursive.
+func (w *walker) field(name string, s *jsonSchema, required bool, depth int) Field {
+	res := Field{Name: name, Type: w.typeName(s), Required: required}
+
+	ref := refOf(s)
+	r := w.resolve(s)
+	res.Description = r.Description
+	if items := w.elem(r); items != nil {
+		r = w.resolve(items)
+		if len(ref) == 0 {
+			ref = refOf(items)
+		}
+	}
+
+	if len(r.Properties) == 0 || (depth > 0 && !w.recursive) || w.visiting[ref] {
+		return res
+	}
+	if len(ref) > 0 {
+		w.visiting[ref] = true
+		defer delete(w.visiting, ref)
+	}
+
+	names := make([]string, 0, len(r.Properties))
+	for k := range r.Properties {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	for _, k := range names {
+		res.Fields = append(res.Fields, w.field(k, r.Properties[k], contains(r.Required, k), depth+1))
+	}
+	return res
+}
+
+// refOf returns the reference of the schema, also when wrapped
+// in allOf (as done by OpenAPI v3 to add a description or a default).
+func refOf(s *jsonSchema) string {
+	if len(s.Ref) == 0 && len(s.AllOf) == 1 {
+		return s.AllOf[0].Ref
+	}
+	return s.Ref
+}
+
+func refName(ref string) string {
+	return strings.TrimPrefix(ref, "#/components/schemas/")
+}
+
+func contains(list []string, s string) bool {
+	for _, x := range list {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,239 @@
+package expose
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lucasepe/kube/scheme"
+	kubeutil "github.com/lucasepe/kube/util"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// Opts is a set of options that allows you to expose a workload as a Service.
+type Opts struct {
+	Namespace string
+	// Resource is the workload to expose (e.g. "deployment/web",
+	// "replicaset/web-5d8f7", "pod/web-0" or "service/web").
+	Resource string
+
+	// Name of the Service (defaults to the name of the workload).
+	Name string
+	// Type of the Service (default ClusterIP).
+	Type corev1.ServiceType
+	// Port the Service serves on; when zero the container ports of the
+	// pod template are exposed (all of them, with the same port number).
+	Port int32
+	// TargetPort is the container port name or number the Service
+	// forwards to (defaults to Port).
+	TargetPort string
+	// Protocol of the port (default TCP).
+	Protocol corev1.Protocol
+	// Selector overrides the selector derived from the workload.
+	Selector map[string]string
+	// Labels of the Service (default the labels of the workload).
+	Labels map[string]string
+
+	FieldManager string
+	// DryRun returns the generated Service without creating it.
+	DryRun bool
+}
+
+// Do derives a Service from the workload selector and the container
+// ports of its pod template, and creates it (or just returns it when
+// DryRun is set).
+func Do(f kubeutil.Factory, o Opts) (*corev1.Service, error) {
+	return DoContext(context.Background(), f, o)
+}
+
+// DoContext is like Do but stops when the context is cancelled.
+func DoContext(ctx context.Context, f kubeutil.Factory, o Opts) (*corev1.Service, error) {
+	if len(o.Resource) == 0 {
+		return nil, fmt.Errorf("you must specify the resource to expose")
+	}
+	if len(o.Namespace) == 0 {
+		ns, _, err := f.ToRawKubeConfigLoader().Namespace()
+		if err != nil {
+			return nil, err
+		}
+		o.Namespace = ns
+	}
+
+	infos, err := f.NewBuilder().
+		WithScheme(scheme.Scheme, scheme.Scheme.PrioritizedVersionsAllGroups()...).
+		NamespaceParam(o.Namespace).DefaultNamespace().
+		ResourceTypeOrNameArgs(false, o.Resource).
+		SingleResourceType().
+		Flatten().
+		Do().
+		Infos()
+	if err != nil {
+		return nil, err
+	}
+	if len(infos) != 1 {
+		return nil, fmt.Errorf("expected a single resource, found %d", len(infos))
+	}
+
+	svc, err := Generate(infos[0].Object, o)
+	if err != nil {
+		return nil, err
+	}
+	if o.DryRun {
+		return svc, nil
+	}
+
+	cli, err := f.KubernetesClientSet()
+	if err != nil {
+		return nil, err
+	}
+	return cli.CoreV1().Services(svc.Namespace).Create(ctx, svc,
+		metav1.CreateOptions{FieldManager: o.FieldManager})
+}
+
+// Generate returns the Service exposing the object.
+func Generate(obj runtime.Object, o Opts) (*corev1.Service, error) {
+	accessor, ok := obj.(metav1.Object)
+	if !ok {
+		return nil, fmt.Errorf("cannot expose a %T", obj)
+	}
+
+	selector := o.Selector
+	if len(selector) == 0 {
+		var err error
+		selector, err = selectorFor(obj)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	ports, err := servicePorts(obj, o)
+	if err != nil {
+		return nil, err
+	}
+
+	name := o.Name
+	if len(name) == 0 {
+		name = accessor.GetName()
+	}
+	svcLabels := o.Labels
+	if len(svcLabels) == 0 {
+		svcLabels = accessor.GetLabels()
+	}
+	svcType := o.Type
+	if len(svcType) == 0 {
+		svcType = corev1.ServiceTypeClusterIP
+	}
+
+	return &corev1.Service{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Service"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: accessor.GetNamespace(),
+			Labels:    svcLabels,
+		},
+		Spec: corev1.ServiceSpec{
+			Type:     svcType,
+			Selector: selector,
+			Ports:    ports,
+		},
+	}, nil
+}
+
+// selectorFor returns the selector of the pods of the object; only
+// equality based selectors can be used by a Service.
+func selectorFor(obj runtime.Object) (map[string]string, error) {
+	if pod, ok := obj.(*corev1.Pod); ok {
+		if len(pod.Labels) == 0 {
+			return nil, fmt.Errorf("the pod %s has no labels and cannot be exposed", pod.Name)
+		}
+		return pod.Labels, nil
+	}
+
+	_, selector, err := kubeutil.SelectorsForObject(obj)
+	if err != nil {
+		return nil, fmt.Errorf("cannot expose a %T: %w", obj, err)
+	}
+	res, err := labels.ConvertSelectorToLabelsMap(selector.String())
+	if err != nil {
+		return nil, fmt.Errorf("the selector %q cannot be used by a Service (only equality based requirements are supported)", selector)
+	}
+	return res, nil
+}
+
+func servicePorts(obj runtime.Object, o Opts) ([]corev1.ServicePort, error) {
+	protocol := o.Protocol
+	if len(protocol) == 0 {
+		protocol = corev1.ProtocolTCP
+	}
+
+	if o.Port > 0 {
+		target := intstr.FromInt(int(o.Port))
+		if len(o.TargetPort) > 0 {
+			target = intstr.Parse(o.TargetPort)
+		}
+		return []corev1.ServicePort{
+			{Port: o.Port, TargetPort: target, Protocol: protocol},
+		}, nil
+	}
+
+	if svc, ok := obj.(*corev1.Service); ok {
+		return svc.Spec.Ports, nil
+	}
+
+	spec := podSpecFor(obj)
+	if spec == nil {
+		return nil, fmt.Errorf("cannot find the ports of a %T, you must specify a port", obj)
+	}
+
+	ports := []corev1.ServicePort{}
+	for _, c := range spec.Containers {
+		for _, cp := range c.Ports {
+			sp := corev1.ServicePort{
+				Name:       cp.Name,
+				Port:       cp.ContainerPort,
+				TargetPort: intstr.FromInt(int(cp.ContainerPort)),
+				Protocol:   cp.Protocol,
+			}
+			if len(sp.Protocol) == 0 {
+				sp.Protocol = protocol
+			}
+			ports = append(ports, sp)
+		}
+	}
+	if len(ports) == 0 {
+		return nil, fmt.Errorf("no container ports found, you must specify a port")
+	}
+	// multiple ports must be named
+	if len(ports) > 1 {
+		for i := range ports {
+			if len(ports[i].Name) == 0 {
+				ports[i].Name = fmt.Sprintf("port-%d", i+1)
+			}
+		}
+	}
+	return ports, nil
+}
+
+func podSpecFor(obj runtime.Object) *corev1.PodSpec {
+	switch t := obj.(type) {
+	case *corev1.Pod:
+		return &t.Spec
+	case *corev1.ReplicationController:
+		if t.Spec.Template != nil {
+			return &t.Spec.Template.Spec
+		}
+	case *appsv1.Deployment:
+		return &t.Spec.Template.Spec
+	case *appsv1.ReplicaSet:
+		return &t.Spec.Template.Spec
+	case *appsv1.StatefulSet:
+		return &t.Spec.Template.Spec
+	case *appsv1.DaemonSet:
+		return &t.Spec.Template.Spec
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package expose
+
+import (
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGenerate(t *testing.T) {
+	deploy := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "web",
+			Namespace: "demo",
+			Labels:    map[string]string{"app": "web"},
+		},
+		Spec: appsv1.DeploymentSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{Name: "app", Ports: []corev1.ContainerPort{{ContainerPort: 8080}, {Name: "metrics", ContainerPort: 9090}}},
+					},
+				},
+			},
+		},
+	}
+
+	svc, err := Generate(deploy, Opts{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if svc.Name != "web" || svc.Namespace != "demo" || svc.Spec.Type != corev1.ServiceTypeClusterIP {
+		t.Fatalf("unexpected service: %s/%s %s", svc.Namespace, svc.Name, svc.Spec.Type)
+	}
+	if svc.Spec.Selector["app"] != "web" || len(svc.Spec.Selector) != 1 {
+		t.Fatalf("unexpected selector: %v", svc.Spec.Selector)
+	}
+	if len(svc.Spec.Ports) != 2 {
+		t.Fatalf("expected 2 ports, got %d", len(svc.Spec.Ports))
+	}
+	if p := svc.Spec.Ports[0]; p.Name != "port-1" || p.Port != 8080 || p.Protocol != corev1.ProtocolTCP {
+		t.Fatalf("unexpected first port: %+v", p)
+	}
+
+	svc, err = Generate(deploy, Opts{Name: "web-lb", Type: corev1.ServiceTypeLoadBalancer, Port: 80, TargetPort: "http"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(svc.Spec.Ports) != 1 || svc.Spec.Ports[0].Port != 80 || svc.Spec.Ports[0].TargetPort.StrVal != "http" {
+		t.Fatalf("unexpected ports: %+v", svc.Spec.Ports)
+	}
+
+	deploy.Spec.Selector = &metav1.LabelSelector{
+		MatchExpressions: []metav1.LabelSelectorRequirement{
+			{Key: "app", Operator: metav1.LabelSelectorOpIn, Values: []string{"web", "api"}},
+		},
+	}
+	if _, err := Generate(deploy, Opts{Port: 80}); err == nil {
+		t.Fatal("expected an error for a set based selector")
+	}
+}
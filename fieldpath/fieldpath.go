@@ -0,0 +1,276 @@
+package fieldpath
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// segment is a step of a path: a map key, a list index or a wildcard.
+type segment struct {
+	key      string
+	index    int
+	isIndex  bool
+	wildcard bool
+}
+
+// Path is a parsed field path.
+type Path []segment
+
+// Parse parses a dotted path with optional list indexes, wildcards and
+// quoted keys, e.g.:
+//
+//	metadata.name
+//	spec.containers[0].image
+//	spec.containers[*].ports[*].containerPort
+//	status.conditions[-1].type
+//	metadata.labels['app.kubernetes.io/name']
+//	metadata.annotations.*
+//
+// The JSONPath style braces and leading dot ("{.metadata.name}") are accepted.
+func Parse(path string) (Path, error) {
+	s := strings.TrimSpace(path)
+	if strings.HasPrefix(s, "{") && strings.HasSuffix(s, "}") {
+		s = s[1 : len(s)-1]
+	}
+	s = strings.TrimPrefix(s, ".")
+	if len(s) == 0 {
+		return nil, fmt.Errorf("empty field path")
+	}
+
+	res := Path{}
+	for i := 0; i < len(s); {
+		switch s[i] {
+		case '.':
+			i++
+			if i == len(s) || s[i] == '.' || s[i] == '[' {
+				return nil, fmt.Errorf("invalid field path %q: empty key at %d", path, i)
+			}
+		case '[':
+			end := strings.IndexByte(s[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("invalid field path %q: unterminated [ at %d", path, i)
+			}
+			seg, err := parseBracket(s[i+1 : i+end])
+			if err != nil {
+				return nil, fmt.Errorf("invalid field path %q: %w", path, err)
+			}
+			res = append(res, seg)
+			i += end + 1
+		default:
+			end := strings.IndexAny(s[i:], ".[")
+			if end < 0 {
+				end = len(s) - i
+			}
+			key := s[i : i+end]
+			if key == "*" {
+				res = append(res, segment{wildcard: true})
+			} else {
+				res = append(res, segment{key: key})
+			}
+			i += end
+		}
+	}
+	return res, nil
+}
+
+// MustParse is like Parse but panics if the path is invalid.
+func MustParse(path string) Path {
+	p, err := Parse(path)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+func parseBracket(s string) (segment, error) {
+	s = strings.TrimSpace(s)
+	switch {
+	case s == "*":
+		return segment{wildcard: true}, nil
+	case len(s) >= 2 && (s[0] == '\'' || s[0] == '"') && s[len(s)-1] == s[0]:
+		return segment{key: s[1 : len(s)-1]}, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return segment{}, fmt.Errorf("invalid index %q", s)
+	}
+	return segment{index: n, isIndex: true}, nil
+}
+
+// String returns the path in canonical form.
+func (p Path) String() string {
+	var sb strings.Builder
+	for _, seg := range p {
+		switch {
+		case seg.wildcard:
+			sb.WriteString("[*]")
+		case seg.isIndex:
+			fmt.Fprintf(&sb, "[%d]", seg.index)
+		case strings.ContainsAny(seg.key, ".[]"):
+			fmt.Fprintf(&sb, "['%s']", seg.key)
+		default:
+			if sb.Len() > 0 {
+				sb.WriteByte('.')
+			}
+			sb.WriteString(seg.key)
+		}
+	}
+	return sb.String()
+}
+
+// Values returns all the values matching the path (more than one
+// only with wildcards), in order. Missing fields are skipped.
+// The values are not copied.
+func (p Path) Values(obj interface{}) []interface{} {
+	cur := []interface{}{obj}
+	for _, seg := range p {
+		next := []interface{}{}
+		for _, v := range cur {
+			next = append(next, seg.apply(v)...)
+		}
+		if len(next) == 0 {
+			return nil
+		}
+		cur = next
+	}
+	return cur
+}
+
+func (seg segment) apply(v interface{}) []interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		if seg.wildcard {
+			keys := make([]string, 0, len(t))
+			for k := range t {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			res := make([]interface{}, 0, len(keys))
+			for _, k := range keys {
+				res = append(res, t[k])
+			}
+			return res
+		}
+		if seg.isIndex {
+			return nil
+		}
+		if x, ok := t[seg.key]; ok {
+			return []interface{}{x}
+		}
+	case []interface{}:
+		if seg.wildcard {
+			return t
+		}
+		if !seg.isIndex {
+			return nil
+		}
+		i := seg.index
+		if i < 0 {
+			i += len(t)
+		}
+		if i >= 0 && i < len(t) {
+			return []interface{}{t[i]}
+		}
+	}
+	return nil
+}
+
+// Get returns all the values matching the path (see Parse).
+func Get(obj map[string]interface{}, path string) ([]interface{}, error) {
+	p, err := Parse(path)
+	if err != nil {
+		return nil, err
+	}
+	return p.Values(obj), nil
+}
+
+// String returns the first value matching the path, failing if
+// it is not a string. The bool is false if nothing matches.
+func String(obj map[string]interface{}, path string) (string, bool, error) {
+	v, found, err := first(obj, path)
+	if !found || err != nil {
+		return "", found, err
+	}
+	s, ok := v.(string)
+	if !ok {
+		return "", true, fmt.Errorf("%s accessor error: %v is of the type %T, expected string", path, v, v)
+	}
+	return s, true, nil
+}
+
+// Int64 returns the first value matching the path, failing if it
+// is not an integer. The bool is false if nothing matches.
+func Int64(obj map[string]interface{}, path string) (int64, bool, error) {
+	v, found, err := first(obj, path)
+	if !found || err != nil {
+		return 0, found, err
+	}
+	switch n := v.(type) {
+	case int64:
+		return n, true, nil
+	case int:
+		return int64(n), true, nil
+	case int32:
+		return int64(n), true, nil
+	case float64:
+		if n == float64(int64(n)) {
+			return int64(n), true, nil
+		}
+	}
+	return 0, true, fmt.Errorf("%s accessor error: %v is of the type %T, expected int64", path, v, v)
+}
+
+// Bool returns the first value matching the path, failing if
+// it is not a bool. The bool is false if nothing matches.
+func Bool(obj map[string]interface{}, path string) (bool, bool, error) {
+	v, found, err := first(obj, path)
+	if !found || err != nil {
+		return false, found, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, true, fmt.Errorf("%s accessor error: %v is of the type %T, expected bool", path, v, v)
+	}
+	return b, true, nil
+}
+
+// Strings returns all the values matching the path formatted as
+// strings (e.g. all the images with "spec.containers[*].image").
+func Strings(obj map[string]interface{}, path string) ([]string, error) {
+	values, err := Get(obj, path)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]string, 0, len(values))
+	for _, v := range values {
+		res = append(res, Format(v))
+	}
+	return res, nil
+}
+
+// Format returns the value as a string: strings as they are, integral
+// numbers without decimals, nil as empty, anything else via fmt.
+func Format(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	case float64:
+		if t == float64(int64(t)) {
+			return strconv.FormatInt(int64(t), 10)
+		}
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	}
+	return fmt.Sprint(v)
+}
+
+func first(obj map[string]interface{}, path string) (interface{}, bool, error) {
+	values, err := Get(obj, path)
+	if err != nil || len(values) == 0 {
+		return nil, false, err
+	}
+	return values[0], true, nil
+}
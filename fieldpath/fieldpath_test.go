@@ -0,0 +1,88 @@
+package fieldpath
+
+import (
+	"reflect"
+	"testing"
+)
+
+func testPod() map[string]interface{} {
+	return map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"name": "web",
+			"labels": map[string]interface{}{
+				"app.kubernetes.io/name": "nginx",
+				"tier":                   "frontend",
+			},
+		},
+		"spec": map[string]interface{}{
+			"containers": []interface{}{
+				map[string]interface{}{
+					"name":  "nginx",
+					"image": "nginx:1.23",
+					"ports": []interface{}{
+						map[string]interface{}{"containerPort": int64(80)},
+						map[string]interface{}{"containerPort": float64(443)},
+					},
+				},
+				map[string]interface{}{"name": "sidecar", "image": "busybox"},
+			},
+			"hostNetwork": true,
+		},
+	}
+}
+
+func TestStrings(t *testing.T) {
+	tests := map[string][]string{
+		"metadata.name":                             {"web"},
+		"{.metadata.name}":                          {"web"},
+		"spec.containers[*].image":                  {"nginx:1.23", "busybox"},
+		"spec.containers[-1].name":                  {"sidecar"},
+		"spec.containers[*].ports[*].containerPort": {"80", "443"},
+		"metadata.labels['app.kubernetes.io/name']": {"nginx"},
+		"metadata.labels.*":                         {"nginx", "frontend"},
+		"spec.containers[5].name":                   {},
+		"status.phase":                              {},
+	}
+	for path, want := range tests {
+		got, err := Strings(testPod(), path)
+		if err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("%s: expected %v, got %v", path, want, got)
+		}
+	}
+}
+
+func TestTyped(t *testing.T) {
+	obj := testPod()
+
+	if s, found, err := String(obj, "spec.containers[0].name"); err != nil || !found || s != "nginx" {
+		t.Fatalf("unexpected result: %q %v %v", s, found, err)
+	}
+	if n, found, err := Int64(obj, "spec.containers[0].ports[1].containerPort"); err != nil || !found || n != 443 {
+		t.Fatalf("unexpected result: %d %v %v", n, found, err)
+	}
+	if b, found, err := Bool(obj, "spec.hostNetwork"); err != nil || !found || !b {
+		t.Fatalf("unexpected result: %v %v %v", b, found, err)
+	}
+	if _, found, err := String(obj, "spec.dnsPolicy"); err != nil || found {
+		t.Fatalf("expected not found, got %v %v", found, err)
+	}
+	if _, _, err := String(obj, "spec.hostNetwork"); err == nil {
+		t.Fatal("expected a type error")
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, path := range []string{"", "spec..name", "spec.containers[", "spec.containers[x]"} {
+		if _, err := Parse(path); err == nil {
+			t.Fatalf("%q: expected an error", path)
+		}
+	}
+
+	p := MustParse("metadata.labels['app.kubernetes.io/name']")
+	if got := p.String(); got != "metadata.labels['app.kubernetes.io/name']" {
+		t.Fatalf("unexpected canonical form: %s", got)
+	}
+}
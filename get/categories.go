@@ -0,0 +1,82 @@
+package kube
+
+import (
+	"fmt"
+	"strings"
+
+	kubeutil "github.com/lucasepe/kube/util"
+	"k8s.io/client-go/restmapper"
+)
+
+// expandCategories replaces the categories found in the resource types
+// argument using Opts.Categories and Opts.ExtendCategories. The other
+// categories are left to the builder, that expands them via discovery.
+func expandCategories(f kubeutil.Factory, o Opts) ([]string, error) {
+	if len(o.Resources) == 0 || strings.Contains(o.Resources[0], "/") ||
+		(len(o.Categories) == 0 && len(o.ExtendCategories) == 0) {
+		return o.Resources, nil
+	}
+
+	return replaceCategories(o, func() (restmapper.CategoryExpander, error) {
+		dc, err := f.ToDiscoveryClient()
+		if err != nil {
+			return nil, err
+		}
+		return restmapper.NewDiscoveryCategoryExpander(dc), nil
+	})
+}
+
+// replaceCategories is expandCategories with the discovery
+// expander returned by newExpander, invoked only if needed.
+func replaceCategories(o Opts, newExpander func() (restmapper.CategoryExpander, error)) ([]string, error) {
+	var expander restmapper.CategoryExpander
+	discovered := func(category string) ([]string, error) {
+		if expander == nil {
+			var err error
+			if expander, err = newExpander(); err != nil {
+				return nil, err
+			}
+		}
+
+		grs, ok := expander.Expand(category)
+		if !ok {
+			return nil, fmt.Errorf("unknown category %q, use Categories to define it", category)
+		}
+		res := make([]string, 0, len(grs))
+		for _, gr := range grs {
+			res = append(res, gr.String())
+		}
+		return res, nil
+	}
+
+	types := []string{}
+	seen := map[string]bool{}
+	add := func(list ...string) {
+		for _, it := range list {
+			if !seen[it] {
+				seen[it] = true
+				types = append(types, it)
+			}
+		}
+	}
+
+	for _, typ := range strings.Split(o.Resources[0], ",") {
+		if list, ok := o.Categories[typ]; ok {
+			add(list...)
+			continue
+		}
+		extra, ok := o.ExtendCategories[typ]
+		if !ok {
+			add(typ)
+			continue
+		}
+		list, err := discovered(typ)
+		if err != nil {
+			return nil, err
+		}
+		add(list...)
+		add(extra...)
+	}
+
+	return append([]string{strings.Join(types, ",")}, o.Resources[1:]...), nil
+}
@@ -0,0 +1,43 @@
+package kube
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/restmapper"
+)
+
+func TestReplaceCategories(t *testing.T) {
+	calls := 0
+	newExpander := func() (restmapper.CategoryExpander, error) {
+		calls++
+		return restmapper.SimpleCategoryExpander{Expansions: map[string][]schema.GroupResource{
+			"all": {{Resource: "pods"}, {Group: "apps", Resource: "deployments"}},
+		}}, nil
+	}
+
+	got, err := replaceCategories(Opts{
+		Resources:        []string{"all,mine,secrets", "web"},
+		Categories:       map[string][]string{"mine": {"configmaps", "pods"}},
+		ExtendCategories: map[string][]string{"all": {"configmaps"}},
+	}, newExpander)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"pods,deployments.apps,configmaps,secrets", "web"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	if calls != 1 {
+		t.Fatalf("expected the expander to be created once, got %d", calls)
+	}
+
+	_, err = replaceCategories(Opts{
+		Resources:        []string{"missing"},
+		ExtendCategories: map[string][]string{"missing": {"configmaps"}},
+	}, newExpander)
+	if err == nil {
+		t.Fatal("expected an error extending an unknown category")
+	}
+}
@@ -1,9 +1,16 @@
 package kube
 
 import (
+	"context"
+	"fmt"
+	"io"
+
 	kubeutil "github.com/lucasepe/kube/util"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/client-go/rest"
 )
 
 type Opts struct {
@@ -15,44 +22,255 @@ type Opts struct {
 	Namespace      string
 	Subresource    string
 	IgnoreNotFound bool
+	// IgnoreForbidden skips the resources the user is not allowed
+	// to read (e.g. some of the types of a category).
+	IgnoreForbidden bool
+	// IgnoreErrors skips the errors matching any of the functions.
+	// The other errors are aggregated and returned along with the
+	// objects that could be read.
+	IgnoreErrors []func(error) bool
+	// Filenames are files, directories or URLs ("-" for stdin)
+	// containing manifests; the objects are looked up in the cluster.
+	Filenames []string
+	Recursive bool
+	// Kustomize is a kustomization directory; the objects it
+	// generates are looked up in the cluster.
+	Kustomize string
+	// Readers are streams of YAML or JSON manifests; the objects
+	// are looked up in the cluster.
+	Readers []io.Reader
+	// Local returns the objects of the manifests as they are parsed,
+	// without looking them up in the cluster.
+	Local bool
+
+	// Categories overrides the resources a category expands to
+	// (e.g. {"all": {"pods", "deployments.apps"}}).
+	Categories map[string][]string
+	// ExtendCategories adds resources to the ones a category
+	// expands to via discovery (e.g. {"all": {"configmaps"}});
+	// the category must be known to the server.
+	ExtendCategories map[string][]string
+
+	// MetadataOnly reads only the metadata of the objects (via
+	// the metadata client), reducing the payload of large objects.
+	MetadataOnly bool
+
+	// ResourceVersion reads the objects at (or not older than, see
+	// ResourceVersionMatch) this version: "0" allows reads from the
+	// apiserver cache, empty (default) requires a quorum read.
+	// Setting it disables ChunkSize, except with MetadataOnly;
+	// it cannot be used with manifests.
+	ResourceVersion string
+	// ResourceVersionMatch is metav1.ResourceVersionMatchExact or
+	// metav1.ResourceVersionMatchNotOlderThan (requires ResourceVersion).
+	ResourceVersionMatch metav1.ResourceVersionMatch
+
+	// OutputNames returns, as kubectl get -o name, only the kind and
+	// the name of the objects (see ObjectName): the kind is resolved
+	// with the RESTMapper and, unless manifests or Subresource are
+	// set, only the metadata is read.
+	OutputNames bool
 }
 
 func Do(f kubeutil.Factory, o Opts) ([]*unstructured.Unstructured, error) {
+	return DoContext(context.Background(), f, o)
+}
+
+// DoContext is like Do but stops when the context is cancelled.
+// The builder requests are not cancellable, so the context is
+// checked between the objects (the metadata requests are cancelled).
+func DoContext(ctx context.Context, f kubeutil.Factory, o Opts) ([]*unstructured.Unstructured, error) {
 	if o.ChunkSize <= 0 {
 		o.ChunkSize = kubeutil.DefaultChunkSize
 	}
 
 	objs := []*unstructured.Unstructured{}
 
-	r := f.NewBuilder().
+	if err := validateResourceVersion(o); err != nil {
+		return objs, err
+	}
+
+	resources, err := expandCategories(f, o)
+	if err != nil {
+		return objs, err
+	}
+
+	o, add, err := outputNames(f, o, func(obj *unstructured.Unstructured) error {
+		objs = append(objs, obj)
+		return nil
+	})
+	if err != nil {
+		return objs, err
+	}
+
+	if o.MetadataOnly {
+		err := visitMetadata(ctx, f, o, resources, add)
+		return objs, err
+	}
+
+	r := newBuilder(f, o, resources).ContinueOnError().Do()
+
+	r.IgnoreErrors(o.errorFilters()...)
+	if err := r.Err(); err != nil {
+		return objs, err
+	}
+
+	err = r.Visit(func(info *resource.Info, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		return add(info.Object.(*unstructured.Unstructured))
+	})
+	if ctx.Err() != nil {
+		return objs, ctx.Err()
+	}
+
+	return objs, err
+}
+
+// Stream is like Do but, instead of returning all the objects at once,
+// invokes fn for each of them as soon as the page (of ChunkSize objects)
+// holding it arrives, so that the memory usage does not grow with the
+// number of objects. An error returned by fn stops the listing.
+func Stream(f kubeutil.Factory, o Opts, fn func(obj *unstructured.Unstructured) error) error {
+	return StreamContext(context.Background(), f, o, fn)
+}
+
+// StreamContext is like Stream but stops when the context is cancelled
+// (checked between the objects, as DoContext does).
+func StreamContext(ctx context.Context, f kubeutil.Factory, o Opts, fn func(obj *unstructured.Unstructured) error) error {
+	if o.ChunkSize <= 0 {
+		o.ChunkSize = kubeutil.DefaultChunkSize
+	}
+
+	if err := validateResourceVersion(o); err != nil {
+		return err
+	}
+
+	resources, err := expandCategories(f, o)
+	if err != nil {
+		return err
+	}
+
+	o, fn, err = outputNames(f, o, fn)
+	if err != nil {
+		return err
+	}
+
+	if o.MetadataOnly {
+		return visitMetadata(ctx, f, o, resources, fn)
+	}
+
+	r := newBuilder(f, o, resources).Do()
+	r.IgnoreErrors(o.errorFilters()...)
+
+	return r.Visit(func(info *resource.Info, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		obj, ok := info.Object.(*unstructured.Unstructured)
+		if !ok {
+			return nil
+		}
+		return fn(obj)
+	})
+}
+
+// newBuilder returns the builder of the objects selected by the options,
+// shared by DoContext and StreamContext. The objects of the manifests
+// are looked up in the cluster, unless Local.
+func newBuilder(f kubeutil.Factory, o Opts, resources []string) *resource.Builder {
+	b := f.NewBuilder()
+	if len(o.ResourceVersion) > 0 {
+		// the resource version cannot be set along with the
+		// continue token of the next pages, so no chunking
+		o.ChunkSize = 0
+		b = b.TransformRequests(func(req *rest.Request) {
+			req.Param("resourceVersion", o.ResourceVersion)
+			if len(o.ResourceVersionMatch) > 0 {
+				req.Param("resourceVersionMatch", string(o.ResourceVersionMatch))
+			}
+		})
+	}
+
+	b = b.
 		Unstructured().
 		NamespaceParam(o.Namespace).DefaultNamespace().AllNamespaces(o.AllNamespaces).
-		//FilenameParam(o.ExplicitNamespace, &o.FilenameOptions).
+		FilenameParam(false, &resource.FilenameOptions{
+			Filenames: o.Filenames,
+			Recursive: o.Recursive,
+			Kustomize: o.Kustomize,
+		}).
 		LabelSelectorParam(o.LabelSelector).
 		FieldSelectorParam(o.FieldSelector).
 		Subresource(o.Subresource).
 		RequestChunksOf(o.ChunkSize).
-		ResourceTypeOrNameArgs(true, o.Resources...).
-		ContinueOnError().
-		Latest().
-		Flatten().
-		Do()
+		ResourceTypeOrNameArgs(true, resources...).
+		Flatten()
+	for i, r := range o.Readers {
+		b = b.Stream(r, fmt.Sprintf("reader-%d", i))
+	}
+	switch {
+	case o.Local:
+		b = b.Local()
+	case o.fromManifests():
+		b = b.Latest()
+	}
+	return b
+}
+
+// fromManifests returns true if the objects are read from manifests.
+func (o Opts) fromManifests() bool {
+	return len(o.Filenames) > 0 || len(o.Kustomize) > 0 || len(o.Readers) > 0
+}
 
+// errorFilters returns the functions matching the errors to ignore.
+func (o Opts) errorFilters() []resource.ErrMatchFunc {
+	res := []resource.ErrMatchFunc{}
+	for _, fn := range o.IgnoreErrors {
+		res = append(res, fn)
+	}
 	if o.IgnoreNotFound {
-		r.IgnoreErrors(apierrors.IsNotFound)
+		res = append(res, apierrors.IsNotFound)
 	}
-	if err := r.Err(); err != nil {
-		return objs, err
+	if o.IgnoreForbidden {
+		res = append(res, apierrors.IsForbidden)
 	}
+	return res
+}
 
-	infos, err := r.Infos()
-	if err != nil {
-		return objs, err
+// ignored returns true if the error matches any of the filters.
+func (o Opts) ignored(err error) bool {
+	for _, fn := range o.errorFilters() {
+		if fn(err) {
+			return true
+		}
 	}
+	return false
+}
 
-	for _, info := range infos {
-		objs = append(objs, info.Object.(*unstructured.Unstructured))
+func validateResourceVersion(o Opts) error {
+	if len(o.ResourceVersion) > 0 && o.fromManifests() {
+		// the objects of the manifests are read with a GET,
+		// which cannot honor a resource version match
+		return fmt.Errorf("a resource version cannot be used with manifests")
 	}
 
-	return objs, nil
+	switch o.ResourceVersionMatch {
+	case "":
+		return nil
+	case metav1.ResourceVersionMatchExact, metav1.ResourceVersionMatchNotOlderThan:
+		if len(o.ResourceVersion) == 0 {
+			return fmt.Errorf("resource version match %s requires a resource version", o.ResourceVersionMatch)
+		}
+		return nil
+	}
+	return fmt.Errorf("invalid resource version match %q, must be %s or %s", o.ResourceVersionMatch,
+		metav1.ResourceVersionMatchExact, metav1.ResourceVersionMatchNotOlderThan)
 }
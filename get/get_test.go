@@ -0,0 +1,33 @@
+package kube
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestValidateResourceVersion(t *testing.T) {
+	tests := []struct {
+		name string
+		o    Opts
+		ok   bool
+	}{
+		{"none", Opts{}, true},
+		{"version", Opts{ResourceVersion: "0"}, true},
+		{"exact", Opts{ResourceVersion: "42", ResourceVersionMatch: metav1.ResourceVersionMatchExact}, true},
+		{"match without version", Opts{ResourceVersionMatch: metav1.ResourceVersionMatchExact}, false},
+		{"invalid match", Opts{ResourceVersion: "42", ResourceVersionMatch: "Latest"}, false},
+		{"filenames", Opts{ResourceVersion: "42", Filenames: []string{"deploy.yaml"}}, false},
+		{"readers", Opts{ResourceVersion: "42", Readers: []io.Reader{strings.NewReader("")}}, false},
+		{"manifests without version", Opts{Kustomize: "overlays/prod"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateResourceVersion(tt.o); (err == nil) != tt.ok {
+				t.Fatalf("unexpected validation result: %v", err)
+			}
+		})
+	}
+}
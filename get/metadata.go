@@ -0,0 +1,197 @@
+package kube
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	kubeutil "github.com/lucasepe/kube/util"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/restmapper"
+)
+
+// metadataTarget is a resource type to list, or to get by names.
+type metadataTarget struct {
+	mapping *meta.RESTMapping
+	names   []string
+}
+
+// visitMetadata invokes fn for each selected object, reading only
+// its metadata with the metadata client. The returned objects keep
+// the kind of the resource but hold only apiVersion, kind and metadata.
+func visitMetadata(ctx context.Context, f kubeutil.Factory, o Opts, resources []string, fn func(obj *unstructured.Unstructured) error) error {
+	if len(o.Subresource) > 0 {
+		return fmt.Errorf("subresources are not supported when getting only the metadata")
+	}
+	if o.fromManifests() {
+		return fmt.Errorf("manifests are not supported when getting only the metadata")
+	}
+
+	targets, err := metadataTargets(f, resources)
+	if err != nil {
+		return err
+	}
+
+	namespace := o.Namespace
+	if o.AllNamespaces {
+		namespace = metav1.NamespaceAll
+	} else if len(namespace) == 0 {
+		namespace, _, err = f.ToRawKubeConfigLoader().Namespace()
+		if err != nil {
+			return err
+		}
+	}
+
+	mc, err := kubeutil.MetadataClient(f)
+	if err != nil {
+		return err
+	}
+
+	errs := []error{}
+	for _, t := range targets {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		ns := namespace
+		if t.mapping.Scope.Name() != meta.RESTScopeNameNamespace {
+			ns = metav1.NamespaceAll
+		}
+		ri := mc.Resource(t.mapping.Resource).Namespace(ns)
+		gvk := t.mapping.GroupVersionKind
+
+		for _, name := range t.names {
+			item, err := ri.Get(ctx, name, metav1.GetOptions{ResourceVersion: o.ResourceVersion})
+			if err != nil {
+				if !o.ignored(err) {
+					errs = append(errs, err)
+				}
+				continue
+			}
+			if err := visitPartial(item, gvk, fn); err != nil {
+				return err
+			}
+		}
+		if len(t.names) > 0 {
+			continue
+		}
+
+		opts := metav1.ListOptions{
+			LabelSelector:        o.LabelSelector,
+			FieldSelector:        o.FieldSelector,
+			Limit:                o.ChunkSize,
+			ResourceVersion:      o.ResourceVersion,
+			ResourceVersionMatch: o.ResourceVersionMatch,
+		}
+		for {
+			list, err := ri.List(ctx, opts)
+			if err != nil {
+				if !o.ignored(err) {
+					errs = append(errs, err)
+				}
+				break
+			}
+			for i := range list.Items {
+				if err := visitPartial(&list.Items[i], gvk, fn); err != nil {
+					return err
+				}
+			}
+			if len(list.Continue) == 0 {
+				break
+			}
+			opts.Continue = list.Continue
+			opts.ResourceVersion, opts.ResourceVersionMatch = "", ""
+		}
+	}
+
+	return utilerrors.NewAggregate(errs)
+}
+
+func visitPartial(item *metav1.PartialObjectMetadata, gvk schema.GroupVersionKind, fn func(obj *unstructured.Unstructured) error) error {
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(item)
+	if err != nil {
+		return err
+	}
+	obj := &unstructured.Unstructured{Object: content}
+	obj.SetGroupVersionKind(gvk)
+	return fn(obj)
+}
+
+// metadataTargets resolves the resource arguments, in the same
+// forms accepted by the builder: "type/name ..." or "type[,type...] [name ...]".
+func metadataTargets(f kubeutil.Factory, resources []string) ([]metadataTarget, error) {
+	if len(resources) == 0 {
+		return nil, fmt.Errorf("you must specify the type of resource to get")
+	}
+
+	mapper, err := f.ToRESTMapper()
+	if err != nil {
+		return nil, err
+	}
+
+	targets := []metadataTarget{}
+	if strings.Contains(resources[0], "/") {
+		for _, arg := range resources {
+			parts := strings.SplitN(arg, "/", 2)
+			if len(parts) != 2 || len(parts[0]) == 0 || len(parts[1]) == 0 {
+				return nil, fmt.Errorf("arguments in resource/name form must have a single resource and name")
+			}
+			mappings, err := mappingsFor(f, mapper, parts[0], false)
+			if err != nil {
+				return nil, err
+			}
+			targets = append(targets, metadataTarget{mapping: mappings[0], names: []string{parts[1]}})
+		}
+		return targets, nil
+	}
+
+	names := resources[1:]
+	for _, typ := range strings.Split(resources[0], ",") {
+		mappings, err := mappingsFor(f, mapper, typ, len(names) == 0)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range mappings {
+			targets = append(targets, metadataTarget{mapping: m, names: names})
+		}
+	}
+	return targets, nil
+}
+
+// mappingsFor returns the mapping of the resource type or, if allowed,
+// the mappings of the resources of the category with that name.
+func mappingsFor(f kubeutil.Factory, mapper meta.RESTMapper, typ string, categories bool) ([]*meta.RESTMapping, error) {
+	grs := []schema.GroupResource{schema.ParseGroupResource(typ)}
+
+	if _, err := mapper.ResourceFor(grs[0].WithVersion("")); err != nil && categories {
+		dc, derr := f.ToDiscoveryClient()
+		if derr != nil {
+			return nil, derr
+		}
+		if expanded, ok := restmapper.NewDiscoveryCategoryExpander(dc).Expand(typ); ok {
+			grs = expanded
+		}
+	}
+
+	res := make([]*meta.RESTMapping, 0, len(grs))
+	for _, gr := range grs {
+		gvr, err := mapper.ResourceFor(gr.WithVersion(""))
+		if err != nil {
+			return nil, err
+		}
+		gvk, err := mapper.KindFor(gvr)
+		if err != nil {
+			return nil, err
+		}
+		m, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, m)
+	}
+	return res, nil
+}
@@ -0,0 +1,41 @@
+package kube
+
+import (
+	"context"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestVisitPartial(t *testing.T) {
+	item := &metav1.PartialObjectMetadata{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", Labels: map[string]string{"app": "web"}},
+	}
+	gvk := schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}
+
+	var got *unstructured.Unstructured
+	err := visitPartial(item, gvk, func(obj *unstructured.Unstructured) error {
+		got = obj
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.GroupVersionKind() != gvk || got.GetName() != "web" || got.GetLabels()["app"] != "web" {
+		t.Fatalf("unexpected object %v", got.Object)
+	}
+}
+
+func TestVisitMetadataUnsupported(t *testing.T) {
+	for _, o := range []Opts{
+		{Subresource: "status"},
+		{Filenames: []string{"deploy.yaml"}},
+	} {
+		err := visitMetadata(context.Background(), nil, o, []string{"deployments"}, func(*unstructured.Unstructured) error { return nil })
+		if err == nil {
+			t.Errorf("expected an error for %+v", o)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package kube
+
+import (
+	"strings"
+
+	kubeutil "github.com/lucasepe/kube/util"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// ObjectName returns the "kind[.group]/name" of the object
+// (e.g. "pod/web-0", "deployment.apps/nginx"), as kubectl get -o name.
+func ObjectName(obj *unstructured.Unstructured) string {
+	gk := obj.GroupVersionKind().GroupKind()
+	return strings.ToLower(gk.String()) + "/" + obj.GetName()
+}
+
+// outputNames returns, if OutputNames is set, the options reading only
+// the metadata and fn wrapped to be passed the objects reduced by nameOnly.
+func outputNames(f kubeutil.Factory, o Opts, fn func(obj *unstructured.Unstructured) error) (Opts, func(obj *unstructured.Unstructured) error, error) {
+	if !o.OutputNames {
+		return o, fn, nil
+	}
+	if !o.fromManifests() && len(o.Subresource) == 0 {
+		o.MetadataOnly = true
+	}
+
+	mapper, err := f.ToRESTMapper()
+	if err != nil {
+		return o, nil, err
+	}
+
+	return o, func(obj *unstructured.Unstructured) error {
+		res, err := nameOnly(mapper, obj)
+		if err != nil {
+			return err
+		}
+		return fn(res)
+	}, nil
+}
+
+// nameOnly returns an object holding only the kind, resolved
+// with the mapper, the namespace and the name of obj.
+func nameOnly(mapper meta.RESTMapper, obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	gvk := obj.GroupVersionKind()
+	mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &unstructured.Unstructured{}
+	res.SetGroupVersionKind(mapping.GroupVersionKind)
+	res.SetNamespace(obj.GetNamespace())
+	res.SetName(obj.GetName())
+	return res, nil
+}
@@ -0,0 +1,48 @@
+package kube
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestNameOnly(t *testing.T) {
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Pod"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}, meta.RESTScopeNamespace)
+
+	tests := []struct {
+		apiVersion, kind, want string
+	}{
+		{"v1", "Pod", "pod/web"},
+		{"apps/v1", "Deployment", "deployment.apps/web"},
+	}
+	for _, tt := range tests {
+		obj := &unstructured.Unstructured{}
+		obj.SetAPIVersion(tt.apiVersion)
+		obj.SetKind(tt.kind)
+		obj.SetNamespace("default")
+		obj.SetName("web")
+		obj.SetLabels(map[string]string{"app": "web"})
+
+		res, err := nameOnly(mapper, obj)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := ObjectName(res); got != tt.want {
+			t.Fatalf("expected %q, got %q", tt.want, got)
+		}
+		if res.GetNamespace() != "default" || res.GetLabels() != nil {
+			t.Fatalf("expected only the kind, the namespace and the name, got %v", res.Object)
+		}
+	}
+
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion("example.com/v1")
+	obj.SetKind("Widget")
+	if _, err := nameOnly(mapper, obj); err == nil {
+		t.Fatal("expected an error for an unknown kind")
+	}
+}
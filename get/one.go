@@ -0,0 +1,58 @@
+package kube
+
+import (
+	"fmt"
+
+	kubeutil "github.com/lucasepe/kube/util"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// OneOpts is a set of options that allows you to get a single object.
+type OneOpts struct {
+	// Resource is the resource type (e.g. "pods", "deploy", "deployments.apps").
+	Resource string
+	Name     string
+	// Namespace defaults to the one of the current context.
+	Namespace string
+}
+
+// One returns the named object. When it does not exist the
+// error satisfies apierrors.IsNotFound.
+func One(f kubeutil.Factory, o OneOpts) (*unstructured.Unstructured, error) {
+	if len(o.Resource) == 0 || len(o.Name) == 0 {
+		return nil, fmt.Errorf("you must specify the resource type and the name")
+	}
+
+	infos, err := f.NewBuilder().
+		Unstructured().
+		NamespaceParam(o.Namespace).DefaultNamespace().
+		ResourceNames(o.Resource, o.Name).
+		SingleResourceType().
+		Flatten().
+		Do().
+		Infos()
+	if err != nil {
+		return nil, err
+	}
+	if len(infos) != 1 {
+		return nil, fmt.Errorf("expected a single %s named %s, found %d", o.Resource, o.Name, len(infos))
+	}
+
+	obj, ok := infos[0].Object.(*unstructured.Unstructured)
+	if !ok {
+		return nil, fmt.Errorf("unexpected object type %T", infos[0].Object)
+	}
+	return obj, nil
+}
+
+// OneTyped is like One but returns the object decoded into T, a pointer
+// to a type registered in scheme.Scheme (e.g. *corev1.ConfigMap).
+func OneTyped[T runtime.Object](f kubeutil.Factory, o OneOpts) (T, error) {
+	obj, err := One(f, o)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return Decode[T](obj)
+}
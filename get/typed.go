@@ -0,0 +1,64 @@
+package kube
+
+import (
+	"fmt"
+
+	"github.com/lucasepe/kube/scheme"
+	kubeutil "github.com/lucasepe/kube/util"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+// DoTyped is like Do but returns the objects decoded into T, a
+// pointer to a type registered in scheme.Scheme (e.g. *corev1.Pod).
+// The objects that cannot be decoded (e.g. of other kinds) are
+// skipped: like Do, the objects decoded are returned along with
+// an aggregate of the errors, those of Do included.
+func DoTyped[T runtime.Object](f kubeutil.Factory, o Opts) ([]T, error) {
+	objs, err := Do(f, o)
+
+	res, errs := decodeAll[T](objs)
+	if err != nil {
+		errs = append([]error{err}, errs...)
+	}
+	return res, utilerrors.NewAggregate(errs)
+}
+
+// decodeAll decodes the objects into T, skipping (and returning
+// the errors of) the ones that cannot be decoded.
+func decodeAll[T runtime.Object](objs []*unstructured.Unstructured) ([]T, []error) {
+	res := make([]T, 0, len(objs))
+	errs := []error{}
+	for _, obj := range objs {
+		typed, err := Decode[T](obj)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		res = append(res, typed)
+	}
+	return res, errs
+}
+
+// Decode converts the unstructured object into T, a pointer
+// to a type registered in scheme.Scheme (e.g. *appsv1.Deployment).
+func Decode[T runtime.Object](obj *unstructured.Unstructured) (T, error) {
+	var zero T
+
+	gvk := obj.GroupVersionKind()
+	into, err := scheme.Scheme.New(gvk)
+	if err != nil {
+		return zero, err
+	}
+	res, ok := into.(T)
+	if !ok {
+		return zero, fmt.Errorf("cannot decode %s %s into %T", gvk.Kind, obj.GetName(), zero)
+	}
+
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, res); err != nil {
+		return zero, fmt.Errorf("error decoding %s %s: %w", gvk.Kind, obj.GetName(), err)
+	}
+	res.GetObjectKind().SetGroupVersionKind(gvk)
+	return res, nil
+}
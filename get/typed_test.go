@@ -0,0 +1,60 @@
+package kube
+
+import (
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestDecode(t *testing.T) {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Pod",
+		"metadata":   map[string]interface{}{"name": "web", "namespace": "default"},
+		"spec": map[string]interface{}{
+			"containers": []interface{}{
+				map[string]interface{}{"name": "nginx", "image": "nginx:1.23"},
+			},
+		},
+	}}
+
+	pod, err := Decode[*corev1.Pod](obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pod.Name != "web" || pod.Spec.Containers[0].Image != "nginx:1.23" {
+		t.Fatalf("unexpected pod: %+v", pod)
+	}
+	if pod.Kind != "Pod" {
+		t.Fatalf("expected kind Pod, got %q", pod.Kind)
+	}
+
+	if _, err := Decode[*appsv1.Deployment](obj); err == nil {
+		t.Fatal("expected an error decoding a Pod into a Deployment")
+	}
+}
+
+func TestDecodeAll(t *testing.T) {
+	objs := []*unstructured.Unstructured{
+		{Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Pod",
+			"metadata":   map[string]interface{}{"name": "web", "namespace": "default"},
+		}},
+		{Object: map[string]interface{}{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata":   map[string]interface{}{"name": "nginx", "namespace": "default"},
+		}},
+	}
+
+	pods, errs := decodeAll[*corev1.Pod](objs)
+	if len(pods) != 1 || pods[0].Name != "web" {
+		t.Fatalf("expected the pod to be decoded, got %v", pods)
+	}
+	if len(errs) != 1 {
+		t.Fatalf("expected an error for the deployment, got %v", errs)
+	}
+}
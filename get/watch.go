@@ -0,0 +1,283 @@
+package kube
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	kubeutil "github.com/lucasepe/kube/util"
+	"golang.org/x/sync/errgroup"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/client-go/tools/pager"
+)
+
+const maxWatchBackoff = 30 * time.Second
+
+// ErrStopWatch can be returned by a WatchHandler to stop watching without errors.
+var ErrStopWatch = errors.New("stop watching")
+
+// WatchHandler is called, one event at a time, for every change of
+// the watched objects (watch.Added, watch.Modified or watch.Deleted).
+type WatchHandler func(eventType watch.EventType, obj *unstructured.Unstructured) error
+
+// errExpired tells that the resource version is too old and a relist is needed.
+var errExpired = errors.New("resource version expired")
+
+// Watch lists the selected objects, delivering them as watch.Added events,
+// then watches them until the context is cancelled or the handler returns
+// an error (ErrStopWatch stops it cleanly). Dropped connections are resumed
+// from the last seen resource version (kept fresh via bookmarks); when the
+// version expires the objects are listed again and only the differences
+// with the known state are delivered.
+func Watch(ctx context.Context, f kubeutil.Factory, o Opts, handler WatchHandler) error {
+	if o.ChunkSize <= 0 {
+		o.ChunkSize = kubeutil.DefaultChunkSize
+	}
+
+	resources, err := expandCategories(f, o)
+	if err != nil {
+		return err
+	}
+
+	r := f.NewBuilder().
+		Unstructured().
+		NamespaceParam(o.Namespace).DefaultNamespace().AllNamespaces(o.AllNamespaces).
+		LabelSelectorParam(o.LabelSelector).
+		FieldSelectorParam(o.FieldSelector).
+		ResourceTypeOrNameArgs(true, resources...).
+		Do()
+	r.IgnoreErrors(o.errorFilters()...)
+
+	targets := []*watchTarget{}
+	err = r.Visit(func(info *resource.Info, err error) error {
+		if err != nil {
+			return err
+		}
+		t := &watchTarget{
+			helper:        resource.NewHelper(info.Client, info.Mapping),
+			namespace:     info.Namespace,
+			chunkSize:     o.ChunkSize,
+			labelSelector: o.LabelSelector,
+			fieldSelector: o.FieldSelector,
+			known:         map[types.UID]*unstructured.Unstructured{},
+		}
+		if len(info.Name) > 0 {
+			t.fieldSelector = fields.OneTermEqualSelector("metadata.name", info.Name).String()
+		}
+		targets = append(targets, t)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if len(targets) == 0 {
+		return fmt.Errorf("no resources to watch")
+	}
+
+	var mu sync.Mutex
+	emit := func(eventType watch.EventType, obj *unstructured.Unstructured) error {
+		mu.Lock()
+		defer mu.Unlock()
+		return handler(eventType, obj)
+	}
+
+	g, gctx := errgroup.WithContext(ctx)
+	for _, t := range targets {
+		t := t
+		g.Go(func() error {
+			return t.run(gctx, emit)
+		})
+	}
+
+	err = g.Wait()
+	if errors.Is(err, ErrStopWatch) || ctx.Err() != nil {
+		return nil
+	}
+	return err
+}
+
+// watchTarget is a single list and watch: a resource type in
+// a namespace (or in all namespaces), or a single named object.
+type watchTarget struct {
+	helper        *resource.Helper
+	namespace     string
+	chunkSize     int64
+	labelSelector string
+	fieldSelector string
+	// known is the last observed state, used to compute
+	// the events to deliver after a relist.
+	known map[types.UID]*unstructured.Unstructured
+}
+
+func (t *watchTarget) run(ctx context.Context, emit WatchHandler) error {
+	backoff := time.Second
+	for {
+		rv, err := t.sync(ctx, emit)
+		if err == nil {
+			err = t.watch(ctx, rv, emit)
+		}
+		if err == errExpired {
+			backoff = time.Second
+			continue
+		}
+		if !isTransient(err) {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > maxWatchBackoff {
+			backoff = maxWatchBackoff
+		}
+	}
+}
+
+func (t *watchTarget) listOptions() metav1.ListOptions {
+	return metav1.ListOptions{
+		LabelSelector: t.labelSelector,
+		FieldSelector: t.fieldSelector,
+	}
+}
+
+// sync lists the objects, delivers the differences with the known
+// state and returns the resource version to start watching from.
+func (t *watchTarget) sync(ctx context.Context, emit WatchHandler) (string, error) {
+	p := pager.New(pager.SimplePageFunc(func(opts metav1.ListOptions) (runtime.Object, error) {
+		return t.helper.List(t.namespace, "", &opts)
+	}))
+	p.PageSize = t.chunkSize
+
+	list, _, err := p.List(ctx, t.listOptions())
+	if err != nil {
+		return "", err
+	}
+	listMeta, err := meta.ListAccessor(list)
+	if err != nil {
+		return "", err
+	}
+	items, err := meta.ExtractList(list)
+	if err != nil {
+		return "", err
+	}
+
+	seen := map[types.UID]bool{}
+	for _, item := range items {
+		obj, ok := item.(*unstructured.Unstructured)
+		if !ok {
+			continue
+		}
+		seen[obj.GetUID()] = true
+		old, found := t.known[obj.GetUID()]
+		t.known[obj.GetUID()] = obj
+
+		switch {
+		case !found:
+			err = emit(watch.Added, obj)
+		case old.GetResourceVersion() != obj.GetResourceVersion():
+			err = emit(watch.Modified, obj)
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+
+	for uid, obj := range t.known {
+		if seen[uid] {
+			continue
+		}
+		delete(t.known, uid)
+		if err := emit(watch.Deleted, obj); err != nil {
+			return "", err
+		}
+	}
+
+	return listMeta.GetResourceVersion(), nil
+}
+
+// watch delivers the events starting from the resource version,
+// resuming the watch every time the server closes it.
+func (t *watchTarget) watch(ctx context.Context, rv string, emit WatchHandler) error {
+	for {
+		opts := t.listOptions()
+		opts.ResourceVersion = rv
+		opts.AllowWatchBookmarks = true
+
+		w, err := t.helper.Watch(t.namespace, "", &opts)
+		if err != nil {
+			if apierrors.IsResourceExpired(err) || apierrors.IsGone(err) {
+				return errExpired
+			}
+			return err
+		}
+
+		rv, err = t.consume(ctx, w, rv, emit)
+		w.Stop()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// consume delivers the events until the watch is closed and
+// returns the last seen resource version.
+func (t *watchTarget) consume(ctx context.Context, w watch.Interface, rv string, emit WatchHandler) (string, error) {
+	for {
+		select {
+		case <-ctx.Done():
+			return rv, ctx.Err()
+		case ev, ok := <-w.ResultChan():
+			if !ok {
+				return rv, nil
+			}
+
+			if ev.Type == watch.Error {
+				err := apierrors.FromObject(ev.Object)
+				if apierrors.IsResourceExpired(err) || apierrors.IsGone(err) {
+					return rv, errExpired
+				}
+				return rv, err
+			}
+
+			obj, ok := ev.Object.(*unstructured.Unstructured)
+			if !ok {
+				continue
+			}
+			rv = obj.GetResourceVersion()
+
+			switch ev.Type {
+			case watch.Bookmark:
+				continue
+			case watch.Deleted:
+				delete(t.known, obj.GetUID())
+			default:
+				t.known[obj.GetUID()] = obj
+			}
+			if err := emit(ev.Type, obj); err != nil {
+				return rv, err
+			}
+		}
+	}
+}
+
+// isTransient returns true for the errors worth a retry.
+func isTransient(err error) bool {
+	return utilnet.IsProbableEOF(err) ||
+		utilnet.IsConnectionRefused(err) || utilnet.IsConnectionReset(err) ||
+		apierrors.IsTooManyRequests(err) || apierrors.IsServerTimeout(err) ||
+		apierrors.IsTimeout(err) || apierrors.IsServiceUnavailable(err) ||
+		apierrors.IsInternalError(err)
+}
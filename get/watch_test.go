@@ -0,0 +1,201 @@
+package kube
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/client-go/rest/fake"
+)
+
+func configMap(uid, rv string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion("v1")
+	obj.SetKind("ConfigMap")
+	obj.SetNamespace("default")
+	obj.SetName("cm-" + uid)
+	obj.SetUID(types.UID(uid))
+	obj.SetResourceVersion(rv)
+	return obj
+}
+
+// recorder collects the delivered events as "type uid@rv".
+type recorder []string
+
+func (r *recorder) handle(eventType watch.EventType, obj *unstructured.Unstructured) error {
+	*r = append(*r, string(eventType)+" "+string(obj.GetUID())+"@"+obj.GetResourceVersion())
+	return nil
+}
+
+// configMapsTarget returns a watch target whose list returns,
+// one call after the other, the given pages of objects.
+func configMapsTarget(t *testing.T, lists ...[]*unstructured.Unstructured) *watchTarget {
+	t.Helper()
+
+	calls := 0
+	client := &fake.RESTClient{
+		GroupVersion:         corev1.SchemeGroupVersion,
+		NegotiatedSerializer: resource.UnstructuredPlusDefaultContentConfig().NegotiatedSerializer,
+		Client: fake.CreateHTTPClient(func(req *http.Request) (*http.Response, error) {
+			if req.Method != http.MethodGet || req.URL.Path != "/namespaces/default/configmaps" || calls >= len(lists) {
+				t.Fatalf("unexpected request: %s %s", req.Method, req.URL)
+			}
+			items := []interface{}{}
+			for _, obj := range lists[calls] {
+				items = append(items, obj.Object)
+			}
+			calls++
+			body, err := json.Marshal(map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "ConfigMapList",
+				"metadata":   map[string]interface{}{"resourceVersion": "100"},
+				"items":      items,
+			})
+			if err != nil {
+				return nil, err
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{"Content-Type": []string{runtime.ContentTypeJSON}},
+				Body:       io.NopCloser(bytes.NewReader(body)),
+			}, nil
+		}),
+	}
+
+	mapping := &meta.RESTMapping{
+		Resource:         corev1.SchemeGroupVersion.WithResource("configmaps"),
+		GroupVersionKind: corev1.SchemeGroupVersion.WithKind("ConfigMap"),
+		Scope:            meta.RESTScopeNamespace,
+	}
+	return &watchTarget{
+		helper:    resource.NewHelper(client, mapping),
+		namespace: "default",
+		chunkSize: 500,
+		known:     map[types.UID]*unstructured.Unstructured{},
+	}
+}
+
+func TestWatchTargetSync(t *testing.T) {
+	target := configMapsTarget(t,
+		[]*unstructured.Unstructured{configMap("a", "1"), configMap("b", "1")},
+		[]*unstructured.Unstructured{configMap("a", "2"), configMap("c", "3")},
+	)
+
+	var events recorder
+	rv, err := target.sync(context.Background(), events.handle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rv != "100" {
+		t.Fatalf("expected the resource version of the list, got %q", rv)
+	}
+	if want := (recorder{"ADDED a@1", "ADDED b@1"}); !reflect.DeepEqual(events, want) {
+		t.Fatalf("expected %v, got %v", want, events)
+	}
+
+	// a relist delivers only the differences with the known state
+	events = nil
+	if _, err := target.sync(context.Background(), events.handle); err != nil {
+		t.Fatal(err)
+	}
+	if want := (recorder{"MODIFIED a@2", "ADDED c@3", "DELETED b@1"}); !reflect.DeepEqual(events, want) {
+		t.Fatalf("expected %v, got %v", want, events)
+	}
+	if len(target.known) != 2 {
+		t.Fatalf("expected the deleted object to be forgotten, got %v", target.known)
+	}
+}
+
+func TestWatchTargetConsume(t *testing.T) {
+	target := &watchTarget{known: map[types.UID]*unstructured.Unstructured{}}
+
+	w := watch.NewFakeWithChanSize(10, false)
+	w.Add(configMap("a", "5"))
+	w.Action(watch.Bookmark, configMap("", "6"))
+	w.Modify(configMap("a", "7"))
+	w.Delete(configMap("a", "8"))
+	w.Action(watch.Bookmark, configMap("", "9"))
+	w.Stop()
+
+	var events recorder
+	rv, err := target.consume(context.Background(), w, "4", events.handle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rv != "9" {
+		t.Fatalf("expected the resource version of the last bookmark, got %q", rv)
+	}
+	if want := (recorder{"ADDED a@5", "MODIFIED a@7", "DELETED a@8"}); !reflect.DeepEqual(events, want) {
+		t.Fatalf("expected %v, got %v", want, events)
+	}
+	if len(target.known) != 0 {
+		t.Fatalf("expected the deleted object to be forgotten, got %v", target.known)
+	}
+}
+
+func TestWatchTargetConsumeExpired(t *testing.T) {
+	target := &watchTarget{known: map[types.UID]*unstructured.Unstructured{}}
+
+	gone := apierrors.NewResourceExpired("too old resource version")
+	w := watch.NewFakeWithChanSize(10, false)
+	w.Add(configMap("a", "5"))
+	w.Error(&gone.ErrStatus)
+
+	var events recorder
+	rv, err := target.consume(context.Background(), w, "4", events.handle)
+	if err != errExpired {
+		t.Fatalf("expected the watch to be expired, got %v", err)
+	}
+	if rv != "5" || len(events) != 1 {
+		t.Fatalf("unexpected resource version %q and events %v", rv, events)
+	}
+}
+
+func TestWatchTargetConsumeStop(t *testing.T) {
+	target := &watchTarget{known: map[types.UID]*unstructured.Unstructured{}}
+
+	w := watch.NewFakeWithChanSize(10, false)
+	w.Add(configMap("a", "5"))
+	w.Add(configMap("b", "6"))
+
+	_, err := target.consume(context.Background(), w, "4", func(watch.EventType, *unstructured.Unstructured) error {
+		return ErrStopWatch
+	})
+	if err != ErrStopWatch {
+		t.Fatalf("expected the handler error, got %v", err)
+	}
+}
+
+func TestIsTransient(t *testing.T) {
+	gr := schema.GroupResource{Resource: "configmaps"}
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{apierrors.NewServiceUnavailable("down"), true},
+		{apierrors.NewTooManyRequests("slow down", 1), true},
+		{apierrors.NewInternalError(io.ErrUnexpectedEOF), true},
+		{io.EOF, true},
+		{apierrors.NewNotFound(gr, "settings"), false},
+		{apierrors.NewForbidden(gr, "settings", nil), false},
+		{ErrStopWatch, false},
+	}
+	for _, tt := range tests {
+		if got := isTransient(tt.err); got != tt.want {
+			t.Errorf("%v: expected %t, got %t", tt.err, tt.want, got)
+		}
+	}
+}
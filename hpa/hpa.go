@@ -0,0 +1,231 @@
+package hpa
+
+import (
+	"context"
+	"fmt"
+
+	kubeutil "github.com/lucasepe/kube/util"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// MetricValue is a metric value or target; only the fields
+// relevant to the metric target type are set.
+type MetricValue struct {
+	Value              *resource.Quantity
+	AverageValue       *resource.Quantity
+	AverageUtilization *int32
+}
+
+// MetricStatus pairs the current value of a metric with its target.
+type MetricStatus struct {
+	Type autoscalingv2.MetricSourceType
+	// Name is the resource or metric name.
+	Name string
+	// Object is the described object for Object metrics (e.g. "Ingress/main").
+	Object string
+	// Container is set for ContainerResource metrics.
+	Container string
+
+	Current MetricValue
+	Target  MetricValue
+}
+
+// Status is the structured status of a HorizontalPodAutoscaler.
+type Status struct {
+	Name      string
+	Namespace string
+	// Target is the scale target reference (e.g. "Deployment/web").
+	Target string
+
+	MinReplicas     int32
+	MaxReplicas     int32
+	CurrentReplicas int32
+	DesiredReplicas int32
+	LastScaleTime   *metav1.Time
+
+	Metrics    []MetricStatus
+	Conditions []autoscalingv2.HorizontalPodAutoscalerCondition
+
+	// AbleToScale and ScalingActive mirror the homonymous conditions.
+	AbleToScale   bool
+	ScalingActive bool
+	// LimitedByMin and LimitedByMax are true when the desired replica
+	// count has been clamped to the min or max replicas.
+	LimitedByMin bool
+	LimitedByMax bool
+	// Stabilized is true when a scale-up or scale-down is being
+	// held back by the stabilization window.
+	Stabilized bool
+}
+
+// Inspect fetches the named autoscaling/v2 HorizontalPodAutoscaler and returns its status.
+func Inspect(ctx context.Context, f kubeutil.Factory, namespace, name string) (*Status, error) {
+	cli, err := f.KubernetesClientSet()
+	if err != nil {
+		return nil, err
+	}
+
+	h, err := cli.AutoscalingV2().HorizontalPodAutoscalers(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	res := StatusOf(h)
+	return &res, nil
+}
+
+// List returns the status of all the HorizontalPodAutoscalers in the namespace
+// (all namespaces if empty).
+func List(ctx context.Context, f kubeutil.Factory, namespace string) ([]Status, error) {
+	cli, err := f.KubernetesClientSet()
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := cli.AutoscalingV2().HorizontalPodAutoscalers(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]Status, 0, len(list.Items))
+	for i := range list.Items {
+		res = append(res, StatusOf(&list.Items[i]))
+	}
+	return res, nil
+}
+
+// StatusOf builds the structured status of the HorizontalPodAutoscaler.
+func StatusOf(h *autoscalingv2.HorizontalPodAutoscaler) Status {
+	res := Status{
+		Name:            h.Name,
+		Namespace:       h.Namespace,
+		Target:          fmt.Sprintf("%s/%s", h.Spec.ScaleTargetRef.Kind, h.Spec.ScaleTargetRef.Name),
+		MinReplicas:     1,
+		MaxReplicas:     h.Spec.MaxReplicas,
+		CurrentReplicas: h.Status.CurrentReplicas,
+		DesiredReplicas: h.Status.DesiredReplicas,
+		LastScaleTime:   h.Status.LastScaleTime,
+		Conditions:      h.Status.Conditions,
+	}
+	if h.Spec.MinReplicas != nil {
+		res.MinReplicas = *h.Spec.MinReplicas
+	}
+
+	for _, c := range h.Status.Conditions {
+		active := c.Status == corev1.ConditionTrue
+		switch c.Type {
+		case autoscalingv2.AbleToScale:
+			res.AbleToScale = active
+			if c.Reason == "ScaleDownStabilized" || c.Reason == "ScaleUpStabilized" {
+				res.Stabilized = true
+			}
+		case autoscalingv2.ScalingActive:
+			res.ScalingActive = active
+		case autoscalingv2.ScalingLimited:
+			if active {
+				res.LimitedByMin = c.Reason == "TooFewReplicas"
+				res.LimitedByMax = c.Reason == "TooManyReplicas"
+			}
+		}
+	}
+
+	current := map[string]autoscalingv2.MetricStatus{}
+	for _, m := range h.Status.CurrentMetrics {
+		current[statusKey(m)] = m
+	}
+
+	for _, spec := range h.Spec.Metrics {
+		ms := MetricStatus{Type: spec.Type}
+		switch spec.Type {
+		case autoscalingv2.ResourceMetricSourceType:
+			ms.Name = string(spec.Resource.Name)
+			ms.Target = targetValue(spec.Resource.Target)
+		case autoscalingv2.ContainerResourceMetricSourceType:
+			ms.Name = string(spec.ContainerResource.Name)
+			ms.Container = spec.ContainerResource.Container
+			ms.Target = targetValue(spec.ContainerResource.Target)
+		case autoscalingv2.PodsMetricSourceType:
+			ms.Name = spec.Pods.Metric.Name
+			ms.Target = targetValue(spec.Pods.Target)
+		case autoscalingv2.ObjectMetricSourceType:
+			ms.Name = spec.Object.Metric.Name
+			ms.Object = fmt.Sprintf("%s/%s", spec.Object.DescribedObject.Kind, spec.Object.DescribedObject.Name)
+			ms.Target = targetValue(spec.Object.Target)
+		case autoscalingv2.ExternalMetricSourceType:
+			ms.Name = spec.External.Metric.Name
+			ms.Target = targetValue(spec.External.Target)
+		}
+
+		if cur, ok := current[specKey(spec)]; ok {
+			ms.Current = currentValue(cur)
+		}
+
+		res.Metrics = append(res.Metrics, ms)
+	}
+
+	return res
+}
+
+func targetValue(t autoscalingv2.MetricTarget) MetricValue {
+	return MetricValue{
+		Value:              t.Value,
+		AverageValue:       t.AverageValue,
+		AverageUtilization: t.AverageUtilization,
+	}
+}
+
+func currentValue(m autoscalingv2.MetricStatus) MetricValue {
+	var v autoscalingv2.MetricValueStatus
+	switch m.Type {
+	case autoscalingv2.ResourceMetricSourceType:
+		v = m.Resource.Current
+	case autoscalingv2.ContainerResourceMetricSourceType:
+		v = m.ContainerResource.Current
+	case autoscalingv2.PodsMetricSourceType:
+		v = m.Pods.Current
+	case autoscalingv2.ObjectMetricSourceType:
+		v = m.Object.Current
+	case autoscalingv2.ExternalMetricSourceType:
+		v = m.External.Current
+	}
+	return MetricValue{
+		Value:              v.Value,
+		AverageValue:       v.AverageValue,
+		AverageUtilization: v.AverageUtilization,
+	}
+}
+
+func specKey(m autoscalingv2.MetricSpec) string {
+	switch m.Type {
+	case autoscalingv2.ResourceMetricSourceType:
+		return fmt.Sprintf("%s/%s", m.Type, m.Resource.Name)
+	case autoscalingv2.ContainerResourceMetricSourceType:
+		return fmt.Sprintf("%s/%s/%s", m.Type, m.ContainerResource.Container, m.ContainerResource.Name)
+	case autoscalingv2.PodsMetricSourceType:
+		return fmt.Sprintf("%s/%s", m.Type, m.Pods.Metric.Name)
+	case autoscalingv2.ObjectMetricSourceType:
+		return fmt.Sprintf("%s/%s/%s/%s", m.Type, m.Object.DescribedObject.Kind, m.Object.DescribedObject.Name, m.Object.Metric.Name)
+	case autoscalingv2.ExternalMetricSourceType:
+		return fmt.Sprintf("%s/%s", m.Type, m.External.Metric.Name)
+	}
+	return string(m.Type)
+}
+
+func statusKey(m autoscalingv2.MetricStatus) string {
+	switch m.Type {
+	case autoscalingv2.ResourceMetricSourceType:
+		return fmt.Sprintf("%s/%s", m.Type, m.Resource.Name)
+	case autoscalingv2.ContainerResourceMetricSourceType:
+		return fmt.Sprintf("%s/%s/%s", m.Type, m.ContainerResource.Container, m.ContainerResource.Name)
+	case autoscalingv2.PodsMetricSourceType:
+		return fmt.Sprintf("%s/%s", m.Type, m.Pods.Metric.Name)
+	case autoscalingv2.ObjectMetricSourceType:
+		return fmt.Sprintf("%s/%s/%s/%s", m.Type, m.Object.DescribedObject.Kind, m.Object.DescribedObject.Name, m.Object.Metric.Name)
+	case autoscalingv2.ExternalMetricSourceType:
+		return fmt.Sprintf("%s/%s", m.Type, m.External.Metric.Name)
+	}
+	return string(m.Type)
+}
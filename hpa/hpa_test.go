@@ -0,0 +1,98 @@
+package hpa
+
+import (
+	"testing"
+
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func int32Ptr(v int32) *int32 { return &v }
+
+func TestStatusOf(t *testing.T) {
+	rps := resource.MustParse("100")
+	h := &autoscalingv2.HorizontalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+		Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{Kind: "Deployment", Name: "web"},
+			MaxReplicas:    10,
+			Metrics: []autoscalingv2.MetricSpec{
+				{
+					Type: autoscalingv2.ResourceMetricSourceType,
+					Resource: &autoscalingv2.ResourceMetricSource{
+						Name:   corev1.ResourceCPU,
+						Target: autoscalingv2.MetricTarget{Type: autoscalingv2.UtilizationMetricType, AverageUtilization: int32Ptr(60)},
+					},
+				},
+				{
+					Type: autoscalingv2.ObjectMetricSourceType,
+					Object: &autoscalingv2.ObjectMetricSource{
+						DescribedObject: autoscalingv2.CrossVersionObjectReference{Kind: "Ingress", Name: "main"},
+						Metric:          autoscalingv2.MetricIdentifier{Name: "requests-per-second"},
+						Target:          autoscalingv2.MetricTarget{Type: autoscalingv2.ValueMetricType, Value: &rps},
+					},
+				},
+			},
+		},
+		Status: autoscalingv2.HorizontalPodAutoscalerStatus{
+			CurrentReplicas: 10,
+			DesiredReplicas: 10,
+			CurrentMetrics: []autoscalingv2.MetricStatus{
+				{
+					Type: autoscalingv2.ResourceMetricSourceType,
+					Resource: &autoscalingv2.ResourceMetricStatus{
+						Name:    corev1.ResourceCPU,
+						Current: autoscalingv2.MetricValueStatus{AverageUtilization: int32Ptr(95)},
+					},
+				},
+			},
+			Conditions: []autoscalingv2.HorizontalPodAutoscalerCondition{
+				{Type: autoscalingv2.AbleToScale, Status: corev1.ConditionTrue, Reason: "ScaleDownStabilized"},
+				{Type: autoscalingv2.ScalingActive, Status: corev1.ConditionTrue},
+				{Type: autoscalingv2.ScalingLimited, Status: corev1.ConditionTrue, Reason: "TooManyReplicas"},
+			},
+		},
+	}
+
+	s := StatusOf(h)
+	if s.Target != "Deployment/web" || s.MinReplicas != 1 || s.MaxReplicas != 10 {
+		t.Fatalf("unexpected status %+v", s)
+	}
+	if !s.AbleToScale || !s.ScalingActive || !s.Stabilized || !s.LimitedByMax || s.LimitedByMin {
+		t.Fatalf("unexpected conditions %+v", s)
+	}
+
+	if len(s.Metrics) != 2 {
+		t.Fatalf("expected a status for each metric spec, got %+v", s.Metrics)
+	}
+	cpu := s.Metrics[0]
+	if cpu.Name != "cpu" || *cpu.Target.AverageUtilization != 60 || *cpu.Current.AverageUtilization != 95 {
+		t.Fatalf("unexpected cpu metric %+v", cpu)
+	}
+	obj := s.Metrics[1]
+	if obj.Object != "Ingress/main" || obj.Target.Value.String() != "100" {
+		t.Fatalf("unexpected object metric %+v", obj)
+	}
+	if obj.Current.Value != nil || obj.Current.AverageValue != nil {
+		t.Fatalf("expected no current value for a metric not yet reported, got %+v", obj.Current)
+	}
+}
+
+func TestStatusOfLimitedByMin(t *testing.T) {
+	h := &autoscalingv2.HorizontalPodAutoscaler{
+		Spec: autoscalingv2.HorizontalPodAutoscalerSpec{MinReplicas: int32Ptr(3), MaxReplicas: 5},
+		Status: autoscalingv2.HorizontalPodAutoscalerStatus{
+			Conditions: []autoscalingv2.HorizontalPodAutoscalerCondition{
+				{Type: autoscalingv2.AbleToScale, Status: corev1.ConditionFalse, Reason: "FailedGetScale"},
+				{Type: autoscalingv2.ScalingLimited, Status: corev1.ConditionTrue, Reason: "TooFewReplicas"},
+			},
+		},
+	}
+
+	s := StatusOf(h)
+	if s.MinReplicas != 3 || s.AbleToScale || s.Stabilized || !s.LimitedByMin || s.LimitedByMax {
+		t.Fatalf("unexpected status %+v", s)
+	}
+}
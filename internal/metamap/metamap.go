@@ -0,0 +1,231 @@
+// Package metamap updates a string map of the object metadata,
+// the labels or the annotations, of the selected resources.
+package metamap
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	kubeutil "github.com/lucasepe/kube/util"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/cli-runtime/pkg/resource"
+)
+
+// Field describes the metadata map being updated.
+type Field struct {
+	// Name is the metadata field, e.g. "labels".
+	Name string
+	// Noun names an entry of the map in the messages, e.g. "label".
+	Noun string
+	// Verb names the update in the messages, e.g. "label".
+	Verb string
+	// ValidateKey returns the problems of a key.
+	ValidateKey func(key string) []string
+	// ValidateValue returns the problems of a value; nil
+	// means that the values are free form.
+	ValidateValue func(value string) []string
+}
+
+// Opts is a set of options that allows you to update the metadata map of resources.
+type Opts struct {
+	Resources     []string
+	LabelSelector string
+	FieldSelector string
+	AllNamespaces bool
+	Namespace     string
+
+	// Values are the entries to add or update.
+	Values map[string]string
+	// Remove are the keys of the entries to remove.
+	Remove []string
+	// Overwrite allows changing the value of existing entries.
+	Overwrite bool
+	// ResourceVersion, if set, makes the update fail if the object
+	// has been modified since (only valid for a single object).
+	ResourceVersion string
+
+	FieldManager string
+	DryRun       bool
+}
+
+// Result is the outcome of the update for a single object.
+type Result struct {
+	Kind      string
+	Namespace string
+	Name      string
+	// Changed is false if the object already had the desired entries.
+	Changed bool
+	// Object is the updated object (or the current one if not changed).
+	Object *unstructured.Unstructured
+}
+
+// ParseArgs parses the entries in the kubectl syntax: "key=value"
+// to set an entry and "key-" to remove it.
+func ParseArgs(field Field, args []string) (map[string]string, []string, error) {
+	values := map[string]string{}
+	remove := []string{}
+	for _, arg := range args {
+		switch {
+		case strings.Contains(arg, "="):
+			parts := strings.SplitN(arg, "=", 2)
+			values[parts[0]] = parts[1]
+		case strings.HasSuffix(arg, "-"):
+			remove = append(remove, strings.TrimSuffix(arg, "-"))
+		default:
+			return nil, nil, fmt.Errorf("invalid %s spec %q (use key=value or key-)", field.Noun, arg)
+		}
+	}
+	return values, remove, nil
+}
+
+// Do adds, updates and removes the entries of the field of the selected
+// resources. Objects already having the desired entries are not updated.
+// Failures, including the resources that could not be found, do not stop
+// the other updates; all the errors are returned at the end.
+func Do(ctx context.Context, f kubeutil.Factory, field Field, o Opts) ([]Result, error) {
+	if len(o.Values) == 0 && len(o.Remove) == 0 {
+		return nil, fmt.Errorf("at least one %s update is required", field.Noun)
+	}
+	if err := Validate(field, o); err != nil {
+		return nil, err
+	}
+
+	if !o.AllNamespaces && len(o.Namespace) == 0 {
+		ns, _, err := f.ToRawKubeConfigLoader().Namespace()
+		if err != nil {
+			return nil, err
+		}
+		o.Namespace = ns
+	}
+
+	r := f.NewBuilder().
+		Unstructured().
+		ContinueOnError().
+		NamespaceParam(o.Namespace).DefaultNamespace().AllNamespaces(o.AllNamespaces).
+		LabelSelectorParam(o.LabelSelector).
+		FieldSelectorParam(o.FieldSelector).
+		ResourceTypeOrNameArgs(true, o.Resources...).
+		Flatten().
+		Latest().
+		Do()
+	if err := r.Err(); err != nil {
+		return nil, err
+	}
+
+	errs := []error{}
+	infos, err := r.Infos()
+	if err != nil {
+		// the objects found are updated anyway
+		errs = append(errs, err)
+	}
+	if len(o.ResourceVersion) > 0 && len(infos) > 1 {
+		return nil, fmt.Errorf("ResourceVersion may only be used with a single resource")
+	}
+
+	results := []Result{}
+	for _, info := range infos {
+		if err := ctx.Err(); err != nil {
+			errs = append(errs, err)
+			break
+		}
+		res, err := Update(info, field, o)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		results = append(results, res)
+	}
+
+	return results, utilerrors.NewAggregate(errs)
+}
+
+// Update patches the field of the object, if something changes
+// or a ResourceVersion precondition is set.
+func Update(info *resource.Info, field Field, o Opts) (Result, error) {
+	obj, ok := info.Object.(*unstructured.Unstructured)
+	if !ok {
+		return Result{}, fmt.Errorf("unexpected object type %T for %s", info.Object, info.ObjectName())
+	}
+	res := Result{
+		Kind:      info.Mapping.GroupVersionKind.Kind,
+		Namespace: info.Namespace,
+		Name:      info.Name,
+		Object:    obj,
+	}
+
+	current, _, _ := unstructured.NestedStringMap(obj.Object, "metadata", field.Name)
+	changes := map[string]interface{}{}
+	for key, value := range o.Values {
+		old, exists := current[key]
+		if exists && old == value {
+			continue
+		}
+		if exists && !o.Overwrite {
+			return res, fmt.Errorf("%s already has a value (%s) for %s %q, and Overwrite is false",
+				info.ObjectName(), old, field.Noun, key)
+		}
+		changes[key] = value
+	}
+	for _, key := range o.Remove {
+		if _, exists := current[key]; exists {
+			changes[key] = nil
+		}
+	}
+	if len(changes) == 0 && len(o.ResourceVersion) == 0 {
+		return res, nil
+	}
+
+	metadata := map[string]interface{}{field.Name: changes}
+	if len(o.ResourceVersion) > 0 {
+		metadata["resourceVersion"] = o.ResourceVersion
+	}
+	data, err := json.Marshal(map[string]interface{}{"metadata": metadata})
+	if err != nil {
+		return res, err
+	}
+
+	patched, err := resource.NewHelper(info.Client, info.Mapping).
+		WithFieldManager(o.FieldManager).
+		DryRun(o.DryRun).
+		Patch(info.Namespace, info.Name, types.MergePatchType, data, &metav1.PatchOptions{})
+	if err != nil {
+		return res, fmt.Errorf("unable to %s %s: %w", field.Verb, info.ObjectName(), err)
+	}
+	if u, ok := patched.(*unstructured.Unstructured); ok {
+		res.Object = u
+	}
+	res.Changed = len(changes) > 0
+
+	return res, nil
+}
+
+// Validate checks the keys, both the updated and the removed
+// ones, and the values of the update.
+func Validate(field Field, o Opts) error {
+	errs := []error{}
+	for key, value := range o.Values {
+		for _, msg := range field.ValidateKey(key) {
+			errs = append(errs, fmt.Errorf("invalid %s key %q: %s", field.Noun, key, msg))
+		}
+		if field.ValidateValue == nil {
+			continue
+		}
+		for _, msg := range field.ValidateValue(value) {
+			errs = append(errs, fmt.Errorf("invalid %s value %q: %s", field.Noun, value, msg))
+		}
+	}
+	for _, key := range o.Remove {
+		for _, msg := range field.ValidateKey(key) {
+			errs = append(errs, fmt.Errorf("invalid %s key %q: %s", field.Noun, key, msg))
+		}
+		if _, ok := o.Values[key]; ok {
+			errs = append(errs, fmt.Errorf("cannot modify and remove %s %q at the same time", field.Noun, key))
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
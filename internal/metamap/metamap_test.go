@@ -0,0 +1,209 @@
+package metamap
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/client-go/rest/fake"
+)
+
+var (
+	labels = Field{
+		Name:          "labels",
+		Noun:          "label",
+		Verb:          "label",
+		ValidateKey:   validation.IsQualifiedName,
+		ValidateValue: validation.IsValidLabelValue,
+	}
+	annotations = Field{
+		Name:        "annotations",
+		Noun:        "annotation",
+		Verb:        "annotate",
+		ValidateKey: validation.IsQualifiedName,
+	}
+)
+
+// podInfo returns the info of a pod with the given entries in the
+// metadata field whose patches are recorded in patches and echoed
+// back as the result.
+func podInfo(t *testing.T, field string, entries map[string]string, patches *[]map[string]interface{}) *resource.Info {
+	t.Helper()
+
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion("v1")
+	obj.SetKind("Pod")
+	obj.SetNamespace("default")
+	obj.SetName("web-0")
+	if err := unstructured.SetNestedStringMap(obj.Object, entries, "metadata", field); err != nil {
+		t.Fatal(err)
+	}
+
+	client := &fake.RESTClient{
+		GroupVersion:         corev1.SchemeGroupVersion,
+		NegotiatedSerializer: resource.UnstructuredPlusDefaultContentConfig().NegotiatedSerializer,
+		Client: fake.CreateHTTPClient(func(req *http.Request) (*http.Response, error) {
+			if req.Method != http.MethodPatch || req.Header.Get("Content-Type") != string(types.MergePatchType) {
+				t.Fatalf("unexpected request: %s %s", req.Method, req.URL)
+			}
+			dat, err := io.ReadAll(req.Body)
+			if err != nil {
+				return nil, err
+			}
+			patch := map[string]interface{}{}
+			if err := json.Unmarshal(dat, &patch); err != nil {
+				return nil, err
+			}
+			*patches = append(*patches, patch)
+
+			body, err := obj.MarshalJSON()
+			if err != nil {
+				return nil, err
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{"Content-Type": []string{runtime.ContentTypeJSON}},
+				Body:       io.NopCloser(bytes.NewReader(body)),
+			}, nil
+		}),
+	}
+
+	return &resource.Info{
+		Client:    client,
+		Namespace: "default",
+		Name:      "web-0",
+		Object:    obj.DeepCopy(),
+		Mapping: &meta.RESTMapping{
+			Resource:         corev1.SchemeGroupVersion.WithResource("pods"),
+			GroupVersionKind: corev1.SchemeGroupVersion.WithKind("Pod"),
+			Scope:            meta.RESTScopeNamespace,
+		},
+	}
+}
+
+func TestParseArgs(t *testing.T) {
+	values, remove, err := ParseArgs(labels, []string{"app=web", "tier=", "old-"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(values, map[string]string{"app": "web", "tier": ""}) {
+		t.Fatalf("unexpected values %v", values)
+	}
+	if !reflect.DeepEqual(remove, []string{"old"}) {
+		t.Fatalf("unexpected removals %v", remove)
+	}
+
+	if _, _, err := ParseArgs(labels, []string{"app"}); err == nil {
+		t.Fatal("expected an error for a spec without value")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := Validate(labels, Opts{Values: map[string]string{"app": "web"}, Remove: []string{"tier"}}); err != nil {
+		t.Fatal(err)
+	}
+	// without a value validator the values are free form
+	if err := Validate(annotations, Opts{Values: map[string]string{"owner": "team a, on call"}}); err != nil {
+		t.Fatal(err)
+	}
+	for _, o := range []Opts{
+		{Values: map[string]string{"bad key": "web"}},
+		{Values: map[string]string{"app": "not a value"}},
+		{Values: map[string]string{"app": "web"}, Remove: []string{"app"}},
+		{Remove: []string{"bad key"}},
+	} {
+		if err := Validate(labels, o); err == nil {
+			t.Errorf("expected an error for %+v", o)
+		}
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	for _, field := range []Field{labels, annotations} {
+		var patches []map[string]interface{}
+		info := podInfo(t, field.Name, map[string]string{"app": "web", "old": "x"}, &patches)
+
+		res, err := Update(info, field, Opts{
+			Values: map[string]string{"app": "web", "tier": "frontend"},
+			Remove: []string{"old", "missing"},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !res.Changed || res.Kind != "Pod" || res.Name != "web-0" {
+			t.Fatalf("unexpected result %+v", res)
+		}
+
+		want := map[string]interface{}{
+			"metadata": map[string]interface{}{
+				field.Name: map[string]interface{}{"tier": "frontend", "old": nil},
+			},
+		}
+		if len(patches) != 1 || !reflect.DeepEqual(patches[0], want) {
+			t.Fatalf("expected %v, got %v", want, patches)
+		}
+	}
+}
+
+func TestUpdateUnchanged(t *testing.T) {
+	var patches []map[string]interface{}
+	info := podInfo(t, "labels", map[string]string{"app": "web"}, &patches)
+
+	res, err := Update(info, labels, Opts{Values: map[string]string{"app": "web"}, Remove: []string{"missing"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Changed || len(patches) != 0 {
+		t.Fatalf("expected no patches, got %v", patches)
+	}
+}
+
+func TestUpdateOverwrite(t *testing.T) {
+	var patches []map[string]interface{}
+	info := podInfo(t, "labels", map[string]string{"app": "web"}, &patches)
+
+	if _, err := Update(info, labels, Opts{Values: map[string]string{"app": "api"}}); err == nil {
+		t.Fatal("expected an error without Overwrite")
+	}
+	if len(patches) != 0 {
+		t.Fatalf("expected no patches, got %v", patches)
+	}
+
+	res, err := Update(info, labels, Opts{Values: map[string]string{"app": "api"}, Overwrite: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Changed || len(patches) != 1 {
+		t.Fatalf("expected the value to be overwritten, got %v", patches)
+	}
+}
+
+func TestUpdateResourceVersion(t *testing.T) {
+	var patches []map[string]interface{}
+	info := podInfo(t, "labels", map[string]string{"app": "web"}, &patches)
+
+	res, err := Update(info, labels, Opts{Values: map[string]string{"app": "web"}, ResourceVersion: "42"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Changed {
+		t.Fatal("expected no changes")
+	}
+	if len(patches) != 1 {
+		t.Fatalf("expected the resource version to be checked, got %v", patches)
+	}
+	md := patches[0]["metadata"].(map[string]interface{})
+	if md["resourceVersion"] != "42" {
+		t.Fatalf("expected the resource version precondition, got %v", md)
+	}
+}
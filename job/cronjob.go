@@ -0,0 +1,126 @@
+package job
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	kubeutil "github.com/lucasepe/kube/util"
+	"github.com/robfig/cron/v3"
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// maxMissedRuns mirrors the CronJob controller limit over which
+	// it stops counting the missed start times.
+	maxMissedRuns = 100
+)
+
+// CronJobSchedule describes the schedule of a CronJob.
+type CronJobSchedule struct {
+	Name      string
+	Namespace string
+	Schedule  string
+	// Location is the time zone used to evaluate the schedule.
+	Location  *time.Location
+	Suspended bool
+
+	// NextRuns are the upcoming scheduled times (empty if suspended).
+	NextRuns []time.Time
+
+	LastScheduleTime   *metav1.Time
+	LastSuccessfulTime *metav1.Time
+	ActiveJobs         int
+
+	// MissedRuns counts the schedules that passed without a Job being
+	// started (capped at 100 like the CronJob controller does).
+	MissedRuns int
+	// MissedDeadline is true when the most recent missed schedule can
+	// no longer be started because startingDeadlineSeconds has elapsed.
+	MissedDeadline bool
+}
+
+// InspectCronJob fetches the CronJob and reports its schedule with the next n run times.
+func InspectCronJob(ctx context.Context, f kubeutil.Factory, namespace, name string, n int) (*CronJobSchedule, error) {
+	cli, err := f.KubernetesClientSet()
+	if err != nil {
+		return nil, err
+	}
+
+	cj, err := cli.BatchV1().CronJobs(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	return InspectCronJobObject(cj, time.Now(), n)
+}
+
+// InspectCronJobObject reports the schedule of the CronJob as seen at time now.
+//
+// The schedule is evaluated in spec.timeZone when set, in the CRON_TZ/TZ
+// prefix of the schedule when present, and in UTC otherwise.
+func InspectCronJobObject(cj *batchv1.CronJob, now time.Time, n int) (*CronJobSchedule, error) {
+	loc := time.UTC
+	if cj.Spec.TimeZone != nil && len(*cj.Spec.TimeZone) > 0 {
+		var err error
+		loc, err = time.LoadLocation(*cj.Spec.TimeZone)
+		if err != nil {
+			return nil, fmt.Errorf("invalid time zone %q: %w", *cj.Spec.TimeZone, err)
+		}
+	}
+
+	spec := cj.Spec.Schedule
+	if !strings.HasPrefix(spec, "CRON_TZ=") && !strings.HasPrefix(spec, "TZ=") {
+		spec = fmt.Sprintf("CRON_TZ=%s %s", loc.String(), spec)
+	}
+	sched, err := cron.ParseStandard(spec)
+	if err != nil {
+		return nil, fmt.Errorf("unparseable schedule %q: %w", cj.Spec.Schedule, err)
+	}
+
+	res := &CronJobSchedule{
+		Name:               cj.Name,
+		Namespace:          cj.Namespace,
+		Schedule:           cj.Spec.Schedule,
+		Location:           loc,
+		Suspended:          cj.Spec.Suspend != nil && *cj.Spec.Suspend,
+		LastScheduleTime:   cj.Status.LastScheduleTime,
+		LastSuccessfulTime: cj.Status.LastSuccessfulTime,
+		ActiveJobs:         len(cj.Status.Active),
+	}
+
+	if !res.Suspended {
+		t := now
+		for i := 0; i < n; i++ {
+			t = sched.Next(t)
+			if t.IsZero() {
+				break
+			}
+			res.NextRuns = append(res.NextRuns, t)
+		}
+	}
+
+	// the earliest time a missed run could have been scheduled
+	earliest := cj.CreationTimestamp.Time
+	if cj.Status.LastScheduleTime != nil {
+		earliest = cj.Status.LastScheduleTime.Time
+	}
+	var latestMissed time.Time
+	if !res.Suspended && !earliest.IsZero() {
+		for t := sched.Next(earliest); !t.IsZero() && !t.After(now); t = sched.Next(t) {
+			latestMissed = t
+			res.MissedRuns++
+			if res.MissedRuns > maxMissedRuns {
+				break
+			}
+		}
+	}
+
+	if d := cj.Spec.StartingDeadlineSeconds; d != nil && !latestMissed.IsZero() {
+		res.MissedDeadline = now.After(latestMissed.Add(time.Duration(*d) * time.Second))
+	}
+
+	return res, nil
+}
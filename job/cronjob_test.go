@@ -0,0 +1,69 @@
+package job
+
+import (
+	"testing"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestInspectCronJobObject(t *testing.T) {
+	now := time.Date(2022, 11, 10, 10, 30, 0, 0, time.UTC)
+	tz := "Europe/Rome"
+	deadline := int64(60)
+
+	cj := &batchv1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "nightly",
+			CreationTimestamp: metav1.NewTime(now.Add(-48 * time.Hour)),
+		},
+		Spec: batchv1.CronJobSpec{
+			Schedule:                "0 2 * * *",
+			TimeZone:                &tz,
+			StartingDeadlineSeconds: &deadline,
+		},
+		Status: batchv1.CronJobStatus{
+			LastScheduleTime: &metav1.Time{Time: now.Add(-33 * time.Hour)},
+		},
+	}
+
+	res, err := InspectCronJobObject(cj, now, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(res.NextRuns) != 2 {
+		t.Fatalf("expected 2 next runs, got %d", len(res.NextRuns))
+	}
+	// 02:00 in Rome is 01:00 UTC in November
+	want := time.Date(2022, 11, 11, 1, 0, 0, 0, time.UTC)
+	if !res.NextRuns[0].Equal(want) {
+		t.Fatalf("expected next run at %s, got %s", want, res.NextRuns[0])
+	}
+
+	if res.MissedRuns != 1 {
+		t.Fatalf("expected 1 missed run, got %d", res.MissedRuns)
+	}
+	if !res.MissedDeadline {
+		t.Fatal("expected the starting deadline to be missed")
+	}
+}
+
+func TestInspectCronJobObjectSuspended(t *testing.T) {
+	suspend := true
+	cj := &batchv1.CronJob{
+		Spec: batchv1.CronJobSpec{
+			Schedule: "*/5 * * * *",
+			Suspend:  &suspend,
+		},
+	}
+
+	res, err := InspectCronJobObject(cj, time.Now(), 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Suspended || len(res.NextRuns) != 0 || res.MissedRuns != 0 {
+		t.Fatalf("unexpected result for suspended cronjob: %+v", res)
+	}
+}
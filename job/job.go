@@ -0,0 +1,298 @@
+package job
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/lucasepe/kube/logs"
+	kubeutil "github.com/lucasepe/kube/util"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	watchtools "k8s.io/client-go/tools/watch"
+)
+
+// Opts is a set of options that controls how a Job is awaited.
+type Opts struct {
+	// StreamLogs follows the logs of the Job's pods as soon as they start.
+	StreamLogs bool
+	// RecordHandler receives the log records when StreamLogs is true;
+	// if nil the logs package default handler is used.
+	RecordHandler func(logs.Record) error
+}
+
+// PodResult describes how a pod of the Job terminated.
+type PodResult struct {
+	Name     string
+	Phase    corev1.PodPhase
+	ExitCode int32
+	Reason   string
+	Message  string
+}
+
+// Result is the final state of a Job.
+type Result struct {
+	Name      string
+	Namespace string
+	Succeeded bool
+	// Reason and Message come from the Failed condition (e.g. BackoffLimitExceeded).
+	Reason  string
+	Message string
+
+	SucceededPods int32
+	FailedPods    int32
+	Pods          []PodResult
+
+	StartTime      *metav1.Time
+	CompletionTime *metav1.Time
+}
+
+// Run creates the Job and waits for its completion.
+func Run(ctx context.Context, f kubeutil.Factory, job *batchv1.Job, o Opts) (*Result, error) {
+	cli, err := f.KubernetesClientSet()
+	if err != nil {
+		return nil, err
+	}
+
+	created, err := cli.BatchV1().Jobs(job.Namespace).Create(ctx, job, metav1.CreateOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	return Wait(ctx, f, created.Namespace+"/"+created.Name, o)
+}
+
+// Wait watches the Job identified by jobKey ("namespace/name") until it is
+// Complete or Failed, optionally streaming the logs of its pods. If the Job
+// finishes but some log stream fails, the Result is returned along with
+// the error.
+func Wait(ctx context.Context, f kubeutil.Factory, jobKey string, o Opts) (*Result, error) {
+	namespace, name, err := cache.SplitMetaNamespaceKey(jobKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(namespace) == 0 {
+		namespace, _, err = f.ToRawKubeConfigLoader().Namespace()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	cli, err := f.KubernetesClientSet()
+	if err != nil {
+		return nil, err
+	}
+
+	stream := func(ctx context.Context, pod *corev1.Pod) error {
+		return logs.DoContext(ctx, f, logs.Opts{
+			Namespace: pod.Namespace,
+			Object:    pod,
+			Follow:    true,
+			// every container of the pod is followed at once
+			MaxFollowConcurrency: len(pod.Spec.InitContainers) + len(pod.Spec.Containers) + len(pod.Spec.EphemeralContainers),
+			RecordHandler:        o.RecordHandler,
+		})
+	}
+	return waitWithClient(ctx, cli, namespace, name, o, stream)
+}
+
+// this is split for easy test-ability
+func waitWithClient(ctx context.Context, cli kubernetes.Interface, namespace, name string, o Opts, stream func(context.Context, *corev1.Pod) error) (*Result, error) {
+	job, err := cli.BatchV1().Jobs(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	// the pods are watched until the job finishes, while
+	// their log streams end by themselves once terminated
+	var wg sync.WaitGroup
+	var logsErr error
+	watchCtx, stopWatch := context.WithCancel(ctx)
+	defer stopWatch()
+	logsCtx, stopLogs := context.WithCancel(ctx)
+	defer stopLogs()
+
+	if o.StreamLogs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			logsErr = streamPodLogs(watchCtx, logsCtx, cli, job, stream)
+		}()
+	}
+
+	lw := &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			options.FieldSelector = fields.OneTermEqualSelector("metadata.name", name).String()
+			return cli.BatchV1().Jobs(namespace).List(ctx, options)
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			options.FieldSelector = fields.OneTermEqualSelector("metadata.name", name).String()
+			return cli.BatchV1().Jobs(namespace).Watch(ctx, options)
+		},
+	}
+
+	ev, err := watchtools.UntilWithSync(ctx, lw, &batchv1.Job{}, nil, func(ev watch.Event) (bool, error) {
+		if ev.Type == watch.Deleted {
+			return false, fmt.Errorf("job %s/%s was deleted", namespace, name)
+		}
+		j, ok := ev.Object.(*batchv1.Job)
+		if !ok {
+			return false, nil
+		}
+		return isFinished(j), nil
+	})
+	stopWatch()
+	if err != nil {
+		stopLogs()
+		wg.Wait()
+		return nil, err
+	}
+	job = ev.Object.(*batchv1.Job)
+
+	res := &Result{
+		Name:           job.Name,
+		Namespace:      job.Namespace,
+		SucceededPods:  job.Status.Succeeded,
+		FailedPods:     job.Status.Failed,
+		StartTime:      job.Status.StartTime,
+		CompletionTime: job.Status.CompletionTime,
+	}
+	for _, c := range job.Status.Conditions {
+		if c.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch c.Type {
+		case batchv1.JobComplete:
+			res.Succeeded = true
+		case batchv1.JobFailed:
+			res.Reason, res.Message = c.Reason, c.Message
+		}
+	}
+	if !res.Succeeded && len(res.Reason) == 0 {
+		res.Reason = "BackoffLimitExceeded"
+		res.Message = fmt.Sprintf("job has reached the specified backoff limit (%d failed pods)", job.Status.Failed)
+	}
+
+	res.Pods, err = podResults(ctx, cli, job)
+	if err != nil {
+		stopLogs()
+		wg.Wait()
+		return res, err
+	}
+
+	// let the log streams drain: pods are terminated at this point
+	wg.Wait()
+
+	return res, logsErr
+}
+
+// isFinished returns true if the job has a Complete or Failed condition,
+// or if it has already exceeded its backoff limit.
+func isFinished(j *batchv1.Job) bool {
+	for _, c := range j.Status.Conditions {
+		if (c.Type == batchv1.JobComplete || c.Type == batchv1.JobFailed) && c.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+
+	if j.Spec.BackoffLimit != nil && j.Status.Active == 0 && j.Status.Failed > *j.Spec.BackoffLimit {
+		return true
+	}
+
+	return false
+}
+
+func podResults(ctx context.Context, cli kubernetes.Interface, job *batchv1.Job) ([]PodResult, error) {
+	selector, err := metav1.LabelSelectorAsSelector(job.Spec.Selector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid label selector: %v", err)
+	}
+
+	list, err := cli.CoreV1().Pods(job.Namespace).List(ctx, metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, err
+	}
+
+	res := []PodResult{}
+	for _, pod := range list.Items {
+		pr := PodResult{Name: pod.Name, Phase: pod.Status.Phase, Reason: pod.Status.Reason, Message: pod.Status.Message}
+		for _, cs := range pod.Status.ContainerStatuses {
+			if t := cs.State.Terminated; t != nil && t.ExitCode != 0 {
+				pr.ExitCode, pr.Reason, pr.Message = t.ExitCode, t.Reason, t.Message
+				break
+			}
+		}
+		res = append(res, pr)
+	}
+	return res, nil
+}
+
+// streamPodLogs follows the logs of every pod of the job once it starts
+// running, watching the pods until watchCtx is cancelled; then it waits
+// for the streams, running with logsCtx, to end and returns their errors.
+func streamPodLogs(watchCtx, logsCtx context.Context, cli kubernetes.Interface, job *batchv1.Job, stream func(context.Context, *corev1.Pod) error) error {
+	selector, err := metav1.LabelSelectorAsSelector(job.Spec.Selector)
+	if err != nil {
+		return fmt.Errorf("invalid label selector: %v", err)
+	}
+
+	lw := &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			options.LabelSelector = selector.String()
+			return cli.CoreV1().Pods(job.Namespace).List(watchCtx, options)
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			options.LabelSelector = selector.String()
+			return cli.CoreV1().Pods(job.Namespace).Watch(watchCtx, options)
+		},
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	errs := []error{}
+	streamed := map[types.UID]bool{}
+	start := func(pod *corev1.Pod) {
+		if streamed[pod.UID] || pod.Status.Phase == corev1.PodPending || pod.Status.Phase == corev1.PodUnknown {
+			return
+		}
+		streamed[pod.UID] = true
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := stream(logsCtx, pod); err != nil {
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("streaming logs of pod %s: %w", pod.Name, err))
+				mu.Unlock()
+			}
+		}()
+	}
+
+	watchtools.UntilWithSync(watchCtx, lw, &corev1.Pod{}, nil, func(ev watch.Event) (bool, error) {
+		if pod, ok := ev.Object.(*corev1.Pod); ok && ev.Type != watch.Deleted {
+			start(pod)
+		}
+		return false, nil
+	})
+
+	// the pods terminated before the watch has seen them running
+	if logsCtx.Err() == nil {
+		list, err := cli.CoreV1().Pods(job.Namespace).List(logsCtx, metav1.ListOptions{LabelSelector: selector.String()})
+		if err == nil {
+			for i := range list.Items {
+				start(&list.Items[i])
+			}
+		}
+	}
+
+	wg.Wait()
+
+	return utilerrors.NewAggregate(errs)
+}
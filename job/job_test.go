@@ -0,0 +1,132 @@
+package job
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestWaitStreamLogs(t *testing.T) {
+	labels := map[string]string{"job-name": "migrate"}
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{Name: "migrate", Namespace: "default"},
+		Spec: batchv1.JobSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+		},
+		Status: batchv1.JobStatus{
+			Succeeded: 1,
+			Conditions: []batchv1.JobCondition{
+				{Type: batchv1.JobComplete, Status: corev1.ConditionTrue},
+			},
+		},
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "migrate-x7k2p", Namespace: "default", UID: "1", Labels: labels},
+		Status:     corev1.PodStatus{Phase: corev1.PodSucceeded},
+	}
+	cli := fake.NewSimpleClientset(job, pod)
+
+	var mu sync.Mutex
+	streamed := []string{}
+	stream := func(_ context.Context, pod *corev1.Pod) error {
+		mu.Lock()
+		defer mu.Unlock()
+		streamed = append(streamed, pod.Name)
+		return nil
+	}
+
+	type outcome struct {
+		res *Result
+		err error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		res, err := waitWithClient(context.Background(), cli, "default", "migrate", Opts{StreamLogs: true}, stream)
+		done <- outcome{res, err}
+	}()
+
+	var out outcome
+	select {
+	case out = <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("Wait did not return once the job finished")
+	}
+	if out.err != nil {
+		t.Fatal(out.err)
+	}
+	if !out.res.Succeeded || len(out.res.Pods) != 1 {
+		t.Fatalf("unexpected result: %+v", out.res)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(streamed) != 1 || streamed[0] != "migrate-x7k2p" {
+		t.Fatalf("expected the logs of the pod to be streamed, got %v", streamed)
+	}
+}
+
+func TestWaitFailed(t *testing.T) {
+	backoff := int32(1)
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{Name: "migrate", Namespace: "default"},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: &backoff,
+			Selector:     &metav1.LabelSelector{MatchLabels: map[string]string{"job-name": "migrate"}},
+		},
+		Status: batchv1.JobStatus{Failed: 2},
+	}
+	cli := fake.NewSimpleClientset(job)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	res, err := waitWithClient(ctx, cli, "default", "migrate", Opts{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Succeeded || res.Reason != "BackoffLimitExceeded" {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+}
+
+func TestWaitStreamLogsError(t *testing.T) {
+	labels := map[string]string{"job-name": "migrate"}
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{Name: "migrate", Namespace: "default"},
+		Spec: batchv1.JobSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+		},
+		Status: batchv1.JobStatus{
+			Succeeded: 1,
+			Conditions: []batchv1.JobCondition{
+				{Type: batchv1.JobComplete, Status: corev1.ConditionTrue},
+			},
+		},
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "migrate-x7k2p", Namespace: "default", UID: "1", Labels: labels},
+		Status:     corev1.PodStatus{Phase: corev1.PodSucceeded},
+	}
+	cli := fake.NewSimpleClientset(job, pod)
+
+	stream := func(_ context.Context, pod *corev1.Pod) error {
+		return errors.New("too many containers")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	res, err := waitWithClient(ctx, cli, "default", "migrate", Opts{StreamLogs: true}, stream)
+	if err == nil || !strings.Contains(err.Error(), "migrate-x7k2p") {
+		t.Fatalf("expected the log stream error to be reported, got %v", err)
+	}
+	if res == nil || !res.Succeeded {
+		t.Fatalf("expected the result along with the error, got %+v", res)
+	}
+}
@@ -0,0 +1,94 @@
+package label
+
+import (
+	"context"
+
+	"github.com/lucasepe/kube/internal/metamap"
+	kubeutil "github.com/lucasepe/kube/util"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// Opts is a set of options that allows you to update the labels of resources.
+type Opts struct {
+	Resources     []string
+	LabelSelector string
+	FieldSelector string
+	AllNamespaces bool
+	Namespace     string
+
+	// Labels are the labels to add or update.
+	Labels map[string]string
+	// Remove are the keys of the labels to remove.
+	Remove []string
+	// Overwrite allows changing the value of existing labels.
+	Overwrite bool
+	// ResourceVersion, if set, makes the update fail if the object
+	// has been modified since (only valid for a single object).
+	ResourceVersion string
+
+	FieldManager string
+	DryRun       bool
+}
+
+// Result is the outcome of the update for a single object.
+type Result struct {
+	Kind      string
+	Namespace string
+	Name      string
+	// Changed is false if the object already had the desired labels.
+	Changed bool
+	// Object is the updated object (or the current one if not changed).
+	Object *unstructured.Unstructured
+}
+
+var field = metamap.Field{
+	Name:          "labels",
+	Noun:          "label",
+	Verb:          "label",
+	ValidateKey:   validation.IsQualifiedName,
+	ValidateValue: validation.IsValidLabelValue,
+}
+
+// ParseArgs parses labels in the kubectl label syntax: "key=value"
+// to set a label and "key-" to remove it.
+func ParseArgs(args []string) (map[string]string, []string, error) {
+	return metamap.ParseArgs(field, args)
+}
+
+// Do adds, updates and removes the labels of the selected resources.
+// Objects already having the desired labels are not updated. Failures
+// do not stop the other updates; all the errors are returned at the end.
+func Do(f kubeutil.Factory, o Opts) ([]Result, error) {
+	return DoContext(context.Background(), f, o)
+}
+
+// DoContext is like Do but stops when the context is cancelled.
+func DoContext(ctx context.Context, f kubeutil.Factory, o Opts) ([]Result, error) {
+	all, err := metamap.Do(ctx, f, field, o.toMetamap())
+	if all == nil {
+		return nil, err
+	}
+
+	res := make([]Result, 0, len(all))
+	for _, it := range all {
+		res = append(res, Result(it))
+	}
+	return res, err
+}
+
+func (o Opts) toMetamap() metamap.Opts {
+	return metamap.Opts{
+		Resources:       o.Resources,
+		LabelSelector:   o.LabelSelector,
+		FieldSelector:   o.FieldSelector,
+		AllNamespaces:   o.AllNamespaces,
+		Namespace:       o.Namespace,
+		Values:          o.Labels,
+		Remove:          o.Remove,
+		Overwrite:       o.Overwrite,
+		ResourceVersion: o.ResourceVersion,
+		FieldManager:    o.FieldManager,
+		DryRun:          o.DryRun,
+	}
+}
@@ -0,0 +1,41 @@
+package label
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/lucasepe/kube/internal/metamap"
+)
+
+func TestParseArgs(t *testing.T) {
+	labels, remove, err := ParseArgs([]string{"app=web", "tier=", "old-"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(labels, map[string]string{"app": "web", "tier": ""}) {
+		t.Fatalf("unexpected labels %v", labels)
+	}
+	if !reflect.DeepEqual(remove, []string{"old"}) {
+		t.Fatalf("unexpected removals %v", remove)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	validate := func(o Opts) error {
+		return metamap.Validate(field, o.toMetamap())
+	}
+
+	if err := validate(Opts{Labels: map[string]string{"app": "web"}, Remove: []string{"tier"}}); err != nil {
+		t.Fatal(err)
+	}
+	for _, o := range []Opts{
+		{Labels: map[string]string{"bad key": "web"}},
+		{Labels: map[string]string{"app": "not a value"}},
+		{Labels: map[string]string{"app": "web"}, Remove: []string{"app"}},
+		{Remove: []string{"bad key"}},
+	} {
+		if err := validate(o); err == nil {
+			t.Errorf("expected an error for %+v", o)
+		}
+	}
+}
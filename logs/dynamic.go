@@ -0,0 +1,253 @@
+package logs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/lucasepe/kube/scheme"
+	kubeutil "github.com/lucasepe/kube/util"
+	"golang.org/x/sync/errgroup"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/reference"
+)
+
+// dynamicFollower streams the logs of the pods matching the selector,
+// attaching to the containers as they start (new pods and restarts)
+// and detaching from the deleted pods.
+type dynamicFollower struct {
+	o         Opts
+	clientset corev1client.CoreV1Interface
+	opts      *corev1.PodLogOptions
+	budget    *byteBudget
+	started   time.Time
+
+	ctx context.Context
+	g   *errgroup.Group
+
+	mu      sync.Mutex
+	streams map[string]containerStream
+	active  int
+}
+
+type containerStream struct {
+	pod    types.UID
+	cancel context.CancelFunc
+}
+
+// followSelector follows the logs of the pods matching o.Selector until
+// the context is cancelled, watching the pods to attach to the new and
+// restarted containers. The containers started before the call get the
+// logs selected by Tail and Since, the others their whole logs.
+func (o Opts) followSelector(ctx context.Context, f kubeutil.Factory, budget *byteBudget) error {
+	opts, ok := o.Options.(*corev1.PodLogOptions)
+	if !ok {
+		return fmt.Errorf("provided options object is not a PodLogOptions")
+	}
+
+	clientConfig, err := f.ToRESTConfig()
+	if err != nil {
+		return err
+	}
+	clientset, err := corev1client.NewForConfig(clientConfig)
+	if err != nil {
+		return err
+	}
+
+	namespace := o.Namespace
+	if len(namespace) == 0 {
+		namespace, _, err = f.ToRawKubeConfigLoader().Namespace()
+		if err != nil {
+			return err
+		}
+	}
+
+	parent := ctx
+	g, ctx := errgroup.WithContext(ctx)
+
+	if o.MergeWindow > 0 {
+		m := newMerger(o.MergeWindow, o.RecordHandler)
+		o.RecordHandler = m.add
+		g.Go(func() error {
+			return m.run(ctx, nil)
+		})
+	}
+
+	df := &dynamicFollower{
+		o:         o,
+		clientset: clientset,
+		opts:      opts,
+		budget:    budget,
+		started:   time.Now(),
+		ctx:       ctx,
+		g:         g,
+		streams:   map[string]containerStream{},
+	}
+
+	lw := cache.NewFilteredListWatchFromClient(clientset.RESTClient(), "pods", namespace,
+		func(lo *metav1.ListOptions) {
+			lo.LabelSelector = o.Selector
+		})
+	informer := cache.NewSharedIndexInformer(lw, &corev1.Pod{}, 0, cache.Indexers{})
+	if err := informer.SetWatchErrorHandler(func(_ *cache.Reflector, err error) {
+		df.watchError(err)
+	}); err != nil {
+		return err
+	}
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if pod, ok := obj.(*corev1.Pod); ok {
+				df.attach(pod)
+			}
+		},
+		UpdateFunc: func(_, obj interface{}) {
+			if pod, ok := obj.(*corev1.Pod); ok {
+				df.attach(pod)
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			if pod, ok := obj.(*corev1.Pod); ok {
+				df.detach(pod)
+			}
+		},
+	})
+	g.Go(func() error {
+		informer.Run(ctx.Done())
+		return nil
+	})
+
+	<-ctx.Done()
+	err = g.Wait()
+	if parent.Err() != nil {
+		return nil
+	}
+	return err
+}
+
+// attach starts a stream for each started container
+// of the pod, unless already streamed.
+func (df *dynamicFollower) attach(pod *corev1.Pod) {
+	if pod.DeletionTimestamp != nil {
+		return
+	}
+
+	statuses := append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...)
+	statuses = append(statuses, pod.Status.ContainerStatuses...)
+	statuses = append(statuses, pod.Status.EphemeralContainerStatuses...)
+
+	df.mu.Lock()
+	defer df.mu.Unlock()
+
+	for _, cs := range statuses {
+		if !df.o.AllContainers && cs.Name != df.opts.Container {
+			continue
+		}
+		if len(cs.ContainerID) == 0 || (cs.State.Running == nil && cs.State.Terminated == nil) {
+			continue
+		}
+		if _, ok := df.streams[cs.ContainerID]; ok {
+			continue
+		}
+		if df.active >= df.o.MaxFollowConcurrency {
+			df.g.Go(func() error {
+				return fmt.Errorf(
+					"attempting to follow more than %d log streams, the maximum allowed concurrency",
+					df.o.MaxFollowConcurrency,
+				)
+			})
+			return
+		}
+
+		_, fieldPath := kubeutil.FindContainerByName(pod, cs.Name)
+		ref, err := reference.GetPartialReference(scheme.Scheme, pod, fieldPath)
+		if err != nil {
+			df.g.Go(func() error {
+				return fmt.Errorf("unable to construct reference to '%#v': %w", pod, err)
+			})
+			return
+		}
+
+		opts := df.opts.DeepCopy()
+		opts.Container = cs.Name
+		if startedAt(cs).After(df.started) {
+			// a new container, get all its logs
+			opts.TailLines, opts.SinceSeconds, opts.SinceTime = nil, nil, nil
+		}
+
+		var request rest.ResponseWrapper = df.clientset.Pods(pod.Namespace).GetLogs(pod.Name, opts)
+		if df.budget != nil {
+			request = &budgetResponseWrapper{ResponseWrapper: request, budget: df.budget}
+		}
+
+		ctx, cancel := context.WithCancel(df.ctx)
+		df.streams[cs.ContainerID] = containerStream{pod: pod.UID, cancel: cancel}
+		df.active++
+		df.g.Go(func() error {
+			defer func() {
+				cancel()
+				df.mu.Lock()
+				df.active--
+				df.mu.Unlock()
+			}()
+			err := df.o.consumeStream(ctx, *ref, request)
+			if ctx.Err() != nil && df.ctx.Err() == nil {
+				// detached from the deleted pod
+				return nil
+			}
+			return err
+		})
+	}
+}
+
+// watchError reports the failures of the pods list and watch: the
+// denied requests stop following, the others are retried by the
+// informer and only notified.
+func (df *dynamicFollower) watchError(err error) {
+	switch {
+	case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF), apierrors.IsResourceExpired(err), apierrors.IsGone(err):
+		// the watch is restarted
+	case apierrors.IsUnauthorized(err), apierrors.IsForbidden(err):
+		df.g.Go(func() error {
+			return fmt.Errorf("unable to watch the pods: %w", err)
+		})
+	default:
+		if df.o.Notify != nil {
+			df.o.Notify(fmt.Sprintf("Watching the pods failed, retrying: %v", err))
+		}
+	}
+}
+
+// detach stops the streams of the deleted pod.
+func (df *dynamicFollower) detach(pod *corev1.Pod) {
+	df.mu.Lock()
+	defer df.mu.Unlock()
+
+	// the entries are kept, the containers will not start again
+	for _, s := range df.streams {
+		if s.pod == pod.UID {
+			s.cancel()
+		}
+	}
+}
+
+func startedAt(cs corev1.ContainerStatus) time.Time {
+	switch {
+	case cs.State.Running != nil:
+		return cs.State.Running.StartedAt.Time
+	case cs.State.Terminated != nil:
+		return cs.State.Terminated.StartedAt.Time
+	}
+	return time.Time{}
+}
@@ -0,0 +1,35 @@
+package logs
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"golang.org/x/sync/errgroup"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestDynamicFollowerWatchError(t *testing.T) {
+	notified := []string{}
+	g, _ := errgroup.WithContext(context.Background())
+	df := &dynamicFollower{
+		o: Opts{Notify: func(msg string) { notified = append(notified, msg) }},
+		g: g,
+	}
+
+	df.watchError(io.EOF)
+	df.watchError(errors.New("connection refused"))
+	if len(notified) != 1 {
+		t.Fatalf("expected a notification for the transient error, got %v", notified)
+	}
+	if err := g.Wait(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	df.watchError(apierrors.NewForbidden(schema.GroupResource{Resource: "pods"}, "", errors.New("denied")))
+	if err := g.Wait(); !apierrors.IsForbidden(err) {
+		t.Fatalf("expected the forbidden error, got %v", err)
+	}
+}
@@ -0,0 +1,113 @@
+package logs
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// DefaultLevels is the severity of the common level names,
+// used by Opts.MinLevel when Opts.Levels is not set.
+var DefaultLevels = map[string]int{
+	"trace":    0,
+	"debug":    1,
+	"info":     2,
+	"notice":   2,
+	"warn":     3,
+	"warning":  3,
+	"error":    4,
+	"err":      4,
+	"critical": 5,
+	"crit":     5,
+	"fatal":    5,
+	"panic":    5,
+}
+
+// lineFilter tells which records are delivered to the RecordHandler.
+type lineFilter struct {
+	include []*regexp.Regexp
+	exclude []*regexp.Regexp
+
+	levels   map[string]int
+	minLevel int
+}
+
+func newLineFilter(include, exclude []string) (*lineFilter, error) {
+	lf := &lineFilter{}
+	var err error
+	if lf.include, err = compileAll(include); err != nil {
+		return nil, fmt.Errorf("invalid include pattern: %w", err)
+	}
+	if lf.exclude, err = compileAll(exclude); err != nil {
+		return nil, fmt.Errorf("invalid exclude pattern: %w", err)
+	}
+	return lf, nil
+}
+
+// withMinLevel drops the records with a level less severe than min,
+// according to levels; the names are matched ignoring the case.
+func (lf *lineFilter) withMinLevel(min string, levels map[string]int) error {
+	if len(min) == 0 {
+		return nil
+	}
+	if levels == nil {
+		levels = DefaultLevels
+	}
+
+	lf.levels = make(map[string]int, len(levels))
+	for name, sev := range levels {
+		lf.levels[strings.ToLower(name)] = sev
+	}
+	sev, ok := lf.levels[strings.ToLower(min)]
+	if !ok {
+		return fmt.Errorf("unknown log level %q", min)
+	}
+	lf.minLevel = sev
+	return nil
+}
+
+func compileAll(patterns []string) ([]*regexp.Regexp, error) {
+	res := make([]*regexp.Regexp, 0, len(patterns))
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, re)
+	}
+	return res, nil
+}
+
+// match returns true if the line of the record matches one of the
+// include patterns, if any, and none of the exclude ones and, with a
+// min level, if the level of the record is unknown or severe enough.
+func (lf *lineFilter) match(rec Record) bool {
+	if lf.levels != nil {
+		if sev, ok := lf.levels[strings.ToLower(rec.level)]; ok && sev < lf.minLevel {
+			return false
+		}
+	}
+
+	line := rec.raw
+	if line == nil {
+		line = []byte(rec.msg)
+	}
+
+	if len(lf.include) > 0 {
+		found := false
+		for _, re := range lf.include {
+			if found = re.Match(line); found {
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	for _, re := range lf.exclude {
+		if re.Match(line) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,57 @@
+package logs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLineFilter(t *testing.T) {
+	lf, err := newLineFilter([]string{"error", "warn"}, []string{"healthz"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]bool{
+		"error syncing web":     true,
+		"warn: slow request":    true,
+		"error probing healthz": false,
+		"info: started":         false,
+	}
+	for line, want := range tests {
+		if got := lf.match(NewRecord(time.Time{}, line)); got != want {
+			t.Errorf("%q: expected %t, got %t", line, want, got)
+		}
+	}
+
+	if _, err := newLineFilter([]string{"("}, nil); err == nil {
+		t.Fatal("expected an error")
+	}
+}
+
+func TestLineFilterMinLevel(t *testing.T) {
+	lf, err := newLineFilter(nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := lf.withMinLevel("WARN", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]bool{
+		"debug":   false,
+		"INFO":    false,
+		"Warning": true,
+		"ERROR":   true,
+		"":        true,
+		"verbose": true,
+	}
+	for level, want := range tests {
+		if got := lf.match(NewRecord(time.Time{}, "msg").WithLevel(level)); got != want {
+			t.Errorf("%q: expected %t, got %t", level, want, got)
+		}
+	}
+
+	if err := lf.withMinLevel("loud", nil); err == nil {
+		t.Fatal("expected an error")
+	}
+}
@@ -0,0 +1,170 @@
+package logs
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"sync/atomic"
+
+	corev1 "k8s.io/api/core/v1"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/rest"
+)
+
+const (
+	probeTailLines  = int64(20)
+	probeLimitBytes = int64(16 * 1024)
+)
+
+// ErrByteBudgetExceeded is returned by Do when the logs have been
+// truncated because Opts.MaxBytes has been reached.
+var ErrByteBudgetExceeded = errors.New("logs truncated: byte budget exceeded")
+
+// SizeEstimate is the outcome of the probe issued for a container
+// before fetching its logs.
+type SizeEstimate struct {
+	Ref corev1.ObjectReference
+	// SampleLines and SampleBytes describe the probed tail of the log.
+	SampleLines int64
+	SampleBytes int64
+	// AvgLineBytes is the average size of a log line in the sample.
+	AvgLineBytes int64
+	// Estimated is the expected size of the full fetch, computed from the
+	// average line size and Opts.Tail, or -1 when it can't be estimated.
+	Estimated int64
+}
+
+// probeSizes issues a small tail request for every container to estimate
+// the volume of its logs, invoking o.OnSizeEstimate with the results.
+func (o *Opts) probeSizes(ctx context.Context, clientset corev1client.CoreV1Interface, refs []corev1.ObjectReference) error {
+	opts, ok := o.Options.(*corev1.PodLogOptions)
+	if !ok {
+		return errors.New("provided options object is not a PodLogOptions")
+	}
+
+	for _, ref := range refs {
+		probeOpts := opts.DeepCopy()
+		probeOpts.Follow = false
+		probeOpts.Container = o.containerNameFromRef(ref)
+		tail, limit := probeTailLines, probeLimitBytes
+		probeOpts.TailLines = &tail
+		probeOpts.LimitBytes = &limit
+
+		est := SizeEstimate{Ref: ref, Estimated: -1}
+
+		rc, err := clientset.Pods(ref.Namespace).GetLogs(ref.Name, probeOpts).Stream(ctx)
+		if err != nil {
+			return err
+		}
+		r := bufio.NewReader(rc)
+		for {
+			line, err := r.ReadBytes('\n')
+			est.SampleBytes += int64(len(line))
+			if len(line) > 0 {
+				est.SampleLines++
+			}
+			if err != nil {
+				break
+			}
+		}
+		rc.Close()
+
+		if est.SampleLines > 0 {
+			est.AvgLineBytes = est.SampleBytes / est.SampleLines
+			if o.Tail > 0 {
+				lines := o.Tail
+				if est.SampleLines < probeTailLines && est.SampleLines < lines {
+					// the whole log fits in the sample
+					lines = est.SampleLines
+				}
+				est.Estimated = est.AvgLineBytes * lines
+			} else if est.SampleLines < probeTailLines && est.SampleBytes < probeLimitBytes {
+				est.Estimated = est.SampleBytes
+			}
+		} else {
+			est.Estimated = 0
+		}
+
+		if err := o.OnSizeEstimate(est); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (o *Opts) containerNameFromRef(ref corev1.ObjectReference) string {
+	if len(ref.FieldPath) > 0 {
+		containerName := o.containerNameFromRefSpecRegexp.FindStringSubmatch(ref.FieldPath)
+		if len(containerName) == 2 {
+			return containerName[1]
+		}
+	}
+	return ""
+}
+
+// byteBudget is an aggregate limit shared by all the log streams.
+type byteBudget struct {
+	remaining int64
+	exceeded  int32
+}
+
+func newByteBudget(max int64) *byteBudget {
+	return &byteBudget{remaining: max}
+}
+
+// take reserves up to n bytes returning how many are granted.
+func (b *byteBudget) take(n int64) int64 {
+	for {
+		cur := atomic.LoadInt64(&b.remaining)
+		if cur <= 0 {
+			atomic.StoreInt32(&b.exceeded, 1)
+			return 0
+		}
+		granted := n
+		if granted > cur {
+			granted = cur
+		}
+		if atomic.CompareAndSwapInt64(&b.remaining, cur, cur-granted) {
+			if granted < n {
+				atomic.StoreInt32(&b.exceeded, 1)
+			}
+			return granted
+		}
+	}
+}
+
+func (b *byteBudget) Exceeded() bool {
+	return atomic.LoadInt32(&b.exceeded) == 1
+}
+
+// budgetResponseWrapper limits the bytes read from the wrapped request.
+type budgetResponseWrapper struct {
+	rest.ResponseWrapper
+	budget *byteBudget
+}
+
+func (w *budgetResponseWrapper) Stream(ctx context.Context) (io.ReadCloser, error) {
+	rc, err := w.ResponseWrapper.Stream(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &budgetReader{ReadCloser: rc, budget: w.budget}, nil
+}
+
+type budgetReader struct {
+	io.ReadCloser
+	budget *byteBudget
+}
+
+func (r *budgetReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	if n > 0 {
+		granted := int(r.budget.take(int64(n)))
+		if granted < n {
+			return granted, io.EOF
+		}
+	}
+	return n, err
+}
@@ -1,9 +1,13 @@
 package logs
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"io"
 	"regexp"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/lucasepe/kube/scheme"
@@ -12,6 +16,8 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/rest"
 )
 
@@ -25,7 +31,37 @@ type Opts struct {
 	AllContainers bool
 	Options       runtime.Object
 
+	// PodNames selects more pods by name, along with PodName;
+	// when following, they share MaxFollowConcurrency.
+	PodNames []string
+
+	// Resource selects, in place of PodName, a workload in the
+	// "type/name" form (e.g. "deploy/web", "sts/db", "job/migrate"):
+	// the logs are taken from its first pod or, with AllPods, from all
+	// its pods (up to MaxFollowConcurrency streams when following).
+	Resource string
+	AllPods  bool
+
 	RecordHandler func(Record) error
+	// LineParser turns each log line (without the timestamp added by
+	// the API server) into a Record (default ParseConsoleLine, see also
+	// ParseJSONLine). When it fails the whole line is the message.
+	LineParser func([]byte) (Record, error)
+	// Raw delivers every line, blank lines included, as the message
+	// without parsing it; only the timestamp added by the API server
+	// is split from the line. See also Record.Raw.
+	Raw bool
+	// WriterFor, if set, returns the writer receiving the log lines of
+	// each container, as received (see Record.Raw), in place of the
+	// RecordHandler: the lines are not parsed. The same writer, if
+	// returned for more containers, must be safe for concurrent use.
+	WriterFor func(ref corev1.ObjectReference) io.Writer
+	// NoTimestamps doesn't request the timestamps to the API server, so
+	// that the lines are received byte-exact (see Record.Raw); the time
+	// of the records is then the one found by the LineParser, if any.
+	// It is negated (rather than a Timestamps option) so that the zero
+	// value keeps requesting the timestamps, as Do always did.
+	NoTimestamps bool
 
 	// PodLogOptions
 	SinceTime                    string
@@ -38,15 +74,77 @@ type Opts struct {
 	Container                    string
 	InsecureSkipTLSVerifyBackend bool
 
-	Selector             string
+	// Selector selects the pods by label.
+	Selector string
+	// FollowNewPods, with Follow and a Selector, watches the pods until
+	// the context is cancelled (see DoContext): the streams start for
+	// the new and restarted containers and stop for the deleted pods.
+	// Otherwise only the pods found when starting are followed.
+	FollowNewPods bool
+	// MaxFollowConcurrency is the max number of streams followed at
+	// the same time (default 5).
 	MaxFollowConcurrency int
+	// MergeWindow, when following more containers, delivers the records
+	// ordered by timestamp, holding each of them up to this duration to
+	// wait for the lines of the other containers; the RecordHandler is
+	// then invoked from a single goroutine. Zero delivers the records
+	// as they arrive.
+	MergeWindow time.Duration
+	// Prefix sets, like kubectl logs --prefix, the source of each record
+	// as "[namespace/pod/container]", printed before the line by the
+	// default RecordHandler (see Record.Prefix).
+	Prefix bool
+	// Include and Exclude are regular expressions matched against each
+	// line as received (see Record.Raw): only the lines matching one of Include,
+	// when set, and none of Exclude are delivered to the RecordHandler.
+	Include []string
+	Exclude []string
+	// MinLevel delivers only the records with a level at least as severe
+	// (e.g. "warn"); the records without a level, or with an unknown
+	// one, are always delivered. Levels ranks the level names, matched
+	// ignoring the case (default DefaultLevels).
+	MinLevel string
+	Levels   map[string]int
+
+	// MaxBytes is an aggregate byte budget across all the fetched
+	// containers; when reached, the streams are truncated and Do
+	// returns ErrByteBudgetExceeded. Zero means no limit.
+	MaxBytes int64
+	// OnSizeEstimate, if set, is invoked for every container with the
+	// estimated log volume before the full fetch; returning an error
+	// aborts the operation before any log is downloaded.
+	OnSizeEstimate func(SizeEstimate) error
+	// OnStreamError is invoked, with IgnoreLogErrors, for each failed
+	// stream, while the others keep flowing; without it the failures are
+	// returned aggregated when all the streams are done. The errors of
+	// the RecordHandler stop all the streams anyway.
+	OnStreamError func(ref corev1.ObjectReference, err error)
+
+	// BufferSize, when set, queues the records in a buffer of this size
+	// so that a slow RecordHandler doesn't stall the streams; the handler
+	// is invoked from a single goroutine. OverflowPolicy tells what to do
+	// when the buffer is full (default Block), OnDrop is invoked for the
+	// discarded records.
+	BufferSize     int
+	OverflowPolicy OverflowPolicy
+	OnDrop         func(Record)
+	// QPS limits the rate of the records delivered to the RecordHandler,
+	// buffered as above; zero means no limit.
+	QPS float32
+	// Burst is the max burst of records allowed when QPS is set (default 1).
+	Burst int
 
 	Object        runtime.Object
 	GetPodTimeout time.Duration
 	LogsForObject LogsForObjectFunc
+	// Notify, if set, receives the hints for the user, like the pod chosen
+	// among the ones of a workload or the defaulted container.
+	Notify func(string)
 
 	containerNameFromRefSpecRegexp *regexp.Regexp
-	requestConsumeFn               func(rest.ResponseWrapper, func(rec Record) error) error
+	requestConsumeFn               func(context.Context, rest.ResponseWrapper, func(rec Record) error) error
+	filter                         *lineFilter
+	streamErrs                     *streamErrors
 }
 
 func (o *Opts) toLogOptions() (*corev1.PodLogOptions, error) {
@@ -54,7 +152,7 @@ func (o *Opts) toLogOptions() (*corev1.PodLogOptions, error) {
 		Container:                    o.Container,
 		Follow:                       o.Follow,
 		Previous:                     o.Previous,
-		Timestamps:                   true,
+		Timestamps:                   !o.NoTimestamps,
 		InsecureSkipTLSVerifyBackend: o.InsecureSkipTLSVerifyBackend,
 	}
 
@@ -87,13 +185,31 @@ func (o *Opts) toLogOptions() (*corev1.PodLogOptions, error) {
 	return logOptions, nil
 }
 
-func (o *Opts) complete(f kubeutil.Factory) error {
+func (o *Opts) complete(ctx context.Context, f kubeutil.Factory) error {
 	o.containerNameFromRefSpecRegexp =
 		regexp.MustCompile(`spec\.(?:initContainers|containers|ephemeralContainers){(.+)}`)
 
-	o.requestConsumeFn = defaultRequestConsumeFn
+	if o.LineParser == nil {
+		o.LineParser = ParseConsoleLine
+	}
+	raw := o.Raw || o.WriterFor != nil
+	parse := o.LineParser
+	if raw {
+		parse = nil
+	}
+	o.requestConsumeFn = newRequestConsumeFn(parse, raw, !o.NoTimestamps)
+
+	filter, err := newLineFilter(o.Include, o.Exclude)
+	if err != nil {
+		return err
+	}
+	if err := filter.withMinLevel(o.MinLevel, o.Levels); err != nil {
+		return err
+	}
+	o.filter = filter
+	o.streamErrs = &streamErrors{}
 
-	o.LogsForObject = logsForObject
+	o.LogsForObject = newLogsForObject(o.Notify)
 
 	if len(o.Container) == 0 {
 		o.AllContainers = true
@@ -103,6 +219,15 @@ func (o *Opts) complete(f kubeutil.Factory) error {
 		o.LimitBytes = 0
 	}
 
+	if o.MaxBytes < 0 {
+		o.MaxBytes = 0
+	}
+
+	if o.MaxBytes > 0 && (o.LimitBytes == 0 || o.LimitBytes > o.MaxBytes) {
+		// no single container can exceed the aggregate budget
+		o.LimitBytes = o.MaxBytes
+	}
+
 	if o.Since < 0 {
 		o.Since = 0
 	}
@@ -123,19 +248,38 @@ func (o *Opts) complete(f kubeutil.Factory) error {
 		o.RecordHandler = defaultRecordHandler
 	}
 
-	var err error
 	o.Options, err = o.toLogOptions()
 	if err != nil {
 		return err
 	}
 
+	if o.FollowNewPods && (!o.Follow || len(o.Selector) == 0) {
+		return errors.New("following the new pods requires Follow and a selector")
+	}
+
+	if len(o.Resource) > 0 {
+		if len(o.PodName) > 0 || len(o.PodNames) > 0 || len(o.Selector) > 0 {
+			return errors.New("only one of resource, pod name and selector can be set")
+		}
+		if parts := strings.SplitN(o.Resource, "/", 2); len(parts) != 2 || len(parts[0]) == 0 || len(parts[1]) == 0 {
+			return fmt.Errorf("resource %q must be in the type/name form", o.Resource)
+		}
+	}
+
 	if o.Object == nil {
 		builder := f.NewBuilder().
 			WithScheme(scheme.Scheme, scheme.Scheme.PrioritizedVersionsAllGroups()...).
 			NamespaceParam(o.Namespace).DefaultNamespace().
 			SingleResourceType()
+		names := o.PodNames
 		if o.PodName != "" {
-			builder.ResourceNames("pods", o.PodName)
+			names = append([]string{o.PodName}, names...)
+		}
+		if len(names) > 0 {
+			builder.ResourceNames("pods", names...)
+		}
+		if o.Resource != "" {
+			builder.ResourceTypeOrNameArgs(true, o.Resource)
 		}
 		if o.Selector != "" {
 			builder.ResourceTypes("pods").LabelSelectorParam(o.Selector)
@@ -144,28 +288,135 @@ func (o *Opts) complete(f kubeutil.Factory) error {
 		if err != nil {
 			return err
 		}
-		if o.Selector == "" && len(infos) != 1 {
+		switch {
+		case o.Selector == "" && len(names) > 1:
+			list := &corev1.PodList{}
+			for _, info := range infos {
+				pod, ok := info.Object.(*corev1.Pod)
+				if !ok {
+					return fmt.Errorf("expected a pod, got %T", info.Object)
+				}
+				list.Items = append(list.Items, *pod)
+			}
+			o.Object = list
+		case o.Selector == "" && len(infos) != 1:
 			return errors.New("expected a resource")
+		default:
+			o.Object = infos[0].Object
 		}
-		o.Object = infos[0].Object
 		if o.Selector != "" && len(o.Object.(*corev1.PodList).Items) == 0 {
 			return fmt.Errorf("no resources found in %s namespace", o.Namespace)
 		}
 	}
 
+	if o.AllPods {
+		switch o.Object.(type) {
+		case *corev1.Pod, *corev1.PodList:
+		default:
+			pods, err := podsForObject(ctx, f, o.Object)
+			if err != nil {
+				return err
+			}
+			o.Object = pods
+		}
+	}
+
 	return nil
 }
 
+// Do gets the logs selected by the options, delivering the records to
+// o.RecordHandler. With FollowNewPods the pods are watched until the
+// context is cancelled, so it never returns here: use DoContext.
 func Do(f kubeutil.Factory, o Opts) error {
-	if err := o.complete(f); err != nil {
+	return DoContext(context.Background(), f, o)
+}
+
+// DoContext is like Do but stops when the context is cancelled
+// (e.g. to stop following the logs).
+func DoContext(ctx context.Context, f kubeutil.Factory, o Opts) error {
+	if err := o.complete(ctx, f); err != nil {
 		return err
 	}
 
-	requests, err := o.LogsForObject(f, o.Object, o.Options, o.GetPodTimeout, o.AllContainers)
+	var budget *byteBudget
+	if o.MaxBytes > 0 {
+		budget = newByteBudget(o.MaxBytes)
+	}
+
+	var consume func(ctx context.Context) error
+	if o.FollowNewPods {
+		consume = func(ctx context.Context) error {
+			return o.followSelector(ctx, f, budget)
+		}
+	} else {
+		requests, err := o.LogsForObject(ctx, f, o.Object, o.Options, o.GetPodTimeout, o.AllContainers)
+		if err != nil {
+			return err
+		}
+
+		if o.OnSizeEstimate != nil {
+			if err := o.estimateSizes(ctx, f, requests); err != nil {
+				return err
+			}
+		}
+
+		if budget != nil {
+			for ref, req := range requests {
+				requests[ref] = &budgetResponseWrapper{ResponseWrapper: req, budget: budget}
+			}
+		}
+
+		consume = func(ctx context.Context) error {
+			return o.consumeRequests(ctx, requests)
+		}
+	}
+
+	var err error
+	if o.BufferSize > 0 || o.QPS > 0 {
+		q := newRecordQueue(o)
+		o.RecordHandler = q.push
+
+		g, gctx := errgroup.WithContext(ctx)
+		g.Go(func() error {
+			return q.run(gctx)
+		})
+		g.Go(func() error {
+			defer q.close()
+			return consume(gctx)
+		})
+		err = g.Wait()
+	} else {
+		err = consume(ctx)
+	}
+	if err == nil {
+		err = o.streamErrs.aggregate()
+	}
+	if err == nil && budget != nil && budget.Exceeded() {
+		err = ErrByteBudgetExceeded
+	}
+	return err
+}
+
+func (o *Opts) estimateSizes(ctx context.Context, f kubeutil.Factory, requests map[corev1.ObjectReference]rest.ResponseWrapper) error {
+	clientConfig, err := f.ToRESTConfig()
+	if err != nil {
+		return err
+	}
+
+	clientset, err := corev1client.NewForConfig(clientConfig)
 	if err != nil {
 		return err
 	}
 
+	refs := make([]corev1.ObjectReference, 0, len(requests))
+	for ref := range requests {
+		refs = append(refs, ref)
+	}
+
+	return o.probeSizes(ctx, clientset, refs)
+}
+
+func (o *Opts) consumeRequests(ctx context.Context, requests map[corev1.ObjectReference]rest.ResponseWrapper) error {
 	if o.Follow && len(requests) > 1 {
 		if len(requests) > o.MaxFollowConcurrency {
 			return fmt.Errorf(
@@ -174,28 +425,51 @@ func Do(f kubeutil.Factory, o Opts) error {
 			)
 		}
 
-		return o.parallelConsumeRequest(requests)
+		if o.MergeWindow > 0 {
+			return o.mergedConsumeRequest(ctx, requests)
+		}
+		return o.parallelConsumeRequest(ctx, requests)
 	}
 
-	return o.sequentialConsumeRequest(requests)
+	return o.sequentialConsumeRequest(ctx, requests)
 }
 
-func (o Opts) parallelConsumeRequest(requests map[corev1.ObjectReference]rest.ResponseWrapper) error {
-	g := new(errgroup.Group)
+func (o Opts) parallelConsumeRequest(ctx context.Context, requests map[corev1.ObjectReference]rest.ResponseWrapper) error {
+	g, ctx := errgroup.WithContext(ctx)
 
-	for _, request := range requests {
-		req := request
+	for ref, request := range requests {
+		ref, req := ref, request
 		g.Go(func() error {
-			return o.requestConsumeFn(req, o.RecordHandler)
+			return o.consumeStream(ctx, ref, req)
 		})
 	}
 
 	return g.Wait()
 }
 
-func (o Opts) sequentialConsumeRequest(requests map[corev1.ObjectReference]rest.ResponseWrapper) error {
-	for _, request := range requests {
-		err := o.requestConsumeFn(request, o.RecordHandler)
+// mergedConsumeRequest is like parallelConsumeRequest, but delivers
+// the records of all the requests ordered by timestamp.
+func (o Opts) mergedConsumeRequest(ctx context.Context, requests map[corev1.ObjectReference]rest.ResponseWrapper) error {
+	g, ctx := errgroup.WithContext(ctx)
+	m := newMerger(o.MergeWindow, o.RecordHandler)
+
+	streams := o
+	streams.RecordHandler = m.add
+	done := make(chan struct{})
+	g.Go(func() error {
+		defer close(done)
+		return streams.parallelConsumeRequest(ctx, requests)
+	})
+	g.Go(func() error {
+		return m.run(ctx, done)
+	})
+
+	return g.Wait()
+}
+
+func (o Opts) sequentialConsumeRequest(ctx context.Context, requests map[corev1.ObjectReference]rest.ResponseWrapper) error {
+	for ref, request := range requests {
+		err := o.consumeStream(ctx, ref, request)
 		if err != nil {
 			return err
 		}
@@ -203,3 +477,76 @@ func (o Opts) sequentialConsumeRequest(requests map[corev1.ObjectReference]rest.
 
 	return nil
 }
+
+// consumeStream delivers the records of the request. With IgnoreLogErrors,
+// the failure of the stream is reported and not returned, unless caused
+// by the RecordHandler or by the cancellation of the context.
+func (o Opts) consumeStream(ctx context.Context, ref corev1.ObjectReference, request rest.ResponseWrapper) error {
+	var handlerErr error
+	handler := o.recordHandlerFor(ref)
+	err := o.requestConsumeFn(ctx, request, func(rec Record) error {
+		handlerErr = handler(rec)
+		return handlerErr
+	})
+	if err == nil || handlerErr != nil || ctx.Err() != nil || !o.IgnoreLogErrors {
+		return err
+	}
+
+	err = fmt.Errorf("%s/%s: %w", ref.Namespace, ref.Name, err)
+	if o.OnStreamError != nil {
+		o.OnStreamError(ref, err)
+	} else {
+		o.streamErrs.add(err)
+	}
+	return nil
+}
+
+// streamErrors collects the failures of the streams, with IgnoreLogErrors.
+type streamErrors struct {
+	mu   sync.Mutex
+	errs []error
+}
+
+func (se *streamErrors) add(err error) {
+	se.mu.Lock()
+	defer se.mu.Unlock()
+	se.errs = append(se.errs, err)
+}
+
+func (se *streamErrors) aggregate() error {
+	if se == nil {
+		return nil
+	}
+	se.mu.Lock()
+	defer se.mu.Unlock()
+	return utilerrors.NewAggregate(se.errs)
+}
+
+// recordHandlerFor returns a RecordHandler that fills the records
+// with the pod and the container they come from, or that writes
+// them to the writer of the container.
+func (o Opts) recordHandlerFor(ref corev1.ObjectReference) func(Record) error {
+	container := o.containerNameFromRef(ref)
+	prefix := ""
+	if o.Prefix {
+		prefix = fmt.Sprintf("[%s/%s/%s]", ref.Namespace, ref.Name, container)
+	}
+	handler := o.RecordHandler
+	if o.WriterFor != nil {
+		w := o.WriterFor(ref)
+		if w == nil {
+			w = io.Discard
+		}
+		handler = func(rec Record) error {
+			_, err := w.Write(append(rec.raw, '\n'))
+			return err
+		}
+	}
+	return func(rec Record) error {
+		if o.filter != nil && !o.filter.match(rec) {
+			return nil
+		}
+		rec.ref, rec.container, rec.prefix = ref, container, prefix
+		return handler(rec)
+	}
+}
@@ -1,13 +1,14 @@
 package logs
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"os"
 	"sort"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
@@ -20,9 +21,20 @@ import (
 )
 
 // LogsForObjectFunc is a function type that can tell you how to get logs for a runtime.object
-type LogsForObjectFunc func(restClientGetter genericclioptions.RESTClientGetter, object, options runtime.Object, timeout time.Duration, allContainers bool) (map[corev1.ObjectReference]rest.ResponseWrapper, error)
+type LogsForObjectFunc func(ctx context.Context, restClientGetter genericclioptions.RESTClientGetter, object, options runtime.Object, timeout time.Duration, allContainers bool) (map[corev1.ObjectReference]rest.ResponseWrapper, error)
 
-func logsForObject(restClientGetter genericclioptions.RESTClientGetter, object, options runtime.Object, timeout time.Duration, allContainers bool) (map[corev1.ObjectReference]rest.ResponseWrapper, error) {
+// newLogsForObject returns the LogsForObjectFunc that
+// reports the hints for the user (e.g. the chosen pod) to notify.
+func newLogsForObject(notify func(string)) LogsForObjectFunc {
+	if notify == nil {
+		notify = func(string) {}
+	}
+	return func(ctx context.Context, restClientGetter genericclioptions.RESTClientGetter, object, options runtime.Object, timeout time.Duration, allContainers bool) (map[corev1.ObjectReference]rest.ResponseWrapper, error) {
+		return logsForObject(ctx, restClientGetter, object, options, timeout, allContainers, notify)
+	}
+}
+
+func logsForObject(ctx context.Context, restClientGetter genericclioptions.RESTClientGetter, object, options runtime.Object, timeout time.Duration, allContainers bool, notify func(string)) (map[corev1.ObjectReference]rest.ResponseWrapper, error) {
 	clientConfig, err := restClientGetter.ToRESTConfig()
 	if err != nil {
 		return nil, err
@@ -32,11 +44,11 @@ func logsForObject(restClientGetter genericclioptions.RESTClientGetter, object,
 	if err != nil {
 		return nil, err
 	}
-	return logsForObjectWithClient(clientset, object, options, timeout, allContainers)
+	return logsForObjectWithClient(ctx, clientset, object, options, timeout, allContainers, notify)
 }
 
 // this is split for easy test-ability
-func logsForObjectWithClient(clientset corev1client.CoreV1Interface, object, options runtime.Object, timeout time.Duration, allContainers bool) (map[corev1.ObjectReference]rest.ResponseWrapper, error) {
+func logsForObjectWithClient(ctx context.Context, clientset corev1client.CoreV1Interface, object, options runtime.Object, timeout time.Duration, allContainers bool, notify func(string)) (map[corev1.ObjectReference]rest.ResponseWrapper, error) {
 	opts, ok := options.(*corev1.PodLogOptions)
 	if !ok {
 		return nil, errors.New("provided options object is not a PodLogOptions")
@@ -46,7 +58,7 @@ func logsForObjectWithClient(clientset corev1client.CoreV1Interface, object, opt
 	case *corev1.PodList:
 		ret := make(map[corev1.ObjectReference]rest.ResponseWrapper)
 		for i := range t.Items {
-			currRet, err := logsForObjectWithClient(clientset, &t.Items[i], options, timeout, allContainers)
+			currRet, err := logsForObjectWithClient(ctx, clientset, &t.Items[i], options, timeout, allContainers, notify)
 			if err != nil {
 				return nil, err
 			}
@@ -67,9 +79,9 @@ func logsForObjectWithClient(clientset corev1client.CoreV1Interface, object, opt
 			if currOpts.Container == "" {
 				// Default to the first container name(aligning behavior with `kubectl exec').
 				currOpts.Container = t.Spec.Containers[0].Name
-				// TODO: if len(t.Spec.Containers) > 1 || len(t.Spec.InitContainers) > 0 || len(t.Spec.EphemeralContainers) > 0 {
-				//	fmt.Fprintf(os.Stderr, "Defaulted container %q out of: %s\n", currOpts.Container, kubeutil.AllContainerNames(t))
-				//}
+				if len(t.Spec.Containers) > 1 || len(t.Spec.InitContainers) > 0 || len(t.Spec.EphemeralContainers) > 0 {
+					notify(fmt.Sprintf("Defaulted container %q out of: %s", currOpts.Container, kubeutil.AllContainerNames(t)))
+				}
 			}
 
 			container, fieldPath := kubeutil.FindContainerByName(t, currOpts.Container)
@@ -90,7 +102,7 @@ func logsForObjectWithClient(clientset corev1client.CoreV1Interface, object, opt
 		for _, c := range t.Spec.InitContainers {
 			currOpts := opts.DeepCopy()
 			currOpts.Container = c.Name
-			currRet, err := logsForObjectWithClient(clientset, t, currOpts, timeout, false)
+			currRet, err := logsForObjectWithClient(ctx, clientset, t, currOpts, timeout, false, notify)
 			if err != nil {
 				return nil, err
 			}
@@ -101,7 +113,7 @@ func logsForObjectWithClient(clientset corev1client.CoreV1Interface, object, opt
 		for _, c := range t.Spec.Containers {
 			currOpts := opts.DeepCopy()
 			currOpts.Container = c.Name
-			currRet, err := logsForObjectWithClient(clientset, t, currOpts, timeout, false)
+			currRet, err := logsForObjectWithClient(ctx, clientset, t, currOpts, timeout, false, notify)
 			if err != nil {
 				return nil, err
 			}
@@ -112,7 +124,7 @@ func logsForObjectWithClient(clientset corev1client.CoreV1Interface, object, opt
 		for _, c := range t.Spec.EphemeralContainers {
 			currOpts := opts.DeepCopy()
 			currOpts.Container = c.Name
-			currRet, err := logsForObjectWithClient(clientset, t, currOpts, timeout, false)
+			currRet, err := logsForObjectWithClient(ctx, clientset, t, currOpts, timeout, false, notify)
 			if err != nil {
 				return nil, err
 			}
@@ -130,13 +142,46 @@ func logsForObjectWithClient(clientset corev1client.CoreV1Interface, object, opt
 	}
 
 	sortBy := func(pods []*corev1.Pod) sort.Interface { return kubeutil.ByLogging(pods) }
-	pod, numPods, err := kubeutil.GetFirstPod(clientset, namespace, selector.String(), timeout, sortBy)
+	pod, numPods, err := kubeutil.GetFirstPodContext(ctx, clientset, namespace, selector.String(), timeout, sortBy)
 	if err != nil {
 		return nil, err
 	}
 	if numPods > 1 {
-		fmt.Fprintf(os.Stderr, "Found %v pods, using pod/%v\n", numPods, pod.Name)
+		notify(fmt.Sprintf("Found %v pods, using pod/%v", numPods, pod.Name))
 	}
 
-	return logsForObjectWithClient(clientset, pod, options, timeout, allContainers)
+	return logsForObjectWithClient(ctx, clientset, pod, options, timeout, allContainers, notify)
+}
+
+// podsForObject returns all the pods selected by the object (e.g. the pods
+// of a deployment), in place of the first one picked by logsForObject.
+func podsForObject(ctx context.Context, restClientGetter genericclioptions.RESTClientGetter, object runtime.Object) (*corev1.PodList, error) {
+	clientConfig, err := restClientGetter.ToRESTConfig()
+	if err != nil {
+		return nil, err
+	}
+	clientset, err := corev1client.NewForConfig(clientConfig)
+	if err != nil {
+		return nil, err
+	}
+	return podsForObjectWithClient(ctx, clientset, object)
+}
+
+func podsForObjectWithClient(ctx context.Context, clientset corev1client.CoreV1Interface, object runtime.Object) (*corev1.PodList, error) {
+	namespace, selector, err := kubeutil.SelectorsForObject(object)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get the logs from %T: %v", object, err)
+	}
+
+	pods, err := clientset.Pods(namespace).List(ctx, metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, err
+	}
+	if len(pods.Items) == 0 {
+		return nil, fmt.Errorf("no pods found for selector %q in %s namespace", selector.String(), namespace)
+	}
+	sort.Slice(pods.Items, func(i, j int) bool {
+		return pods.Items[i].Name < pods.Items[j].Name
+	})
+	return pods, nil
 }
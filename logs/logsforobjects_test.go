@@ -0,0 +1,88 @@
+package logs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func testDeployment() *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+		Spec: appsv1.DeploymentSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+		},
+	}
+}
+
+func testPod(name string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: map[string]string{"app": "web"}},
+		Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "web"}}},
+		Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+}
+
+func TestLogsForObjectFirstPod(t *testing.T) {
+	cli := fake.NewSimpleClientset(testPod("web-1"), testPod("web-2"))
+
+	notified := []string{}
+	requests, err := logsForObjectWithClient(context.Background(), cli.CoreV1(), testDeployment(),
+		&corev1.PodLogOptions{}, time.Second, false, func(msg string) { notified = append(notified, msg) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(requests) != 1 {
+		t.Fatalf("expected a request, got %d", len(requests))
+	}
+	if len(notified) != 1 {
+		t.Fatalf("expected the chosen pod to be notified, got %v", notified)
+	}
+}
+
+func TestLogsForObjectCancelled(t *testing.T) {
+	cli := fake.NewSimpleClientset()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		// no pods yet: waits for one until the context is done
+		_, err := logsForObjectWithClient(ctx, cli.CoreV1(), testDeployment(),
+			&corev1.PodLogOptions{}, 0, false, func(string) {})
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Fatal("expected an error once the context is done")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the context was not honoured while waiting for a pod")
+	}
+}
+
+func TestPodsForObject(t *testing.T) {
+	other := testPod("db-0")
+	other.Labels = map[string]string{"app": "db"}
+	cli := fake.NewSimpleClientset(testPod("web-2"), testPod("web-1"), other)
+
+	pods, err := podsForObjectWithClient(context.Background(), cli.CoreV1(), testDeployment())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pods.Items) != 2 || pods.Items[0].Name != "web-1" || pods.Items[1].Name != "web-2" {
+		t.Fatalf("unexpected pods: %v", pods.Items)
+	}
+
+	if _, err := podsForObjectWithClient(context.Background(), fake.NewSimpleClientset().CoreV1(), testDeployment()); err == nil {
+		t.Fatal("expected an error without pods")
+	}
+}
@@ -0,0 +1,113 @@
+package logs
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+	"time"
+)
+
+// merger orders by timestamp the records coming from more streams,
+// holding each of them up to a window to wait for the others.
+type merger struct {
+	window  time.Duration
+	handler func(Record) error
+
+	mu      sync.Mutex
+	pending recordHeap
+	seq     uint64
+	err     error
+}
+
+func newMerger(window time.Duration, handler func(Record) error) *merger {
+	return &merger{window: window, handler: handler}
+}
+
+// add queues the record; it fails once the handler has failed,
+// to stop the streams.
+func (m *merger) add(rec Record) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.err != nil {
+		return m.err
+	}
+	m.seq++
+	heap.Push(&m.pending, pendingRecord{rec: rec, arrival: time.Now(), seq: m.seq})
+	return nil
+}
+
+// run delivers the records to the handler, from a single goroutine,
+// until done is closed; then it delivers all the queued records.
+func (m *merger) run(ctx context.Context, done <-chan struct{}) error {
+	tick := m.window / 2
+	if tick < 10*time.Millisecond {
+		tick = 10 * time.Millisecond
+	}
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-done:
+			return m.flush(time.Time{})
+		case now := <-ticker.C:
+			if err := m.flush(now); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// flush delivers, in timestamp order, the records queued
+// for at least the window before now, or all if now is zero.
+func (m *merger) flush(now time.Time) error {
+	for {
+		m.mu.Lock()
+		if m.pending.Len() == 0 || (!now.IsZero() && m.pending[0].arrival.Add(m.window).After(now)) {
+			m.mu.Unlock()
+			return nil
+		}
+		next := heap.Pop(&m.pending).(pendingRecord)
+		m.mu.Unlock()
+
+		if err := m.handler(next.rec); err != nil {
+			m.mu.Lock()
+			m.err = err
+			m.mu.Unlock()
+			return err
+		}
+	}
+}
+
+type pendingRecord struct {
+	rec     Record
+	arrival time.Time
+	seq     uint64
+}
+
+// recordHeap sorts the records by timestamp and, for
+// the same timestamp, by arrival.
+type recordHeap []pendingRecord
+
+func (h recordHeap) Len() int { return len(h) }
+
+func (h recordHeap) Less(i, j int) bool {
+	if !h[i].rec.timestamp.Equal(h[j].rec.timestamp) {
+		return h[i].rec.timestamp.Before(h[j].rec.timestamp)
+	}
+	return h[i].seq < h[j].seq
+}
+
+func (h recordHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *recordHeap) Push(x interface{}) { *h = append(*h, x.(pendingRecord)) }
+
+func (h *recordHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	*h = old[:n-1]
+	return x
+}
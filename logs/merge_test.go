@@ -0,0 +1,57 @@
+package logs
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMergerOrdersByTimestamp(t *testing.T) {
+	base := time.Date(2022, 11, 10, 10, 30, 0, 0, time.UTC)
+
+	got := []string{}
+	m := newMerger(time.Minute, func(rec Record) error {
+		got = append(got, rec.Msg())
+		return nil
+	})
+
+	done := make(chan struct{})
+	errc := make(chan error)
+	go func() { errc <- m.run(context.Background(), done) }()
+
+	for _, rec := range []Record{
+		NewRecord(base.Add(2*time.Second), "c"),
+		NewRecord(base, "a"),
+		NewRecord(base.Add(time.Second), "b1"),
+		NewRecord(base.Add(time.Second), "b2"),
+	} {
+		if err := m.add(rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+	close(done)
+
+	if err := <-errc; err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"a", "b1", "b2", "c"}; len(got) != len(want) ||
+		got[0] != want[0] || got[1] != want[1] || got[2] != want[2] || got[3] != want[3] {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
+
+func TestMergerFlushHoldsRecentRecords(t *testing.T) {
+	m := newMerger(time.Minute, func(rec Record) error {
+		t.Fatalf("unexpected record: %+v", rec)
+		return nil
+	})
+	if err := m.add(NewRecord(time.Now(), "recent")); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.flush(time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if m.pending.Len() != 1 {
+		t.Fatalf("expected the record to be held, got %d pending", m.pending.Len())
+	}
+}
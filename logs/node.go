@@ -0,0 +1,104 @@
+package logs
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	kubeutil "github.com/lucasepe/kube/util"
+	"k8s.io/client-go/rest"
+)
+
+// NodeOpts is a set of options that allows you to fetch node level logs
+// through the kubelet /logs endpoint proxied by the API server.
+type NodeOpts struct {
+	// Query lists the services (journald units on Linux) to read using
+	// the NodeLogQuery feature (e.g. "kubelet", "containerd").
+	Query []string
+	// Path is a file path relative to /var/log on the node (e.g. "syslog").
+	// An empty Path with no Query returns the directory listing of /var/log.
+	Path string
+
+	SinceTime time.Time
+	UntilTime time.Time
+	TailLines int64
+	// Pattern filters the returned lines with a regular expression (NodeLogQuery only).
+	Pattern string
+	// Boot selects the boot to read the journal from (0 current, -1 previous...);
+	// nil means not set (NodeLogQuery only).
+	Boot *int
+}
+
+// Node fetches node level logs from the named node, returning the stream.
+// The caller must close the returned reader.
+func Node(ctx context.Context, f kubeutil.Factory, nodeName string, o NodeOpts) (io.ReadCloser, error) {
+	if len(nodeName) == 0 {
+		return nil, fmt.Errorf("node name is required")
+	}
+	if len(o.Query) > 0 && len(o.Path) > 0 {
+		return nil, fmt.Errorf("query and path are mutually exclusive")
+	}
+
+	cfg, err := f.ToRESTConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	httpClient, err := rest.HTTPClientFor(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := url.Parse(cfg.Host)
+	if err != nil {
+		return nil, err
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/") + fmt.Sprintf("/api/v1/nodes/%s/proxy/logs/%s",
+		url.PathEscape(nodeName), strings.TrimPrefix(o.Path, "/"))
+	u.RawQuery = o.query().Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, fmt.Errorf("fetching logs of node %s: %s: %s", nodeName, resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	return resp.Body, nil
+}
+
+func (o NodeOpts) query() url.Values {
+	q := url.Values{}
+	for _, s := range o.Query {
+		q.Add("query", s)
+	}
+	if !o.SinceTime.IsZero() {
+		q.Set("sinceTime", o.SinceTime.UTC().Format(time.RFC3339))
+	}
+	if !o.UntilTime.IsZero() {
+		q.Set("untilTime", o.UntilTime.UTC().Format(time.RFC3339))
+	}
+	if o.TailLines > 0 {
+		q.Set("tailLines", strconv.FormatInt(o.TailLines, 10))
+	}
+	if len(o.Pattern) > 0 {
+		q.Set("pattern", o.Pattern)
+	}
+	if o.Boot != nil {
+		q.Set("boot", strconv.Itoa(*o.Boot))
+	}
+	return q
+}
@@ -0,0 +1,237 @@
+package logs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseConsoleLine is the default line parser. It recognizes the
+// tab separated lines of the console loggers ("time\tlevel\tmessage",
+// e.g. zap) and takes any other line as a plain message.
+func ParseConsoleLine(line []byte) (Record, error) {
+	parts := strings.Split(string(line), "\t")
+	if len(parts) > 1 {
+		if ts, err := time.Parse(time.RFC3339Nano, parts[0]); err == nil {
+			rec := Record{timestamp: ts}
+			if len(parts) > 2 {
+				rec.level, parts = parts[1], parts[2:]
+			} else {
+				parts = parts[1:]
+			}
+			rec.msg = strings.TrimSpace(strings.Join(parts, " "))
+			return rec, nil
+		}
+	}
+
+	return Record{msg: strings.TrimSpace(string(line))}, nil
+}
+
+var (
+	timeKeys   = []string{"ts", "time", "timestamp", "@timestamp"}
+	levelKeys  = []string{"level", "lvl", "severity"}
+	msgKeys    = []string{"msg", "message"}
+	loggerKeys = []string{"logger", "name"}
+)
+
+// ParseJSONLine parses the lines of the JSON loggers (e.g. zap, logrus,
+// slog), mapping the common keys: ts, time or timestamp (RFC 3339 or
+// seconds since the epoch), level, msg or message, and logger.
+// The other keys are available with Record.Fields, the objects
+// and the arrays encoded as JSON.
+func ParseJSONLine(line []byte) (Record, error) {
+	if !bytes.HasPrefix(bytes.TrimSpace(line), []byte("{")) {
+		return Record{}, fmt.Errorf("not a JSON object")
+	}
+
+	values := map[string]interface{}{}
+	dec := json.NewDecoder(bytes.NewReader(line))
+	dec.UseNumber()
+	if err := dec.Decode(&values); err != nil {
+		return Record{}, err
+	}
+
+	rec := Record{}
+	if k, ok := lookup(values, timeKeys); ok {
+		rec.timestamp = parseJSONTime(values[k])
+		delete(values, k)
+	}
+
+	fields := make(map[string]string, len(values))
+	for k, v := range values {
+		switch t := v.(type) {
+		case string:
+			fields[k] = t
+		case json.Number:
+			fields[k] = t.String()
+		case nil:
+			fields[k] = ""
+		default:
+			b, _ := json.Marshal(t)
+			fields[k] = string(b)
+		}
+	}
+	rec.setKnownFields(fields)
+	return rec, nil
+}
+
+// ParseLogfmtLine parses the lines of the logfmt loggers (e.g. logrus
+// text formatter, go-kit, slog), made of key=value pairs with the values
+// optionally quoted. The keys are mapped as for ParseJSONLine, the time
+// in the RFC 3339 format, and the others are available with Record.Fields.
+func ParseLogfmtLine(line []byte) (Record, error) {
+	fields, err := parseLogfmt(string(line))
+	if err != nil {
+		return Record{}, err
+	}
+
+	rec := Record{}
+	if k, ok := lookup(fields, timeKeys); ok {
+		rec.timestamp, _ = time.Parse(time.RFC3339Nano, fields[k])
+		delete(fields, k)
+	}
+	rec.setKnownFields(fields)
+	return rec, nil
+}
+
+// setKnownFields moves the level, the message and the logger
+// from fields to the record, keeping the others as the record fields.
+func (r *Record) setKnownFields(fields map[string]string) {
+	if k, ok := lookup(fields, levelKeys); ok {
+		r.level = fields[k]
+		delete(fields, k)
+	}
+	if k, ok := lookup(fields, msgKeys); ok {
+		r.msg = fields[k]
+		delete(fields, k)
+	}
+	if k, ok := lookup(fields, loggerKeys); ok {
+		r.logger = fields[k]
+		delete(fields, k)
+	}
+	if len(fields) > 0 {
+		r.fields = fields
+	}
+}
+
+// lookup returns the first of keys found in fields.
+func lookup[V any](fields map[string]V, keys []string) (string, bool) {
+	for _, k := range keys {
+		if _, ok := fields[k]; ok {
+			return k, true
+		}
+	}
+	return "", false
+}
+
+// parseLogfmt splits the key=value pairs of a logfmt line.
+// A key without a value (e.g. "key" or "key=") gets the empty value.
+func parseLogfmt(line string) (map[string]string, error) {
+	fields := map[string]string{}
+	pairs := false
+	for i := 0; i < len(line); {
+		if line[i] == ' ' || line[i] == '\t' {
+			i++
+			continue
+		}
+
+		start := i
+		for i < len(line) && line[i] != '=' && line[i] != ' ' && line[i] != '\t' {
+			if line[i] == '"' {
+				return nil, fmt.Errorf("unexpected quote in key at %d", i)
+			}
+			i++
+		}
+		key := line[start:i]
+		if i >= len(line) || line[i] != '=' {
+			fields[key] = ""
+			continue
+		}
+		pairs = true
+		i++
+
+		if i < len(line) && line[i] == '"' {
+			end := i + 1
+			for ; end < len(line) && line[end] != '"'; end++ {
+				if line[end] == '\\' {
+					end++
+				}
+			}
+			if end >= len(line) {
+				return nil, fmt.Errorf("unterminated quoted value for key %q", key)
+			}
+			val, err := strconv.Unquote(line[i : end+1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid quoted value for key %q: %w", key, err)
+			}
+			fields[key], i = val, end+1
+			continue
+		}
+
+		start = i
+		for i < len(line) && line[i] != ' ' && line[i] != '\t' {
+			i++
+		}
+		fields[key] = line[start:i]
+	}
+
+	if !pairs {
+		return nil, fmt.Errorf("not a logfmt line")
+	}
+	return fields, nil
+}
+
+func parseJSONTime(v interface{}) time.Time {
+	switch t := v.(type) {
+	case string:
+		ts, _ := time.Parse(time.RFC3339Nano, t)
+		return ts
+	case json.Number:
+		secs, err := t.Float64()
+		if err != nil {
+			return time.Time{}
+		}
+		return time.Unix(0, int64(secs*float64(time.Second))).UTC()
+	}
+	return time.Time{}
+}
+
+var klogRegexp = regexp.MustCompile(`^([IWEF])(\d{2})(\d{2}) (\d{2}):(\d{2}):(\d{2})\.(\d{6})\s+\d+ ([^\]]+:\d+)\] ?(.*)$`)
+
+var klogLevels = map[string]string{"I": "INFO", "W": "WARNING", "E": "ERROR", "F": "FATAL"}
+
+// now is the reference time used to infer the year of the klog lines.
+var now = time.Now
+
+// ParseKlogLine parses the lines of the klog format, used by the Kubernetes
+// components ("I0102 15:04:05.000000   1234 file.go:123] message"). The year,
+// missing in the format, is the current one, unless it would place the line
+// in the future; the time zone is assumed to be UTC.
+func ParseKlogLine(line []byte) (Record, error) {
+	m := klogRegexp.FindSubmatch(line)
+	if m == nil {
+		return Record{}, fmt.Errorf("not a klog line")
+	}
+
+	atoi := func(b []byte) int {
+		n, _ := strconv.Atoi(string(b))
+		return n
+	}
+	ref := now().UTC()
+	ts := time.Date(ref.Year(), time.Month(atoi(m[2])), atoi(m[3]),
+		atoi(m[4]), atoi(m[5]), atoi(m[6]), atoi(m[7])*1000, time.UTC)
+	if ts.After(ref.Add(24 * time.Hour)) {
+		ts = ts.AddDate(-1, 0, 0)
+	}
+
+	return Record{
+		timestamp: ts,
+		level:     klogLevels[string(m[1])],
+		source:    string(m[8]),
+		msg:       strings.TrimSpace(string(m[9])),
+	}, nil
+}
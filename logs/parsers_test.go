@@ -0,0 +1,116 @@
+package logs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewRecordJSON(t *testing.T) {
+	line := []byte(`2022-11-10T10:30:00.123456789Z {"ts":1668076200.5,"level":"info","logger":"setup","msg":"starting manager"}`)
+
+	rec := newRecord(line, ParseJSONLine, true)
+	if rec.Msg() != "starting manager" || rec.Level() != "info" || rec.Logger() != "setup" {
+		t.Fatalf("unexpected record: %+v", rec)
+	}
+	if want := time.Date(2022, 11, 10, 10, 30, 0, 5e8, time.UTC); !rec.Time().Equal(want) {
+		t.Fatalf("expected %s, got %s", want, rec.Time())
+	}
+	if len(rec.Fields()) != 0 {
+		t.Fatalf("unexpected fields: %v", rec.Fields())
+	}
+}
+
+func TestNewRecordFallback(t *testing.T) {
+	line := []byte("2022-11-10T10:30:00Z plain text line")
+
+	rec := newRecord(line, ParseJSONLine, true)
+	if rec.Msg() != "plain text line" {
+		t.Fatalf("unexpected message: %q", rec.Msg())
+	}
+	if want := time.Date(2022, 11, 10, 10, 30, 0, 0, time.UTC); !rec.Time().Equal(want) {
+		t.Fatalf("expected the API server timestamp, got %s", rec.Time())
+	}
+}
+
+func TestNewRecordRaw(t *testing.T) {
+	line := []byte("2022-11-10T10:30:00Z   indented\tline ")
+
+	rec := newRecord(line, nil, true)
+	if rec.Msg() != "  indented\tline " || string(rec.Raw()) != rec.Msg() {
+		t.Fatalf("unexpected record: %+v", rec)
+	}
+	if rec.Time().IsZero() {
+		t.Fatal("expected the API server timestamp")
+	}
+}
+
+func TestNewRecordParsedKeepsRaw(t *testing.T) {
+	line := []byte("2022-11-10T10:30:00Z   {\"msg\":\"ready\"}\t")
+
+	rec := newRecord(line, ParseJSONLine, true)
+	if rec.Msg() != "ready" {
+		t.Fatalf("unexpected message: %q", rec.Msg())
+	}
+	if want := "  {\"msg\":\"ready\"}\t"; string(rec.Raw()) != want {
+		t.Fatalf("expected the raw line %q, got %q", want, rec.Raw())
+	}
+}
+
+func TestNewRecordNoTimestamps(t *testing.T) {
+	line := []byte("2022-11-10T10:30:00Z written by the app")
+
+	rec := newRecord(line, nil, false)
+	if string(rec.Raw()) != string(line) || !rec.Time().IsZero() {
+		t.Fatalf("unexpected record: %+v", rec)
+	}
+}
+
+func TestParseConsoleLine(t *testing.T) {
+	rec, err := ParseConsoleLine([]byte("2022-11-10T10:30:00Z\tERROR\treconcile failed"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.Level() != "ERROR" || rec.Msg() != "reconcile failed" || rec.Time().IsZero() {
+		t.Fatalf("unexpected record: %+v", rec)
+	}
+}
+
+func TestParseKlogLine(t *testing.T) {
+	defer func(fn func() time.Time) { now = fn }(now)
+	now = func() time.Time { return time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC) }
+
+	rec, err := ParseKlogLine([]byte("E1231 23:59:58.123456    4321 controller.go:114] error syncing 'default/web': timeout"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.Level() != "ERROR" || rec.Source() != "controller.go:114" || rec.Msg() != "error syncing 'default/web': timeout" {
+		t.Fatalf("unexpected record: %+v", rec)
+	}
+	if want := time.Date(2022, 12, 31, 23, 59, 58, 123456000, time.UTC); !rec.Time().Equal(want) {
+		t.Fatalf("expected %s, got %s", want, rec.Time())
+	}
+
+	if _, err := ParseKlogLine([]byte("plain text")); err == nil {
+		t.Fatal("expected an error")
+	}
+}
+
+func TestParseLogfmtLine(t *testing.T) {
+	line := `time=2022-11-10T10:30:00Z level=warn msg="retrying \"web\" sync" attempt=3 dry-run`
+
+	rec, err := ParseLogfmtLine([]byte(line))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.Level() != "warn" || rec.Msg() != `retrying "web" sync` || rec.Time().IsZero() {
+		t.Fatalf("unexpected record: %+v", rec)
+	}
+	fields := rec.Fields()
+	if len(fields) != 2 || fields["attempt"] != "3" || fields["dry-run"] != "" {
+		t.Fatalf("unexpected fields: %v", fields)
+	}
+
+	if _, err := ParseLogfmtLine([]byte("plain text")); err == nil {
+		t.Fatal("expected an error")
+	}
+}
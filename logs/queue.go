@@ -0,0 +1,148 @@
+package logs
+
+import (
+	"context"
+	"sync"
+
+	"k8s.io/client-go/util/flowcontrol"
+)
+
+// OverflowPolicy tells what to do with a record when the buffer is full.
+type OverflowPolicy int
+
+const (
+	// Block waits for room in the buffer, slowing down the streams.
+	Block OverflowPolicy = iota
+	// DropOldest discards the oldest record in the buffer.
+	DropOldest
+	// DropNewest discards the incoming record.
+	DropNewest
+)
+
+// recordQueue decouples the streams from a slow RecordHandler,
+// invoked from a single goroutine at the limited rate.
+type recordQueue struct {
+	size    int
+	policy  OverflowPolicy
+	handler func(Record) error
+	onDrop  func(Record)
+	limiter flowcontrol.RateLimiter
+
+	mu     sync.Mutex
+	cond   *sync.Cond
+	recs   []Record
+	closed bool
+	err    error
+}
+
+func newRecordQueue(o Opts) *recordQueue {
+	q := &recordQueue{
+		size:    o.BufferSize,
+		policy:  o.OverflowPolicy,
+		handler: o.RecordHandler,
+		onDrop:  o.OnDrop,
+	}
+	if q.size <= 0 {
+		q.size = 1
+	}
+	if o.QPS > 0 {
+		burst := o.Burst
+		if burst <= 0 {
+			burst = 1
+		}
+		q.limiter = flowcontrol.NewTokenBucketRateLimiter(o.QPS, burst)
+	}
+	q.cond = sync.NewCond(&q.mu)
+	return q
+}
+
+// push queues the record, applying the overflow policy
+// when full; it fails once the handler has failed.
+func (q *recordQueue) push(rec Record) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for q.policy == Block && len(q.recs) >= q.size && q.err == nil {
+		q.cond.Wait()
+	}
+	if q.err != nil {
+		return q.err
+	}
+
+	if len(q.recs) >= q.size {
+		dropped := rec
+		if q.policy == DropOldest {
+			dropped, q.recs = q.recs[0], append(q.recs[1:], rec)
+		}
+		if q.onDrop != nil {
+			q.onDrop(dropped)
+		}
+		return nil
+	}
+
+	q.recs = append(q.recs, rec)
+	q.cond.Broadcast()
+	return nil
+}
+
+// close tells that no more records will be pushed.
+func (q *recordQueue) close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.closed = true
+	q.cond.Broadcast()
+}
+
+func (q *recordQueue) fail(err error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.err == nil {
+		q.err = err
+	}
+	q.cond.Broadcast()
+}
+
+// run delivers the queued records to the handler until the
+// queue is closed and empty, or the context is cancelled.
+func (q *recordQueue) run(ctx context.Context) error {
+	if q.limiter != nil {
+		defer q.limiter.Stop()
+	}
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-ctx.Done():
+			q.fail(ctx.Err())
+		case <-stop:
+		}
+	}()
+
+	for {
+		q.mu.Lock()
+		for len(q.recs) == 0 && !q.closed && q.err == nil {
+			q.cond.Wait()
+		}
+		if q.err != nil || len(q.recs) == 0 {
+			err := q.err
+			q.mu.Unlock()
+			return err
+		}
+		rec := q.recs[0]
+		q.recs = q.recs[1:]
+		q.cond.Broadcast()
+		q.mu.Unlock()
+
+		if q.limiter != nil {
+			if err := q.limiter.Wait(ctx); err != nil {
+				q.fail(err)
+				return err
+			}
+		}
+		if err := q.handler(rec); err != nil {
+			q.fail(err)
+			return err
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package logs
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRecordQueueOverflow(t *testing.T) {
+	tests := map[OverflowPolicy][]string{
+		DropOldest: {"b", "c"},
+		DropNewest: {"a", "b"},
+	}
+	for policy, want := range tests {
+		got, dropped := []string{}, 0
+		q := newRecordQueue(Opts{
+			BufferSize:     2,
+			OverflowPolicy: policy,
+			RecordHandler: func(rec Record) error {
+				got = append(got, rec.Msg())
+				return nil
+			},
+			OnDrop: func(Record) { dropped++ },
+		})
+
+		for _, msg := range []string{"a", "b", "c"} {
+			if err := q.push(NewRecord(time.Time{}, msg)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		q.close()
+		if err := q.run(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+
+		if dropped != 1 || len(got) != 2 || got[0] != want[0] || got[1] != want[1] {
+			t.Errorf("policy %d: expected %v, got %v (%d dropped)", policy, want, got, dropped)
+		}
+	}
+}
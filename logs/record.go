@@ -2,6 +2,7 @@ package logs
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"fmt"
 	"io"
@@ -9,12 +10,51 @@ import (
 	"strings"
 	"time"
 
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/client-go/rest"
 )
 
 type Record struct {
 	timestamp time.Time
+	level     string
+	logger    string
+	source    string
 	msg       string
+	fields    map[string]string
+	raw       []byte
+
+	ref       corev1.ObjectReference
+	container string
+	prefix    string
+}
+
+// NewRecord returns a record, for the custom line parsers.
+func NewRecord(timestamp time.Time, msg string) Record {
+	return Record{timestamp: timestamp, msg: msg}
+}
+
+// WithLevel returns a copy of the record with the level set.
+func (r Record) WithLevel(level string) Record {
+	r.level = level
+	return r
+}
+
+// WithLogger returns a copy of the record with the logger name set.
+func (r Record) WithLogger(logger string) Record {
+	r.logger = logger
+	return r
+}
+
+// WithSource returns a copy of the record with the source (file:line) set.
+func (r Record) WithSource(source string) Record {
+	r.source = source
+	return r
+}
+
+// WithFields returns a copy of the record with the structured fields set.
+func (r Record) WithFields(fields map[string]string) Record {
+	r.fields = fields
+	return r
 }
 
 func (r Record) Time() time.Time {
@@ -25,26 +65,98 @@ func (r Record) Msg() string {
 	return r.msg
 }
 
+// Level returns the level of the line, if the parser found one.
+func (r Record) Level() string {
+	return r.level
+}
+
+// Logger returns the name of the logger, if the parser found one.
+func (r Record) Logger() string {
+	return r.logger
+}
+
+// Source returns the source file and line that emitted the log, if the parser found them.
+func (r Record) Source() string {
+	return r.source
+}
+
+// Fields returns the structured fields of the line not mapped to the
+// other parts of the record (e.g. the keys of a JSON or logfmt line
+// besides time, level, message and logger), if the parser found any.
+func (r Record) Fields() map[string]string {
+	return r.fields
+}
+
+// Raw returns the line as received, whatever the parser, without the
+// line terminator and the timestamp added by the API server (see
+// Opts.NoTimestamps).
+func (r Record) Raw() []byte {
+	return r.raw
+}
+
+// Namespace returns the namespace of the pod the line comes from.
+func (r Record) Namespace() string {
+	return r.ref.Namespace
+}
+
+// Pod returns the name of the pod the line comes from.
+func (r Record) Pod() string {
+	return r.ref.Name
+}
+
+// Container returns the name of the container the line comes from.
+func (r Record) Container() string {
+	return r.container
+}
+
+// Ref returns the reference to the pod and, in the field
+// path, to the container the line comes from.
+func (r Record) Ref() corev1.ObjectReference {
+	return r.ref
+}
+
+// Prefix returns the "[namespace/pod/container]" prefix of the
+// record, set only with Opts.Prefix.
+func (r Record) Prefix() string {
+	return r.prefix
+}
+
 func (r Record) String() string {
-	return strings.Join([]string{
+	parts := []string{
 		r.timestamp.Format(time.RFC3339),
 		r.msg,
-	}, " ")
-
+	}
+	if len(r.prefix) > 0 {
+		parts = append([]string{r.prefix}, parts...)
+	}
+	return strings.Join(parts, " ")
 }
 
-func newRecord(ln string) Record {
-	rec := Record{}
-	parts := strings.Split(ln, "\t")
-	fmt.Println("==> ", ln, "  - ", len(parts))
-	if len(parts) > 1 {
-		idx := strings.Index(parts[0], " ")
-		if idx != -1 {
-			parts[0] = parts[0][0:idx]
+// newRecord splits the timestamp added by the API server from the line,
+// if timestamps is set, and parses the rest with parse. When parse fails
+// the whole line is the message; when it finds no timestamp the one of
+// the API server is used. A nil parse takes the line, untouched, as the message.
+// The line is kept untouched as the raw bytes of the record anyway.
+func newRecord(line []byte, parse func([]byte) (Record, error), timestamps bool) Record {
+	var ts time.Time
+	if idx := bytes.IndexByte(line, ' '); timestamps && idx != -1 {
+		if t, err := time.Parse(time.RFC3339Nano, string(line[:idx])); err == nil {
+			ts, line = t, line[idx+1:]
 		}
-		rec.timestamp, _ = time.Parse(time.RFC3339, parts[0])
-		rec.msg = strings.TrimSpace(strings.Join(parts[1:], " "))
 	}
+
+	rec := Record{msg: string(line)}
+	if parse != nil {
+		var err error
+		trimmed := bytes.TrimSpace(line)
+		if rec, err = parse(trimmed); err != nil {
+			rec = Record{msg: string(trimmed)}
+		}
+	}
+	if rec.timestamp.IsZero() {
+		rec.timestamp = ts
+	}
+	rec.raw = line
 	return rec
 }
 
@@ -53,7 +165,10 @@ func defaultRecordHandler(rec Record) error {
 	return nil
 }
 
-// defaultRequestConsumeFn reads the data from request, and creates a Record for each line.
+// newRequestConsumeFn returns a function that reads the data from request,
+// and creates a Record for each line using parse; the blank lines are
+// skipped, unless raw is set. Set timestamps if the lines are prefixed
+// with the timestamps added by the API server.
 // It buffers data from requests until the newline or io.EOF
 // occurs in the data, so it doesn't interleave logs sub-line
 // when running concurrently.
@@ -61,30 +176,29 @@ func defaultRecordHandler(rec Record) error {
 // A successful read returns err == nil, not err == io.EOF.
 // Because the function is defined to read from request until io.EOF, it does
 // not treat an io.EOF as an error to be reported.
-func defaultRequestConsumeFn(request rest.ResponseWrapper, fn func(Record) error) error {
-	readCloser, err := request.Stream(context.TODO())
-	if err != nil {
-		return err
-	}
-	defer readCloser.Close()
-
-	r := bufio.NewReader(readCloser)
-	for {
-		dat, err := r.ReadBytes('\n')
+func newRequestConsumeFn(parse func([]byte) (Record, error), raw, timestamps bool) func(context.Context, rest.ResponseWrapper, func(Record) error) error {
+	return func(ctx context.Context, request rest.ResponseWrapper, fn func(Record) error) error {
+		readCloser, err := request.Stream(ctx)
 		if err != nil {
-			if err != io.EOF {
-				return err
-			}
-			return nil
-		}
-
-		rec := newRecord(strings.TrimSpace(string(dat)))
-		if rec.timestamp.IsZero() {
-			continue
+			return err
 		}
+		defer readCloser.Close()
 
-		if err := fn(rec); err != nil {
-			return err
+		r := bufio.NewReader(readCloser)
+		for {
+			dat, err := r.ReadBytes('\n')
+			line := bytes.TrimSuffix(bytes.TrimSuffix(dat, []byte("\n")), []byte("\r"))
+			if len(bytes.TrimSpace(line)) > 0 || (raw && len(dat) > 0) {
+				if err := fn(newRecord(line, parse, timestamps)); err != nil {
+					return err
+				}
+			}
+			if err != nil {
+				if err != io.EOF {
+					return err
+				}
+				return nil
+			}
 		}
 	}
 }
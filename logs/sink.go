@@ -0,0 +1,216 @@
+package logs
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultSinkFileName = "logs.log"
+
+// FileSinkOpts configures a FileSink.
+type FileSinkOpts struct {
+	// Dir is the directory of the log files, created if missing.
+	Dir string
+	// PerContainer writes a file for each container, named
+	// "namespace_pod_container.log"; otherwise all the records go to
+	// the file named Name (default "logs.log").
+	PerContainer bool
+	Name         string
+	// MaxBytes rotates a file when its size would exceed it, MaxAge when
+	// it has been open for longer; zero disables the rotation.
+	MaxBytes int64
+	MaxAge   time.Duration
+	// Compress gzips the rotated files.
+	Compress bool
+}
+
+// FileSink writes the records to rotated files, for archiving the logs.
+// Use its Handle method as Opts.RecordHandler and Close it when done.
+type FileSink struct {
+	o FileSinkOpts
+
+	mu    sync.Mutex
+	files map[string]*sinkFile
+}
+
+type sinkFile struct {
+	path   string
+	f      *os.File
+	size   int64
+	opened time.Time
+}
+
+// NewFileSink returns a sink writing to the files in o.Dir.
+func NewFileSink(o FileSinkOpts) (*FileSink, error) {
+	if len(o.Dir) == 0 {
+		return nil, fmt.Errorf("the directory of the log files is required")
+	}
+	if len(o.Name) == 0 {
+		o.Name = defaultSinkFileName
+	}
+	if err := os.MkdirAll(o.Dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &FileSink{o: o, files: map[string]*sinkFile{}}, nil
+}
+
+// Handle writes the record (see Record.String) to its file,
+// rotating the file if needed.
+func (s *FileSink) Handle(rec Record) error {
+	name := s.o.Name
+	if s.o.PerContainer {
+		name = strings.Join([]string{rec.Namespace(), rec.Pod(), rec.Container()}, "_") + ".log"
+	}
+	line := []byte(rec.String() + "\n")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sf, ok := s.files[name]
+	if !ok {
+		var err error
+		if sf, err = openSinkFile(filepath.Join(s.o.Dir, name)); err != nil {
+			return err
+		}
+		s.files[name] = sf
+	}
+
+	if sf.size > 0 && ((s.o.MaxBytes > 0 && sf.size+int64(len(line)) > s.o.MaxBytes) ||
+		(s.o.MaxAge > 0 && time.Since(sf.opened) > s.o.MaxAge)) {
+		if err := s.rotate(sf); err != nil {
+			return err
+		}
+	}
+
+	n, err := sf.f.Write(line)
+	sf.size += int64(n)
+	return err
+}
+
+// Close closes all the files.
+func (s *FileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var res error
+	for name, sf := range s.files {
+		if err := sf.f.Close(); err != nil && res == nil {
+			res = err
+		}
+		delete(s.files, name)
+	}
+	return res
+}
+
+func openSinkFile(path string) (*sinkFile, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &sinkFile{path: path, f: f, size: fi.Size(), opened: time.Now()}, nil
+}
+
+// rotate renames the file adding the current time to the name,
+// compresses it if requested, and opens a new file.
+func (s *FileSink) rotate(sf *sinkFile) error {
+	if err := sf.f.Close(); err != nil {
+		return err
+	}
+
+	ext := filepath.Ext(sf.path)
+	rotated := fmt.Sprintf("%s-%s%s", strings.TrimSuffix(sf.path, ext),
+		time.Now().UTC().Format("20060102T150405.000000000"), ext)
+	if err := os.Rename(sf.path, rotated); err != nil {
+		return err
+	}
+	if s.o.Compress {
+		if err := gzipFile(rotated); err != nil {
+			return err
+		}
+	}
+
+	next, err := openSinkFile(sf.path)
+	if err != nil {
+		return err
+	}
+	*sf = *next
+	return nil
+}
+
+// gzipFile replaces the file with its compressed version (".gz").
+func gzipFile(path string) error {
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(path + ".gz")
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(out)
+	if _, err := io.Copy(zw, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
+// ndjsonRecord is the JSON form of a Record.
+type ndjsonRecord struct {
+	Time      time.Time         `json:"time"`
+	Level     string            `json:"level,omitempty"`
+	Logger    string            `json:"logger,omitempty"`
+	Source    string            `json:"source,omitempty"`
+	Msg       string            `json:"msg"`
+	Namespace string            `json:"namespace,omitempty"`
+	Pod       string            `json:"pod,omitempty"`
+	Container string            `json:"container,omitempty"`
+	Fields    map[string]string `json:"fields,omitempty"`
+}
+
+// NDJSONHandler returns a RecordHandler that writes each record to w as
+// a JSON object on its own line (e.g. for jq or a bulk ingestion), with
+// the pod and the container it comes from and the parsed fields.
+// It is safe for concurrent use.
+func NDJSONHandler(w io.Writer) func(Record) error {
+	var mu sync.Mutex
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+
+	return func(rec Record) error {
+		mu.Lock()
+		defer mu.Unlock()
+		return enc.Encode(ndjsonRecord{
+			Time:      rec.timestamp,
+			Level:     rec.level,
+			Logger:    rec.logger,
+			Source:    rec.source,
+			Msg:       rec.msg,
+			Namespace: rec.Namespace(),
+			Pod:       rec.Pod(),
+			Container: rec.container,
+			Fields:    rec.fields,
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package logs
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFileSinkRotation(t *testing.T) {
+	dir := t.TempDir()
+	sink, err := NewFileSink(FileSinkOpts{Dir: dir, MaxBytes: 80, Compress: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ts := time.Date(2022, 11, 10, 10, 30, 0, 0, time.UTC)
+	for _, msg := range []string{"first line of the log", "second line of the log", "third"} {
+		if err := sink.Handle(NewRecord(ts, msg)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	rotated, err := filepath.Glob(filepath.Join(dir, "logs-*.log.gz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rotated) != 1 {
+		t.Fatalf("expected a rotated file, got %v", rotated)
+	}
+
+	f, err := os.Open(rotated[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dat, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(dat), "first line of the log") || strings.Contains(string(dat), "second") {
+		t.Fatalf("unexpected rotated content: %q", dat)
+	}
+
+	dat, err = os.ReadFile(filepath.Join(dir, "logs.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "2022-11-10T10:30:00Z second line of the log\n2022-11-10T10:30:00Z third\n"; string(dat) != want {
+		t.Fatalf("expected %q, got %q", want, dat)
+	}
+}
+
+func TestNDJSONHandler(t *testing.T) {
+	var buf strings.Builder
+	handler := NDJSONHandler(&buf)
+
+	rec := NewRecord(time.Date(2022, 11, 10, 10, 30, 0, 0, time.UTC), "<done>").
+		WithLevel("info").
+		WithFields(map[string]string{"attempt": "3"})
+	rec.container = "app"
+	if err := handler(rec); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `{"time":"2022-11-10T10:30:00Z","level":"info","msg":"<done>","container":"app","fields":{"attempt":"3"}}` + "\n"
+	if buf.String() != want {
+		t.Fatalf("expected %q, got %q", want, buf.String())
+	}
+}
@@ -0,0 +1,99 @@
+package logs
+
+import (
+	"context"
+	"sync"
+
+	kubeutil "github.com/lucasepe/kube/util"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// RingBuffer keeps the last records of each container.
+type RingBuffer struct {
+	n int
+
+	mu    sync.Mutex
+	rings map[corev1.ObjectReference]*ring
+
+	done chan struct{}
+	err  error
+}
+
+type ring struct {
+	recs []Record
+	next int
+	full bool
+}
+
+// TailBuffer streams the logs selected by o in the background, keeping
+// the last n records of each container in memory (e.g. to render a live
+// tail window); o.RecordHandler is replaced. The streaming stops when
+// the context is cancelled or, without o.Follow, when all the logs have
+// been read.
+func TailBuffer(ctx context.Context, f kubeutil.Factory, o Opts, n int) *RingBuffer {
+	if n <= 0 {
+		n = 1
+	}
+	b := &RingBuffer{
+		n:     n,
+		rings: map[corev1.ObjectReference]*ring{},
+		done:  make(chan struct{}),
+	}
+
+	o.RecordHandler = b.add
+	go func() {
+		defer close(b.done)
+		b.err = DoContext(ctx, f, o)
+	}()
+	return b
+}
+
+func (b *RingBuffer) add(rec Record) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	r, ok := b.rings[rec.ref]
+	if !ok {
+		r = &ring{recs: make([]Record, b.n)}
+		b.rings[rec.ref] = r
+	}
+	r.recs[r.next] = rec
+	if r.next = (r.next + 1) % b.n; r.next == 0 {
+		r.full = true
+	}
+	return nil
+}
+
+// Snapshot returns a copy of the records of each container, oldest first.
+// The reference of a container is the one returned by Record.Ref.
+func (b *RingBuffer) Snapshot() map[corev1.ObjectReference][]Record {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	res := make(map[corev1.ObjectReference][]Record, len(b.rings))
+	for ref, r := range b.rings {
+		if !r.full {
+			res[ref] = append([]Record{}, r.recs[:r.next]...)
+			continue
+		}
+		recs := make([]Record, 0, b.n)
+		recs = append(recs, r.recs[r.next:]...)
+		res[ref] = append(recs, r.recs[:r.next]...)
+	}
+	return res
+}
+
+// Done is closed when the streaming stops.
+func (b *RingBuffer) Done() <-chan struct{} {
+	return b.done
+}
+
+// Err returns the error that stopped the streaming, once Done is closed.
+func (b *RingBuffer) Err() error {
+	select {
+	case <-b.done:
+		return b.err
+	default:
+		return nil
+	}
+}
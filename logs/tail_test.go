@@ -0,0 +1,35 @@
+package logs
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestRingBufferSnapshot(t *testing.T) {
+	b := &RingBuffer{n: 2, rings: map[corev1.ObjectReference]*ring{}}
+
+	web := corev1.ObjectReference{Namespace: "default", Name: "web-0"}
+	db := corev1.ObjectReference{Namespace: "default", Name: "db-0"}
+	for _, msg := range []string{"a", "b", "c"} {
+		rec := NewRecord(time.Time{}, msg)
+		rec.ref = web
+		if err := b.add(rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rec := NewRecord(time.Time{}, "x")
+	rec.ref = db
+	if err := b.add(rec); err != nil {
+		t.Fatal(err)
+	}
+
+	snap := b.Snapshot()
+	if got := snap[web]; len(got) != 2 || got[0].Msg() != "b" || got[1].Msg() != "c" {
+		t.Fatalf("unexpected records for web-0: %+v", got)
+	}
+	if got := snap[db]; len(got) != 1 || got[0].Msg() != "x" {
+		t.Fatalf("unexpected records for db-0: %+v", got)
+	}
+}
@@ -0,0 +1,292 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	kubeutil "github.com/lucasepe/kube/util"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+)
+
+// PodAction is what a drain would do with a pod.
+type PodAction string
+
+const (
+	// ActionEvict means the pod would be evicted.
+	ActionEvict PodAction = "Evict"
+	// ActionSkip means the pod would be left on the node (e.g. mirror pods).
+	ActionSkip PodAction = "Skip"
+	// ActionBlock means the pod would make the drain fail.
+	ActionBlock PodAction = "Block"
+)
+
+// DrainOpts is a set of options that mirrors the behavior of kubectl drain.
+type DrainOpts struct {
+	// IgnoreDaemonSets skips the DaemonSet-managed pods instead of blocking.
+	IgnoreDaemonSets bool
+	// DeleteEmptyDirData allows evicting pods using emptyDir volumes.
+	DeleteEmptyDirData bool
+	// Force allows evicting pods not managed by a controller.
+	Force bool
+	// PodSelector restricts the drain to the pods matching the label selector.
+	PodSelector string
+}
+
+// PodPlan is the planned action for a single pod.
+type PodPlan struct {
+	Namespace string
+	Name      string
+	// Owner is the controller of the pod (e.g. "Deployment/web"), empty if unmanaged.
+	Owner  string
+	Action PodAction
+	// Reason explains the action; for evicted pods it may carry a warning
+	// (e.g. local data loss).
+	Reason string
+	// PDBs lists the PodDisruptionBudgets selecting the pod.
+	PDBs []string
+}
+
+// WorkloadDisruption is the estimated impact of the drain on a workload.
+type WorkloadDisruption struct {
+	Namespace string
+	// Workload is the top level owner (e.g. "Deployment/web").
+	Workload string
+	// Replicas is the desired replica count of the workload, -1 if unknown.
+	Replicas int32
+	// Evicted is the number of pods of the workload evicted from the node.
+	Evicted int32
+}
+
+// Plan is the outcome of a drain simulation.
+type Plan struct {
+	Node      string
+	Evict     []PodPlan
+	Skip      []PodPlan
+	Block     []PodPlan
+	Workloads []WorkloadDisruption
+}
+
+// CanDrain returns true if no pod would block the drain.
+func (p Plan) CanDrain() bool {
+	return len(p.Block) == 0
+}
+
+// DrainPlan computes, without evicting anything, what a drain of the
+// node would do with each of its pods.
+func DrainPlan(ctx context.Context, f kubeutil.Factory, nodeName string, o DrainOpts) (*Plan, error) {
+	cli, err := f.KubernetesClientSet()
+	if err != nil {
+		return nil, err
+	}
+
+	pods, err := kubeutil.PodsOnNode(ctx, f, nodeName, kubeutil.PodsOnNodeOpts{
+		LabelSelector: o.PodSelector,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return planDrain(ctx, cli, nodeName, pods, o)
+}
+
+// planDrain classifies the pods of the node, taking into account the
+// disruptions still allowed by the PodDisruptionBudgets.
+func planDrain(ctx context.Context, cli kubernetes.Interface, nodeName string, pods []corev1.Pod, o DrainOpts) (*Plan, error) {
+	pdbs, err := cli.PolicyV1().PodDisruptionBudgets("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	// remaining disruptions allowed for each PDB
+	allowed := map[string]int32{}
+	for _, pdb := range pdbs.Items {
+		allowed[pdb.Namespace+"/"+pdb.Name] = pdb.Status.DisruptionsAllowed
+	}
+
+	owners := newOwnerResolver(cli)
+	plan := &Plan{Node: nodeName}
+	workloads := map[string]*WorkloadDisruption{}
+
+	for i := range pods {
+		pod := &pods[i]
+
+		pp := PodPlan{Namespace: pod.Namespace, Name: pod.Name}
+		pp.Action, pp.Reason = ClassifyPod(pod, o)
+
+		owner, replicas := owners.resolve(ctx, pod)
+		pp.Owner = owner
+
+		pp.PDBs = matchingPDBs(pod, pdbs.Items)
+		if pp.Action == ActionEvict && !kubeutil.IsPodTerminated(pod) {
+			for _, name := range pp.PDBs {
+				key := pod.Namespace + "/" + name
+				if allowed[key] < 1 {
+					pp.Action = ActionBlock
+					pp.Reason = fmt.Sprintf("eviction would violate PodDisruptionBudget %s", name)
+					break
+				}
+			}
+			if pp.Action == ActionEvict {
+				for _, name := range pp.PDBs {
+					allowed[pod.Namespace+"/"+name]--
+				}
+			}
+		}
+
+		switch pp.Action {
+		case ActionEvict:
+			plan.Evict = append(plan.Evict, pp)
+			if len(owner) > 0 {
+				key := pod.Namespace + "/" + owner
+				wd, ok := workloads[key]
+				if !ok {
+					wd = &WorkloadDisruption{Namespace: pod.Namespace, Workload: owner, Replicas: replicas}
+					workloads[key] = wd
+				}
+				wd.Evicted++
+			}
+		case ActionSkip:
+			plan.Skip = append(plan.Skip, pp)
+		case ActionBlock:
+			plan.Block = append(plan.Block, pp)
+		}
+	}
+
+	for _, wd := range workloads {
+		plan.Workloads = append(plan.Workloads, *wd)
+	}
+	sort.Slice(plan.Workloads, func(i, j int) bool {
+		if plan.Workloads[i].Namespace != plan.Workloads[j].Namespace {
+			return plan.Workloads[i].Namespace < plan.Workloads[j].Namespace
+		}
+		return plan.Workloads[i].Workload < plan.Workloads[j].Workload
+	})
+
+	return plan, nil
+}
+
+// ClassifyPod returns the action a drain would take on the pod,
+// without considering PodDisruptionBudgets, and the reason why.
+func ClassifyPod(pod *corev1.Pod, o DrainOpts) (PodAction, string) {
+	if kubeutil.IsPodTerminated(pod) {
+		return ActionEvict, "pod has completed"
+	}
+
+	if kubeutil.IsMirrorPod(pod) {
+		return ActionSkip, "mirror pod"
+	}
+
+	if kubeutil.IsDaemonSetPod(pod) {
+		if o.IgnoreDaemonSets {
+			return ActionSkip, "DaemonSet-managed pod"
+		}
+		return ActionBlock, "DaemonSet-managed pod (use IgnoreDaemonSets to ignore)"
+	}
+
+	reason := ""
+	if metav1.GetControllerOf(pod) == nil {
+		if !o.Force {
+			return ActionBlock, "pod not managed by a controller (use Force to override)"
+		}
+		reason = "pod not managed by a controller will not be recreated"
+	}
+
+	if hasLocalStorage(pod) {
+		if !o.DeleteEmptyDirData {
+			return ActionBlock, "pod with local storage (use DeleteEmptyDirData to override)"
+		}
+		if len(reason) > 0 {
+			reason += "; "
+		}
+		reason += "emptyDir data will be deleted"
+	}
+
+	return ActionEvict, reason
+}
+
+func hasLocalStorage(pod *corev1.Pod) bool {
+	for _, v := range pod.Spec.Volumes {
+		if v.EmptyDir != nil {
+			return true
+		}
+	}
+	return false
+}
+
+func matchingPDBs(pod *corev1.Pod, pdbs []policyv1.PodDisruptionBudget) []string {
+	res := []string{}
+	for _, pdb := range pdbs {
+		if pdb.Namespace != pod.Namespace {
+			continue
+		}
+		selector, err := metav1.LabelSelectorAsSelector(pdb.Spec.Selector)
+		if err != nil || selector.Empty() {
+			continue
+		}
+		if selector.Matches(labels.Set(pod.Labels)) {
+			res = append(res, pdb.Name)
+		}
+	}
+	return res
+}
+
+type ownerInfo struct {
+	name     string
+	replicas int32
+}
+
+// ownerResolver resolves the top level workload of a pod, following
+// the ReplicaSet -> Deployment chain, caching the lookups.
+type ownerResolver struct {
+	cli   kubernetes.Interface
+	cache map[string]ownerInfo
+}
+
+func newOwnerResolver(cli kubernetes.Interface) *ownerResolver {
+	return &ownerResolver{cli: cli, cache: map[string]ownerInfo{}}
+}
+
+func (r *ownerResolver) resolve(ctx context.Context, pod *corev1.Pod) (string, int32) {
+	ref := metav1.GetControllerOf(pod)
+	if ref == nil {
+		return "", -1
+	}
+
+	key := fmt.Sprintf("%s/%s/%s", pod.Namespace, ref.Kind, ref.Name)
+	if info, ok := r.cache[key]; ok {
+		return info.name, info.replicas
+	}
+
+	info := ownerInfo{name: ref.Kind + "/" + ref.Name, replicas: -1}
+	switch ref.Kind {
+	case "ReplicaSet":
+		rs, err := r.cli.AppsV1().ReplicaSets(pod.Namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+		if err != nil {
+			break
+		}
+		if rs.Spec.Replicas != nil {
+			info.replicas = *rs.Spec.Replicas
+		}
+		if dref := metav1.GetControllerOf(rs); dref != nil && dref.Kind == "Deployment" {
+			info.name = dref.Kind + "/" + dref.Name
+			if d, err := r.cli.AppsV1().Deployments(pod.Namespace).Get(ctx, dref.Name, metav1.GetOptions{}); err == nil && d.Spec.Replicas != nil {
+				info.replicas = *d.Spec.Replicas
+			}
+		}
+	case "StatefulSet":
+		if sts, err := r.cli.AppsV1().StatefulSets(pod.Namespace).Get(ctx, ref.Name, metav1.GetOptions{}); err == nil && sts.Spec.Replicas != nil {
+			info.replicas = *sts.Spec.Replicas
+		}
+	case "ReplicationController":
+		if rc, err := r.cli.CoreV1().ReplicationControllers(pod.Namespace).Get(ctx, ref.Name, metav1.GetOptions{}); err == nil && rc.Spec.Replicas != nil {
+			info.replicas = *rc.Spec.Replicas
+		}
+	}
+
+	r.cache[key] = info
+	return info.name, info.replicas
+}
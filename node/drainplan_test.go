@@ -0,0 +1,135 @@
+package node
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	kubeutil "github.com/lucasepe/kube/util"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func int32Ptr(v int32) *int32 { return &v }
+
+func controlledBy(kind, name string) []metav1.OwnerReference {
+	yes := true
+	return []metav1.OwnerReference{{Kind: kind, Name: name, Controller: &yes}}
+}
+
+func pod(name string, owner []metav1.OwnerReference, lbls map[string]string) corev1.Pod {
+	return corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", OwnerReferences: owner, Labels: lbls},
+		Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+}
+
+func TestClassifyPod(t *testing.T) {
+	emptyDir := pod("cache", controlledBy("ReplicaSet", "cache-1"), nil)
+	emptyDir.Spec.Volumes = []corev1.Volume{{Name: "tmp", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}}}
+
+	completed := pod("job", nil, nil)
+	completed.Status.Phase = corev1.PodSucceeded
+
+	mirror := pod("etcd", nil, nil)
+	mirror.Annotations = map[string]string{kubeutil.MirrorPodAnnotationKey: "hash"}
+
+	tests := []struct {
+		name   string
+		pod    corev1.Pod
+		opts   DrainOpts
+		action PodAction
+	}{
+		{"completed", completed, DrainOpts{}, ActionEvict},
+		{"mirror", mirror, DrainOpts{}, ActionSkip},
+		{"daemonset", pod("agent", controlledBy("DaemonSet", "agent"), nil), DrainOpts{}, ActionBlock},
+		{"daemonset ignored", pod("agent", controlledBy("DaemonSet", "agent"), nil), DrainOpts{IgnoreDaemonSets: true}, ActionSkip},
+		{"unmanaged", pod("bare", nil, nil), DrainOpts{}, ActionBlock},
+		{"unmanaged forced", pod("bare", nil, nil), DrainOpts{Force: true}, ActionEvict},
+		{"emptyDir", emptyDir, DrainOpts{}, ActionBlock},
+		{"emptyDir allowed", emptyDir, DrainOpts{DeleteEmptyDirData: true}, ActionEvict},
+		{"managed", pod("web", controlledBy("ReplicaSet", "web-1"), nil), DrainOpts{}, ActionEvict},
+	}
+	for _, tt := range tests {
+		if action, reason := ClassifyPod(&tt.pod, tt.opts); action != tt.action {
+			t.Errorf("%s: expected %s, got %s (%s)", tt.name, tt.action, action, reason)
+		}
+	}
+
+	bare := pod("bare", nil, nil)
+	bare.Spec.Volumes = emptyDir.Spec.Volumes
+	_, reason := ClassifyPod(&bare, DrainOpts{Force: true, DeleteEmptyDirData: true})
+	if !strings.Contains(reason, "not be recreated") || !strings.Contains(reason, "emptyDir data will be deleted") {
+		t.Fatalf("expected both warnings, got %q", reason)
+	}
+}
+
+func TestPlanDrain(t *testing.T) {
+	cli := fake.NewSimpleClientset(
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+			Spec:       appsv1.DeploymentSpec{Replicas: int32Ptr(3)},
+		},
+		&appsv1.ReplicaSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-1", Namespace: "default", OwnerReferences: controlledBy("Deployment", "web")},
+			Spec:       appsv1.ReplicaSetSpec{Replicas: int32Ptr(3)},
+		},
+		&appsv1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "default"},
+			Spec:       appsv1.StatefulSetSpec{Replicas: int32Ptr(2)},
+		},
+		&policyv1.PodDisruptionBudget{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+			Spec:       policyv1.PodDisruptionBudgetSpec{Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}}},
+			Status:     policyv1.PodDisruptionBudgetStatus{DisruptionsAllowed: 1},
+		},
+	)
+
+	pods := []corev1.Pod{
+		pod("web-a", controlledBy("ReplicaSet", "web-1"), map[string]string{"app": "web"}),
+		pod("web-b", controlledBy("ReplicaSet", "web-1"), map[string]string{"app": "web"}),
+		pod("db-0", controlledBy("StatefulSet", "db"), map[string]string{"app": "db"}),
+		pod("agent", controlledBy("DaemonSet", "agent"), nil),
+	}
+
+	plan, err := planDrain(context.Background(), cli, "worker-1", pods, DrainOpts{IgnoreDaemonSets: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.Node != "worker-1" || plan.CanDrain() {
+		t.Fatalf("expected the drain to be blocked, got %+v", plan)
+	}
+
+	names := func(pps []PodPlan) []string {
+		res := []string{}
+		for _, pp := range pps {
+			res = append(res, pp.Name)
+		}
+		return res
+	}
+	if got := names(plan.Evict); !reflect.DeepEqual(got, []string{"web-a", "db-0"}) {
+		t.Fatalf("unexpected evicted pods %v", got)
+	}
+	if got := names(plan.Skip); !reflect.DeepEqual(got, []string{"agent"}) {
+		t.Fatalf("unexpected skipped pods %v", got)
+	}
+	// the PDB allows a single disruption
+	if len(plan.Block) != 1 || plan.Block[0].Name != "web-b" || !strings.Contains(plan.Block[0].Reason, "PodDisruptionBudget web") {
+		t.Fatalf("expected the second web pod to be blocked by the PDB, got %+v", plan.Block)
+	}
+	if plan.Evict[0].Owner != "Deployment/web" || !reflect.DeepEqual(plan.Evict[0].PDBs, []string{"web"}) {
+		t.Fatalf("unexpected pod plan %+v", plan.Evict[0])
+	}
+
+	want := []WorkloadDisruption{
+		{Namespace: "default", Workload: "Deployment/web", Replicas: 3, Evicted: 1},
+		{Namespace: "default", Workload: "StatefulSet/db", Replicas: 2, Evicted: 1},
+	}
+	if !reflect.DeepEqual(plan.Workloads, want) {
+		t.Fatalf("expected %+v, got %+v", want, plan.Workloads)
+	}
+}
@@ -0,0 +1,57 @@
+package offline
+
+import (
+	"net/http"
+
+	kubeutil "github.com/lucasepe/kube/util"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+const (
+	contextName = "offline"
+	serverURL   = "http://offline.local"
+)
+
+// NewFactory returns a Factory whose clients are served by the store,
+// without any cluster access. Objects created, updated or deleted through
+// the Factory only live in the store. The default namespace is "default".
+func NewFactory(s *Store, opts ...kubeutil.FactoryOption) kubeutil.Factory {
+	cfg := clientcmdapi.NewConfig()
+	cfg.Clusters[contextName] = &clientcmdapi.Cluster{Server: serverURL}
+	cfg.AuthInfos[contextName] = &clientcmdapi.AuthInfo{}
+	cfg.Contexts[contextName] = &clientcmdapi.Context{
+		Cluster:   contextName,
+		AuthInfo:  contextName,
+		Namespace: "default",
+	}
+	cfg.CurrentContext = contextName
+
+	rt := &roundTripper{handler: s.Handler()}
+
+	opts = append([]kubeutil.FactoryOption{
+		kubeutil.WithClientConfig(clientcmd.NewDefaultClientConfig(*cfg, &clientcmd.ConfigOverrides{})),
+		kubeutil.WithMemoryDiscoveryCache(),
+		kubeutil.WithWrapTransport(func(http.RoundTripper) http.RoundTripper {
+			return rt
+		}),
+	}, opts...)
+
+	return kubeutil.NewFactory(contextName, "", opts...)
+}
+
+// NewFactoryFromDir returns a Factory backed by a store
+// loaded with the manifests found in dir.
+func NewFactoryFromDir(dir string, opts ...kubeutil.FactoryOption) (kubeutil.Factory, error) {
+	objs, err := LoadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := NewStore(objs...)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewFactory(s, opts...), nil
+}
@@ -0,0 +1,82 @@
+package offline
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// LoadDir reads all the YAML and JSON manifests found in dir (recursively).
+// Multi-document files and List kinds are expanded.
+func LoadDir(dir string) ([]*unstructured.Unstructured, error) {
+	res := []*unstructured.Unstructured{}
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".yaml", ".yml", ".json":
+		default:
+			return nil
+		}
+
+		fp, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer fp.Close()
+
+		objs, err := Decode(fp)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		res = append(res, objs...)
+		return nil
+	})
+	return res, err
+}
+
+// Decode reads the objects from a stream of YAML or JSON documents.
+func Decode(r io.Reader) ([]*unstructured.Unstructured, error) {
+	res := []*unstructured.Unstructured{}
+
+	dec := utilyaml.NewYAMLOrJSONDecoder(bufio.NewReader(r), 4096)
+	for {
+		obj := &unstructured.Unstructured{}
+		if err := dec.Decode(&obj.Object); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+		if len(obj.Object) == 0 {
+			continue
+		}
+
+		if !obj.IsList() {
+			res = append(res, obj)
+			continue
+		}
+
+		err := obj.EachListItem(func(o runtime.Object) error {
+			res = append(res, o.(*unstructured.Unstructured))
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return res, nil
+}
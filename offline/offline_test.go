@@ -0,0 +1,207 @@
+package offline
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	kube "github.com/lucasepe/kube/get"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+const manifests = `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+  namespace: demo
+  labels:
+    app: web
+data:
+  key: value
+---
+apiVersion: v1
+kind: List
+items:
+- apiVersion: apps/v1
+  kind: Deployment
+  metadata:
+    name: web
+    namespace: demo
+    labels:
+      app: web
+  spec:
+    replicas: 2
+    selector:
+      matchLabels:
+        app: web
+    template:
+      metadata:
+        labels:
+          app: web
+      spec:
+        containers:
+        - name: web
+          image: nginx
+- apiVersion: v1
+  kind: Namespace
+  metadata:
+    name: demo
+`
+
+func newTestStore(t *testing.T) *Store {
+	t.Helper()
+
+	objs, err := Decode(strings.NewReader(manifests))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objs) != 3 {
+		t.Fatalf("expected 3 objects, got %d", len(objs))
+	}
+
+	s, err := NewStore(objs...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestGet(t *testing.T) {
+	f := NewFactory(newTestStore(t))
+
+	objs, err := kube.Do(f, kube.Opts{
+		Resources:     []string{"deploy,cm"},
+		Namespace:     "demo",
+		LabelSelector: "app=web",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := []string{}
+	for _, o := range objs {
+		got = append(got, o.GetKind()+"/"+o.GetName())
+	}
+	if want := "Deployment/web ConfigMap/settings"; strings.Join(got, " ") != want {
+		t.Fatalf("expected %q, got %q", want, strings.Join(got, " "))
+	}
+}
+
+func TestMutationsAndWatch(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cli, err := NewFactory(newTestStore(t)).KubernetesClientSet()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := cli.CoreV1().ConfigMaps("demo").Watch(ctx, metav1.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Stop()
+
+	cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "extra"}, Data: map[string]string{"a": "b"}}
+	if _, err := cli.CoreV1().ConfigMaps("demo").Create(ctx, cm, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	patch := []byte(`{"data":{"a":"c"}}`)
+	if _, err := cli.CoreV1().ConfigMaps("demo").Patch(ctx, "extra", types.StrategicMergePatchType, patch, metav1.PatchOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cli.CoreV1().ConfigMaps("demo").Delete(ctx, "settings", metav1.DeleteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"ADDED/settings", "ADDED/extra", "MODIFIED/extra", "DELETED/settings"}
+	for _, exp := range want {
+		select {
+		case ev := <-w.ResultChan():
+			if ev.Type == watch.Error {
+				t.Fatalf("watch error: %v", ev.Object)
+			}
+			got := string(ev.Type) + "/" + ev.Object.(*corev1.ConfigMap).Name
+			if got != exp {
+				t.Fatalf("expected event %q, got %q", exp, got)
+			}
+		case <-ctx.Done():
+			t.Fatalf("timeout waiting for event %q", exp)
+		}
+	}
+
+	got, err := cli.CoreV1().ConfigMaps("demo").Get(ctx, "extra", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Data["a"] != "c" {
+		t.Fatalf("expected patched value 'c', got %q", got.Data["a"])
+	}
+}
+
+func TestConcurrentCreate(t *testing.T) {
+	s := newTestStore(t)
+	gr := schema.GroupResource{Resource: "configmaps"}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	created := 0
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			obj := &unstructured.Unstructured{}
+			obj.SetAPIVersion("v1")
+			obj.SetKind("ConfigMap")
+			obj.SetNamespace("demo")
+			obj.SetName("racy")
+			if _, err := s.create(gr, obj, false); err == nil {
+				mu.Lock()
+				created++
+				mu.Unlock()
+			} else if !apierrors.IsAlreadyExists(err) {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if created != 1 {
+		t.Fatalf("expected a single create to succeed, got %d", created)
+	}
+}
+
+func TestWatchInitialEvents(t *testing.T) {
+	objs := []*unstructured.Unstructured{}
+	for i := 0; i < watchBufferSize+10; i++ {
+		obj := &unstructured.Unstructured{}
+		obj.SetAPIVersion("v1")
+		obj.SetKind("ConfigMap")
+		obj.SetNamespace("demo")
+		obj.SetName(fmt.Sprintf("cm-%d", i))
+		objs = append(objs, obj)
+	}
+	s, err := NewStore(objs...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := s.watch(schema.GroupResource{Resource: "configmaps"}, "demo", nil, nil, -1)
+	defer s.stopWatch(w)
+
+	if got := len(w.ch); got != len(objs) {
+		t.Fatalf("expected %d initial events, got %d", len(objs), got)
+	}
+}
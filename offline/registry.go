@@ -0,0 +1,243 @@
+package offline
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/lucasepe/kube/scheme"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/version"
+)
+
+var (
+	allVerbs = metav1.Verbs{"create", "delete", "deletecollection", "get", "list", "patch", "update", "watch"}
+
+	// clusterScopedKinds are the built-in kinds that are not namespaced.
+	clusterScopedKinds = sets.NewString(
+		"APIService", "CertificateSigningRequest", "ClusterRole", "ClusterRoleBinding",
+		"ComponentStatus", "CSIDriver", "CSINode", "CustomResourceDefinition", "FlowSchema",
+		"IngressClass", "MutatingWebhookConfiguration", "Namespace", "Node", "PersistentVolume",
+		"PodSecurityPolicy", "PriorityClass", "PriorityLevelConfiguration", "RuntimeClass",
+		"StorageClass", "ValidatingWebhookConfiguration", "VolumeAttachment",
+	)
+
+	// virtualKinds are registered in the scheme but are not stored resources.
+	virtualKinds = sets.NewString(
+		"AdmissionReview", "Binding", "DeploymentRollback", "Eviction", "ImageReview",
+		"LocalSubjectAccessReview", "PodStatusResult", "RangeAllocation", "Scale",
+		"SelfSubjectAccessReview", "SelfSubjectRulesReview", "SerializedReference",
+		"SubjectAccessReview", "TokenRequest", "TokenReview",
+	)
+
+	// excludedGroups are legacy groups whose resources moved elsewhere.
+	excludedGroups = sets.NewString("extensions", "imagepolicy.k8s.io", "admission.k8s.io", "meta.k8s.io", "internal.apiserver.k8s.io")
+
+	shortNames = map[string][]string{
+		"configmaps": {"cm"}, "cronjobs": {"cj"}, "customresourcedefinitions": {"crd", "crds"},
+		"daemonsets": {"ds"}, "deployments": {"deploy"}, "endpoints": {"ep"}, "events": {"ev"},
+		"horizontalpodautoscalers": {"hpa"}, "ingresses": {"ing"}, "limitranges": {"limits"},
+		"namespaces": {"ns"}, "networkpolicies": {"netpol"}, "nodes": {"no"},
+		"persistentvolumeclaims": {"pvc"}, "persistentvolumes": {"pv"}, "poddisruptionbudgets": {"pdb"},
+		"pods": {"po"}, "replicasets": {"rs"}, "replicationcontrollers": {"rc"},
+		"resourcequotas": {"quota"}, "serviceaccounts": {"sa"}, "services": {"svc"},
+		"statefulsets": {"sts"}, "storageclasses": {"sc"},
+	}
+
+	allCategory = sets.NewString(
+		"cronjobs", "daemonsets", "deployments", "horizontalpodautoscalers", "jobs",
+		"pods", "replicasets", "replicationcontrollers", "services", "statefulsets",
+	)
+)
+
+var (
+	builtinOnce      sync.Once
+	builtinResources map[schema.GroupVersion][]metav1.APIResource
+	builtinOrder     []schema.GroupVersion
+)
+
+// builtins returns the resources of the preferred version of each
+// group registered in the scheme, in the discovery order.
+func builtins() (map[schema.GroupVersion][]metav1.APIResource, []schema.GroupVersion) {
+	builtinOnce.Do(func() {
+		builtinResources = map[schema.GroupVersion][]metav1.APIResource{}
+
+		// the most stable version of each group is the preferred one
+		latest := map[string]string{}
+		for gvk := range scheme.Scheme.AllKnownTypes() {
+			if excludedGroups.Has(gvk.Group) || gvk.Version == runtime.APIVersionInternal {
+				continue
+			}
+			if v, ok := latest[gvk.Group]; !ok || version.CompareKubeAwareVersionStrings(gvk.Version, v) > 0 {
+				latest[gvk.Group] = gvk.Version
+			}
+		}
+		for group, ver := range latest {
+			builtinOrder = append(builtinOrder, schema.GroupVersion{Group: group, Version: ver})
+		}
+		// the core group comes first, like on a real server
+		sort.Slice(builtinOrder, func(i, j int) bool {
+			return builtinOrder[i].Group < builtinOrder[j].Group
+		})
+
+		preferred := map[schema.GroupVersion]bool{}
+		for _, gv := range builtinOrder {
+			preferred[gv] = true
+		}
+
+		for gvk := range scheme.Scheme.AllKnownTypes() {
+			gv := gvk.GroupVersion()
+			if !preferred[gv] || strings.HasSuffix(gvk.Kind, "List") || virtualKinds.Has(gvk.Kind) {
+				continue
+			}
+			obj, err := scheme.Scheme.New(gvk)
+			if err != nil {
+				continue
+			}
+			if _, err := meta.Accessor(obj); err != nil {
+				continue
+			}
+			builtinResources[gv] = append(builtinResources[gv], newAPIResource(gvk, !clusterScopedKinds.Has(gvk.Kind)))
+		}
+
+		// drop the groups serving only virtual kinds
+		served := builtinOrder[:0]
+		for _, gv := range builtinOrder {
+			if list, ok := builtinResources[gv]; ok {
+				sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+				served = append(served, gv)
+			}
+		}
+		builtinOrder = served
+	})
+
+	return builtinResources, builtinOrder
+}
+
+func newAPIResource(gvk schema.GroupVersionKind, namespaced bool) metav1.APIResource {
+	plural, singular := meta.UnsafeGuessKindToResource(gvk)
+	res := metav1.APIResource{
+		Name:         plural.Resource,
+		SingularName: singular.Resource,
+		Namespaced:   namespaced,
+		Kind:         gvk.Kind,
+		Verbs:        allVerbs,
+		ShortNames:   shortNames[plural.Resource],
+	}
+	if allCategory.Has(plural.Resource) {
+		res.Categories = []string{"all"}
+	}
+	return res
+}
+
+// registry returns the resources served by the store: the built-in
+// ones, those declared by the stored CRDs and those inferred from
+// the stored objects of unknown kinds.
+func (s *Store) registry() (map[schema.GroupVersion][]metav1.APIResource, []schema.GroupVersion) {
+	base, order := builtins()
+
+	res := make(map[schema.GroupVersion][]metav1.APIResource, len(base))
+	for gv, list := range base {
+		res[gv] = append([]metav1.APIResource(nil), list...)
+	}
+	order = append([]schema.GroupVersion(nil), order...)
+
+	add := func(gv schema.GroupVersion, r metav1.APIResource) {
+		for _, it := range res[gv] {
+			if it.Name == r.Name {
+				return
+			}
+		}
+		if _, ok := res[gv]; !ok {
+			order = append(order, gv)
+		}
+		res[gv] = append(res[gv], r)
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	crdGR := schema.GroupResource{Group: "apiextensions.k8s.io", Resource: "customresourcedefinitions"}
+	for _, crd := range s.objects[crdGR] {
+		group, _, _ := unstructured.NestedString(crd.Object, "spec", "group")
+		scope, _, _ := unstructured.NestedString(crd.Object, "spec", "scope")
+		plural, _, _ := unstructured.NestedString(crd.Object, "spec", "names", "plural")
+		singular, _, _ := unstructured.NestedString(crd.Object, "spec", "names", "singular")
+		kind, _, _ := unstructured.NestedString(crd.Object, "spec", "names", "kind")
+		short, _, _ := unstructured.NestedStringSlice(crd.Object, "spec", "names", "shortNames")
+		categories, _, _ := unstructured.NestedStringSlice(crd.Object, "spec", "names", "categories")
+		versions, _, _ := unstructured.NestedSlice(crd.Object, "spec", "versions")
+		for _, v := range versions {
+			vm, ok := v.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if served, ok := vm["served"].(bool); ok && !served {
+				continue
+			}
+			name, _ := vm["name"].(string)
+			add(schema.GroupVersion{Group: group, Version: name}, metav1.APIResource{
+				Name:         plural,
+				SingularName: singular,
+				Namespaced:   scope != "Cluster",
+				Kind:         kind,
+				Verbs:        allVerbs,
+				ShortNames:   short,
+				Categories:   categories,
+			})
+		}
+	}
+
+	for gr, objs := range s.objects {
+		for _, obj := range objs {
+			gvk := obj.GroupVersionKind()
+			add(gvk.GroupVersion(), metav1.APIResource{
+				Name:       gr.Resource,
+				Namespaced: len(obj.GetNamespace()) > 0,
+				Kind:       gvk.Kind,
+				Verbs:      allVerbs,
+			})
+			break
+		}
+	}
+
+	return res, order
+}
+
+// resourceFor returns the resource name and scope of the kind.
+func (s *Store) resourceFor(gvk schema.GroupVersionKind) (metav1.APIResource, bool) {
+	reg, _ := s.registry()
+	for _, r := range reg[gvk.GroupVersion()] {
+		if r.Kind == gvk.Kind {
+			return r, true
+		}
+	}
+	// same kind served by another version of the group
+	for gv, list := range reg {
+		if gv.Group != gvk.Group {
+			continue
+		}
+		for _, r := range list {
+			if r.Kind == gvk.Kind {
+				return r, true
+			}
+		}
+	}
+	return metav1.APIResource{}, false
+}
+
+// kindFor returns the kind served at the group version resource.
+func (s *Store) kindFor(gvr schema.GroupVersionResource) (metav1.APIResource, bool) {
+	reg, _ := s.registry()
+	for _, r := range reg[gvr.GroupVersion()] {
+		if r.Name == gvr.Resource {
+			return r, true
+		}
+	}
+	return metav1.APIResource{}, false
+}
@@ -0,0 +1,465 @@
+package offline
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/lucasepe/kube/scheme"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	utiljson "k8s.io/apimachinery/pkg/util/json"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"k8s.io/apimachinery/pkg/version"
+	"sigs.k8s.io/yaml"
+)
+
+// Handler returns an http.Handler serving the store content following
+// the Kubernetes API conventions (discovery, get, list, watch, create,
+// update, patch and delete). Subresources other than status are not supported.
+func (s *Store) Handler() http.Handler {
+	return &server{store: s}
+}
+
+type server struct {
+	store *Store
+}
+
+func (srv *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(r.URL.Path, "/")
+	segs := strings.Split(path, "/")
+
+	switch {
+	case path == "version":
+		writeJSON(w, http.StatusOK, &version.Info{
+			Major:      "1",
+			Minor:      "25",
+			GitVersion: "v1.25.4-offline",
+			Platform:   "offline",
+		})
+	case path == "healthz" || path == "livez" || path == "readyz":
+		w.Write([]byte("ok"))
+	case path == "api":
+		writeJSON(w, http.StatusOK, &metav1.APIVersions{
+			TypeMeta: metav1.TypeMeta{Kind: "APIVersions"},
+			Versions: []string{"v1"},
+		})
+	case path == "apis":
+		srv.serveGroups(w)
+	case segs[0] == "api" && len(segs) == 2:
+		srv.serveResources(w, schema.GroupVersion{Version: segs[1]})
+	case segs[0] == "apis" && len(segs) == 3:
+		srv.serveResources(w, schema.GroupVersion{Group: segs[1], Version: segs[2]})
+	case segs[0] == "api" && len(segs) > 2:
+		srv.serveObjects(w, r, schema.GroupVersion{Version: segs[1]}, segs[2:])
+	case segs[0] == "apis" && len(segs) > 3:
+		srv.serveObjects(w, r, schema.GroupVersion{Group: segs[1], Version: segs[2]}, segs[3:])
+	default:
+		writeError(w, apierrors.NewNotFound(schema.GroupResource{}, r.URL.Path))
+	}
+}
+
+func (srv *server) serveGroups(w http.ResponseWriter) {
+	_, order := srv.store.registry()
+
+	list := &metav1.APIGroupList{TypeMeta: metav1.TypeMeta{Kind: "APIGroupList", APIVersion: "v1"}}
+	index := map[string]int{}
+	for _, gv := range order {
+		if len(gv.Group) == 0 {
+			continue
+		}
+		ver := metav1.GroupVersionForDiscovery{GroupVersion: gv.String(), Version: gv.Version}
+		i, ok := index[gv.Group]
+		if !ok {
+			index[gv.Group] = len(list.Groups)
+			list.Groups = append(list.Groups, metav1.APIGroup{Name: gv.Group, PreferredVersion: ver})
+			i = len(list.Groups) - 1
+		}
+		list.Groups[i].Versions = append(list.Groups[i].Versions, ver)
+	}
+
+	writeJSON(w, http.StatusOK, list)
+}
+
+func (srv *server) serveResources(w http.ResponseWriter, gv schema.GroupVersion) {
+	reg, _ := srv.store.registry()
+	resources, ok := reg[gv]
+	if !ok {
+		writeError(w, apierrors.NewNotFound(schema.GroupResource{}, gv.String()))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, &metav1.APIResourceList{
+		TypeMeta:     metav1.TypeMeta{Kind: "APIResourceList", APIVersion: "v1"},
+		GroupVersion: gv.String(),
+		APIResources: resources,
+	})
+}
+
+// request holds the coordinates of a resource request.
+type request struct {
+	gv          schema.GroupVersion
+	gr          schema.GroupResource
+	res         metav1.APIResource
+	namespace   string
+	name        string
+	subresource string
+	dryRun      bool
+}
+
+func (srv *server) serveObjects(w http.ResponseWriter, r *http.Request, gv schema.GroupVersion, segs []string) {
+	req := request{gv: gv, dryRun: len(r.URL.Query()["dryRun"]) > 0}
+
+	// namespaces/{ns}/{resource}, unless it is a namespace subresource
+	if len(segs) >= 3 && segs[0] == "namespaces" {
+		if _, ok := srv.store.kindFor(gv.WithResource(segs[2])); ok || len(segs) > 3 {
+			req.namespace, segs = segs[1], segs[2:]
+		}
+	}
+
+	res, ok := srv.store.kindFor(gv.WithResource(segs[0]))
+	if !ok {
+		writeError(w, apierrors.NewNotFound(schema.GroupResource{Group: gv.Group, Resource: segs[0]}, ""))
+		return
+	}
+	req.res = res
+	req.gr = schema.GroupResource{Group: gv.Group, Resource: res.Name}
+	if !res.Namespaced {
+		req.namespace = ""
+	}
+	if len(segs) > 1 {
+		req.name = segs[1]
+	}
+	if len(segs) > 2 {
+		req.subresource = strings.Join(segs[2:], "/")
+	}
+	if len(req.subresource) > 0 && req.subresource != "status" {
+		writeError(w, apierrors.NewMethodNotSupported(req.gr, req.subresource))
+		return
+	}
+
+	var (
+		obj runtime.Object
+		err error
+	)
+	code := http.StatusOK
+	switch {
+	case r.Method == http.MethodGet && len(req.name) == 0:
+		if v := r.URL.Query().Get("watch"); v == "true" || v == "1" {
+			srv.serveWatch(w, r, req)
+			return
+		}
+		obj, err = srv.list(r, req)
+	case r.Method == http.MethodGet:
+		obj, err = srv.store.get(req.gr, req.namespace, req.name)
+	case r.Method == http.MethodPost && len(req.name) == 0:
+		obj, err = srv.create(r, req)
+		code = http.StatusCreated
+	case r.Method == http.MethodPut && len(req.name) > 0:
+		obj, err = srv.update(r, req)
+	case r.Method == http.MethodPatch && len(req.name) > 0:
+		obj, err = srv.patch(r, req)
+	case r.Method == http.MethodDelete && len(req.name) > 0:
+		obj, err = srv.store.delete(req.gr, req.namespace, req.name, req.dryRun)
+	case r.Method == http.MethodDelete:
+		obj, err = srv.deleteCollection(r, req)
+	default:
+		err = apierrors.NewMethodNotSupported(req.gr, strings.ToLower(r.Method))
+	}
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if u, ok := obj.(*unstructured.Unstructured); ok {
+		u.SetAPIVersion(gv.String())
+	}
+	writeJSON(w, code, obj)
+}
+
+func (srv *server) selectors(r *http.Request) (labels.Selector, fields.Selector, error) {
+	ls, err := labels.Parse(r.URL.Query().Get("labelSelector"))
+	if err != nil {
+		return nil, nil, apierrors.NewBadRequest(err.Error())
+	}
+	fs, err := fields.ParseSelector(r.URL.Query().Get("fieldSelector"))
+	if err != nil {
+		return nil, nil, apierrors.NewBadRequest(err.Error())
+	}
+	return ls, fs, nil
+}
+
+func (srv *server) list(r *http.Request, req request) (runtime.Object, error) {
+	ls, fs, err := srv.selectors(r)
+	if err != nil {
+		return nil, err
+	}
+
+	items, rv := srv.store.list(req.gr, req.namespace, ls, fs)
+
+	list := &unstructured.UnstructuredList{Object: map[string]interface{}{
+		"apiVersion": req.gv.String(),
+		"kind":       req.res.Kind + "List",
+	}}
+	list.SetResourceVersion(rv)
+
+	// the continue token is simply the offset of the next chunk
+	offset, _ := strconv.Atoi(r.URL.Query().Get("continue"))
+	if offset > len(items) {
+		offset = len(items)
+	}
+	items = items[offset:]
+	if limit, _ := strconv.Atoi(r.URL.Query().Get("limit")); limit > 0 && limit < len(items) {
+		items = items[:limit]
+		list.SetContinue(strconv.Itoa(offset + limit))
+	}
+
+	for _, it := range items {
+		it.SetAPIVersion(req.gv.String())
+		list.Items = append(list.Items, *it)
+	}
+
+	return list, nil
+}
+
+func (srv *server) serveWatch(w http.ResponseWriter, r *http.Request, req request) {
+	ls, fs, err := srv.selectors(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	since := int64(-1)
+	if rv := r.URL.Query().Get("resourceVersion"); len(rv) > 0 && rv != "0" {
+		since, err = strconv.ParseInt(rv, 10, 64)
+		if err != nil {
+			writeError(w, apierrors.NewBadRequest(fmt.Sprintf("invalid resource version %q", rv)))
+			return
+		}
+	}
+
+	timeout := time.Hour
+	if secs, _ := strconv.Atoi(r.URL.Query().Get("timeoutSeconds")); secs > 0 {
+		timeout = time.Duration(secs) * time.Second
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	wt := srv.store.watch(req.gr, req.namespace, ls, fs, since)
+	defer srv.store.stopWatch(wt)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	flusher, _ := w.(http.Flusher)
+	if flusher != nil {
+		flusher.Flush()
+	}
+
+	enc := json.NewEncoder(w)
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-timer.C:
+			return
+		case ev, ok := <-wt.ch:
+			if !ok {
+				return
+			}
+			obj := ev.Object.(*unstructured.Unstructured)
+			obj.SetAPIVersion(req.gv.String())
+			raw, err := obj.MarshalJSON()
+			if err != nil {
+				return
+			}
+			err = enc.Encode(&metav1.WatchEvent{Type: string(ev.Type), Object: runtime.RawExtension{Raw: raw}})
+			if err != nil {
+				return
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+	}
+}
+
+// decode reads the request body, filling the namespace and type
+// information when missing.
+func (srv *server) decode(r *http.Request, req request) (*unstructured.Unstructured, error) {
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, apierrors.NewBadRequest(err.Error())
+	}
+	return srv.decodeData(data, req)
+}
+
+func (srv *server) decodeData(data []byte, req request) (*unstructured.Unstructured, error) {
+	obj := &unstructured.Unstructured{}
+	if err := utiljson.Unmarshal(data, &obj.Object); err != nil {
+		return nil, apierrors.NewBadRequest(err.Error())
+	}
+	if obj.Object == nil {
+		return nil, apierrors.NewBadRequest("empty request body")
+	}
+	if len(obj.GetAPIVersion()) == 0 {
+		obj.SetAPIVersion(req.gv.String())
+	}
+	if len(obj.GetKind()) == 0 {
+		obj.SetKind(req.res.Kind)
+	}
+	if obj.GetKind() != req.res.Kind {
+		return nil, apierrors.NewBadRequest(fmt.Sprintf("the kind %q does not match the resource %q", obj.GetKind(), req.gr))
+	}
+
+	if !req.res.Namespaced {
+		obj.SetNamespace("")
+	} else if len(obj.GetNamespace()) == 0 {
+		obj.SetNamespace(req.namespace)
+	} else if obj.GetNamespace() != req.namespace {
+		return nil, apierrors.NewBadRequest("the namespace of the provided object does not match the namespace sent on the request")
+	}
+
+	return obj, nil
+}
+
+func (srv *server) create(r *http.Request, req request) (runtime.Object, error) {
+	obj, err := srv.decode(r, req)
+	if err != nil {
+		return nil, err
+	}
+	obj.SetResourceVersion("")
+	obj.SetUID("")
+	return srv.store.create(req.gr, obj, req.dryRun)
+}
+
+func (srv *server) update(r *http.Request, req request) (runtime.Object, error) {
+	obj, err := srv.decode(r, req)
+	if err != nil {
+		return nil, err
+	}
+	if obj.GetName() != req.name {
+		return nil, apierrors.NewBadRequest(fmt.Sprintf("the name of the object (%s) does not match the name on the URL (%s)", obj.GetName(), req.name))
+	}
+	return srv.store.update(req.gr, obj, req.dryRun)
+}
+
+func (srv *server) patch(r *http.Request, req request) (runtime.Object, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, apierrors.NewBadRequest(err.Error())
+	}
+
+	patchType := types.PatchType(r.Header.Get("Content-Type"))
+	if patchType == types.ApplyPatchType {
+		if body, err = yaml.YAMLToJSON(body); err != nil {
+			return nil, apierrors.NewBadRequest(err.Error())
+		}
+	}
+
+	cur, err := srv.store.get(req.gr, req.namespace, req.name)
+	if apierrors.IsNotFound(err) && patchType == types.ApplyPatchType {
+		obj, err := srv.decodeData(body, req)
+		if err != nil {
+			return nil, err
+		}
+		obj.SetName(req.name)
+		return srv.store.create(req.gr, obj, req.dryRun)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	original, err := cur.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+
+	var patched []byte
+	switch patchType {
+	case types.JSONPatchType:
+		var p jsonpatch.Patch
+		if p, err = jsonpatch.DecodePatch(body); err == nil {
+			patched, err = p.Apply(original)
+		}
+	case types.MergePatchType, types.ApplyPatchType:
+		patched, err = jsonpatch.MergePatch(original, body)
+	case types.StrategicMergePatchType:
+		// custom resources have no patch strategy, fallback to merge
+		typed, serr := scheme.Scheme.New(cur.GroupVersionKind())
+		if serr != nil {
+			patched, err = jsonpatch.MergePatch(original, body)
+		} else {
+			patched, err = strategicpatch.StrategicMergePatch(original, body, typed)
+		}
+	default:
+		return nil, apierrors.NewBadRequest(fmt.Sprintf("unsupported patch type %q", patchType))
+	}
+	if err != nil {
+		return nil, apierrors.NewBadRequest(err.Error())
+	}
+
+	obj, err := srv.decodeData(patched, req)
+	if err != nil {
+		return nil, err
+	}
+	return srv.store.update(req.gr, obj, req.dryRun)
+}
+
+func (srv *server) deleteCollection(r *http.Request, req request) (runtime.Object, error) {
+	ls, fs, err := srv.selectors(r)
+	if err != nil {
+		return nil, err
+	}
+
+	items, _ := srv.store.list(req.gr, req.namespace, ls, fs)
+
+	list := &unstructured.UnstructuredList{Object: map[string]interface{}{
+		"apiVersion": req.gv.String(),
+		"kind":       req.res.Kind + "List",
+	}}
+	for _, it := range items {
+		obj, err := srv.store.delete(req.gr, it.GetNamespace(), it.GetName(), req.dryRun)
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		list.Items = append(list.Items, *obj)
+	}
+
+	return list, nil
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		code = http.StatusInternalServerError
+		data, _ = json.Marshal(apierrors.NewInternalError(err).Status())
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	w.Write(data)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	var st metav1.Status
+	if se, ok := err.(apierrors.APIStatus); ok {
+		st = se.Status()
+	} else {
+		st = apierrors.NewInternalError(err).Status()
+	}
+	st.Kind, st.APIVersion = "Status", "v1"
+	writeJSON(w, int(st.Code), &st)
+}
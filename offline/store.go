@@ -0,0 +1,359 @@
+package offline
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+const (
+	watchBufferSize = 1024
+)
+
+// Store is an in-memory object store that backs the offline Factory.
+// It is safe for concurrent use.
+type Store struct {
+	mu       sync.RWMutex
+	objects  map[schema.GroupResource]map[string]*unstructured.Unstructured
+	rv       int64
+	watchers map[*watcher]bool
+}
+
+// NewStore returns a Store holding the given objects.
+func NewStore(objs ...*unstructured.Unstructured) (*Store, error) {
+	s := &Store{
+		objects:  map[schema.GroupResource]map[string]*unstructured.Unstructured{},
+		watchers: map[*watcher]bool{},
+	}
+	if err := s.Add(objs...); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Add stores (or replaces) the objects, as if they were created on a cluster.
+func (s *Store) Add(objs ...*unstructured.Unstructured) error {
+	for _, obj := range objs {
+		gr, namespaced, err := s.groupResourceOf(obj)
+		if err != nil {
+			return err
+		}
+		if namespaced && len(obj.GetNamespace()) == 0 {
+			obj = obj.DeepCopy()
+			obj.SetNamespace(metav1.NamespaceDefault)
+		}
+		if _, err := s.upsert(gr, obj, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Objects returns a copy of all the stored objects, sorted by
+// group, resource, namespace and name.
+func (s *Store) Objects() []*unstructured.Unstructured {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	grs := make([]schema.GroupResource, 0, len(s.objects))
+	for gr := range s.objects {
+		grs = append(grs, gr)
+	}
+	sort.Slice(grs, func(i, j int) bool { return grs[i].String() < grs[j].String() })
+
+	res := []*unstructured.Unstructured{}
+	for _, gr := range grs {
+		keys := make([]string, 0, len(s.objects[gr]))
+		for k := range s.objects[gr] {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			res = append(res, s.objects[gr][k].DeepCopy())
+		}
+	}
+	return res
+}
+
+func (s *Store) groupResourceOf(obj *unstructured.Unstructured) (schema.GroupResource, bool, error) {
+	gvk := obj.GroupVersionKind()
+	if len(gvk.Kind) == 0 || len(gvk.Version) == 0 {
+		return schema.GroupResource{}, false, fmt.Errorf("object %q has no apiVersion or kind", obj.GetName())
+	}
+	if r, ok := s.resourceFor(gvk); ok {
+		return schema.GroupResource{Group: gvk.Group, Resource: r.Name}, r.Namespaced, nil
+	}
+	plural, _ := meta.UnsafeGuessKindToResource(gvk)
+	return plural.GroupResource(), len(obj.GetNamespace()) > 0, nil
+}
+
+func objectKey(namespace, name string) string {
+	if len(namespace) == 0 {
+		return name
+	}
+	return namespace + "/" + name
+}
+
+func (s *Store) get(gr schema.GroupResource, namespace, name string) (*unstructured.Unstructured, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	obj, ok := s.objects[gr][objectKey(namespace, name)]
+	if !ok {
+		return nil, apierrors.NewNotFound(gr, name)
+	}
+	return obj.DeepCopy(), nil
+}
+
+func (s *Store) list(gr schema.GroupResource, namespace string, ls labels.Selector, fs fields.Selector) ([]*unstructured.Unstructured, string) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	res := []*unstructured.Unstructured{}
+	for _, obj := range s.objects[gr] {
+		if matches(obj, namespace, ls, fs) {
+			res = append(res, obj.DeepCopy())
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return objectKey(res[i].GetNamespace(), res[i].GetName()) < objectKey(res[j].GetNamespace(), res[j].GetName())
+	})
+	return res, strconv.FormatInt(s.rv, 10)
+}
+
+// create stores a new object; fails if it already exists.
+func (s *Store) create(gr schema.GroupResource, obj *unstructured.Unstructured, dryRun bool) (*unstructured.Unstructured, error) {
+	if len(obj.GetName()) == 0 && len(obj.GetGenerateName()) > 0 {
+		obj.SetName(obj.GetGenerateName() + string(uuid.NewUUID())[:5])
+	}
+	if len(obj.GetName()) == 0 {
+		return nil, apierrors.NewBadRequest("name or generateName is required")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.objects[gr][objectKey(obj.GetNamespace(), obj.GetName())]; exists {
+		return nil, apierrors.NewAlreadyExists(gr, obj.GetName())
+	}
+
+	return s.upsertLocked(gr, obj, dryRun)
+}
+
+// update replaces an existing object, honoring the resourceVersion precondition.
+func (s *Store) update(gr schema.GroupResource, obj *unstructured.Unstructured, dryRun bool) (*unstructured.Unstructured, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cur, ok := s.objects[gr][objectKey(obj.GetNamespace(), obj.GetName())]
+	if !ok {
+		return nil, apierrors.NewNotFound(gr, obj.GetName())
+	}
+	if rv := obj.GetResourceVersion(); len(rv) > 0 && rv != cur.GetResourceVersion() {
+		return nil, apierrors.NewConflict(gr, obj.GetName(),
+			fmt.Errorf("the object has been modified; please apply your changes to the latest version and try again"))
+	}
+
+	obj.SetUID(cur.GetUID())
+	obj.SetCreationTimestamp(cur.GetCreationTimestamp())
+	obj.SetGeneration(cur.GetGeneration())
+	if !equalField(cur, obj, "spec") {
+		obj.SetGeneration(cur.GetGeneration() + 1)
+	}
+
+	return s.upsertLocked(gr, obj, dryRun)
+}
+
+func (s *Store) upsert(gr schema.GroupResource, obj *unstructured.Unstructured, dryRun bool) (*unstructured.Unstructured, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.upsertLocked(gr, obj, dryRun)
+}
+
+// upsertLocked must be called holding the lock.
+func (s *Store) upsertLocked(gr schema.GroupResource, obj *unstructured.Unstructured, dryRun bool) (*unstructured.Unstructured, error) {
+	obj = obj.DeepCopy()
+
+	key := objectKey(obj.GetNamespace(), obj.GetName())
+	prev, exists := s.objects[gr][key]
+
+	if len(obj.GetUID()) == 0 {
+		obj.SetUID(uuid.NewUUID())
+	}
+	if ts := obj.GetCreationTimestamp(); ts.IsZero() {
+		obj.SetCreationTimestamp(metav1.NewTime(time.Now().Truncate(time.Second)))
+	}
+	if obj.GetGeneration() == 0 {
+		obj.SetGeneration(1)
+	}
+
+	if dryRun {
+		return obj, nil
+	}
+
+	s.rv++
+	obj.SetResourceVersion(strconv.FormatInt(s.rv, 10))
+
+	if _, ok := s.objects[gr]; !ok {
+		s.objects[gr] = map[string]*unstructured.Unstructured{}
+	}
+	s.objects[gr][key] = obj
+
+	evType := watch.Added
+	if exists && prev != nil {
+		evType = watch.Modified
+	}
+	s.notify(gr, evType, obj)
+
+	return obj.DeepCopy(), nil
+}
+
+func (s *Store) delete(gr schema.GroupResource, namespace, name string, dryRun bool) (*unstructured.Unstructured, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := objectKey(namespace, name)
+	obj, ok := s.objects[gr][key]
+	if !ok {
+		return nil, apierrors.NewNotFound(gr, name)
+	}
+	if dryRun {
+		return obj.DeepCopy(), nil
+	}
+
+	delete(s.objects[gr], key)
+	s.rv++
+	obj.SetResourceVersion(strconv.FormatInt(s.rv, 10))
+	s.notify(gr, watch.Deleted, obj)
+
+	return obj.DeepCopy(), nil
+}
+
+type watcher struct {
+	gr        schema.GroupResource
+	namespace string
+	ls        labels.Selector
+	fs        fields.Selector
+	ch        chan watch.Event
+}
+
+// watch registers a watcher; the stored objects changed after the
+// resource version since are sent first (all of them if since < 0).
+// No history is kept, so deletions that happened before are not replayed.
+func (s *Store) watch(gr schema.GroupResource, namespace string, ls labels.Selector, fs fields.Selector, since int64) *watcher {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	evType := watch.Added
+	if since >= 0 {
+		evType = watch.Modified
+	}
+	initial := []watch.Event{}
+	for _, obj := range s.objects[gr] {
+		if !matches(obj, namespace, ls, fs) {
+			continue
+		}
+		if rv, _ := strconv.ParseInt(obj.GetResourceVersion(), 10, 64); rv > since {
+			initial = append(initial, watch.Event{Type: evType, Object: obj.DeepCopy()})
+		}
+	}
+
+	// the buffer holds all the initial events, leaving
+	// watchBufferSize room for the changes that follow
+	w := &watcher{
+		gr:        gr,
+		namespace: namespace,
+		ls:        ls,
+		fs:        fs,
+		ch:        make(chan watch.Event, len(initial)+watchBufferSize),
+	}
+	for _, ev := range initial {
+		w.ch <- ev
+	}
+	s.watchers[w] = true
+
+	return w
+}
+
+func (s *Store) stopWatch(w *watcher) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.watchers[w] {
+		delete(s.watchers, w)
+		close(w.ch)
+	}
+}
+
+// notify must be called holding the lock. Watchers too slow to keep up
+// are closed, so that their clients re-list.
+func (s *Store) notify(gr schema.GroupResource, evType watch.EventType, obj *unstructured.Unstructured) {
+	for w := range s.watchers {
+		if w.gr != gr || !matches(obj, w.namespace, w.ls, w.fs) {
+			continue
+		}
+		select {
+		case w.ch <- watch.Event{Type: evType, Object: obj.DeepCopy()}:
+		default:
+			delete(s.watchers, w)
+			close(w.ch)
+		}
+	}
+}
+
+func matches(obj *unstructured.Unstructured, namespace string, ls labels.Selector, fs fields.Selector) bool {
+	if len(namespace) > 0 && obj.GetNamespace() != namespace {
+		return false
+	}
+	if ls != nil && !ls.Matches(labels.Set(obj.GetLabels())) {
+		return false
+	}
+	if fs != nil && !fs.Empty() {
+		set := fields.Set{}
+		for _, req := range fs.Requirements() {
+			val, _, _ := unstructured.NestedFieldNoCopy(obj.Object, splitFieldPath(req.Field)...)
+			set[req.Field] = fmt.Sprint(valueOrEmpty(val))
+		}
+		if !fs.Matches(set) {
+			return false
+		}
+	}
+	return true
+}
+
+func valueOrEmpty(v interface{}) interface{} {
+	if v == nil {
+		return ""
+	}
+	return v
+}
+
+func splitFieldPath(field string) []string {
+	res := []string{}
+	start := 0
+	for i := 0; i < len(field); i++ {
+		if field[i] == '.' {
+			res = append(res, field[start:i])
+			start = i + 1
+		}
+	}
+	return append(res, field[start:])
+}
+
+func equalField(a, b *unstructured.Unstructured, field string) bool {
+	va, _, _ := unstructured.NestedFieldNoCopy(a.Object, field)
+	vb, _, _ := unstructured.NestedFieldNoCopy(b.Object, field)
+	return fmt.Sprint(va) == fmt.Sprint(vb)
+}
@@ -0,0 +1,96 @@
+package offline
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// roundTripper serves the requests in process, without any network
+// connection. The response is returned as soon as the handler writes
+// the headers, so that streaming responses (i.e. watches) work.
+type roundTripper struct {
+	handler http.Handler
+}
+
+func (rt *roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, cancel := context.WithCancel(req.Context())
+
+	in := req.Clone(ctx)
+	if in.Body == nil {
+		in.Body = http.NoBody
+	}
+
+	pr, pw := io.Pipe()
+	rw := &pipeResponseWriter{
+		header: http.Header{},
+		pw:     pw,
+		ready:  make(chan struct{}),
+	}
+
+	go func() {
+		defer pw.Close()
+		defer rw.WriteHeader(http.StatusOK)
+		rt.handler.ServeHTTP(rw, in)
+	}()
+
+	select {
+	case <-rw.ready:
+	case <-req.Context().Done():
+		cancel()
+		return nil, req.Context().Err()
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", rw.code, http.StatusText(rw.code)),
+		StatusCode:    rw.code,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        rw.header,
+		Body:          &pipeBody{PipeReader: pr, cancel: cancel},
+		ContentLength: -1,
+		Request:       req,
+	}, nil
+}
+
+// pipeBody stops the handler when the client closes the response body.
+type pipeBody struct {
+	*io.PipeReader
+	cancel context.CancelFunc
+}
+
+func (b *pipeBody) Close() error {
+	b.cancel()
+	return b.PipeReader.Close()
+}
+
+type pipeResponseWriter struct {
+	header http.Header
+	pw     *io.PipeWriter
+	code   int
+	once   sync.Once
+	ready  chan struct{}
+}
+
+func (w *pipeResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *pipeResponseWriter) WriteHeader(code int) {
+	w.once.Do(func() {
+		w.code = code
+		w.header = w.header.Clone()
+		close(w.ready)
+	})
+}
+
+func (w *pipeResponseWriter) Write(data []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	return w.pw.Write(data)
+}
+
+// Flush is a no-op: every write is delivered to the reader.
+func (w *pipeResponseWriter) Flush() {}
@@ -0,0 +1,114 @@
+package patch
+
+import (
+	"context"
+	"fmt"
+
+	kubeutil "github.com/lucasepe/kube/util"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/cli-runtime/pkg/resource"
+)
+
+// Opts is a set of options that allows you to patch resources.
+type Opts struct {
+	// Type is the patch type: types.StrategicMergePatchType (default),
+	// types.MergePatchType or types.JSONPatchType.
+	Type types.PatchType
+	// Patch is the patch document, in JSON or YAML.
+	Patch []byte
+
+	Resources     []string
+	LabelSelector string
+	AllNamespaces bool
+	Namespace     string
+	// Subresource to patch (e.g. "status" or "scale").
+	Subresource string
+
+	FieldManager string
+	DryRun       bool
+}
+
+// Do patches the selected resources returning the patched objects.
+// Failures do not stop the other patches; all the errors are returned at the end.
+func Do(f kubeutil.Factory, o Opts) ([]*unstructured.Unstructured, error) {
+	return DoContext(context.Background(), f, o)
+}
+
+// DoContext is like Do but stops when the context is cancelled.
+func DoContext(ctx context.Context, f kubeutil.Factory, o Opts) ([]*unstructured.Unstructured, error) {
+	if len(o.Type) == 0 {
+		o.Type = types.StrategicMergePatchType
+	}
+	switch o.Type {
+	case types.StrategicMergePatchType, types.MergePatchType, types.JSONPatchType:
+	default:
+		return nil, fmt.Errorf("unsupported patch type %q", o.Type)
+	}
+
+	if !o.AllNamespaces && len(o.Namespace) == 0 {
+		ns, _, err := f.ToRawKubeConfigLoader().Namespace()
+		if err != nil {
+			return nil, err
+		}
+		o.Namespace = ns
+	}
+
+	data, err := yaml.ToJSON(o.Patch)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse the patch: %w", err)
+	}
+
+	r := f.NewBuilder().
+		Unstructured().
+		ContinueOnError().
+		NamespaceParam(o.Namespace).DefaultNamespace().AllNamespaces(o.AllNamespaces).
+		LabelSelectorParam(o.LabelSelector).
+		Subresource(o.Subresource).
+		ResourceTypeOrNameArgs(false, o.Resources...).
+		Flatten().
+		Do()
+	if err := r.Err(); err != nil {
+		return nil, err
+	}
+
+	return patchAll(ctx, r, o, data)
+}
+
+// patchAll applies the JSON patch data to the objects of the visitor.
+func patchAll(ctx context.Context, v resource.Visitor, o Opts, data []byte) ([]*unstructured.Unstructured, error) {
+	objs := []*unstructured.Unstructured{}
+	errs := []error{}
+	err := v.Visit(func(info *resource.Info, err error) error {
+		if err != nil {
+			errs = append(errs, err)
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		obj, err := resource.NewHelper(info.Client, info.Mapping).
+			WithFieldManager(o.FieldManager).
+			WithSubresource(o.Subresource).
+			DryRun(o.DryRun).
+			Patch(info.Namespace, info.Name, o.Type, data, &metav1.PatchOptions{})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("unable to patch %s: %w", info.ObjectName(), err))
+			return nil
+		}
+
+		if u, ok := obj.(*unstructured.Unstructured); ok {
+			objs = append(objs, u)
+		}
+		return nil
+	})
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	return objs, utilerrors.NewAggregate(errs)
+}
@@ -0,0 +1,139 @@
+package patch
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/client-go/rest/fake"
+)
+
+type request struct {
+	method      string
+	path        string
+	contentType string
+	query       map[string][]string
+	body        string
+}
+
+// deploymentInfo returns the info of a deployment whose patch
+// requests are answered with the given code and recorded in reqs.
+func deploymentInfo(t *testing.T, code int, reqs *[]request) *resource.Info {
+	t.Helper()
+
+	client := &fake.RESTClient{
+		GroupVersion:         appsv1.SchemeGroupVersion,
+		NegotiatedSerializer: resource.UnstructuredPlusDefaultContentConfig().NegotiatedSerializer,
+		Client: fake.CreateHTTPClient(func(req *http.Request) (*http.Response, error) {
+			body, err := io.ReadAll(req.Body)
+			if err != nil {
+				return nil, err
+			}
+			*reqs = append(*reqs, request{
+				method:      req.Method,
+				path:        req.URL.Path,
+				contentType: req.Header.Get("Content-Type"),
+				query:       req.URL.Query(),
+				body:        string(body),
+			})
+
+			res := `{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"name":"web","namespace":"default"},"spec":{"replicas":3}}`
+			if code != http.StatusOK {
+				res = `{"apiVersion":"v1","kind":"Status","status":"Failure","reason":"NotFound","code":404}`
+			}
+			return &http.Response{
+				StatusCode: code,
+				Header:     http.Header{"Content-Type": []string{runtime.ContentTypeJSON}},
+				Body:       io.NopCloser(bytes.NewReader([]byte(res))),
+			}, nil
+		}),
+	}
+
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion("apps/v1")
+	obj.SetKind("Deployment")
+	obj.SetNamespace("default")
+	obj.SetName("web")
+
+	return &resource.Info{
+		Client:    client,
+		Namespace: "default",
+		Name:      "web",
+		Object:    obj,
+		Mapping: &meta.RESTMapping{
+			Resource: appsv1.SchemeGroupVersion.WithResource("deployments"),
+			Scope:    meta.RESTScopeNamespace,
+		},
+	}
+}
+
+func TestPatchAll(t *testing.T) {
+	var reqs []request
+	v := resource.InfoListVisitor{deploymentInfo(t, http.StatusOK, &reqs)}
+
+	data := []byte(`{"spec":{"replicas":3}}`)
+	objs, err := patchAll(context.Background(), v, Opts{
+		Type:         types.MergePatchType,
+		Subresource:  "scale",
+		FieldManager: "tests",
+	}, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objs) != 1 {
+		t.Fatalf("expected the patched object, got %v", objs)
+	}
+	if replicas, _, _ := unstructured.NestedInt64(objs[0].Object, "spec", "replicas"); replicas != 3 {
+		t.Fatalf("expected the object returned by the server, got %v", objs[0])
+	}
+
+	if len(reqs) != 1 {
+		t.Fatalf("expected a patch request, got %d", len(reqs))
+	}
+	req := reqs[0]
+	if req.method != http.MethodPatch || req.path != "/namespaces/default/deployments/web/scale" {
+		t.Fatalf("unexpected request %s %s", req.method, req.path)
+	}
+	if req.contentType != string(types.MergePatchType) {
+		t.Fatalf("unexpected content type %q", req.contentType)
+	}
+	if req.body != string(data) {
+		t.Fatalf("unexpected body %q", req.body)
+	}
+	if fm := req.query["fieldManager"]; len(fm) != 1 || fm[0] != "tests" {
+		t.Fatalf("expected the field manager, got %v", req.query)
+	}
+}
+
+func TestPatchAllContinueOnError(t *testing.T) {
+	var reqs []request
+	v := resource.InfoListVisitor{
+		deploymentInfo(t, http.StatusNotFound, &reqs),
+		deploymentInfo(t, http.StatusOK, &reqs),
+	}
+
+	objs, err := patchAll(context.Background(), v, Opts{Type: types.StrategicMergePatchType, DryRun: true}, []byte(`{}`))
+	if err == nil {
+		t.Fatal("expected the not found error")
+	}
+	if len(reqs) != 2 || len(objs) != 1 {
+		t.Fatalf("expected both the patches to be sent and one to succeed, got %d requests and %v", len(reqs), objs)
+	}
+	if dr := reqs[1].query["dryRun"]; len(dr) != 1 || dr[0] != "All" {
+		t.Fatalf("expected the dry run, got %v", reqs[1].query)
+	}
+}
+
+func TestDoUnsupportedType(t *testing.T) {
+	if _, err := Do(nil, Opts{Type: types.ApplyPatchType}); err == nil {
+		t.Fatal("expected an error for the apply patch type")
+	}
+}
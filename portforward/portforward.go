@@ -0,0 +1,248 @@
+package portforward
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	kubeutil "github.com/lucasepe/kube/util"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/portforward"
+	"k8s.io/client-go/transport/spdy"
+)
+
+const (
+	defaultPodPortForwardTimeout = 60 * time.Second
+)
+
+// Opts is a set of options that allows you to forward local ports to a pod.
+type Opts struct {
+	Namespace string
+	PodName   string
+	// Object is a pod, a workload or a service (the first of the selected
+	// pods is used); when set, PodName is ignored. The ports of a service
+	// are translated to the target ports of the pod.
+	Object runtime.Object
+	// Ports are in the form "[local:]remote"; a local port of 0
+	// (e.g. ":8080") binds a random free port.
+	Ports []string
+	// Addresses to listen on (default "localhost").
+	Addresses []string
+
+	// Out and ErrOut receive the forwarder messages (discarded if nil).
+	Out    io.Writer
+	ErrOut io.Writer
+
+	GetPodTimeout time.Duration
+}
+
+// Forwarder forwards local ports to a pod.
+type Forwarder struct {
+	// Pod is the target of the port forward.
+	Pod *corev1.Pod
+
+	fw       *portforward.PortForwarder
+	stopCh   chan struct{}
+	stopOnce sync.Once
+	readyCh  chan struct{}
+}
+
+// New resolves the target pod and prepares the port forward.
+// Call ForwardPorts to start it.
+func New(f kubeutil.Factory, o Opts) (*Forwarder, error) {
+	return NewContext(context.Background(), f, o)
+}
+
+// NewContext is like New but stops when the context is cancelled.
+func NewContext(ctx context.Context, f kubeutil.Factory, o Opts) (*Forwarder, error) {
+	if len(o.Ports) == 0 {
+		return nil, fmt.Errorf("at least 1 port is required for port-forward")
+	}
+	if o.GetPodTimeout <= 0 {
+		o.GetPodTimeout = defaultPodPortForwardTimeout
+	}
+	if len(o.Addresses) == 0 {
+		o.Addresses = []string{"localhost"}
+	}
+	if o.Out == nil {
+		o.Out = io.Discard
+	}
+	if o.ErrOut == nil {
+		o.ErrOut = io.Discard
+	}
+	if len(o.Namespace) == 0 {
+		ns, _, err := f.ToRawKubeConfigLoader().Namespace()
+		if err != nil {
+			return nil, err
+		}
+		o.Namespace = ns
+	}
+
+	cli, err := f.KubernetesClientSet()
+	if err != nil {
+		return nil, err
+	}
+
+	obj := o.Object
+	if obj == nil {
+		obj, err = cli.CoreV1().Pods(o.Namespace).Get(ctx, o.PodName, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	pod, err := kubeutil.AttachablePodForObjectContext(ctx, cli.CoreV1(), obj, o.GetPodTimeout)
+	if err != nil {
+		return nil, err
+	}
+	if pod.Status.Phase != corev1.PodRunning {
+		return nil, fmt.Errorf("unable to forward port because pod is not running. Current status=%v", pod.Status.Phase)
+	}
+
+	ports := o.Ports
+	if svc, ok := obj.(*corev1.Service); ok {
+		if ports, err = translateServicePorts(svc, pod, o.Ports); err != nil {
+			return nil, err
+		}
+	}
+
+	cfg, err := f.ToRESTConfig()
+	if err != nil {
+		return nil, err
+	}
+	transport, upgrader, err := spdy.RoundTripperFor(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	req := cli.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(pod.Namespace).
+		Name(pod.Name).
+		SubResource("portforward")
+	dialer := spdy.NewDialer(upgrader, &http.Client{Transport: transport}, http.MethodPost, req.URL())
+
+	res := &Forwarder{
+		Pod:     pod,
+		stopCh:  make(chan struct{}),
+		readyCh: make(chan struct{}),
+	}
+	res.fw, err = portforward.NewOnAddresses(dialer, o.Addresses, ports, res.stopCh, res.readyCh, o.Out, o.ErrOut)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// ForwardPorts starts the port forward and blocks until Stop is called
+// or the connection to the pod is lost.
+func (fw *Forwarder) ForwardPorts() error {
+	return fw.fw.ForwardPorts()
+}
+
+// Ready is closed when the local ports are listening.
+func (fw *Forwarder) Ready() <-chan struct{} {
+	return fw.readyCh
+}
+
+// Stop terminates the port forward. It is safe to call it more than once.
+func (fw *Forwarder) Stop() {
+	fw.stopOnce.Do(func() { close(fw.stopCh) })
+}
+
+// Ports returns the forwarded ports, with the actual local ports
+// bound when random ones were requested. Valid once Ready is closed.
+func (fw *Forwarder) Ports() ([]portforward.ForwardedPort, error) {
+	return fw.fw.GetPorts()
+}
+
+// Do forwards the ports until the stop channel is closed. The ready
+// channel, if not nil, is closed when the local ports are listening.
+func Do(f kubeutil.Factory, o Opts, stopCh <-chan struct{}, readyCh chan<- struct{}) error {
+	return DoContext(context.Background(), f, o, stopCh, readyCh)
+}
+
+// DoContext is like Do but stops also when the context is cancelled.
+func DoContext(ctx context.Context, f kubeutil.Factory, o Opts, stopCh <-chan struct{}, readyCh chan<- struct{}) error {
+	fw, err := NewContext(ctx, f, o)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		select {
+		case <-stopCh:
+			fw.Stop()
+		case <-ctx.Done():
+			fw.Stop()
+		case <-fw.stopCh:
+		}
+	}()
+	if readyCh != nil {
+		go func() {
+			select {
+			case <-fw.Ready():
+				close(readyCh)
+			case <-fw.stopCh:
+			}
+		}()
+	}
+
+	defer fw.Stop()
+	return fw.ForwardPorts()
+}
+
+// translateServicePorts maps the service ports to the target ports
+// of the pod, keeping the service port as local port if unspecified.
+func translateServicePorts(svc *corev1.Service, pod *corev1.Pod, ports []string) ([]string, error) {
+	res := make([]string, 0, len(ports))
+	for _, p := range ports {
+		local, remote := p, p
+		if parts := strings.SplitN(p, ":", 2); len(parts) == 2 {
+			local, remote = parts[0], parts[1]
+		}
+
+		port, err := strconv.ParseInt(remote, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid port %q: %w", remote, err)
+		}
+
+		target, err := containerPortForServicePort(svc, pod, int32(port))
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, fmt.Sprintf("%s:%d", local, target))
+	}
+	return res, nil
+}
+
+func containerPortForServicePort(svc *corev1.Service, pod *corev1.Pod, port int32) (int32, error) {
+	for _, sp := range svc.Spec.Ports {
+		if sp.Port != port {
+			continue
+		}
+		if sp.TargetPort.IntValue() > 0 {
+			return int32(sp.TargetPort.IntValue()), nil
+		}
+		if len(sp.TargetPort.StrVal) == 0 {
+			return port, nil
+		}
+		for _, c := range pod.Spec.Containers {
+			for _, cp := range c.Ports {
+				if cp.Name == sp.TargetPort.StrVal {
+					return cp.ContainerPort, nil
+				}
+			}
+		}
+		return 0, fmt.Errorf("cannot find the container port named %q in pod %s", sp.TargetPort.StrVal, pod.Name)
+	}
+	return 0, fmt.Errorf("service %s does not have a service port %d", svc.Name, port)
+}
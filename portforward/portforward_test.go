@@ -0,0 +1,58 @@
+package portforward
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestTranslateServicePorts(t *testing.T) {
+	svc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "web"},
+		Spec: corev1.ServiceSpec{
+			Ports: []corev1.ServicePort{
+				{Port: 80, TargetPort: intstr.FromInt(8080)},
+				{Port: 443, TargetPort: intstr.FromString("https")},
+				{Port: 9090},
+			},
+		},
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "web-0"},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{
+				Name:  "app",
+				Ports: []corev1.ContainerPort{{Name: "https", ContainerPort: 8443}},
+			}},
+		},
+	}
+
+	got, err := translateServicePorts(svc, pod, []string{"80", "5000:443", ":9090"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"80:8080", "5000:8443", ":9090"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+
+	for _, ports := range [][]string{{"8080"}, {"http"}} {
+		if _, err := translateServicePorts(svc, pod, ports); err == nil {
+			t.Errorf("expected an error for %v", ports)
+		}
+	}
+
+	pod.Spec.Containers[0].Ports = nil
+	if _, err := translateServicePorts(svc, pod, []string{"443"}); err == nil {
+		t.Fatal("expected an error for a missing named port")
+	}
+}
+
+func TestNewNoPorts(t *testing.T) {
+	if _, err := New(nil, Opts{PodName: "web-0"}); err == nil {
+		t.Fatal("expected an error without ports")
+	}
+}
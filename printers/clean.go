@@ -0,0 +1,90 @@
+package printers
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+// LastAppliedConfigAnnotation is the annotation set by kubectl client-side apply.
+const LastAppliedConfigAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+
+// CleanOpts is a set of options that allows you to keep some of
+// the fields that Clean removes by default.
+type CleanOpts struct {
+	KeepManagedFields     bool
+	KeepResourceVersion   bool
+	KeepUID               bool
+	KeepCreationTimestamp bool
+	KeepStatus            bool
+	// KeepServerMetadata keeps generation, selfLink, deletion grace
+	// period and the last applied configuration annotation.
+	KeepServerMetadata bool
+}
+
+// Clean returns a copy of the object without the fields set by the
+// server (kubectl-neat style), so that it can be applied again.
+func Clean(obj *unstructured.Unstructured, o CleanOpts) *unstructured.Unstructured {
+	obj = obj.DeepCopy()
+	if !o.KeepManagedFields {
+		obj.SetManagedFields(nil)
+	}
+	if !o.KeepResourceVersion {
+		obj.SetResourceVersion("")
+	}
+	if !o.KeepUID {
+		obj.SetUID("")
+	}
+	if !o.KeepCreationTimestamp {
+		unstructured.RemoveNestedField(obj.Object, "metadata", "creationTimestamp")
+	}
+	if !o.KeepStatus {
+		unstructured.RemoveNestedField(obj.Object, "status")
+	}
+	if !o.KeepServerMetadata {
+		unstructured.RemoveNestedField(obj.Object, "metadata", "generation")
+		unstructured.RemoveNestedField(obj.Object, "metadata", "selfLink")
+		unstructured.RemoveNestedField(obj.Object, "metadata", "deletionGracePeriodSeconds")
+		if annotations := obj.GetAnnotations(); annotations != nil {
+			delete(annotations, LastAppliedConfigAnnotation)
+			if len(annotations) == 0 {
+				annotations = nil
+			}
+			obj.SetAnnotations(annotations)
+		}
+	}
+	return obj
+}
+
+// ToYAML returns the cleaned objects as a multi document YAML stream.
+func ToYAML(objs []*unstructured.Unstructured, o CleanOpts) ([]byte, error) {
+	var buf bytes.Buffer
+	for i, obj := range objs {
+		if i > 0 {
+			buf.WriteString("---\n")
+		}
+		data, err := yaml.Marshal(Clean(obj, o).Object)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(data)
+	}
+	return buf.Bytes(), nil
+}
+
+// ToJSON returns the cleaned object as indented JSON or, when there
+// are many objects, a List holding them in "items".
+func ToJSON(objs []*unstructured.Unstructured, o CleanOpts) ([]byte, error) {
+	cleaned := make([]*unstructured.Unstructured, 0, len(objs))
+	for _, obj := range objs {
+		cleaned = append(cleaned, Clean(obj, o))
+	}
+
+	data, err := json.MarshalIndent(templateData(cleaned), "", "    ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
@@ -0,0 +1,70 @@
+package printers
+
+import (
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestClean(t *testing.T) {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata": map[string]interface{}{
+			"name":              "demo",
+			"namespace":         "default",
+			"uid":               "1234",
+			"resourceVersion":   "42",
+			"generation":        int64(1),
+			"creationTimestamp": "2022-01-01T00:00:00Z",
+			"managedFields":     []interface{}{map[string]interface{}{"manager": "kubectl"}},
+			"annotations": map[string]interface{}{
+				LastAppliedConfigAnnotation: "{}",
+			},
+		},
+		"data":   map[string]interface{}{"key": "value"},
+		"status": map[string]interface{}{"phase": "Active"},
+	}}
+
+	data, err := ToYAML([]*unstructured.Unstructured{obj}, CleanOpts{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `apiVersion: v1
+data:
+  key: value
+kind: ConfigMap
+metadata:
+  name: demo
+  namespace: default
+`
+	if got := string(data); got != want {
+		t.Fatalf("expected:\n%s\ngot:\n%s", want, got)
+	}
+
+	kept := Clean(obj, CleanOpts{KeepStatus: true, KeepUID: true})
+	if _, ok := kept.Object["status"]; !ok {
+		t.Fatal("expected status to be kept")
+	}
+	if kept.GetUID() != "1234" {
+		t.Fatalf("expected uid to be kept, got %q", kept.GetUID())
+	}
+	if obj.GetResourceVersion() != "42" {
+		t.Fatal("expected the original object to be unchanged")
+	}
+}
+
+func TestToJSONList(t *testing.T) {
+	objs := []*unstructured.Unstructured{
+		{Object: map[string]interface{}{"kind": "Pod", "metadata": map[string]interface{}{"name": "a"}}},
+		{Object: map[string]interface{}{"kind": "Pod", "metadata": map[string]interface{}{"name": "b"}}},
+	}
+	data, err := ToJSON(objs, CleanOpts{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"kind": "List"`) {
+		t.Fatalf("expected a List, got:\n%s", data)
+	}
+}
@@ -0,0 +1,107 @@
+package printers
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/util/jsonpath"
+)
+
+var jsonRegexp = regexp.MustCompile(`^\{\.?([^{}]+)\}$|^\.?([^{}]+)$`)
+
+// JSONPath renders objects using a JSONPath template, in the
+// kubectl -o jsonpath dialect (e.g. "{.metadata.name}" or
+// `{range .items[*]}{.metadata.name}{"\t"}{.status.phase}{"\n"}{end}`).
+type JSONPath struct {
+	jp *jsonpath.JSONPath
+}
+
+// NewJSONPath parses the template. Missing keys render as empty.
+func NewJSONPath(template string) (*JSONPath, error) {
+	jp := jsonpath.New("out").AllowMissingKeys(true)
+	if err := jp.Parse(template); err != nil {
+		return nil, fmt.Errorf("error parsing jsonpath %s: %w", template, err)
+	}
+	return &JSONPath{jp: jp}, nil
+}
+
+// NewRelaxedJSONPath parses a single field expression accepting the
+// relaxed syntax of kubectl custom columns and sort-by, where the
+// braces and the leading dot are optional (e.g. "metadata.name").
+func NewRelaxedJSONPath(expr string) (*JSONPath, error) {
+	relaxed, err := RelaxedJSONPathExpression(expr)
+	if err != nil {
+		return nil, err
+	}
+	return NewJSONPath(relaxed)
+}
+
+// RelaxedJSONPathExpression turns an expression like "metadata.name"
+// or ".metadata.name" into "{.metadata.name}".
+func RelaxedJSONPathExpression(expr string) (string, error) {
+	if len(expr) == 0 {
+		return expr, nil
+	}
+	submatches := jsonRegexp.FindStringSubmatch(expr)
+	if submatches == nil {
+		return "", fmt.Errorf("unexpected path string, expected a 'name1.name2' or '.name1.name2' or '{name1.name2}' or '{.name1.name2}'")
+	}
+	if len(submatches) != 3 {
+		return "", fmt.Errorf("unexpected submatch list: %v", submatches)
+	}
+	field := submatches[1]
+	if len(field) == 0 {
+		field = submatches[2]
+	}
+	return fmt.Sprintf("{.%s}", strings.TrimPrefix(field, ".")), nil
+}
+
+// Print renders the template against the object or, when there are
+// many objects, against a List holding them in "items" (as kubectl does).
+func (p *JSONPath) Print(w io.Writer, objs ...*unstructured.Unstructured) error {
+	if err := p.jp.Execute(w, templateData(objs)); err != nil {
+		return fmt.Errorf("error executing jsonpath: %w", err)
+	}
+	return nil
+}
+
+// FindResults evaluates the template against the object returning the
+// matched values as strings (one slice per template expression).
+func (p *JSONPath) FindResults(obj *unstructured.Unstructured) ([][]string, error) {
+	results, err := p.jp.FindResults(obj.Object)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([][]string, 0, len(results))
+	for _, values := range results {
+		strs := make([]string, 0, len(values))
+		for _, v := range values {
+			strs = append(strs, fmt.Sprint(v.Interface()))
+		}
+		res = append(res, strs)
+	}
+	return res, nil
+}
+
+// templateData returns the content of the object or, when there
+// are many objects, a List holding them in "items".
+func templateData(objs []*unstructured.Unstructured) interface{} {
+	if len(objs) == 1 {
+		return objs[0].Object
+	}
+
+	items := make([]interface{}, 0, len(objs))
+	for _, obj := range objs {
+		items = append(items, obj.Object)
+	}
+	return map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "List",
+		"metadata":   map[string]interface{}{},
+		"items":      items,
+	}
+}
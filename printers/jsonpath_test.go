@@ -0,0 +1,64 @@
+package printers
+
+import (
+	"bytes"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestRelaxedJSONPathExpression(t *testing.T) {
+	tests := map[string]string{
+		"metadata.name":    "{.metadata.name}",
+		".metadata.name":   "{.metadata.name}",
+		"{metadata.name}":  "{.metadata.name}",
+		"{.metadata.name}": "{.metadata.name}",
+	}
+	for in, want := range tests {
+		got, err := RelaxedJSONPathExpression(in)
+		if err != nil {
+			t.Fatalf("%s: %v", in, err)
+		}
+		if got != want {
+			t.Fatalf("%s: expected %s, got %s", in, want, got)
+		}
+	}
+
+	if _, err := RelaxedJSONPathExpression("{.metadata}{.name}"); err == nil {
+		t.Fatal("expected an error for multiple expressions")
+	}
+}
+
+func TestJSONPathPrint(t *testing.T) {
+	pod := func(name, phase string) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"kind":     "Pod",
+			"metadata": map[string]interface{}{"name": name},
+			"status":   map[string]interface{}{"phase": phase},
+		}}
+	}
+
+	p, err := NewJSONPath(`{range .items[*]}{.metadata.name}{"\t"}{.status.phase}{"\n"}{end}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := p.Print(&buf, pod("web-0", "Running"), pod("web-1", "Pending")); err != nil {
+		t.Fatal(err)
+	}
+	if want := "web-0\tRunning\nweb-1\tPending\n"; buf.String() != want {
+		t.Fatalf("expected %q, got %q", want, buf.String())
+	}
+
+	p, err = NewJSONPath("{.metadata.name} {.spec.nodeName}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf.Reset()
+	if err := p.Print(&buf, pod("web-0", "Running")); err != nil {
+		t.Fatal(err)
+	}
+	if want := "web-0 "; buf.String() != want {
+		t.Fatalf("expected %q, got %q", want, buf.String())
+	}
+}
@@ -0,0 +1,214 @@
+package printers
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/lucasepe/kube/apiresources"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/duration"
+)
+
+// Table is a set of rows rendered as aligned columns.
+type Table struct {
+	Headers []string
+	Rows    [][]string
+}
+
+// Print writes the table to w, with the columns separated by
+// (at least) three spaces, as kubectl does.
+func (t *Table) Print(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 10, 4, 3, ' ', 0)
+	if len(t.Headers) > 0 {
+		fmt.Fprintln(tw, strings.Join(t.Headers, "\t"))
+	}
+	for _, row := range t.Rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
+
+// TableOpts is a set of options that allows you to build tables.
+type TableOpts struct {
+	// WithNamespace adds the NAMESPACE column.
+	WithNamespace bool
+	// WithKind prefixes the names with the resource kind (e.g. "pod/web-0").
+	WithKind bool
+	// NoHeaders omits the header row.
+	NoHeaders bool
+	// LabelColumns adds a column with the value of each of these
+	// labels (kubectl get --label-columns).
+	LabelColumns []string
+	// AnnotationColumns adds a column with the value of each of these annotations.
+	AnnotationColumns []string
+	// Now is the reference time of the ages (defaults to time.Now).
+	Now func() time.Time
+}
+
+func (o TableOpts) now() time.Time {
+	if o.Now != nil {
+		return o.Now()
+	}
+	return time.Now()
+}
+
+func (o TableOpts) headers(headers ...string) []string {
+	if o.NoHeaders {
+		return nil
+	}
+	if o.WithNamespace {
+		headers = append([]string{"NAMESPACE"}, headers...)
+	}
+	return headers
+}
+
+func (o TableOpts) row(namespace string, cells ...string) []string {
+	if o.WithNamespace {
+		cells = append([]string{namespace}, cells...)
+	}
+	return cells
+}
+
+// ObjectsTable returns the NAME and AGE table of the objects (as returned by get.Do).
+func ObjectsTable(objs []*unstructured.Unstructured, o TableOpts) *Table {
+	now := o.now()
+	headers := []string{"NAME", "AGE"}
+	headers = append(headers, keyHeaders(o.LabelColumns)...)
+	headers = append(headers, keyHeaders(o.AnnotationColumns)...)
+
+	t := &Table{Headers: o.headers(headers...)}
+	for _, obj := range objs {
+		name := obj.GetName()
+		if o.WithKind {
+			name = strings.ToLower(obj.GetKind()) + "/" + name
+		}
+		cells := []string{name, HumanAge(obj.GetCreationTimestamp().Time, now)}
+		cells = append(cells, keyValues(obj.GetLabels(), o.LabelColumns)...)
+		cells = append(cells, keyValues(obj.GetAnnotations(), o.AnnotationColumns)...)
+		t.Rows = append(t.Rows, o.row(obj.GetNamespace(), cells...))
+	}
+	return t
+}
+
+// ObjectRef identifies an object in the results.
+type ObjectRef struct {
+	Kind      string
+	Namespace string
+	Name      string
+}
+
+// LabelValues returns, for each object, the values of the labels
+// with the given keys (all the labels if no key is given).
+// Missing labels are omitted.
+func LabelValues(objs []*unstructured.Unstructured, keys ...string) map[ObjectRef]map[string]string {
+	return extractValues(objs, (*unstructured.Unstructured).GetLabels, keys)
+}
+
+// AnnotationValues returns, for each object, the values of the annotations
+// with the given keys (all the annotations if no key is given).
+// Missing annotations are omitted.
+func AnnotationValues(objs []*unstructured.Unstructured, keys ...string) map[ObjectRef]map[string]string {
+	return extractValues(objs, (*unstructured.Unstructured).GetAnnotations, keys)
+}
+
+func extractValues(objs []*unstructured.Unstructured, get func(*unstructured.Unstructured) map[string]string, keys []string) map[ObjectRef]map[string]string {
+	res := make(map[ObjectRef]map[string]string, len(objs))
+	for _, obj := range objs {
+		all := get(obj)
+		values := map[string]string{}
+		if len(keys) == 0 {
+			for k, v := range all {
+				values[k] = v
+			}
+		}
+		for _, k := range keys {
+			if v, ok := all[k]; ok {
+				values[k] = v
+			}
+		}
+		ref := ObjectRef{Kind: obj.GetKind(), Namespace: obj.GetNamespace(), Name: obj.GetName()}
+		res[ref] = values
+	}
+	return res
+}
+
+// keyHeaders returns the column headers of the label or annotation
+// keys: the name without the prefix, upper case (as kubectl does).
+func keyHeaders(keys []string) []string {
+	res := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts := strings.Split(k, "/")
+		res = append(res, strings.ToUpper(parts[len(parts)-1]))
+	}
+	return res
+}
+
+func keyValues(m map[string]string, keys []string) []string {
+	res := make([]string, 0, len(keys))
+	for _, k := range keys {
+		res = append(res, m[k])
+	}
+	return res
+}
+
+// EventsTable returns the table of the events (as returned by events.Do).
+func EventsTable(events []corev1.Event, o TableOpts) *Table {
+	now := o.now()
+	t := &Table{Headers: o.headers("LAST SEEN", "TYPE", "REASON", "OBJECT", "MESSAGE")}
+	for _, ev := range events {
+		object := strings.ToLower(ev.InvolvedObject.Kind) + "/" + ev.InvolvedObject.Name
+		if len(ev.InvolvedObject.FieldPath) > 0 {
+			object += " (" + ev.InvolvedObject.FieldPath + ")"
+		}
+		lastSeen := HumanAge(eventTime(ev), now)
+		if ev.Count > 1 {
+			lastSeen = fmt.Sprintf("%s (x%d over %s)", lastSeen, ev.Count, HumanAge(ev.FirstTimestamp.Time, now))
+		}
+		t.Rows = append(t.Rows, o.row(ev.Namespace,
+			lastSeen, ev.Type, ev.Reason, object, strings.TrimSpace(ev.Message)))
+	}
+	return t
+}
+
+// APIResourcesTable returns the table of the API resources (as returned by apiresources.Do).
+func APIResourcesTable(resources []apiresources.GroupResource, o TableOpts) *Table {
+	o.WithNamespace = false
+	t := &Table{Headers: o.headers("NAME", "SHORTNAMES", "APIVERSION", "NAMESPACED", "KIND")}
+	for _, r := range resources {
+		t.Rows = append(t.Rows, []string{
+			r.APIResource.Name,
+			strings.Join(r.APIResource.ShortNames, ","),
+			r.APIGroupVersion,
+			fmt.Sprint(r.APIResource.Namespaced),
+			r.APIResource.Kind,
+		})
+	}
+	return t
+}
+
+// HumanAge returns the elapsed time since t in a kubectl like format
+// (e.g. "5m", "3d4h"), or "<unknown>" if t is zero.
+func HumanAge(t time.Time, now time.Time) string {
+	if t.IsZero() {
+		return "<unknown>"
+	}
+	return duration.HumanDuration(now.Sub(t))
+}
+
+// eventTime returns the last time the event was observed.
+func eventTime(ev corev1.Event) time.Time {
+	if ev.Series != nil {
+		return ev.Series.LastObservedTime.Time
+	}
+	if !ev.LastTimestamp.Time.IsZero() {
+		return ev.LastTimestamp.Time
+	}
+	if !ev.EventTime.Time.IsZero() {
+		return ev.EventTime.Time
+	}
+	return ev.CreationTimestamp.Time
+}
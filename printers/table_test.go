@@ -0,0 +1,74 @@
+package printers
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestObjectsTable(t *testing.T) {
+	now := time.Date(2022, 11, 10, 10, 30, 0, 0, time.UTC)
+
+	pod := &unstructured.Unstructured{}
+	pod.SetKind("Pod")
+	pod.SetNamespace("demo")
+	pod.SetName("web-0")
+	pod.SetCreationTimestamp(metav1.NewTime(now.Add(-90 * time.Minute)))
+
+	cm := &unstructured.Unstructured{}
+	cm.SetKind("ConfigMap")
+	cm.SetNamespace("kube-system")
+	cm.SetName("coredns")
+	cm.SetCreationTimestamp(metav1.NewTime(now.Add(-49 * time.Hour)))
+
+	table := ObjectsTable([]*unstructured.Unstructured{pod, cm}, TableOpts{
+		WithNamespace: true,
+		WithKind:      true,
+		Now:           func() time.Time { return now },
+	})
+
+	var buf bytes.Buffer
+	if err := table.Print(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "NAMESPACE     NAME                AGE\n" +
+		"demo          pod/web-0           90m\n" +
+		"kube-system   configmap/coredns   2d1h\n"
+	if got := buf.String(); got != want {
+		t.Fatalf("unexpected table:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestObjectsTableLabelColumns(t *testing.T) {
+	pod := &unstructured.Unstructured{}
+	pod.SetKind("Pod")
+	pod.SetName("web-0")
+	pod.SetLabels(map[string]string{"app.kubernetes.io/name": "nginx", "tier": "frontend"})
+	pod.SetAnnotations(map[string]string{"owner": "team-a"})
+
+	table := ObjectsTable([]*unstructured.Unstructured{pod}, TableOpts{
+		LabelColumns:      []string{"app.kubernetes.io/name", "version"},
+		AnnotationColumns: []string{"owner"},
+	})
+
+	var buf bytes.Buffer
+	if err := table.Print(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "NAME      AGE         NAME      VERSION   OWNER\n" +
+		"web-0     <unknown>   nginx               team-a\n"
+	if got := buf.String(); got != want {
+		t.Fatalf("unexpected table:\n%q\nwant:\n%q", got, want)
+	}
+
+	values := LabelValues([]*unstructured.Unstructured{pod}, "tier", "version")
+	got := values[ObjectRef{Kind: "Pod", Name: "web-0"}]
+	if len(got) != 1 || got["tier"] != "frontend" {
+		t.Fatalf("unexpected label values: %v", got)
+	}
+}
@@ -0,0 +1,131 @@
+package printers
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"reflect"
+	"text/template"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// TemplateFuncs are the functions available to the templates, the
+// same of kubectl -o go-template (no sprig) plus base and dir.
+var TemplateFuncs = template.FuncMap{
+	"exists":       exists,
+	"base64decode": base64decode,
+	"base64encode": base64encode,
+	"base":         path.Base,
+	"dir":          path.Dir,
+}
+
+// GoTemplate renders objects using a Go template (kubectl -o go-template).
+type GoTemplate struct {
+	tmpl *template.Template
+}
+
+// NewGoTemplate parses the template. Unless allowMissingKeys is set,
+// a missing map key makes the rendering fail.
+func NewGoTemplate(text string, allowMissingKeys bool) (*GoTemplate, error) {
+	tmpl, err := template.New("output").Funcs(TemplateFuncs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing template %s: %w", text, err)
+	}
+	if allowMissingKeys {
+		tmpl.Option("missingkey=default")
+	} else {
+		tmpl.Option("missingkey=error")
+	}
+	return &GoTemplate{tmpl: tmpl}, nil
+}
+
+// NewGoTemplateFile parses the template read from the file (kubectl -o go-template-file).
+func NewGoTemplateFile(filename string, allowMissingKeys bool) (*GoTemplate, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("error reading template %s: %w", filename, err)
+	}
+	return NewGoTemplate(string(data), allowMissingKeys)
+}
+
+// Print renders the template against the object or, when there are
+// many objects, against a List holding them in "items" (as kubectl does).
+func (p *GoTemplate) Print(w io.Writer, objs ...*unstructured.Unstructured) error {
+	if err := p.tmpl.Execute(w, templateData(objs)); err != nil {
+		return fmt.Errorf("error executing template: %w", err)
+	}
+	return nil
+}
+
+// exists returns true if it would be possible to call the index
+// function with these arguments (e.g. {{if exists . "status" "phase"}}).
+func exists(item interface{}, indices ...interface{}) bool {
+	v := reflect.ValueOf(item)
+	for _, i := range indices {
+		index := reflect.ValueOf(i)
+		var isNil bool
+		if v, isNil = indirect(v); isNil {
+			return false
+		}
+		switch v.Kind() {
+		case reflect.Array, reflect.Slice, reflect.String:
+			var x int64
+			switch index.Kind() {
+			case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+				x = index.Int()
+			case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+				x = int64(index.Uint())
+			default:
+				return false
+			}
+			if x < 0 || x >= int64(v.Len()) {
+				return false
+			}
+			v = v.Index(int(x))
+		case reflect.Map:
+			if !index.IsValid() {
+				index = reflect.Zero(v.Type().Key())
+			}
+			if !index.Type().AssignableTo(v.Type().Key()) {
+				return false
+			}
+			if x := v.MapIndex(index); x.IsValid() {
+				v = x
+			} else {
+				v = reflect.Zero(v.Type().Elem())
+			}
+		default:
+			return false
+		}
+	}
+	_, isNil := indirect(v)
+	return !isNil
+}
+
+// indirect returns the item at the end of indirection, and a bool to indicate if it's nil.
+func indirect(v reflect.Value) (rv reflect.Value, isNil bool) {
+	for ; v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface; v = v.Elem() {
+		if v.IsNil() {
+			return v, true
+		}
+		if v.Kind() == reflect.Interface && v.NumMethod() > 0 {
+			break
+		}
+	}
+	return v, false
+}
+
+func base64decode(v string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(v)
+	if err != nil {
+		return "", fmt.Errorf("base64 decode failed: %w", err)
+	}
+	return string(data), nil
+}
+
+func base64encode(v string) string {
+	return base64.StdEncoding.EncodeToString([]byte(v))
+}
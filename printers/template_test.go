@@ -0,0 +1,48 @@
+package printers
+
+import (
+	"bytes"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestGoTemplatePrint(t *testing.T) {
+	secret := &unstructured.Unstructured{Object: map[string]interface{}{
+		"kind":     "Secret",
+		"metadata": map[string]interface{}{"name": "creds"},
+		"data":     map[string]interface{}{"password": "czNjcjN0"},
+	}}
+
+	p, err := NewGoTemplate(`{{.metadata.name}}={{base64decode .data.password}}{{if exists . "data" "token"}} token{{end}}`, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := p.Print(&buf, secret); err != nil {
+		t.Fatal(err)
+	}
+	if want := "creds=s3cr3t"; buf.String() != want {
+		t.Fatalf("expected %q, got %q", want, buf.String())
+	}
+
+	p, err = NewGoTemplate(`{{range .items}}{{base .metadata.name}} {{end}}`, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf.Reset()
+	if err := p.Print(&buf, secret, secret); err != nil {
+		t.Fatal(err)
+	}
+	if want := "creds creds "; buf.String() != want {
+		t.Fatalf("expected %q, got %q", want, buf.String())
+	}
+
+	p, err = NewGoTemplate(`{{.spec.missing}}`, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Print(&buf, secret); err == nil {
+		t.Fatal("expected an error for a missing key")
+	}
+}
@@ -0,0 +1,249 @@
+package rightsizing
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	kubeutil "github.com/lucasepe/kube/util"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	defaultWindow   = 10 * time.Minute
+	defaultInterval = 30 * time.Second
+	defaultHeadroom = 0.15
+)
+
+// Opts is a set of options that controls the usage sampling.
+type Opts struct {
+	Namespace     string
+	AllNamespaces bool
+	LabelSelector string
+
+	// Window is the overall sampling duration (10m by default).
+	Window time.Duration
+	// Interval is the time between two samples (30s by default).
+	Interval time.Duration
+	// Headroom is the fraction added to the p95 usage to compute the
+	// suggested request (0.15 by default).
+	Headroom float64
+}
+
+// ResourceStats compares the observed usage of a resource with its request and limit.
+type ResourceStats struct {
+	Request *resource.Quantity
+	Limit   *resource.Quantity
+
+	P50 resource.Quantity
+	P95 resource.Quantity
+	Max resource.Quantity
+
+	// Suggested is the recommended request (p95 plus headroom).
+	Suggested resource.Quantity
+}
+
+// Recommendation is the rightsizing suggestion for a container of a workload.
+type Recommendation struct {
+	Namespace string
+	// Workload is the top level owner (e.g. "Deployment/web") or "Pod/<name>".
+	Workload  string
+	Container string
+	Samples   int
+
+	CPU    ResourceStats
+	Memory ResourceStats
+}
+
+type containerKey struct {
+	namespace, workload, container string
+}
+
+type containerSamples struct {
+	cpu, memory []int64
+	resources   corev1.ResourceRequirements
+}
+
+// Analyze samples the pod metrics for the configured window and returns
+// per-workload, per-container usage statistics compared to requests and limits.
+func Analyze(ctx context.Context, f kubeutil.Factory, o Opts) ([]Recommendation, error) {
+	if o.Window <= 0 {
+		o.Window = defaultWindow
+	}
+	if o.Interval <= 0 {
+		o.Interval = defaultInterval
+	}
+	if o.Headroom <= 0 {
+		o.Headroom = defaultHeadroom
+	}
+
+	var err error
+	if o.AllNamespaces {
+		o.Namespace = ""
+	} else if len(o.Namespace) == 0 {
+		o.Namespace, _, err = f.ToRawKubeConfigLoader().Namespace()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	cli, err := f.KubernetesClientSet()
+	if err != nil {
+		return nil, err
+	}
+	mcli, err := kubeutil.MetricsClientSet(f)
+	if err != nil {
+		return nil, err
+	}
+
+	samples := map[containerKey]*containerSamples{}
+	workloads := map[string]string{}
+
+	ctx, cancel := context.WithTimeout(ctx, o.Window)
+	defer cancel()
+
+	ticker := time.NewTicker(o.Interval)
+	defer ticker.Stop()
+
+	sample := func() error {
+		pods, err := cli.CoreV1().Pods(o.Namespace).List(ctx, metav1.ListOptions{LabelSelector: o.LabelSelector})
+		if err != nil {
+			return err
+		}
+		specs := map[string]*corev1.Pod{}
+		for i := range pods.Items {
+			pod := &pods.Items[i]
+			specs[pod.Namespace+"/"+pod.Name] = pod
+			workloads[pod.Namespace+"/"+pod.Name] = workloadOf(pod)
+		}
+
+		metrics, err := mcli.MetricsV1beta1().PodMetricses(o.Namespace).List(ctx, metav1.ListOptions{LabelSelector: o.LabelSelector})
+		if err != nil {
+			return err
+		}
+
+		for _, pm := range metrics.Items {
+			podKey := pm.Namespace + "/" + pm.Name
+			pod, ok := specs[podKey]
+			if !ok {
+				continue
+			}
+			for _, cm := range pm.Containers {
+				key := containerKey{namespace: pm.Namespace, workload: workloads[podKey], container: cm.Name}
+				cs, ok := samples[key]
+				if !ok {
+					cs = &containerSamples{}
+					if c, _ := kubeutil.FindContainerByName(pod, cm.Name); c != nil {
+						cs.resources = c.Resources
+					}
+					samples[key] = cs
+				}
+				cs.cpu = append(cs.cpu, cm.Usage.Cpu().MilliValue())
+				cs.memory = append(cs.memory, cm.Usage.Memory().Value())
+			}
+		}
+		return nil
+	}
+
+	for done := false; !done; {
+		// errors caused by the end of the window are not failures
+		if err := sample(); err != nil && ctx.Err() == nil {
+			return nil, err
+		}
+
+		select {
+		case <-ctx.Done():
+			done = true
+		case <-ticker.C:
+		}
+	}
+
+	if len(samples) == 0 {
+		return nil, fmt.Errorf("no pod metrics collected")
+	}
+
+	res := make([]Recommendation, 0, len(samples))
+	for key, cs := range samples {
+		rec := Recommendation{
+			Namespace: key.namespace,
+			Workload:  key.workload,
+			Container: key.container,
+			Samples:   len(cs.cpu),
+			CPU:       stats(cs.cpu, cs.resources, corev1.ResourceCPU, o.Headroom),
+			Memory:    stats(cs.memory, cs.resources, corev1.ResourceMemory, o.Headroom),
+		}
+		res = append(res, rec)
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Namespace != res[j].Namespace {
+			return res[i].Namespace < res[j].Namespace
+		}
+		if res[i].Workload != res[j].Workload {
+			return res[i].Workload < res[j].Workload
+		}
+		return res[i].Container < res[j].Container
+	})
+
+	return res, nil
+}
+
+func stats(values []int64, rr corev1.ResourceRequirements, name corev1.ResourceName, headroom float64) ResourceStats {
+	sorted := append([]int64(nil), values...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	quantity := func(v int64) resource.Quantity {
+		if name == corev1.ResourceCPU {
+			return *resource.NewMilliQuantity(v, resource.DecimalSI)
+		}
+		return *resource.NewQuantity(v, resource.BinarySI)
+	}
+
+	p95 := percentile(sorted, 0.95)
+	res := ResourceStats{
+		P50:       quantity(percentile(sorted, 0.50)),
+		P95:       quantity(p95),
+		Max:       quantity(sorted[len(sorted)-1]),
+		Suggested: quantity(int64(math.Ceil(float64(p95) * (1 + headroom)))),
+	}
+	if q, ok := rr.Requests[name]; ok {
+		res.Request = &q
+	}
+	if q, ok := rr.Limits[name]; ok {
+		res.Limit = &q
+	}
+	return res
+}
+
+// percentile returns the nearest-rank percentile of the sorted values.
+func percentile(sorted []int64, p float64) int64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	idx := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if idx < 0 {
+		idx = 0
+	}
+	return sorted[idx]
+}
+
+// workloadOf returns the top level owner of the pod, inferring the
+// Deployment from the ReplicaSet name and its pod-template-hash label.
+func workloadOf(pod *corev1.Pod) string {
+	ref := metav1.GetControllerOf(pod)
+	if ref == nil {
+		return "Pod/" + pod.Name
+	}
+	if ref.Kind == "ReplicaSet" {
+		if hash, ok := pod.Labels[appsv1.DefaultDeploymentUniqueLabelKey]; ok && strings.HasSuffix(ref.Name, "-"+hash) {
+			return "Deployment/" + strings.TrimSuffix(ref.Name, "-"+hash)
+		}
+	}
+	return ref.Kind + "/" + ref.Name
+}
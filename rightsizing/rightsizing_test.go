@@ -0,0 +1,92 @@
+package rightsizing
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPercentile(t *testing.T) {
+	sorted := []int64{10, 20, 30, 40, 50, 60, 70, 80, 90, 100}
+	tests := map[float64]int64{0: 10, 0.5: 50, 0.95: 100, 1: 100}
+	for p, want := range tests {
+		if got := percentile(sorted, p); got != want {
+			t.Errorf("p%v: expected %d, got %d", p*100, want, got)
+		}
+	}
+	if got := percentile(nil, 0.5); got != 0 {
+		t.Errorf("expected 0 without values, got %d", got)
+	}
+}
+
+func TestStats(t *testing.T) {
+	rr := corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("500m")},
+		Limits:   corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("256Mi")},
+	}
+
+	cpu := stats([]int64{300, 100, 200, 400}, rr, corev1.ResourceCPU, 0.5)
+	if cpu.P50.String() != "200m" || cpu.P95.String() != "400m" || cpu.Max.String() != "400m" {
+		t.Fatalf("unexpected cpu stats %+v", cpu)
+	}
+	if cpu.Suggested.String() != "600m" {
+		t.Fatalf("expected the p95 plus headroom, got %s", cpu.Suggested.String())
+	}
+	if cpu.Request == nil || cpu.Request.String() != "500m" || cpu.Limit != nil {
+		t.Fatalf("unexpected cpu request and limit %v %v", cpu.Request, cpu.Limit)
+	}
+
+	mem := stats([]int64{64 << 20}, rr, corev1.ResourceMemory, 0.25)
+	if mem.Max.String() != "64Mi" || mem.Suggested.String() != "80Mi" {
+		t.Fatalf("unexpected memory stats %+v", mem)
+	}
+	if mem.Request != nil || mem.Limit == nil || mem.Limit.String() != "256Mi" {
+		t.Fatalf("unexpected memory request and limit %v %v", mem.Request, mem.Limit)
+	}
+}
+
+func TestWorkloadOf(t *testing.T) {
+	controller := func(kind, name string) []metav1.OwnerReference {
+		yes := true
+		return []metav1.OwnerReference{{Kind: kind, Name: name, Controller: &yes}}
+	}
+
+	tests := []struct {
+		pod  corev1.Pod
+		want string
+	}{
+		{
+			corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "standalone"}},
+			"Pod/standalone",
+		},
+		{
+			corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+				Name:            "web-5d78c9b8f-abcde",
+				Labels:          map[string]string{"pod-template-hash": "5d78c9b8f"},
+				OwnerReferences: controller("ReplicaSet", "web-5d78c9b8f"),
+			}},
+			"Deployment/web",
+		},
+		{
+			corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+				Name:            "legacy-abcde",
+				OwnerReferences: controller("ReplicaSet", "legacy"),
+			}},
+			"ReplicaSet/legacy",
+		},
+		{
+			corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+				Name:            "db-0",
+				OwnerReferences: controller("StatefulSet", "db"),
+			}},
+			"StatefulSet/db",
+		},
+	}
+	for _, tt := range tests {
+		if got := workloadOf(&tt.pod); got != tt.want {
+			t.Errorf("%s: expected %s, got %s", tt.pod.Name, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,298 @@
+package rollout
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+
+	kubeutil "github.com/lucasepe/kube/util"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// RevisionAnnotation is the revision annotation of a deployment's replica sets.
+	RevisionAnnotation = "deployment.kubernetes.io/revision"
+	// ChangeCauseAnnotation is the change-cause annotation recorded by tools.
+	ChangeCauseAnnotation = "kubernetes.io/change-cause"
+)
+
+// annotationsToSkip lists the annotations that should be preserved
+// from the deployment and not copied from the replica set when rolling back.
+var annotationsToSkip = map[string]bool{
+	corev1.LastAppliedConfigAnnotation:          true,
+	RevisionAnnotation:                          true,
+	"deployment.kubernetes.io/revision-history": true,
+	"deployment.kubernetes.io/desired-replicas": true,
+	"deployment.kubernetes.io/max-replicas":     true,
+	appsv1.DeprecatedRollbackTo:                 true,
+}
+
+// Opts identifies a Deployment and the revision to operate on.
+type Opts struct {
+	Namespace string
+	Name      string
+	// ToRevision is the target revision; zero means the previous one.
+	ToRevision int64
+	// DryRun submits the rollback patch using server-side dry-run.
+	DryRun bool
+}
+
+// Revision is a Deployment revision backed by one of its ReplicaSets.
+type Revision struct {
+	Number      int64
+	ChangeCause string
+	Current     bool
+	ReplicaSet  *appsv1.ReplicaSet
+}
+
+func (o *Opts) complete(f kubeutil.Factory) error {
+	if len(o.Name) == 0 {
+		return fmt.Errorf("a deployment name is required")
+	}
+	if len(o.Namespace) == 0 {
+		ns, _, err := f.ToRawKubeConfigLoader().Namespace()
+		if err != nil {
+			return err
+		}
+		o.Namespace = ns
+	}
+	return nil
+}
+
+// History returns the revisions of the Deployment ordered from the oldest to the newest.
+func History(f kubeutil.Factory, o Opts) ([]Revision, error) {
+	return HistoryContext(context.Background(), f, o)
+}
+
+// HistoryContext is like History but stops when the context is cancelled.
+func HistoryContext(ctx context.Context, f kubeutil.Factory, o Opts) ([]Revision, error) {
+	if err := o.complete(f); err != nil {
+		return nil, err
+	}
+
+	cli, err := f.KubernetesClientSet()
+	if err != nil {
+		return nil, err
+	}
+
+	deployment, err := cli.AppsV1().Deployments(o.Namespace).Get(ctx, o.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	return historyForDeployment(ctx, cli, deployment)
+}
+
+// Diff returns the unified diff between the current pod template of the
+// Deployment and the one recorded by the target revision.
+func Diff(f kubeutil.Factory, o Opts) (string, error) {
+	return DiffContext(context.Background(), f, o)
+}
+
+// DiffContext is like Diff but stops when the context is cancelled.
+func DiffContext(ctx context.Context, f kubeutil.Factory, o Opts) (string, error) {
+	if err := o.complete(f); err != nil {
+		return "", err
+	}
+
+	cli, err := f.KubernetesClientSet()
+	if err != nil {
+		return "", err
+	}
+
+	deployment, err := cli.AppsV1().Deployments(o.Namespace).Get(ctx, o.Name, metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+
+	revs, err := historyForDeployment(ctx, cli, deployment)
+	if err != nil {
+		return "", err
+	}
+
+	rev, err := findRevision(revs, o.ToRevision)
+	if err != nil {
+		return "", err
+	}
+
+	current, err := templateYAML(deployment.Spec.Template)
+	if err != nil {
+		return "", err
+	}
+	target, err := templateYAML(rev.ReplicaSet.Spec.Template)
+	if err != nil {
+		return "", err
+	}
+
+	return kubeutil.UnifiedDiff("current", fmt.Sprintf("revision %d", rev.Number), current, target), nil
+}
+
+// Undo rolls the Deployment back to the target revision patching its
+// pod template and annotations, and returns the patched Deployment.
+//
+// If the current template already matches the revision nothing is changed.
+func Undo(f kubeutil.Factory, o Opts) (*appsv1.Deployment, error) {
+	return UndoContext(context.Background(), f, o)
+}
+
+// UndoContext is like Undo but stops when the context is cancelled.
+func UndoContext(ctx context.Context, f kubeutil.Factory, o Opts) (*appsv1.Deployment, error) {
+	if err := o.complete(f); err != nil {
+		return nil, err
+	}
+
+	cli, err := f.KubernetesClientSet()
+	if err != nil {
+		return nil, err
+	}
+
+	return undo(ctx, cli, o)
+}
+
+func undo(ctx context.Context, cli kubernetes.Interface, o Opts) (*appsv1.Deployment, error) {
+	deployment, err := cli.AppsV1().Deployments(o.Namespace).Get(ctx, o.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	if deployment.Spec.Paused {
+		return nil, fmt.Errorf("you cannot rollback a paused deployment; resume it first")
+	}
+
+	revs, err := historyForDeployment(ctx, cli, deployment)
+	if err != nil {
+		return nil, err
+	}
+
+	rev, err := findRevision(revs, o.ToRevision)
+	if err != nil {
+		return nil, err
+	}
+
+	template := rev.ReplicaSet.Spec.Template.DeepCopy()
+	delete(template.Labels, appsv1.DefaultDeploymentUniqueLabelKey)
+
+	if equalIgnoreHash(&deployment.Spec.Template, template) {
+		return deployment, nil
+	}
+
+	annotations := map[string]string{}
+	for k, v := range deployment.Annotations {
+		if annotationsToSkip[k] {
+			annotations[k] = v
+		}
+	}
+	for k, v := range rev.ReplicaSet.Annotations {
+		if !annotationsToSkip[k] {
+			annotations[k] = v
+		}
+	}
+
+	patch, err := json.Marshal([]interface{}{
+		map[string]interface{}{"op": "replace", "path": "/spec/template", "value": template},
+		map[string]interface{}{"op": "replace", "path": "/metadata/annotations", "value": annotations},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	patchOptions := metav1.PatchOptions{}
+	if o.DryRun {
+		patchOptions.DryRun = []string{metav1.DryRunAll}
+	}
+
+	return cli.AppsV1().Deployments(o.Namespace).Patch(ctx, o.Name, types.JSONPatchType, patch, patchOptions)
+}
+
+func historyForDeployment(ctx context.Context, cli kubernetes.Interface, deployment *appsv1.Deployment) ([]Revision, error) {
+	selector, err := metav1.LabelSelectorAsSelector(deployment.Spec.Selector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid label selector: %v", err)
+	}
+
+	list, err := cli.AppsV1().ReplicaSets(deployment.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: selector.String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	revs := []Revision{}
+	for i := range list.Items {
+		rs := &list.Items[i]
+		if ref := metav1.GetControllerOf(rs); ref == nil || ref.UID != deployment.UID {
+			continue
+		}
+
+		num, err := revisionOf(rs)
+		if err != nil {
+			return nil, err
+		}
+
+		revs = append(revs, Revision{
+			Number:      num,
+			ChangeCause: rs.Annotations[ChangeCauseAnnotation],
+			Current:     equalIgnoreHash(&deployment.Spec.Template, &rs.Spec.Template),
+			ReplicaSet:  rs,
+		})
+	}
+
+	sort.Slice(revs, func(i, j int) bool { return revs[i].Number < revs[j].Number })
+
+	return revs, nil
+}
+
+// findRevision returns the target revision; zero means the one before the latest.
+func findRevision(revs []Revision, toRevision int64) (*Revision, error) {
+	if toRevision == 0 {
+		if len(revs) < 2 {
+			return nil, fmt.Errorf("no rollout history found")
+		}
+		return &revs[len(revs)-2], nil
+	}
+
+	for i := range revs {
+		if revs[i].Number == toRevision {
+			return &revs[i], nil
+		}
+	}
+
+	return nil, fmt.Errorf("unable to find specified revision %d in history", toRevision)
+}
+
+func revisionOf(rs *appsv1.ReplicaSet) (int64, error) {
+	v, ok := rs.Annotations[RevisionAnnotation]
+	if !ok {
+		return 0, nil
+	}
+	num, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid revision annotation %q on replica set %s: %w", v, rs.Name, err)
+	}
+	return num, nil
+}
+
+// equalIgnoreHash compares two pod templates ignoring the pod-template-hash label.
+func equalIgnoreHash(t1, t2 *corev1.PodTemplateSpec) bool {
+	t1Copy, t2Copy := t1.DeepCopy(), t2.DeepCopy()
+	delete(t1Copy.Labels, appsv1.DefaultDeploymentUniqueLabelKey)
+	delete(t2Copy.Labels, appsv1.DefaultDeploymentUniqueLabelKey)
+	return apiequality.Semantic.DeepEqual(t1Copy, t2Copy)
+}
+
+func templateYAML(t corev1.PodTemplateSpec) (string, error) {
+	tCopy := t.DeepCopy()
+	delete(tCopy.Labels, appsv1.DefaultDeploymentUniqueLabelKey)
+	dat, err := yaml.Marshal(tCopy)
+	if err != nil {
+		return "", err
+	}
+	return string(dat), nil
+}
@@ -0,0 +1,142 @@
+package rollout
+
+import (
+	"context"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func podTemplate(image string) corev1.PodTemplateSpec {
+	return corev1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "web"}},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: "web", Image: image}},
+		},
+	}
+}
+
+func replicaSet(owner *appsv1.Deployment, name, revision, image string) *appsv1.ReplicaSet {
+	template := podTemplate(image)
+	template.Labels[appsv1.DefaultDeploymentUniqueLabelKey] = name
+
+	return &appsv1.ReplicaSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   owner.Namespace,
+			Labels:      map[string]string{"app": "web"},
+			Annotations: map[string]string{RevisionAnnotation: revision, ChangeCauseAnnotation: "image " + image},
+			OwnerReferences: []metav1.OwnerReference{
+				*metav1.NewControllerRef(owner, appsv1.SchemeGroupVersion.WithKind("Deployment")),
+			},
+		},
+		Spec: appsv1.ReplicaSetSpec{Template: template},
+	}
+}
+
+func newDeployment() *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "web",
+			Namespace:   "team-a",
+			UID:         types.UID("web-uid"),
+			Annotations: map[string]string{RevisionAnnotation: "3"},
+		},
+		Spec: appsv1.DeploymentSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+			Template: podTemplate("nginx:1.23"),
+		},
+	}
+}
+
+func TestOptsDefaultNamespace(t *testing.T) {
+	o := Opts{Name: "web"}
+	if err := o.complete(newFactory("team-a")); err != nil {
+		t.Fatal(err)
+	}
+	if o.Namespace != "team-a" {
+		t.Fatalf("expected the kubeconfig namespace, got %q", o.Namespace)
+	}
+
+	if err := (&Opts{}).complete(newFactory("team-a")); err == nil {
+		t.Fatal("expected an error without a deployment name")
+	}
+}
+
+func TestHistory(t *testing.T) {
+	deployment := newDeployment()
+	other := newDeployment()
+	other.Name, other.UID = "other", types.UID("other-uid")
+
+	cli := fake.NewSimpleClientset(
+		replicaSet(deployment, "web-3", "3", "nginx:1.23"),
+		replicaSet(deployment, "web-1", "1", "nginx:1.21"),
+		replicaSet(deployment, "web-2", "2", "nginx:1.22"),
+		replicaSet(other, "other-1", "1", "nginx:1.21"),
+	)
+
+	revs, err := historyForDeployment(context.Background(), cli, deployment)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(revs) != 3 {
+		t.Fatalf("expected 3 revisions, got %d", len(revs))
+	}
+	for i, rev := range revs {
+		if rev.Number != int64(i+1) {
+			t.Fatalf("expected revision %d, got %d", i+1, rev.Number)
+		}
+		if rev.Current != (i == 2) {
+			t.Fatalf("unexpected current flag for revision %d", rev.Number)
+		}
+	}
+	if revs[1].ChangeCause != "image nginx:1.22" {
+		t.Fatalf("unexpected change cause: %q", revs[1].ChangeCause)
+	}
+}
+
+func TestUndo(t *testing.T) {
+	deployment := newDeployment()
+	cli := fake.NewSimpleClientset(deployment,
+		replicaSet(deployment, "web-1", "1", "nginx:1.21"),
+		replicaSet(deployment, "web-2", "2", "nginx:1.22"),
+		replicaSet(deployment, "web-3", "3", "nginx:1.23"),
+	)
+
+	res, err := undo(context.Background(), cli, Opts{Namespace: "team-a", Name: "web"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := res.Spec.Template.Spec.Containers[0].Image; got != "nginx:1.22" {
+		t.Fatalf("expected the previous revision image, got %q", got)
+	}
+	if _, ok := res.Spec.Template.Labels[appsv1.DefaultDeploymentUniqueLabelKey]; ok {
+		t.Fatal("the pod-template-hash label must not be copied")
+	}
+	if res.Annotations[RevisionAnnotation] != "3" || res.Annotations[ChangeCauseAnnotation] != "image nginx:1.22" {
+		t.Fatalf("unexpected annotations: %v", res.Annotations)
+	}
+
+	if _, err := undo(context.Background(), cli, Opts{Namespace: "team-a", Name: "web", ToRevision: 9}); err == nil {
+		t.Fatal("expected an error for a missing revision")
+	}
+}
+
+func TestUndoPaused(t *testing.T) {
+	deployment := newDeployment()
+	deployment.Spec.Paused = true
+	cli := fake.NewSimpleClientset(deployment)
+
+	if _, err := undo(context.Background(), cli, Opts{Namespace: "team-a", Name: "web"}); err == nil {
+		t.Fatal("expected an error for a paused deployment")
+	}
+	for _, action := range cli.Actions() {
+		if action.GetVerb() == "patch" {
+			t.Fatal("a paused deployment must not be patched")
+		}
+	}
+}
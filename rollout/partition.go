@@ -0,0 +1,141 @@
+package rollout
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	kubeutil "github.com/lucasepe/kube/util"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	appsv1client "k8s.io/client-go/kubernetes/typed/apps/v1"
+)
+
+const (
+	defaultPollInterval = 2 * time.Second
+	defaultStepTimeout  = 5 * time.Minute
+)
+
+// PartitionOpts is a set of options that drives a partitioned (canary)
+// update of a StatefulSet.
+type PartitionOpts struct {
+	Namespace string
+	Name      string
+
+	// Steps are the partition values applied in order, e.g. [4, 2, 0]:
+	// pods with an ordinal >= partition are updated at every step.
+	Steps []int32
+
+	// StepTimeout bounds the wait for the updated pods of each step to become ready.
+	StepTimeout  time.Duration
+	PollInterval time.Duration
+
+	// OnProgress, if set, is invoked with the status messages while waiting.
+	OnProgress func(partition int32, msg string)
+
+	// Proceed, if set, is invoked after each completed step (except the last one);
+	// returning false stops the update leaving the current partition in place.
+	Proceed func(partition int32) (bool, error)
+}
+
+// SetPartition patches the rolling update partition of the StatefulSet.
+func SetPartition(f kubeutil.Factory, namespace, name string, partition int32) (*appsv1.StatefulSet, error) {
+	return SetPartitionContext(context.Background(), f, namespace, name, partition)
+}
+
+// SetPartitionContext is like SetPartition but stops when the context is cancelled.
+func SetPartitionContext(ctx context.Context, f kubeutil.Factory, namespace, name string, partition int32) (*appsv1.StatefulSet, error) {
+	cli, err := f.KubernetesClientSet()
+	if err != nil {
+		return nil, err
+	}
+
+	return setPartition(ctx, cli.AppsV1().StatefulSets(namespace), name, partition)
+}
+
+// PartitionedUpdate steps the StatefulSet partition down through o.Steps,
+// waiting at every step for the updated ordinals to become ready.
+func PartitionedUpdate(f kubeutil.Factory, o PartitionOpts) error {
+	return PartitionedUpdateContext(context.Background(), f, o)
+}
+
+// PartitionedUpdateContext is like PartitionedUpdate but stops when the
+// context is cancelled, leaving the current partition in place.
+func PartitionedUpdateContext(ctx context.Context, f kubeutil.Factory, o PartitionOpts) error {
+	if len(o.Steps) == 0 {
+		return fmt.Errorf("at least one partition step is required")
+	}
+	for i := 1; i < len(o.Steps); i++ {
+		if o.Steps[i] >= o.Steps[i-1] {
+			return fmt.Errorf("partition steps must be strictly decreasing")
+		}
+	}
+	if o.StepTimeout <= 0 {
+		o.StepTimeout = defaultStepTimeout
+	}
+	if o.PollInterval <= 0 {
+		o.PollInterval = defaultPollInterval
+	}
+
+	cli, err := f.KubernetesClientSet()
+	if err != nil {
+		return err
+	}
+
+	return partitionedUpdate(ctx, cli.AppsV1().StatefulSets(o.Namespace), o)
+}
+
+func setPartition(ctx context.Context, sts appsv1client.StatefulSetInterface, name string, partition int32) (*appsv1.StatefulSet, error) {
+	patch := fmt.Sprintf(`{"spec":{"updateStrategy":{"type":%q,"rollingUpdate":{"partition":%d}}}}`,
+		appsv1.RollingUpdateStatefulSetStrategyType, partition)
+
+	return sts.Patch(ctx, name, types.StrategicMergePatchType, []byte(patch), metav1.PatchOptions{})
+}
+
+func partitionedUpdate(ctx context.Context, sts appsv1client.StatefulSetInterface, o PartitionOpts) error {
+	for i, partition := range o.Steps {
+		if partition < 0 {
+			return fmt.Errorf("invalid partition %d", partition)
+		}
+		if _, err := setPartition(ctx, sts, o.Name, partition); err != nil {
+			return err
+		}
+
+		stepCtx, cancel := context.WithTimeout(ctx, o.StepTimeout)
+		err := wait.PollImmediateUntilWithContext(stepCtx, o.PollInterval, func(ctx context.Context) (bool, error) {
+			obj, err := sts.Get(ctx, o.Name, metav1.GetOptions{})
+			if err != nil {
+				return false, err
+			}
+			msg, done, err := Status(obj)
+			if err != nil {
+				return false, err
+			}
+			if o.OnProgress != nil {
+				o.OnProgress(partition, msg)
+			}
+			return done, nil
+		})
+		cancel()
+		if err != nil {
+			if ctx.Err() != nil {
+				err = ctx.Err()
+			}
+			return fmt.Errorf("waiting for partition %d of statefulset %s/%s: %w", partition, o.Namespace, o.Name, err)
+		}
+
+		if o.Proceed != nil && i < len(o.Steps)-1 {
+			ok, err := o.Proceed(partition)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return nil
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,73 @@
+package rollout
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestPartitionedUpdateCancel(t *testing.T) {
+	replicas := int32(3)
+	cli := fake.NewSimpleClientset(&appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "default", Generation: 1},
+		Spec: appsv1.StatefulSetSpec{
+			Replicas:       &replicas,
+			UpdateStrategy: appsv1.StatefulSetUpdateStrategy{Type: appsv1.RollingUpdateStatefulSetStrategyType},
+		},
+		Status: appsv1.StatefulSetStatus{
+			ObservedGeneration: 1,
+			ReadyReplicas:      3,
+			UpdatedReplicas:    1,
+			CurrentRevision:    "db-1",
+			UpdateRevision:     "db-2",
+		},
+	})
+	sts := cli.AppsV1().StatefulSets("default")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	proceeded := []int32{}
+	o := PartitionOpts{
+		Namespace:    "default",
+		Name:         "db",
+		Steps:        []int32{2, 0},
+		StepTimeout:  time.Minute,
+		PollInterval: 10 * time.Millisecond,
+		Proceed: func(partition int32) (bool, error) {
+			proceeded = append(proceeded, partition)
+			return true, nil
+		},
+		// the last step never completes: the rolling update is still in progress
+		OnProgress: func(partition int32, msg string) {
+			if partition == 0 {
+				cancel()
+			}
+		},
+	}
+
+	start := time.Now()
+	err := partitionedUpdate(ctx, sts, o)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the update to be cancelled, got %v", err)
+	}
+	if time.Since(start) > 10*time.Second {
+		t.Fatal("expected the cancellation to stop the wait")
+	}
+	if len(proceeded) != 1 || proceeded[0] != 2 {
+		t.Fatalf("expected to proceed after the first step only, got %v", proceeded)
+	}
+
+	obj, err := sts.Get(context.Background(), "db", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := obj.Spec.UpdateStrategy.RollingUpdate.Partition; p == nil || *p != 0 {
+		t.Fatalf("expected the last partition to be applied, got %v", p)
+	}
+}
@@ -0,0 +1,178 @@
+package rollout
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	kubeutil "github.com/lucasepe/kube/util"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/dynamic"
+)
+
+const (
+	// RestartedAtAnnotation is the pod template annotation set by Restart
+	// (the same used by kubectl rollout restart).
+	RestartedAtAnnotation = "kubectl.kubernetes.io/restartedAt"
+)
+
+var restartableKinds = []struct {
+	kind string
+	gvr  schema.GroupVersionResource
+}{
+	{"Deployment", schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}},
+	{"StatefulSet", schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "statefulsets"}},
+	{"DaemonSet", schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "daemonsets"}},
+}
+
+// RestartOpts selects the workloads to restart.
+type RestartOpts struct {
+	Namespace string
+	// AllNamespaces restarts the selected workloads of every namespace.
+	AllNamespaces bool
+	// Name requires Kind to be set too.
+	Name string
+	// Kind restricts the restart to deployments, statefulsets or daemonsets;
+	// kinds, resource names and short names (e.g. "deploy") are accepted.
+	// Empty means all of them.
+	Kind string
+	// LabelSelector selects the workloads to restart when Name is empty.
+	LabelSelector string
+	// DryRun submits the patches using server-side dry-run.
+	DryRun bool
+}
+
+func (o *RestartOpts) complete(f kubeutil.Factory) error {
+	if len(o.Name) == 0 && len(o.LabelSelector) == 0 && len(o.Kind) == 0 {
+		return fmt.Errorf("you must specify a name, a label selector or a kind")
+	}
+	if len(o.Name) > 0 && len(o.Kind) == 0 {
+		return fmt.Errorf("you must specify the kind of the workload %q", o.Name)
+	}
+	if o.AllNamespaces && len(o.Name) > 0 {
+		return fmt.Errorf("a workload name cannot be used with all namespaces")
+	}
+
+	if len(o.Kind) > 0 {
+		mapper, err := f.ToRESTMapper()
+		if err != nil {
+			return err
+		}
+		if o.Kind, err = restartableKind(mapper, o.Kind); err != nil {
+			return err
+		}
+	}
+
+	if o.AllNamespaces {
+		o.Namespace = metav1.NamespaceAll
+		return nil
+	}
+	if len(o.Namespace) == 0 {
+		ns, _, err := f.ToRawKubeConfigLoader().Namespace()
+		if err != nil {
+			return err
+		}
+		o.Namespace = ns
+	}
+	return nil
+}
+
+// restartableKind resolves the kind, resource or short name
+// through the RESTMapper to one of the restartable kinds.
+func restartableKind(mapper meta.RESTMapper, kind string) (string, error) {
+	// the mapper matches the resources case-insensitively, by plural
+	// and singular name, the latter being the lower-cased kind
+	gvr, err := mapper.ResourceFor(schema.ParseGroupResource(kind).WithVersion(""))
+	if err != nil {
+		return "", err
+	}
+
+	for _, rk := range restartableKinds {
+		if rk.gvr.GroupResource() == gvr.GroupResource() {
+			return rk.kind, nil
+		}
+	}
+	return "", fmt.Errorf("%s cannot be restarted: only deployments, statefulsets and daemonsets are supported", kind)
+}
+
+// Restart triggers a new rollout of the selected Deployments, StatefulSets
+// and DaemonSets patching their pod template with the restartedAt annotation.
+// Workloads are selected by Kind and Name, or by LabelSelector (all the
+// workloads of Kind if both are empty). Returns the restarted objects.
+func Restart(f kubeutil.Factory, o RestartOpts) ([]*unstructured.Unstructured, error) {
+	return RestartContext(context.Background(), f, o)
+}
+
+// RestartContext is like Restart but stops when the context is cancelled.
+func RestartContext(ctx context.Context, f kubeutil.Factory, o RestartOpts) ([]*unstructured.Unstructured, error) {
+	if err := o.complete(f); err != nil {
+		return nil, err
+	}
+
+	dyn, err := f.DynamicClient()
+	if err != nil {
+		return nil, err
+	}
+
+	return restart(ctx, dyn, o, time.Now())
+}
+
+func restart(ctx context.Context, dyn dynamic.Interface, o RestartOpts, now time.Time) ([]*unstructured.Unstructured, error) {
+	patch := fmt.Sprintf(`{"spec":{"template":{"metadata":{"annotations":{%q:%q}}}}}`,
+		RestartedAtAnnotation, now.Format(time.RFC3339))
+
+	patchOptions := metav1.PatchOptions{}
+	if o.DryRun {
+		patchOptions.DryRun = []string{metav1.DryRunAll}
+	}
+
+	res := []*unstructured.Unstructured{}
+	errs := []error{}
+	for _, rk := range restartableKinds {
+		if len(o.Kind) > 0 && o.Kind != rk.kind {
+			continue
+		}
+		ri := dyn.Resource(rk.gvr)
+
+		targets := []unstructured.Unstructured{}
+		if len(o.Name) > 0 {
+			obj, err := ri.Namespace(o.Namespace).Get(ctx, o.Name, metav1.GetOptions{})
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			targets = append(targets, *obj)
+		} else {
+			list, err := ri.Namespace(o.Namespace).List(ctx, metav1.ListOptions{LabelSelector: o.LabelSelector})
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			targets = list.Items
+		}
+
+		for _, obj := range targets {
+			if paused, _, _ := unstructured.NestedBool(obj.Object, "spec", "paused"); paused {
+				errs = append(errs, fmt.Errorf("cannot restart paused %s %s/%s, resume it first",
+					strings.ToLower(rk.kind), obj.GetNamespace(), obj.GetName()))
+				continue
+			}
+
+			out, err := ri.Namespace(obj.GetNamespace()).
+				Patch(ctx, obj.GetName(), types.MergePatchType, []byte(patch), patchOptions)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			res = append(res, out)
+		}
+	}
+
+	return res, utilerrors.NewAggregate(errs)
+}
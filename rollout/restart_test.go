@@ -0,0 +1,157 @@
+package rollout
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	kubeutil "github.com/lucasepe/kube/util"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// newFactory returns a factory whose kubeconfig defaults to the given namespace.
+func newFactory(namespace string) kubeutil.Factory {
+	cfg := clientcmdapi.Config{
+		Clusters:       map[string]*clientcmdapi.Cluster{"test": {Server: "https://127.0.0.1:6443"}},
+		Contexts:       map[string]*clientcmdapi.Context{"test": {Cluster: "test", Namespace: namespace}},
+		CurrentContext: "test",
+	}
+	return kubeutil.NewFactory("", "",
+		kubeutil.WithClientConfig(clientcmd.NewDefaultClientConfig(cfg, &clientcmd.ConfigOverrides{})))
+}
+
+func workload(kind, namespace, name string, labels map[string]string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion("apps/v1")
+	obj.SetKind(kind)
+	obj.SetNamespace(namespace)
+	obj.SetName(name)
+	obj.SetLabels(labels)
+	return obj
+}
+
+func newDynamicClient(objs ...runtime.Object) *dynamicfake.FakeDynamicClient {
+	listKinds := map[schema.GroupVersionResource]string{}
+	for _, rk := range restartableKinds {
+		listKinds[rk.gvr] = rk.kind + "List"
+	}
+	return dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds, objs...)
+}
+
+func TestRestartOptsDefaultNamespace(t *testing.T) {
+	f := newFactory("team-a")
+
+	o := RestartOpts{LabelSelector: "app=web"}
+	if err := o.complete(f); err != nil {
+		t.Fatal(err)
+	}
+	if o.Namespace != "team-a" {
+		t.Fatalf("expected the kubeconfig namespace, got %q", o.Namespace)
+	}
+
+	o = RestartOpts{Namespace: "team-b", LabelSelector: "app=web", AllNamespaces: true}
+	if err := o.complete(f); err != nil {
+		t.Fatal(err)
+	}
+	if o.Namespace != metav1.NamespaceAll {
+		t.Fatalf("expected all namespaces, got %q", o.Namespace)
+	}
+
+	if err := (&RestartOpts{}).complete(f); err == nil {
+		t.Fatal("expected an error without name, selector or kind")
+	}
+	if err := (&RestartOpts{Name: "web"}).complete(f); err == nil {
+		t.Fatal("expected an error for a name without kind")
+	}
+	if err := (&RestartOpts{Name: "web", Kind: "deploy", AllNamespaces: true}).complete(f); err == nil {
+		t.Fatal("expected an error for a name across all namespaces")
+	}
+}
+
+func TestRestartableKind(t *testing.T) {
+	mapper := meta.NewDefaultRESTMapper(nil)
+	for _, rk := range restartableKinds {
+		mapper.Add(rk.gvr.GroupVersion().WithKind(rk.kind), meta.RESTScopeNamespace)
+	}
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Pod"}, meta.RESTScopeNamespace)
+
+	tests := map[string]string{
+		"Deployment":       "Deployment",
+		"deployments":      "Deployment",
+		"statefulset":      "StatefulSet",
+		"daemonsets.apps":  "DaemonSet",
+		"StatefulSet.apps": "StatefulSet",
+	}
+	for in, want := range tests {
+		got, err := restartableKind(mapper, in)
+		if err != nil {
+			t.Errorf("%s: %v", in, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("%s: expected %s, got %s", in, want, got)
+		}
+	}
+
+	if _, err := restartableKind(mapper, "pods"); err == nil {
+		t.Fatal("expected an error for a kind that cannot be restarted")
+	}
+	if _, err := restartableKind(mapper, "cronjobs"); err == nil {
+		t.Fatal("expected an error for an unknown resource")
+	}
+}
+
+func TestRestart(t *testing.T) {
+	labels := map[string]string{"app": "web"}
+	paused := workload("Deployment", "team-a", "paused", labels)
+	if err := unstructured.SetNestedField(paused.Object, true, "spec", "paused"); err != nil {
+		t.Fatal(err)
+	}
+
+	dyn := newDynamicClient(
+		workload("Deployment", "team-a", "web", labels),
+		workload("StatefulSet", "team-b", "db", labels),
+		workload("DaemonSet", "team-a", "agent", nil),
+		paused,
+	)
+
+	now := time.Date(2022, 11, 10, 10, 30, 0, 0, time.UTC)
+	res, err := restart(context.Background(), dyn, RestartOpts{LabelSelector: "app=web"}, now)
+	if err == nil {
+		t.Fatal("expected an error for the paused deployment")
+	}
+
+	got := map[string]string{}
+	for _, obj := range res {
+		ann, _, _ := unstructured.NestedString(obj.Object,
+			"spec", "template", "metadata", "annotations", RestartedAtAnnotation)
+		got[obj.GetNamespace()+"/"+obj.GetName()] = ann
+	}
+	want := now.Format(time.RFC3339)
+	if len(got) != 2 || got["team-a/web"] != want || got["team-b/db"] != want {
+		t.Fatalf("unexpected restarted workloads: %v", got)
+	}
+}
+
+func TestRestartByName(t *testing.T) {
+	dyn := newDynamicClient(workload("StatefulSet", "team-a", "db", nil))
+
+	res, err := restart(context.Background(), dyn, RestartOpts{Namespace: "team-a", Name: "db", Kind: "StatefulSet"}, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != 1 || res[0].GetKind() != "StatefulSet" {
+		t.Fatalf("unexpected restarted workloads: %v", res)
+	}
+
+	if _, err := restart(context.Background(), dyn, RestartOpts{Namespace: "team-b", Name: "db", Kind: "StatefulSet"}, time.Now()); err == nil {
+		t.Fatal("expected an error for a missing workload")
+	}
+}
@@ -0,0 +1,136 @@
+package rollout
+
+import (
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Status returns a message describing the rollout status of a Deployment,
+// DaemonSet or StatefulSet (typed or unstructured) and whether it is complete.
+func Status(obj runtime.Object) (string, bool, error) {
+	if u, ok := obj.(*unstructured.Unstructured); ok {
+		typed, err := toTyped(u)
+		if err != nil {
+			return "", false, err
+		}
+		obj = typed
+	}
+
+	switch t := obj.(type) {
+	case *appsv1.Deployment:
+		return deploymentStatus(t)
+	case *appsv1.DaemonSet:
+		return daemonSetStatus(t)
+	case *appsv1.StatefulSet:
+		return statefulSetStatus(t)
+	}
+
+	return "", false, fmt.Errorf("no status viewer has been implemented for %T", obj)
+}
+
+func toTyped(u *unstructured.Unstructured) (runtime.Object, error) {
+	var obj runtime.Object
+	switch u.GroupVersionKind().GroupKind() {
+	case appsv1.SchemeGroupVersion.WithKind("Deployment").GroupKind():
+		obj = &appsv1.Deployment{}
+	case appsv1.SchemeGroupVersion.WithKind("DaemonSet").GroupKind():
+		obj = &appsv1.DaemonSet{}
+	case appsv1.SchemeGroupVersion.WithKind("StatefulSet").GroupKind():
+		obj = &appsv1.StatefulSet{}
+	default:
+		return nil, fmt.Errorf("no status viewer has been implemented for %s", u.GroupVersionKind().Kind)
+	}
+
+	err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.UnstructuredContent(), obj)
+	return obj, err
+}
+
+func deploymentStatus(d *appsv1.Deployment) (string, bool, error) {
+	if d.Generation > d.Status.ObservedGeneration {
+		return "Waiting for deployment spec update to be observed...", false, nil
+	}
+
+	for _, c := range d.Status.Conditions {
+		if c.Type == appsv1.DeploymentProgressing && c.Reason == "ProgressDeadlineExceeded" {
+			return "", false, fmt.Errorf("deployment %q exceeded its progress deadline", d.Name)
+		}
+	}
+
+	replicas := int32(1)
+	if d.Spec.Replicas != nil {
+		replicas = *d.Spec.Replicas
+	}
+
+	if d.Status.UpdatedReplicas < replicas {
+		return fmt.Sprintf("Waiting for deployment %q rollout to finish: %d out of %d new replicas have been updated...",
+			d.Name, d.Status.UpdatedReplicas, replicas), false, nil
+	}
+	if d.Status.Replicas > d.Status.UpdatedReplicas {
+		return fmt.Sprintf("Waiting for deployment %q rollout to finish: %d old replicas are pending termination...",
+			d.Name, d.Status.Replicas-d.Status.UpdatedReplicas), false, nil
+	}
+	if d.Status.AvailableReplicas < d.Status.UpdatedReplicas {
+		return fmt.Sprintf("Waiting for deployment %q rollout to finish: %d of %d updated replicas are available...",
+			d.Name, d.Status.AvailableReplicas, d.Status.UpdatedReplicas), false, nil
+	}
+
+	return fmt.Sprintf("deployment %q successfully rolled out", d.Name), true, nil
+}
+
+func daemonSetStatus(ds *appsv1.DaemonSet) (string, bool, error) {
+	if ds.Spec.UpdateStrategy.Type != appsv1.RollingUpdateDaemonSetStrategyType {
+		return "", true, fmt.Errorf("rollout status is only available for %s strategy type", appsv1.RollingUpdateDaemonSetStrategyType)
+	}
+
+	if ds.Generation > ds.Status.ObservedGeneration {
+		return "Waiting for daemon set spec update to be observed...", false, nil
+	}
+	if ds.Status.UpdatedNumberScheduled < ds.Status.DesiredNumberScheduled {
+		return fmt.Sprintf("Waiting for daemon set %q rollout to finish: %d out of %d new pods have been updated...",
+			ds.Name, ds.Status.UpdatedNumberScheduled, ds.Status.DesiredNumberScheduled), false, nil
+	}
+	if ds.Status.NumberAvailable < ds.Status.DesiredNumberScheduled {
+		return fmt.Sprintf("Waiting for daemon set %q rollout to finish: %d of %d updated pods are available...",
+			ds.Name, ds.Status.NumberAvailable, ds.Status.DesiredNumberScheduled), false, nil
+	}
+
+	return fmt.Sprintf("daemon set %q successfully rolled out", ds.Name), true, nil
+}
+
+func statefulSetStatus(sts *appsv1.StatefulSet) (string, bool, error) {
+	if sts.Spec.UpdateStrategy.Type != appsv1.RollingUpdateStatefulSetStrategyType {
+		return "", true, fmt.Errorf("rollout status is only available for %s strategy type", appsv1.RollingUpdateStatefulSetStrategyType)
+	}
+
+	if sts.Status.ObservedGeneration == 0 || sts.Generation > sts.Status.ObservedGeneration {
+		return "Waiting for statefulset spec update to be observed...", false, nil
+	}
+
+	replicas := int32(1)
+	if sts.Spec.Replicas != nil {
+		replicas = *sts.Spec.Replicas
+	}
+	if sts.Status.ReadyReplicas < replicas {
+		return fmt.Sprintf("Waiting for %d pods to be ready...", replicas-sts.Status.ReadyReplicas), false, nil
+	}
+
+	if ru := sts.Spec.UpdateStrategy.RollingUpdate; ru != nil && ru.Partition != nil && *ru.Partition > 0 {
+		if sts.Status.UpdatedReplicas < replicas-*ru.Partition {
+			return fmt.Sprintf("Waiting for partitioned roll out to finish: %d out of %d new pods have been updated...",
+				sts.Status.UpdatedReplicas, replicas-*ru.Partition), false, nil
+		}
+		return fmt.Sprintf("partitioned roll out complete: %d new pods have been updated...",
+			sts.Status.UpdatedReplicas), true, nil
+	}
+
+	if sts.Status.UpdateRevision != sts.Status.CurrentRevision {
+		return fmt.Sprintf("waiting for statefulset rolling update to complete %d pods at revision %s...",
+			sts.Status.UpdatedReplicas, sts.Status.UpdateRevision), false, nil
+	}
+
+	return fmt.Sprintf("statefulset rolling update complete %d pods at revision %s...",
+		sts.Status.CurrentReplicas, sts.Status.CurrentRevision), true, nil
+}
@@ -0,0 +1,138 @@
+package scale
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	kubeutil "github.com/lucasepe/kube/util"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+	scaleclient "k8s.io/client-go/scale"
+)
+
+const (
+	defaultWaitTimeout  = 5 * time.Minute
+	defaultPollInterval = time.Second
+)
+
+// Opts is a set of options that allows you to scale a resource.
+type Opts struct {
+	Namespace string
+	// Resource is the resource type (e.g. "deployments", "sts", "widgets.example.com").
+	Resource string
+	Name     string
+	Replicas int32
+	// CurrentReplicas, if not nil, is a precondition on the current size.
+	CurrentReplicas *int32
+
+	// Wait blocks until the observed replicas match the desired ones.
+	Wait bool
+	// WaitTimeout is the max time to wait (default 5m).
+	WaitTimeout time.Duration
+}
+
+// Do scales the resource through its scale subresource, so that any
+// resource implementing it (CRDs included) is supported.
+func Do(f kubeutil.Factory, o Opts) (*autoscalingv1.Scale, error) {
+	return DoContext(context.Background(), f, o)
+}
+
+// DoContext is like Do but stops when the context is cancelled.
+func DoContext(ctx context.Context, f kubeutil.Factory, o Opts) (*autoscalingv1.Scale, error) {
+	if err := o.complete(f); err != nil {
+		return nil, err
+	}
+
+	gvr, cli, err := client(f, o.Resource)
+	if err != nil {
+		return nil, err
+	}
+
+	return scale(ctx, cli.Scales(o.Namespace), gvr.GroupResource(), o)
+}
+
+// complete sets the defaults of the options.
+func (o *Opts) complete(f kubeutil.Factory) error {
+	if o.WaitTimeout <= 0 {
+		o.WaitTimeout = defaultWaitTimeout
+	}
+	if len(o.Namespace) == 0 {
+		ns, _, err := f.ToRawKubeConfigLoader().Namespace()
+		if err != nil {
+			return err
+		}
+		o.Namespace = ns
+	}
+	return nil
+}
+
+// scale updates the scale subresource and, if requested,
+// waits for the observed replicas to match.
+func scale(ctx context.Context, scales scaleclient.ScaleInterface, gr schema.GroupResource, o Opts) (*autoscalingv1.Scale, error) {
+	cur, err := scales.Get(ctx, gr, o.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	if o.CurrentReplicas != nil && cur.Spec.Replicas != *o.CurrentReplicas {
+		return nil, fmt.Errorf("expected %d replicas for %s/%s, found %d",
+			*o.CurrentReplicas, gr.Resource, o.Name, cur.Spec.Replicas)
+	}
+
+	cur.Spec.Replicas = o.Replicas
+	res, err := scales.Update(ctx, gr, cur, metav1.UpdateOptions{})
+	if err != nil {
+		return nil, err
+	}
+	if !o.Wait {
+		return res, nil
+	}
+
+	err = wait.PollImmediateWithContext(ctx, defaultPollInterval, o.WaitTimeout, func(ctx context.Context) (bool, error) {
+		res, err = scales.Get(ctx, gr, o.Name, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		return res.Status.Replicas == res.Spec.Replicas, nil
+	})
+	if err != nil && ctx.Err() != nil {
+		err = ctx.Err()
+	}
+	if err != nil {
+		return res, fmt.Errorf("waiting for %s/%s to have %d replicas: %w", gr.Resource, o.Name, o.Replicas, err)
+	}
+
+	return res, nil
+}
+
+// client resolves the resource type and returns a scale client.
+func client(f kubeutil.Factory, resource string) (schema.GroupVersionResource, scaleclient.ScalesGetter, error) {
+	mapper, err := f.ToRESTMapper()
+	if err != nil {
+		return schema.GroupVersionResource{}, nil, err
+	}
+	gvr, err := mapper.ResourceFor(schema.ParseGroupResource(resource).WithVersion(""))
+	if err != nil {
+		return schema.GroupVersionResource{}, nil, err
+	}
+
+	dc, err := f.ToDiscoveryClient()
+	if err != nil {
+		return schema.GroupVersionResource{}, nil, err
+	}
+	cfg, err := f.ToRESTConfig()
+	if err != nil {
+		return schema.GroupVersionResource{}, nil, err
+	}
+
+	cli, err := scaleclient.NewForConfig(cfg, mapper, dynamic.LegacyAPIPathResolverFunc,
+		scaleclient.NewDiscoveryScaleKindResolver(dc))
+	if err != nil {
+		return schema.GroupVersionResource{}, nil, err
+	}
+
+	return gvr, cli, nil
+}
@@ -0,0 +1,149 @@
+package scale
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	kubeutil "github.com/lucasepe/kube/util"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakescale "k8s.io/client-go/scale/fake"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+var deployments = schema.GroupResource{Group: "apps", Resource: "deployments"}
+
+// newScaleClient returns a fake scale client for a deployment with the
+// given replicas; the observed replicas reach the desired ones after
+// the number of gets given by lag.
+func newScaleClient(replicas int32, lag int) (*fakescale.FakeScaleClient, *[]int32) {
+	cur := &autoscalingv1.Scale{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+		Spec:       autoscalingv1.ScaleSpec{Replicas: replicas},
+		Status:     autoscalingv1.ScaleStatus{Replicas: replicas},
+	}
+	updates := []int32{}
+
+	cli := &fakescale.FakeScaleClient{}
+	cli.AddReactor("get", "deployments", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		if lag > 0 && len(updates) > 0 {
+			lag--
+		} else {
+			cur.Status.Replicas = cur.Spec.Replicas
+		}
+		return true, cur.DeepCopy(), nil
+	})
+	cli.AddReactor("update", "deployments", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		obj := action.(clienttesting.UpdateAction).GetObject().(*autoscalingv1.Scale)
+		updates = append(updates, obj.Spec.Replicas)
+		cur.Spec.Replicas = obj.Spec.Replicas
+		return true, cur.DeepCopy(), nil
+	})
+	return cli, &updates
+}
+
+func TestOptsDefaults(t *testing.T) {
+	cfg := clientcmdapi.Config{
+		Clusters:       map[string]*clientcmdapi.Cluster{"test": {Server: "https://127.0.0.1:6443"}},
+		Contexts:       map[string]*clientcmdapi.Context{"test": {Cluster: "test", Namespace: "team-a"}},
+		CurrentContext: "test",
+	}
+	f := kubeutil.NewFactory("", "",
+		kubeutil.WithClientConfig(clientcmd.NewDefaultClientConfig(cfg, &clientcmd.ConfigOverrides{})))
+
+	o := Opts{Name: "web"}
+	if err := o.complete(f); err != nil {
+		t.Fatal(err)
+	}
+	if o.Namespace != "team-a" {
+		t.Fatalf("expected the namespace of the current context, got %q", o.Namespace)
+	}
+	if o.WaitTimeout != defaultWaitTimeout {
+		t.Fatalf("expected the default wait timeout, got %s", o.WaitTimeout)
+	}
+}
+
+func TestScale(t *testing.T) {
+	cli, updates := newScaleClient(1, 0)
+
+	res, err := scale(context.Background(), cli.Scales("default"), deployments, Opts{Name: "web", Replicas: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Spec.Replicas != 3 {
+		t.Fatalf("expected 3 replicas, got %d", res.Spec.Replicas)
+	}
+	if len(*updates) != 1 || (*updates)[0] != 3 {
+		t.Fatalf("expected a single update to 3 replicas, got %v", *updates)
+	}
+}
+
+func TestScaleCurrentReplicas(t *testing.T) {
+	cli, updates := newScaleClient(2, 0)
+
+	current := int32(1)
+	_, err := scale(context.Background(), cli.Scales("default"), deployments, Opts{
+		Name:            "web",
+		Replicas:        3,
+		CurrentReplicas: &current,
+	})
+	if err == nil {
+		t.Fatal("expected the precondition to fail")
+	}
+	if len(*updates) != 0 {
+		t.Fatalf("expected no updates, got %v", *updates)
+	}
+}
+
+func TestScaleWait(t *testing.T) {
+	cli, _ := newScaleClient(1, 1)
+
+	res, err := scale(context.Background(), cli.Scales("default"), deployments, Opts{
+		Name:        "web",
+		Replicas:    3,
+		Wait:        true,
+		WaitTimeout: 10 * time.Second,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Status.Replicas != 3 {
+		t.Fatalf("expected to wait for 3 observed replicas, got %d", res.Status.Replicas)
+	}
+}
+
+func TestScaleWaitTimeout(t *testing.T) {
+	cli, _ := newScaleClient(1, 100)
+
+	_, err := scale(context.Background(), cli.Scales("default"), deployments, Opts{
+		Name:        "web",
+		Replicas:    3,
+		Wait:        true,
+		WaitTimeout: 10 * time.Millisecond,
+	})
+	if err == nil {
+		t.Fatal("expected the wait to time out")
+	}
+}
+
+func TestScaleWaitCancelled(t *testing.T) {
+	cli, _ := newScaleClient(1, 100)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := scale(ctx, cli.Scales("default"), deployments, Opts{
+		Name:        "web",
+		Replicas:    3,
+		Wait:        true,
+		WaitTimeout: time.Minute,
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the context error, got %v", err)
+	}
+}
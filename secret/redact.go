@@ -0,0 +1,54 @@
+package secret
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Redact returns a copy of the secret data safe for display, where each value
+// is replaced by its size (e.g. "<redacted: 42 bytes>").
+func Redact(data map[string][]byte) map[string]string {
+	res := make(map[string]string, len(data))
+	for k, v := range data {
+		res[k] = RedactValue(v, 0)
+	}
+	return res
+}
+
+// RedactValue hides the value showing at most its first visible characters
+// followed by its size; visible is ignored when the value is too short
+// to be safely disclosed in part.
+func RedactValue(v []byte, visible int) string {
+	if visible <= 0 || len(v) < 4*visible {
+		return fmt.Sprintf("<redacted: %d bytes>", len(v))
+	}
+	return fmt.Sprintf("%s<redacted: %d bytes>", v[:visible], len(v))
+}
+
+// RedactDockerConfig returns a copy of the docker config with passwords and auths hidden.
+func RedactDockerConfig(cfg *DockerConfig) *DockerConfig {
+	if cfg == nil {
+		return nil
+	}
+
+	res := &DockerConfig{Auths: make(map[string]DockerAuth, len(cfg.Auths))}
+	for registry, auth := range cfg.Auths {
+		if len(auth.Password) > 0 {
+			auth.Password = RedactValue([]byte(auth.Password), 0)
+		}
+		if len(auth.Auth) > 0 {
+			auth.Auth = RedactValue([]byte(auth.Auth), 0)
+		}
+		res.Auths[registry] = auth
+	}
+	return res
+}
+
+// RedactToken hides the signature of a JWT keeping header and claims readable.
+func RedactToken(token string) string {
+	parts := strings.Split(strings.TrimSpace(token), ".")
+	if len(parts) != 3 {
+		return RedactValue([]byte(token), 0)
+	}
+	return strings.Join([]string{parts[0], parts[1], "<redacted>"}, ".")
+}
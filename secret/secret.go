@@ -0,0 +1,279 @@
+package secret
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	kubeutil "github.com/lucasepe/kube/util"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// Decoded is a Secret with its data decoded and, where
+// recognized, parsed according to the secret type.
+type Decoded struct {
+	Name      string
+	Namespace string
+	Type      corev1.SecretType
+	Data      map[string][]byte
+
+	// Kubeconfigs holds the data keys that contain a valid kubeconfig.
+	Kubeconfigs map[string]*clientcmdapi.Config
+	// DockerConfig is set for dockerconfigjson and dockercfg secrets.
+	DockerConfig *DockerConfig
+	// Certificates holds the PEM certificates found in the data keys.
+	Certificates []Certificate
+	// Token holds the claims of a service account token secret.
+	Token *TokenClaims
+}
+
+// DockerConfig holds the registry credentials of an image pull secret.
+type DockerConfig struct {
+	Auths map[string]DockerAuth `json:"auths"`
+}
+
+// DockerAuth is the credential for a single registry.
+type DockerAuth struct {
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	Email    string `json:"email,omitempty"`
+	Auth     string `json:"auth,omitempty"`
+}
+
+// Certificate summarizes an x509 certificate found in a secret.
+type Certificate struct {
+	Key       string
+	Subject   string
+	Issuer    string
+	DNSNames  []string
+	IsCA      bool
+	NotBefore time.Time
+	NotAfter  time.Time
+}
+
+// ExpiresIn returns the time left before the certificate expires
+// (negative if already expired).
+func (c Certificate) ExpiresIn(now time.Time) time.Duration {
+	return c.NotAfter.Sub(now)
+}
+
+// TokenClaims are the (unverified) claims of a service account JWT.
+type TokenClaims struct {
+	Issuer    string    `json:"iss,omitempty"`
+	Subject   string    `json:"sub,omitempty"`
+	Audience  []string  `json:"-"`
+	ExpiresAt time.Time `json:"-"`
+	IssuedAt  time.Time `json:"-"`
+
+	Namespace      string `json:"kubernetes.io/serviceaccount/namespace,omitempty"`
+	ServiceAccount string `json:"kubernetes.io/serviceaccount/service-account.name,omitempty"`
+}
+
+// Get fetches the named Secret and decodes it.
+func Get(ctx context.Context, f kubeutil.Factory, namespace, name string) (*Decoded, error) {
+	cli, err := f.KubernetesClientSet()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(namespace) == 0 {
+		namespace, _, err = f.ToRawKubeConfigLoader().Namespace()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sec, err := cli.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	return Decode(sec)
+}
+
+// Decode parses the data of the Secret according to its type.
+//
+// Data keys that can't be parsed are kept in Data without errors;
+// only the type-mandated keys (e.g. .dockerconfigjson) are validated.
+func Decode(sec *corev1.Secret) (*Decoded, error) {
+	res := &Decoded{
+		Name:      sec.Name,
+		Namespace: sec.Namespace,
+		Type:      sec.Type,
+		Data:      make(map[string][]byte, len(sec.Data)+len(sec.StringData)),
+	}
+	for k, v := range sec.Data {
+		res.Data[k] = v
+	}
+	for k, v := range sec.StringData {
+		res.Data[k] = []byte(v)
+	}
+
+	var err error
+	switch sec.Type {
+	case corev1.SecretTypeDockerConfigJson:
+		res.DockerConfig, err = parseDockerConfig(res.Data[corev1.DockerConfigJsonKey], true)
+	case corev1.SecretTypeDockercfg:
+		res.DockerConfig, err = parseDockerConfig(res.Data[corev1.DockerConfigKey], false)
+	case corev1.SecretTypeServiceAccountToken:
+		res.Token, err = ParseToken(string(res.Data[corev1.ServiceAccountTokenKey]))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("secret %s/%s: %w", sec.Namespace, sec.Name, err)
+	}
+
+	for _, k := range sortedKeys(res.Data) {
+		v := res.Data[k]
+		if certs := parseCertificates(k, v); len(certs) > 0 {
+			res.Certificates = append(res.Certificates, certs...)
+			continue
+		}
+		if looksLikeKubeconfig(v) {
+			if cfg, err := clientcmd.Load(v); err == nil {
+				if res.Kubeconfigs == nil {
+					res.Kubeconfigs = map[string]*clientcmdapi.Config{}
+				}
+				res.Kubeconfigs[k] = cfg
+			}
+		}
+	}
+
+	return res, nil
+}
+
+func parseDockerConfig(dat []byte, isJSON bool) (*DockerConfig, error) {
+	if len(dat) == 0 {
+		return nil, fmt.Errorf("missing docker config data")
+	}
+
+	cfg := &DockerConfig{}
+	if isJSON {
+		if err := json.Unmarshal(dat, cfg); err != nil {
+			return nil, fmt.Errorf("invalid docker config json: %w", err)
+		}
+	} else if err := json.Unmarshal(dat, &cfg.Auths); err != nil {
+		return nil, fmt.Errorf("invalid docker config: %w", err)
+	}
+
+	// fill username and password from the encoded auth when missing
+	for registry, auth := range cfg.Auths {
+		if len(auth.Auth) == 0 || len(auth.Username) > 0 {
+			continue
+		}
+		dec, err := base64.StdEncoding.DecodeString(auth.Auth)
+		if err != nil {
+			continue
+		}
+		if user, pass, ok := strings.Cut(string(dec), ":"); ok {
+			auth.Username, auth.Password = user, pass
+			cfg.Auths[registry] = auth
+		}
+	}
+
+	return cfg, nil
+}
+
+func parseCertificates(key string, dat []byte) []Certificate {
+	res := []Certificate{}
+	for rest := dat; len(rest) > 0; {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			continue
+		}
+		res = append(res, Certificate{
+			Key:       key,
+			Subject:   cert.Subject.String(),
+			Issuer:    cert.Issuer.String(),
+			DNSNames:  cert.DNSNames,
+			IsCA:      cert.IsCA,
+			NotBefore: cert.NotBefore,
+			NotAfter:  cert.NotAfter,
+		})
+	}
+	return res
+}
+
+func looksLikeKubeconfig(dat []byte) bool {
+	s := string(dat)
+	return strings.Contains(s, "clusters") && strings.Contains(s, "contexts")
+}
+
+// ParseToken extracts the claims of a JWT without verifying its signature.
+func ParseToken(token string) (*TokenClaims, error) {
+	parts := strings.Split(strings.TrimSpace(token), ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("malformed token: expected 3 parts, got %d", len(parts))
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return nil, fmt.Errorf("malformed token payload: %w", err)
+	}
+
+	raw := struct {
+		TokenClaims
+		Aud        interface{} `json:"aud,omitempty"`
+		Exp        int64       `json:"exp,omitempty"`
+		Iat        int64       `json:"iat,omitempty"`
+		Kubernetes *struct {
+			Namespace      string `json:"namespace"`
+			ServiceAccount struct {
+				Name string `json:"name"`
+			} `json:"serviceaccount"`
+		} `json:"kubernetes.io,omitempty"`
+	}{}
+	if err := json.Unmarshal(payload, &raw); err != nil {
+		return nil, fmt.Errorf("malformed token claims: %w", err)
+	}
+
+	claims := raw.TokenClaims
+	switch aud := raw.Aud.(type) {
+	case string:
+		claims.Audience = []string{aud}
+	case []interface{}:
+		for _, a := range aud {
+			if s, ok := a.(string); ok {
+				claims.Audience = append(claims.Audience, s)
+			}
+		}
+	}
+	if raw.Exp > 0 {
+		claims.ExpiresAt = time.Unix(raw.Exp, 0)
+	}
+	if raw.Iat > 0 {
+		claims.IssuedAt = time.Unix(raw.Iat, 0)
+	}
+	// bound tokens (projected volumes, TokenRequest) use nested claims
+	if raw.Kubernetes != nil {
+		claims.Namespace = raw.Kubernetes.Namespace
+		claims.ServiceAccount = raw.Kubernetes.ServiceAccount.Name
+	}
+
+	return &claims, nil
+}
+
+func sortedKeys(m map[string][]byte) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,186 @@
+package secret
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func token(payload string) string {
+	enc := base64.RawURLEncoding.EncodeToString
+	return enc([]byte(`{"alg":"RS256"}`)) + "." + enc([]byte(payload)) + ".c2lnbmF0dXJl"
+}
+
+func TestDecodeDockerConfig(t *testing.T) {
+	auth := base64.StdEncoding.EncodeToString([]byte("robot:s3cret"))
+	sec := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "pull", Namespace: "default"},
+		Type:       corev1.SecretTypeDockerConfigJson,
+		Data: map[string][]byte{
+			corev1.DockerConfigJsonKey: []byte(`{"auths":{"registry.example.com":{"auth":"` + auth + `"}}}`),
+		},
+	}
+
+	dec, err := Decode(sec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := dec.DockerConfig.Auths["registry.example.com"]
+	if got.Username != "robot" || got.Password != "s3cret" {
+		t.Fatalf("expected the credentials decoded from auth, got %+v", got)
+	}
+
+	red := RedactDockerConfig(dec.DockerConfig).Auths["registry.example.com"]
+	if red.Username != "robot" || red.Password == "s3cret" || red.Auth == auth {
+		t.Fatalf("expected the password and auth to be redacted, got %+v", red)
+	}
+	if dec.DockerConfig.Auths["registry.example.com"].Password != "s3cret" {
+		t.Fatal("expected the original config to be left untouched")
+	}
+}
+
+func TestDecodeInvalidDockerConfig(t *testing.T) {
+	for _, data := range []map[string][]byte{
+		{},
+		{corev1.DockerConfigJsonKey: []byte("{")},
+	} {
+		sec := &corev1.Secret{Type: corev1.SecretTypeDockerConfigJson, Data: data}
+		if _, err := Decode(sec); err == nil {
+			t.Errorf("expected an error for %v", data)
+		}
+	}
+}
+
+func TestDecodeServiceAccountToken(t *testing.T) {
+	exp := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	sec := &corev1.Secret{
+		Type: corev1.SecretTypeServiceAccountToken,
+		Data: map[string][]byte{
+			corev1.ServiceAccountTokenKey: []byte(token(`{"iss":"https://kubernetes.default.svc","aud":"api","exp":1893456000,` +
+				`"kubernetes.io":{"namespace":"ci","serviceaccount":{"name":"deployer"}}}`)),
+		},
+		StringData: map[string]string{"note": "plain"},
+	}
+
+	dec, err := Decode(sec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tc := dec.Token
+	if tc.Namespace != "ci" || tc.ServiceAccount != "deployer" {
+		t.Fatalf("expected the bound token claims, got %+v", tc)
+	}
+	if len(tc.Audience) != 1 || tc.Audience[0] != "api" || !tc.ExpiresAt.Equal(exp) {
+		t.Fatalf("unexpected claims %+v", tc)
+	}
+	if string(dec.Data["note"]) != "plain" {
+		t.Fatalf("expected the string data to be merged, got %v", dec.Data)
+	}
+}
+
+func TestDecodeCertificates(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	notAfter := time.Now().Add(24 * time.Hour).Truncate(time.Second)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "web.example.com"},
+		DNSNames:     []string{"web.example.com"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sec := &corev1.Secret{
+		Type: corev1.SecretTypeTLS,
+		Data: map[string][]byte{
+			corev1.TLSCertKey:       pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+			corev1.TLSPrivateKeyKey: pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: []byte("key")}),
+		},
+	}
+
+	dec, err := Decode(sec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dec.Certificates) != 1 {
+		t.Fatalf("expected a certificate, got %+v", dec.Certificates)
+	}
+	c := dec.Certificates[0]
+	if c.Key != corev1.TLSCertKey || c.Subject != "CN=web.example.com" || !c.NotAfter.Equal(notAfter) {
+		t.Fatalf("unexpected certificate %+v", c)
+	}
+	if d := c.ExpiresIn(notAfter.Add(-time.Hour)); d != time.Hour {
+		t.Fatalf("expected one hour left, got %s", d)
+	}
+}
+
+func TestDecodeKubeconfig(t *testing.T) {
+	sec := &corev1.Secret{
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{
+			"value": []byte(`
+apiVersion: v1
+kind: Config
+clusters:
+- name: prod
+  cluster:
+    server: https://prod.example.com
+contexts:
+- name: prod
+  context:
+    cluster: prod
+current-context: prod
+`),
+			"other": []byte("clusters and contexts in plain text"),
+		},
+	}
+
+	dec, err := Decode(sec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dec.Kubeconfigs) != 1 || dec.Kubeconfigs["value"].CurrentContext != "prod" {
+		t.Fatalf("expected only the valid kubeconfig, got %v", dec.Kubeconfigs)
+	}
+}
+
+func TestRedact(t *testing.T) {
+	if got := RedactValue([]byte("0123456789abcdef"), 4); got != "0123<redacted: 16 bytes>" {
+		t.Fatalf("unexpected redacted value %q", got)
+	}
+	if got := RedactValue([]byte("short"), 4); got != "<redacted: 5 bytes>" {
+		t.Fatalf("expected a short value to be fully redacted, got %q", got)
+	}
+	if got := Redact(map[string][]byte{"password": []byte("s3cret")}); got["password"] != "<redacted: 6 bytes>" {
+		t.Fatalf("unexpected redacted data %v", got)
+	}
+
+	jwt := token(`{"sub":"x"}`)
+	if got := RedactToken(jwt); got[len(got)-len(".<redacted>"):] != ".<redacted>" {
+		t.Fatalf("expected the signature to be redacted, got %q", got)
+	}
+}
+
+func TestParseTokenMalformed(t *testing.T) {
+	for _, tok := range []string{"", "a.b", "a.!!!.c", token("not json")} {
+		if _, err := ParseToken(tok); err == nil {
+			t.Errorf("expected an error for %q", tok)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package selector
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// Builder assembles a label selector one requirement at a time.
+//
+// Every requirement is validated when added; the first error is kept
+// and returned by Build and Selector, so calls can be chained freely:
+//
+//	sel, err := selector.New().Eq("app", "web").In("tier", "fe", "be").NotExists("legacy").Build()
+type Builder struct {
+	reqs labels.Requirements
+	err  error
+}
+
+// New returns an empty label selector builder.
+func New() *Builder {
+	return &Builder{}
+}
+
+// Eq requires the label key to be equal to value.
+func (b *Builder) Eq(key, value string) *Builder {
+	return b.add(key, selection.Equals, value)
+}
+
+// NotEq requires the label key to be different from value (or missing).
+func (b *Builder) NotEq(key, value string) *Builder {
+	return b.add(key, selection.NotEquals, value)
+}
+
+// In requires the label key to be one of values.
+func (b *Builder) In(key string, values ...string) *Builder {
+	return b.add(key, selection.In, values...)
+}
+
+// NotIn requires the label key to be none of values (or missing).
+func (b *Builder) NotIn(key string, values ...string) *Builder {
+	return b.add(key, selection.NotIn, values...)
+}
+
+// Exists requires the label key to be present.
+func (b *Builder) Exists(key string) *Builder {
+	return b.add(key, selection.Exists)
+}
+
+// NotExists requires the label key to be absent.
+func (b *Builder) NotExists(key string) *Builder {
+	return b.add(key, selection.DoesNotExist)
+}
+
+// Set adds an equality requirement for each key/value of the set.
+func (b *Builder) Set(set map[string]string) *Builder {
+	for _, k := range sets.StringKeySet(set).List() {
+		b.Eq(k, set[k])
+	}
+	return b
+}
+
+func (b *Builder) add(key string, op selection.Operator, values ...string) *Builder {
+	if b.err != nil {
+		return b
+	}
+
+	req, err := labels.NewRequirement(key, op, values)
+	if err != nil {
+		b.err = fmt.Errorf("invalid label requirement on %q: %w", key, err)
+		return b
+	}
+	b.reqs = append(b.reqs, *req)
+	return b
+}
+
+// Selector returns the labels.Selector or the first validation error.
+func (b *Builder) Selector() (labels.Selector, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	return labels.NewSelector().Add(b.reqs...), nil
+}
+
+// Build returns the selector string (as accepted by the LabelSelector
+// field of get, logs and events options) or the first validation error.
+func (b *Builder) Build() (string, error) {
+	sel, err := b.Selector()
+	if err != nil {
+		return "", err
+	}
+	return sel.String(), nil
+}
+
+// MustBuild is like Build but panics on validation errors.
+// It is intended for selectors built from constant values.
+func (b *Builder) MustBuild() string {
+	s, err := b.Build()
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// FieldBuilder assembles a field selector one term at a time.
+type FieldBuilder struct {
+	terms []fields.Selector
+	err   error
+}
+
+// NewFields returns an empty field selector builder.
+func NewFields() *FieldBuilder {
+	return &FieldBuilder{}
+}
+
+// Eq requires the field to be equal to value.
+func (b *FieldBuilder) Eq(field, value string) *FieldBuilder {
+	if b.err != nil {
+		return b
+	}
+	if len(field) == 0 {
+		b.err = fmt.Errorf("invalid field requirement: empty field name")
+		return b
+	}
+	b.terms = append(b.terms, fields.OneTermEqualSelector(field, value))
+	return b
+}
+
+// NotEq requires the field to be different from value.
+func (b *FieldBuilder) NotEq(field, value string) *FieldBuilder {
+	if b.err != nil {
+		return b
+	}
+	if len(field) == 0 {
+		b.err = fmt.Errorf("invalid field requirement: empty field name")
+		return b
+	}
+	b.terms = append(b.terms, fields.OneTermNotEqualSelector(field, value))
+	return b
+}
+
+// Selector returns the fields.Selector or the first validation error.
+func (b *FieldBuilder) Selector() (fields.Selector, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	if len(b.terms) == 0 {
+		return fields.Everything(), nil
+	}
+	return fields.AndSelectors(b.terms...), nil
+}
+
+// Build returns the field selector string or the first validation error.
+func (b *FieldBuilder) Build() (string, error) {
+	sel, err := b.Selector()
+	if err != nil {
+		return "", err
+	}
+	return sel.String(), nil
+}
+
+// MustBuild is like Build but panics on validation errors.
+func (b *FieldBuilder) MustBuild() string {
+	s, err := b.Build()
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
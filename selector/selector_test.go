@@ -0,0 +1,45 @@
+package selector
+
+import (
+	"testing"
+)
+
+func TestBuilder(t *testing.T) {
+	got, err := New().Eq("app", "web").In("tier", "fe", "be").NotExists("legacy").Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "app=web,!legacy,tier in (be,fe)"
+	if got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
+
+func TestBuilderInvalid(t *testing.T) {
+	_, err := New().Eq("app", "web").Eq("bad key!", "x").Exists("other").Build()
+	if err == nil {
+		t.Fatal("expected validation error")
+	}
+
+	_, err = New().In("tier").Build()
+	if err == nil {
+		t.Fatal("expected error for empty In values")
+	}
+}
+
+func TestFieldBuilder(t *testing.T) {
+	got, err := NewFields().Eq("spec.nodeName", "node-1").NotEq("status.phase", "Succeeded").Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "spec.nodeName=node-1,status.phase!=Succeeded"
+	if got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+
+	if _, err := NewFields().Eq("", "x").Build(); err == nil {
+		t.Fatal("expected error for empty field name")
+	}
+}
@@ -0,0 +1,163 @@
+package serviceaccount
+
+import (
+	"context"
+	"encoding/json"
+
+	kubeutil "github.com/lucasepe/kube/util"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/util/retry"
+)
+
+// AttachImagePullSecrets adds the named secrets to the imagePullSecrets of the
+// ServiceAccount. Secrets already attached are left untouched.
+func AttachImagePullSecrets(ctx context.Context, f kubeutil.Factory, namespace, name string, secrets ...string) (*corev1.ServiceAccount, error) {
+	return update(ctx, f, namespace, name, attachImagePullSecrets(secrets))
+}
+
+func attachImagePullSecrets(secrets []string) func(*corev1.ServiceAccount) bool {
+	return func(sa *corev1.ServiceAccount) bool {
+		names := make([]string, 0, len(sa.ImagePullSecrets))
+		for _, ref := range sa.ImagePullSecrets {
+			names = append(names, ref.Name)
+		}
+		all, changed := add(names, secrets)
+		if changed {
+			sa.ImagePullSecrets = make([]corev1.LocalObjectReference, 0, len(all))
+			for _, n := range all {
+				sa.ImagePullSecrets = append(sa.ImagePullSecrets, corev1.LocalObjectReference{Name: n})
+			}
+		}
+		return changed
+	}
+}
+
+// DetachImagePullSecrets removes the named secrets from the imagePullSecrets of the ServiceAccount.
+func DetachImagePullSecrets(ctx context.Context, f kubeutil.Factory, namespace, name string, secrets ...string) (*corev1.ServiceAccount, error) {
+	return update(ctx, f, namespace, name, detachImagePullSecrets(secrets))
+}
+
+func detachImagePullSecrets(secrets []string) func(*corev1.ServiceAccount) bool {
+	return func(sa *corev1.ServiceAccount) bool {
+		res := []corev1.LocalObjectReference{}
+		for _, ref := range sa.ImagePullSecrets {
+			if !contains(secrets, ref.Name) {
+				res = append(res, ref)
+			}
+		}
+		changed := len(res) != len(sa.ImagePullSecrets)
+		sa.ImagePullSecrets = res
+		return changed
+	}
+}
+
+// AttachSecrets adds the named secrets to the mountable secrets of the ServiceAccount.
+func AttachSecrets(ctx context.Context, f kubeutil.Factory, namespace, name string, secrets ...string) (*corev1.ServiceAccount, error) {
+	return update(ctx, f, namespace, name, attachSecrets(secrets))
+}
+
+func attachSecrets(secrets []string) func(*corev1.ServiceAccount) bool {
+	return func(sa *corev1.ServiceAccount) bool {
+		names := make([]string, 0, len(sa.Secrets))
+		for _, ref := range sa.Secrets {
+			names = append(names, ref.Name)
+		}
+		all, changed := add(names, secrets)
+		for _, n := range all[len(names):] {
+			sa.Secrets = append(sa.Secrets, corev1.ObjectReference{Name: n})
+		}
+		return changed
+	}
+}
+
+// DetachSecrets removes the named secrets from the mountable secrets of the ServiceAccount.
+func DetachSecrets(ctx context.Context, f kubeutil.Factory, namespace, name string, secrets ...string) (*corev1.ServiceAccount, error) {
+	return update(ctx, f, namespace, name, detachSecrets(secrets))
+}
+
+func detachSecrets(secrets []string) func(*corev1.ServiceAccount) bool {
+	return func(sa *corev1.ServiceAccount) bool {
+		res := []corev1.ObjectReference{}
+		for _, ref := range sa.Secrets {
+			if !contains(secrets, ref.Name) {
+				res = append(res, ref)
+			}
+		}
+		changed := len(res) != len(sa.Secrets)
+		sa.Secrets = res
+		return changed
+	}
+}
+
+// update resolves the namespace and patches the ServiceAccount.
+func update(ctx context.Context, f kubeutil.Factory, namespace, name string, mutate func(*corev1.ServiceAccount) bool) (*corev1.ServiceAccount, error) {
+	cli, err := f.KubernetesClientSet()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(namespace) == 0 {
+		namespace, _, err = f.ToRawKubeConfigLoader().Namespace()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return patch(ctx, cli.CoreV1().ServiceAccounts(namespace), name, mutate)
+}
+
+// patch applies the mutation to the ServiceAccount and, if something changed,
+// sends a merge patch guarded by the resourceVersion retrying on conflicts.
+func patch(ctx context.Context, sas corev1client.ServiceAccountInterface, name string, mutate func(*corev1.ServiceAccount) bool) (*corev1.ServiceAccount, error) {
+	var res *corev1.ServiceAccount
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		sa, err := sas.Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+
+		if !mutate(sa) {
+			res = sa
+			return nil
+		}
+
+		data, err := json.Marshal(map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"resourceVersion": sa.ResourceVersion,
+			},
+			"imagePullSecrets": sa.ImagePullSecrets,
+			"secrets":          sa.Secrets,
+		})
+		if err != nil {
+			return err
+		}
+
+		res, err = sas.Patch(ctx, name, types.MergePatchType, data, metav1.PatchOptions{})
+		return err
+	})
+
+	return res, err
+}
+
+func add(list, items []string) ([]string, bool) {
+	changed := false
+	for _, it := range items {
+		if !contains(list, it) {
+			list = append(list, it)
+			changed = true
+		}
+	}
+	return list, changed
+}
+
+func contains(list []string, s string) bool {
+	for _, it := range list {
+		if it == s {
+			return true
+		}
+	}
+	return false
+}
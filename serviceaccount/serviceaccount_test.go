@@ -0,0 +1,130 @@
+package serviceaccount
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+func serviceAccount() *corev1.ServiceAccount {
+	return &corev1.ServiceAccount{
+		ObjectMeta:       metav1.ObjectMeta{Name: "builder", Namespace: "ci", ResourceVersion: "1"},
+		ImagePullSecrets: []corev1.LocalObjectReference{{Name: "registry"}},
+		Secrets:          []corev1.ObjectReference{{Name: "token"}},
+	}
+}
+
+func pullSecrets(sa *corev1.ServiceAccount) []string {
+	res := []string{}
+	for _, ref := range sa.ImagePullSecrets {
+		res = append(res, ref.Name)
+	}
+	return res
+}
+
+func secrets(sa *corev1.ServiceAccount) []string {
+	res := []string{}
+	for _, ref := range sa.Secrets {
+		res = append(res, ref.Name)
+	}
+	return res
+}
+
+func TestMutators(t *testing.T) {
+	tests := []struct {
+		name    string
+		mutate  func(*corev1.ServiceAccount) bool
+		changed bool
+		pull    []string
+		secrets []string
+	}{
+		{"attach image pull secrets", attachImagePullSecrets([]string{"registry", "mirror"}), true, []string{"registry", "mirror"}, []string{"token"}},
+		{"attach image pull secrets unchanged", attachImagePullSecrets([]string{"registry"}), false, []string{"registry"}, []string{"token"}},
+		{"detach image pull secrets", detachImagePullSecrets([]string{"registry", "missing"}), true, []string{}, []string{"token"}},
+		{"detach image pull secrets unchanged", detachImagePullSecrets([]string{"missing"}), false, []string{"registry"}, []string{"token"}},
+		{"attach secrets", attachSecrets([]string{"token", "ssh"}), true, []string{"registry"}, []string{"token", "ssh"}},
+		{"detach secrets", detachSecrets([]string{"token"}), true, []string{"registry"}, []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sa := serviceAccount()
+			if changed := tt.mutate(sa); changed != tt.changed {
+				t.Fatalf("expected changed=%t, got %t", tt.changed, changed)
+			}
+			if got := pullSecrets(sa); !reflect.DeepEqual(got, tt.pull) {
+				t.Fatalf("expected image pull secrets %v, got %v", tt.pull, got)
+			}
+			if got := secrets(sa); !reflect.DeepEqual(got, tt.secrets) {
+				t.Fatalf("expected secrets %v, got %v", tt.secrets, got)
+			}
+		})
+	}
+}
+
+func TestPatch(t *testing.T) {
+	cli := fake.NewSimpleClientset(serviceAccount())
+
+	sa, err := patch(context.Background(), cli.CoreV1().ServiceAccounts("ci"), "builder", attachImagePullSecrets([]string{"mirror"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := pullSecrets(sa); !reflect.DeepEqual(got, []string{"registry", "mirror"}) {
+		t.Fatalf("unexpected image pull secrets %v", got)
+	}
+	if got := secrets(sa); !reflect.DeepEqual(got, []string{"token"}) {
+		t.Fatalf("expected the secrets to be kept, got %v", got)
+	}
+}
+
+func TestPatchUnchanged(t *testing.T) {
+	cli := fake.NewSimpleClientset(serviceAccount())
+
+	if _, err := patch(context.Background(), cli.CoreV1().ServiceAccounts("ci"), "builder", attachSecrets([]string{"token"})); err != nil {
+		t.Fatal(err)
+	}
+	for _, action := range cli.Actions() {
+		if action.GetVerb() == "patch" {
+			t.Fatal("expected no patch when nothing changed")
+		}
+	}
+}
+
+func TestPatchRetryOnConflict(t *testing.T) {
+	cli := fake.NewSimpleClientset(serviceAccount())
+
+	conflicts := 1
+	cli.PrependReactor("patch", "serviceaccounts", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		if conflicts > 0 {
+			conflicts--
+			return true, nil, apierrors.NewConflict(schema.GroupResource{Resource: "serviceaccounts"}, "builder", nil)
+		}
+		return false, nil, nil
+	})
+
+	sa, err := patch(context.Background(), cli.CoreV1().ServiceAccounts("ci"), "builder", detachSecrets([]string{"token"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sa.Secrets) != 0 {
+		t.Fatalf("expected the secret to be detached, got %v", sa.Secrets)
+	}
+
+	gets := 0
+	for _, action := range cli.Actions() {
+		if action.GetVerb() == "get" {
+			gets++
+		}
+	}
+	if gets != 2 {
+		t.Fatalf("expected the service account to be read again after the conflict, got %d gets", gets)
+	}
+}
@@ -0,0 +1,272 @@
+package status
+
+import (
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// State is the overall state of an object.
+type State string
+
+const (
+	// Current means the object reached the desired state.
+	Current State = "Current"
+	// InProgress means the object is moving toward the desired state.
+	InProgress State = "InProgress"
+	// Failed means the object will not reach the desired state without changes.
+	Failed State = "Failed"
+)
+
+// Condition is a status condition, common to all the kinds.
+type Condition struct {
+	Type    string
+	Status  string
+	Reason  string
+	Message string
+}
+
+// Summary is the normalized status of a workload.
+type Summary struct {
+	Kind      string
+	Namespace string
+	Name      string
+
+	State State
+	// Message tells why the object is not Current.
+	Message string
+	// Phase is the pod phase or, for the jobs, one of
+	// Complete, Failed, Suspended and Running.
+	Phase string
+
+	// Replicas is the desired number of replicas: the scheduled pods
+	// for the daemon sets, the completions for the jobs and the
+	// containers for the pods.
+	Replicas int32
+	// ReadyReplicas are the ready replicas: the succeeded pods
+	// for the jobs, the ready containers for the pods.
+	ReadyReplicas     int32
+	UpdatedReplicas   int32
+	AvailableReplicas int32
+
+	Conditions []Condition
+}
+
+// Get returns the status of a Deployment, StatefulSet, DaemonSet,
+// Job or Pod, typed or unstructured.
+func Get(obj runtime.Object) (*Summary, error) {
+	if u, ok := obj.(*unstructured.Unstructured); ok {
+		typed, err := toTyped(u)
+		if err != nil {
+			return nil, err
+		}
+		obj = typed
+	}
+
+	switch t := obj.(type) {
+	case *appsv1.Deployment:
+		return deploymentStatus(t), nil
+	case *appsv1.StatefulSet:
+		return statefulSetStatus(t), nil
+	case *appsv1.DaemonSet:
+		return daemonSetStatus(t), nil
+	case *batchv1.Job:
+		return jobStatus(t), nil
+	case *corev1.Pod:
+		return podStatus(t), nil
+	}
+
+	return nil, fmt.Errorf("status is not supported for %T", obj)
+}
+
+func toTyped(u *unstructured.Unstructured) (runtime.Object, error) {
+	var obj runtime.Object
+	switch u.GroupVersionKind().GroupKind() {
+	case appsv1.SchemeGroupVersion.WithKind("Deployment").GroupKind():
+		obj = &appsv1.Deployment{}
+	case appsv1.SchemeGroupVersion.WithKind("StatefulSet").GroupKind():
+		obj = &appsv1.StatefulSet{}
+	case appsv1.SchemeGroupVersion.WithKind("DaemonSet").GroupKind():
+		obj = &appsv1.DaemonSet{}
+	case batchv1.SchemeGroupVersion.WithKind("Job").GroupKind():
+		obj = &batchv1.Job{}
+	case corev1.SchemeGroupVersion.WithKind("Pod").GroupKind():
+		obj = &corev1.Pod{}
+	default:
+		return nil, fmt.Errorf("status is not supported for %s", u.GroupVersionKind().Kind)
+	}
+
+	err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.UnstructuredContent(), obj)
+	return obj, err
+}
+
+func newSummary(kind string, meta metav1.ObjectMeta) *Summary {
+	return &Summary{
+		Kind:      kind,
+		Namespace: meta.Namespace,
+		Name:      meta.Name,
+		State:     Current,
+	}
+}
+
+// inProgress sets the state, unless already set to a failure.
+func (s *Summary) inProgress(format string, args ...interface{}) {
+	if s.State == Failed {
+		return
+	}
+	s.State, s.Message = InProgress, fmt.Sprintf(format, args...)
+}
+
+func (s *Summary) failed(format string, args ...interface{}) {
+	s.State, s.Message = Failed, fmt.Sprintf(format, args...)
+}
+
+func replicasOrOne(replicas *int32) int32 {
+	if replicas != nil {
+		return *replicas
+	}
+	return 1
+}
+
+func deploymentStatus(d *appsv1.Deployment) *Summary {
+	s := newSummary("Deployment", d.ObjectMeta)
+	s.Replicas = replicasOrOne(d.Spec.Replicas)
+	s.ReadyReplicas = d.Status.ReadyReplicas
+	s.UpdatedReplicas = d.Status.UpdatedReplicas
+	s.AvailableReplicas = d.Status.AvailableReplicas
+	for _, c := range d.Status.Conditions {
+		s.Conditions = append(s.Conditions, Condition{string(c.Type), string(c.Status), c.Reason, c.Message})
+		if c.Type == appsv1.DeploymentProgressing && c.Reason == "ProgressDeadlineExceeded" {
+			s.failed("progress deadline exceeded")
+		}
+	}
+
+	switch {
+	case d.Generation > d.Status.ObservedGeneration:
+		s.inProgress("spec update not observed yet")
+	case s.UpdatedReplicas < s.Replicas:
+		s.inProgress("%d out of %d new replicas have been updated", s.UpdatedReplicas, s.Replicas)
+	case d.Status.Replicas > s.UpdatedReplicas:
+		s.inProgress("%d old replicas are pending termination", d.Status.Replicas-s.UpdatedReplicas)
+	case s.AvailableReplicas < s.UpdatedReplicas:
+		s.inProgress("%d of %d updated replicas are available", s.AvailableReplicas, s.UpdatedReplicas)
+	}
+	return s
+}
+
+func statefulSetStatus(sts *appsv1.StatefulSet) *Summary {
+	s := newSummary("StatefulSet", sts.ObjectMeta)
+	s.Replicas = replicasOrOne(sts.Spec.Replicas)
+	s.ReadyReplicas = sts.Status.ReadyReplicas
+	s.UpdatedReplicas = sts.Status.UpdatedReplicas
+	s.AvailableReplicas = sts.Status.AvailableReplicas
+	for _, c := range sts.Status.Conditions {
+		s.Conditions = append(s.Conditions, Condition{string(c.Type), string(c.Status), c.Reason, c.Message})
+	}
+
+	partition := int32(0)
+	if ru := sts.Spec.UpdateStrategy.RollingUpdate; ru != nil && ru.Partition != nil {
+		partition = *ru.Partition
+	}
+
+	switch {
+	case sts.Status.ObservedGeneration == 0 || sts.Generation > sts.Status.ObservedGeneration:
+		s.inProgress("spec update not observed yet")
+	case s.ReadyReplicas < s.Replicas:
+		s.inProgress("%d of %d replicas are ready", s.ReadyReplicas, s.Replicas)
+	case sts.Spec.UpdateStrategy.Type != appsv1.RollingUpdateStatefulSetStrategyType:
+	case partition > 0:
+		if s.UpdatedReplicas < s.Replicas-partition {
+			s.inProgress("%d out of %d new replicas have been updated", s.UpdatedReplicas, s.Replicas-partition)
+		}
+	case sts.Status.UpdateRevision != sts.Status.CurrentRevision:
+		s.inProgress("%d replicas updated to revision %s", s.UpdatedReplicas, sts.Status.UpdateRevision)
+	}
+	return s
+}
+
+func daemonSetStatus(ds *appsv1.DaemonSet) *Summary {
+	s := newSummary("DaemonSet", ds.ObjectMeta)
+	s.Replicas = ds.Status.DesiredNumberScheduled
+	s.ReadyReplicas = ds.Status.NumberReady
+	s.UpdatedReplicas = ds.Status.UpdatedNumberScheduled
+	s.AvailableReplicas = ds.Status.NumberAvailable
+	for _, c := range ds.Status.Conditions {
+		s.Conditions = append(s.Conditions, Condition{string(c.Type), string(c.Status), c.Reason, c.Message})
+	}
+
+	switch {
+	case ds.Generation > ds.Status.ObservedGeneration:
+		s.inProgress("spec update not observed yet")
+	case s.UpdatedReplicas < s.Replicas:
+		s.inProgress("%d out of %d new pods have been updated", s.UpdatedReplicas, s.Replicas)
+	case s.AvailableReplicas < s.Replicas:
+		s.inProgress("%d of %d updated pods are available", s.AvailableReplicas, s.Replicas)
+	}
+	return s
+}
+
+func jobStatus(job *batchv1.Job) *Summary {
+	s := newSummary("Job", job.ObjectMeta)
+	s.Replicas = replicasOrOne(job.Spec.Completions)
+	s.ReadyReplicas = job.Status.Succeeded
+	if job.Status.Ready != nil {
+		s.AvailableReplicas = *job.Status.Ready
+	}
+	s.Phase = "Running"
+	for _, c := range job.Status.Conditions {
+		s.Conditions = append(s.Conditions, Condition{string(c.Type), string(c.Status), c.Reason, c.Message})
+		if c.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch c.Type {
+		case batchv1.JobComplete:
+			s.Phase = "Complete"
+		case batchv1.JobFailed:
+			s.Phase = "Failed"
+			s.failed("job failed: %s", c.Reason)
+		case batchv1.JobSuspended:
+			s.Phase = "Suspended"
+		}
+	}
+
+	if s.Phase == "Running" || s.Phase == "Suspended" {
+		s.inProgress("%d of %d completions succeeded", s.ReadyReplicas, s.Replicas)
+	}
+	return s
+}
+
+func podStatus(pod *corev1.Pod) *Summary {
+	s := newSummary("Pod", pod.ObjectMeta)
+	s.Phase = string(pod.Status.Phase)
+	s.Replicas = int32(len(pod.Spec.Containers))
+	for _, cs := range pod.Status.ContainerStatuses {
+		if cs.Ready {
+			s.ReadyReplicas++
+		}
+	}
+	s.AvailableReplicas = s.ReadyReplicas
+	ready := false
+	for _, c := range pod.Status.Conditions {
+		s.Conditions = append(s.Conditions, Condition{string(c.Type), string(c.Status), c.Reason, c.Message})
+		if c.Type == corev1.PodReady && c.Status == corev1.ConditionTrue {
+			ready = true
+		}
+	}
+
+	switch pod.Status.Phase {
+	case corev1.PodSucceeded:
+	case corev1.PodFailed:
+		s.failed("pod failed: %s", pod.Status.Reason)
+	default:
+		if !ready {
+			s.inProgress("%d of %d containers are ready", s.ReadyReplicas, s.Replicas)
+		}
+	}
+	return s
+}
@@ -0,0 +1,82 @@
+package status
+
+import (
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestDeploymentStatus(t *testing.T) {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata":   map[string]interface{}{"name": "web", "namespace": "demo", "generation": int64(2)},
+		"spec":       map[string]interface{}{"replicas": int64(3)},
+		"status": map[string]interface{}{
+			"observedGeneration": int64(2),
+			"replicas":           int64(3),
+			"updatedReplicas":    int64(3),
+			"readyReplicas":      int64(2),
+			"availableReplicas":  int64(2),
+		},
+	}}
+
+	s, err := Get(obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.State != InProgress || s.Replicas != 3 || s.ReadyReplicas != 2 {
+		t.Fatalf("unexpected status: %+v", s)
+	}
+
+	unstructured.SetNestedField(obj.Object, int64(3), "status", "availableReplicas")
+	if s, _ = Get(obj); s.State != Current {
+		t.Fatalf("expected Current, got %+v", s)
+	}
+
+	unstructured.SetNestedSlice(obj.Object, []interface{}{
+		map[string]interface{}{"type": "Progressing", "status": "False", "reason": "ProgressDeadlineExceeded"},
+	}, "status", "conditions")
+	if s, _ = Get(obj); s.State != Failed {
+		t.Fatalf("expected Failed, got %+v", s)
+	}
+}
+
+func TestJobStatus(t *testing.T) {
+	job := &batchv1.Job{}
+	job.Name = "migrate"
+	job.Status.Succeeded = 1
+	job.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue}}
+
+	s, err := Get(job)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.State != Current || s.Phase != "Complete" {
+		t.Fatalf("unexpected status: %+v", s)
+	}
+}
+
+func TestPodStatus(t *testing.T) {
+	pod := &corev1.Pod{}
+	pod.Spec.Containers = []corev1.Container{{Name: "app"}, {Name: "sidecar"}}
+	pod.Status.Phase = corev1.PodRunning
+	pod.Status.ContainerStatuses = []corev1.ContainerStatus{{Name: "app", Ready: true}, {Name: "sidecar"}}
+
+	s, err := Get(pod)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.State != InProgress || s.ReadyReplicas != 1 || s.Replicas != 2 {
+		t.Fatalf("unexpected status: %+v", s)
+	}
+}
+
+func TestUnsupportedKind(t *testing.T) {
+	if _, err := Get(&appsv1.ReplicaSet{}); err == nil {
+		t.Fatal("expected an error")
+	}
+}
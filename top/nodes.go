@@ -0,0 +1,161 @@
+package top
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	kubeutil "github.com/lucasepe/kube/util"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	metricsv1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+)
+
+// NodeSortBy is the sort order of the node metrics.
+type NodeSortBy string
+
+const (
+	// SortByName sorts by node name (the default).
+	SortByName NodeSortBy = "name"
+	// SortByCPU sorts by CPU usage, higher first.
+	SortByCPU NodeSortBy = "cpu"
+	// SortByMemory sorts by memory usage, higher first.
+	SortByMemory NodeSortBy = "memory"
+)
+
+// NodeOpts is a set of options that allows you to get the nodes metrics.
+type NodeOpts struct {
+	LabelSelector string
+	// NodeName restricts the result to a single node.
+	NodeName string
+	SortBy   NodeSortBy
+}
+
+// NodeMetrics is the resource usage of a node compared with its
+// allocatable and capacity amounts.
+type NodeMetrics struct {
+	Name      string
+	Timestamp time.Time
+	Window    time.Duration
+
+	CPU    resource.Quantity
+	Memory resource.Quantity
+
+	AllocatableCPU    resource.Quantity
+	AllocatableMemory resource.Quantity
+	CapacityCPU       resource.Quantity
+	CapacityMemory    resource.Quantity
+}
+
+// CPUPercent returns the CPU usage as a percentage of the allocatable CPU.
+func (n NodeMetrics) CPUPercent() float64 {
+	v, _ := percent(n.CPU, &n.AllocatableCPU)
+	return v
+}
+
+// MemoryPercent returns the memory usage as a percentage of the allocatable memory.
+func (n NodeMetrics) MemoryPercent() float64 {
+	v, _ := percent(n.Memory, &n.AllocatableMemory)
+	return v
+}
+
+// Nodes returns the usage of the selected nodes from the metrics.k8s.io API
+// joined with the nodes allocatable and capacity resources.
+func Nodes(f kubeutil.Factory, o NodeOpts) ([]NodeMetrics, error) {
+	return NodesContext(context.Background(), f, o)
+}
+
+// NodesContext is like Nodes but stops when the context is cancelled.
+func NodesContext(ctx context.Context, f kubeutil.Factory, o NodeOpts) ([]NodeMetrics, error) {
+	switch o.SortBy {
+	case "":
+		o.SortBy = SortByName
+	case SortByName, SortByCPU, SortByMemory:
+	default:
+		return nil, fmt.Errorf("invalid sort order %q (valid values are: name, cpu, memory)", o.SortBy)
+	}
+
+	mc, err := kubeutil.MetricsClientSet(f)
+	if err != nil {
+		return nil, err
+	}
+	cli, err := f.KubernetesClientSet()
+	if err != nil {
+		return nil, err
+	}
+
+	metrics := []metricsv1beta1.NodeMetrics{}
+	nodes := []corev1.Node{}
+	if len(o.NodeName) > 0 {
+		m, err := mc.MetricsV1beta1().NodeMetricses().Get(ctx, o.NodeName, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		metrics = append(metrics, *m)
+
+		node, err := cli.CoreV1().Nodes().Get(ctx, o.NodeName, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, *node)
+	} else {
+		list, err := mc.MetricsV1beta1().NodeMetricses().List(ctx, metav1.ListOptions{LabelSelector: o.LabelSelector})
+		if err != nil {
+			return nil, err
+		}
+		metrics = list.Items
+
+		nodeList, err := cli.CoreV1().Nodes().List(ctx, metav1.ListOptions{LabelSelector: o.LabelSelector})
+		if err != nil {
+			return nil, err
+		}
+		nodes = nodeList.Items
+	}
+
+	return joinNodeMetrics(metrics, nodes, o.SortBy), nil
+}
+
+// joinNodeMetrics joins the usage with the allocatable and
+// capacity resources of the nodes, in the given order.
+func joinNodeMetrics(metrics []metricsv1beta1.NodeMetrics, nodes []corev1.Node, sortBy NodeSortBy) []NodeMetrics {
+	byName := make(map[string]*corev1.Node, len(nodes))
+	for i := range nodes {
+		byName[nodes[i].Name] = &nodes[i]
+	}
+
+	res := make([]NodeMetrics, 0, len(metrics))
+	for _, m := range metrics {
+		nm := NodeMetrics{
+			Name:      m.Name,
+			Timestamp: m.Timestamp.Time,
+			Window:    m.Window.Duration,
+			CPU:       m.Usage.Cpu().DeepCopy(),
+			Memory:    m.Usage.Memory().DeepCopy(),
+		}
+		if node, ok := byName[m.Name]; ok {
+			nm.AllocatableCPU = node.Status.Allocatable.Cpu().DeepCopy()
+			nm.AllocatableMemory = node.Status.Allocatable.Memory().DeepCopy()
+			nm.CapacityCPU = node.Status.Capacity.Cpu().DeepCopy()
+			nm.CapacityMemory = node.Status.Capacity.Memory().DeepCopy()
+		}
+		res = append(res, nm)
+	}
+
+	sort.SliceStable(res, func(i, j int) bool {
+		switch sortBy {
+		case SortByCPU:
+			if c := res[i].CPU.Cmp(res[j].CPU); c != 0 {
+				return c > 0
+			}
+		case SortByMemory:
+			if c := res[i].Memory.Cmp(res[j].Memory); c != 0 {
+				return c > 0
+			}
+		}
+		return res[i].Name < res[j].Name
+	})
+
+	return res
+}
@@ -0,0 +1,64 @@
+package top
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	metricsv1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+)
+
+func TestJoinNodeMetrics(t *testing.T) {
+	metrics := []metricsv1beta1.NodeMetrics{
+		{ObjectMeta: metav1.ObjectMeta{Name: "node-b"}, Usage: usage("1", "1Gi")},
+		{ObjectMeta: metav1.ObjectMeta{Name: "node-a"}, Usage: usage("500m", "3Gi")},
+		{ObjectMeta: metav1.ObjectMeta{Name: "node-c"}, Usage: usage("2", "2Gi")},
+	}
+	nodes := []corev1.Node{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "node-a"},
+			Status:     corev1.NodeStatus{Allocatable: usage("2", "6Gi"), Capacity: usage("4", "8Gi")},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "node-b"},
+			Status:     corev1.NodeStatus{Allocatable: usage("4", "4Gi"), Capacity: usage("4", "4Gi")},
+		},
+	}
+
+	order := func(res []NodeMetrics) string {
+		s := ""
+		for _, nm := range res {
+			s += nm.Name[len(nm.Name)-1:]
+		}
+		return s
+	}
+
+	tests := map[NodeSortBy]string{
+		SortByName:   "abc",
+		SortByCPU:    "cba",
+		SortByMemory: "acb",
+	}
+	for sortBy, want := range tests {
+		if got := order(joinNodeMetrics(metrics, nodes, sortBy)); got != want {
+			t.Errorf("%s: expected %q, got %q", sortBy, want, got)
+		}
+	}
+
+	res := joinNodeMetrics(metrics, nodes, SortByName)
+	if res[0].CPUPercent() != 25 || res[0].MemoryPercent() != 50 {
+		t.Fatalf("expected 25%% cpu and 50%% memory, got %v and %v", res[0].CPUPercent(), res[0].MemoryPercent())
+	}
+	if res[0].CapacityCPU.String() != "4" {
+		t.Fatalf("expected the capacity, got %s", res[0].CapacityCPU.String())
+	}
+	// node-c has metrics but the node was not found
+	if res[2].CPUPercent() != 0 || !res[2].AllocatableCPU.IsZero() {
+		t.Fatalf("expected no allocatable resources, got %+v", res[2])
+	}
+}
+
+func TestNodesInvalidSort(t *testing.T) {
+	if _, err := Nodes(nil, NodeOpts{SortBy: "disk"}); err == nil {
+		t.Fatal("expected an error for an invalid sort order")
+	}
+}
@@ -0,0 +1,158 @@
+package top
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	kubeutil "github.com/lucasepe/kube/util"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	metricsv1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+)
+
+// Opts is a set of options that allows you to get the pods metrics.
+type Opts struct {
+	Namespace     string
+	AllNamespaces bool
+	LabelSelector string
+	// PodName restricts the result to a single pod.
+	PodName string
+	// WithSpecs joins the metrics with the pod specs, so that the
+	// usage can be compared with the requests and limits.
+	WithSpecs bool
+}
+
+// ContainerMetrics is the resource usage of a container.
+type ContainerMetrics struct {
+	Name string
+	Usage
+}
+
+// PodMetrics is the resource usage of a pod (the sum of its containers).
+type PodMetrics struct {
+	Namespace  string
+	Name       string
+	Timestamp  time.Time
+	Window     time.Duration
+	Containers []ContainerMetrics
+	Usage
+}
+
+// Pods returns the usage of the selected pods (and their containers)
+// from the metrics.k8s.io API, sorted by namespace and name.
+func Pods(f kubeutil.Factory, o Opts) ([]PodMetrics, error) {
+	return PodsContext(context.Background(), f, o)
+}
+
+// PodsContext is like Pods but stops when the context is cancelled.
+func PodsContext(ctx context.Context, f kubeutil.Factory, o Opts) ([]PodMetrics, error) {
+	if !o.AllNamespaces && len(o.Namespace) == 0 {
+		ns, _, err := f.ToRawKubeConfigLoader().Namespace()
+		if err != nil {
+			return nil, err
+		}
+		o.Namespace = ns
+	}
+	if o.AllNamespaces {
+		o.Namespace = metav1.NamespaceAll
+	}
+
+	mc, err := kubeutil.MetricsClientSet(f)
+	if err != nil {
+		return nil, err
+	}
+
+	metrics := []metricsv1beta1.PodMetrics{}
+	if len(o.PodName) > 0 {
+		m, err := mc.MetricsV1beta1().PodMetricses(o.Namespace).Get(ctx, o.PodName, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		metrics = append(metrics, *m)
+	} else {
+		list, err := mc.MetricsV1beta1().PodMetricses(o.Namespace).List(ctx, metav1.ListOptions{
+			LabelSelector: o.LabelSelector,
+		})
+		if err != nil {
+			return nil, err
+		}
+		metrics = list.Items
+	}
+
+	specs := map[string]*corev1.Pod{}
+	if o.WithSpecs {
+		cli, err := f.KubernetesClientSet()
+		if err != nil {
+			return nil, err
+		}
+		if specs, err = podSpecs(ctx, cli, o); err != nil {
+			return nil, err
+		}
+	}
+
+	return joinPodMetrics(metrics, specs), nil
+}
+
+// joinPodMetrics sums the usage of the containers and, for the pods
+// found in specs, their requests and limits.
+func joinPodMetrics(metrics []metricsv1beta1.PodMetrics, specs map[string]*corev1.Pod) []PodMetrics {
+	res := make([]PodMetrics, 0, len(metrics))
+	for _, m := range metrics {
+		pm := PodMetrics{
+			Namespace: m.Namespace,
+			Name:      m.Name,
+			Timestamp: m.Timestamp.Time,
+			Window:    m.Window.Duration,
+		}
+
+		pod := specs[m.Namespace+"/"+m.Name]
+		for i, c := range m.Containers {
+			cm := ContainerMetrics{Name: c.Name}
+			cm.CPU = c.Usage.Cpu().DeepCopy()
+			cm.Memory = c.Usage.Memory().DeepCopy()
+			if pod != nil {
+				if container, _ := kubeutil.FindContainerByName(pod, c.Name); container != nil {
+					cm.addResources(container.Resources, true)
+					pm.addResources(container.Resources, i == 0)
+				}
+			}
+			pm.CPU.Add(cm.CPU)
+			pm.Memory.Add(cm.Memory)
+			pm.Containers = append(pm.Containers, cm)
+		}
+		res = append(res, pm)
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Namespace != res[j].Namespace {
+			return res[i].Namespace < res[j].Namespace
+		}
+		return res[i].Name < res[j].Name
+	})
+
+	return res
+}
+
+func podSpecs(ctx context.Context, cli kubernetes.Interface, o Opts) (map[string]*corev1.Pod, error) {
+	res := map[string]*corev1.Pod{}
+	if len(o.PodName) > 0 {
+		pod, err := cli.CoreV1().Pods(o.Namespace).Get(ctx, o.PodName, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		res[pod.Namespace+"/"+pod.Name] = pod
+		return res, nil
+	}
+
+	list, err := cli.CoreV1().Pods(o.Namespace).List(ctx, metav1.ListOptions{LabelSelector: o.LabelSelector})
+	if err != nil {
+		return nil, err
+	}
+	for i := range list.Items {
+		pod := &list.Items[i]
+		res[pod.Namespace+"/"+pod.Name] = pod
+	}
+	return res, nil
+}
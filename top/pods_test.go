@@ -0,0 +1,113 @@
+package top
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	metricsv1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+)
+
+func usage(cpu, memory string) corev1.ResourceList {
+	return corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse(cpu),
+		corev1.ResourceMemory: resource.MustParse(memory),
+	}
+}
+
+func podMetrics(namespace, name string, containers ...metricsv1beta1.ContainerMetrics) metricsv1beta1.PodMetrics {
+	return metricsv1beta1.PodMetrics{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Containers: containers,
+	}
+}
+
+func TestJoinPodMetrics(t *testing.T) {
+	metrics := []metricsv1beta1.PodMetrics{
+		podMetrics("default", "web-0",
+			metricsv1beta1.ContainerMetrics{Name: "app", Usage: usage("200m", "64Mi")},
+			metricsv1beta1.ContainerMetrics{Name: "proxy", Usage: usage("50m", "16Mi")},
+		),
+		podMetrics("default", "api-0",
+			metricsv1beta1.ContainerMetrics{Name: "app", Usage: usage("100m", "32Mi")},
+		),
+	}
+	specs := map[string]*corev1.Pod{
+		"default/web-0": {
+			Spec: corev1.PodSpec{Containers: []corev1.Container{
+				{Name: "app", Resources: corev1.ResourceRequirements{
+					Requests: usage("400m", "128Mi"),
+					Limits:   corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("256Mi")},
+				}},
+				{Name: "proxy", Resources: corev1.ResourceRequirements{
+					Requests: usage("100m", "32Mi"),
+					Limits:   usage("200m", "64Mi"),
+				}},
+			}},
+		},
+	}
+
+	res := joinPodMetrics(metrics, specs)
+	if len(res) != 2 || res[0].Name != "api-0" || res[1].Name != "web-0" {
+		t.Fatalf("expected the pods sorted by name, got %+v", res)
+	}
+
+	api := res[0]
+	if api.CPU.String() != "100m" || api.CPURequest != nil {
+		t.Fatalf("expected the usage without requests, got %+v", api.Usage)
+	}
+
+	web := res[1]
+	if web.CPU.String() != "250m" || web.Memory.String() != "80Mi" {
+		t.Fatalf("expected the sum of the containers, got cpu %s memory %s", web.CPU.String(), web.Memory.String())
+	}
+	if web.CPURequest == nil || web.CPURequest.String() != "500m" {
+		t.Fatalf("expected the sum of the cpu requests, got %v", web.CPURequest)
+	}
+	if web.MemoryLimit == nil || web.MemoryLimit.String() != "320Mi" {
+		t.Fatalf("expected the sum of the memory limits, got %v", web.MemoryLimit)
+	}
+	if web.CPULimit != nil {
+		t.Fatalf("expected an unknown cpu limit as a container has none, got %v", web.CPULimit)
+	}
+	if p, ok := web.CPURequestPercent(); !ok || p != 50 {
+		t.Fatalf("expected 50%% of the cpu request, got %v %t", p, ok)
+	}
+	if _, ok := web.CPULimitPercent(); ok {
+		t.Fatal("expected no cpu limit percentage")
+	}
+
+	if len(web.Containers) != 2 {
+		t.Fatalf("expected the containers metrics, got %+v", web.Containers)
+	}
+	if p, ok := web.Containers[1].MemoryLimitPercent(); !ok || p != 25 {
+		t.Fatalf("expected 25%% of the proxy memory limit, got %v %t", p, ok)
+	}
+}
+
+func TestPodSpecs(t *testing.T) {
+	cli := fake.NewSimpleClientset(
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "web-0", Labels: map[string]string{"app": "web"}}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "api-0", Labels: map[string]string{"app": "api"}}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "other", Name: "web-1", Labels: map[string]string{"app": "web"}}},
+	)
+
+	specs, err := podSpecs(context.Background(), cli, Opts{Namespace: "default", LabelSelector: "app=web"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(specs) != 1 || specs["default/web-0"] == nil {
+		t.Fatalf("expected only default/web-0, got %v", specs)
+	}
+
+	specs, err = podSpecs(context.Background(), cli, Opts{Namespace: "default", PodName: "api-0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(specs) != 1 || specs["default/api-0"] == nil {
+		t.Fatalf("expected only default/api-0, got %v", specs)
+	}
+}
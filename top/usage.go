@@ -0,0 +1,67 @@
+package top
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// Usage is the CPU and memory usage, optionally compared
+// with the requested and the limit (or allocatable) amounts.
+type Usage struct {
+	CPU    resource.Quantity
+	Memory resource.Quantity
+
+	// The following are nil when unknown (not joined or not set).
+	CPURequest    *resource.Quantity
+	CPULimit      *resource.Quantity
+	MemoryRequest *resource.Quantity
+	MemoryLimit   *resource.Quantity
+}
+
+// CPURequestPercent returns the CPU usage as a percentage of the request.
+func (u Usage) CPURequestPercent() (float64, bool) {
+	return percent(u.CPU, u.CPURequest)
+}
+
+// CPULimitPercent returns the CPU usage as a percentage of the limit.
+func (u Usage) CPULimitPercent() (float64, bool) {
+	return percent(u.CPU, u.CPULimit)
+}
+
+// MemoryRequestPercent returns the memory usage as a percentage of the request.
+func (u Usage) MemoryRequestPercent() (float64, bool) {
+	return percent(u.Memory, u.MemoryRequest)
+}
+
+// MemoryLimitPercent returns the memory usage as a percentage of the limit.
+func (u Usage) MemoryLimitPercent() (float64, bool) {
+	return percent(u.Memory, u.MemoryLimit)
+}
+
+func percent(used resource.Quantity, of *resource.Quantity) (float64, bool) {
+	if of == nil || of.IsZero() {
+		return 0, false
+	}
+	return float64(used.MilliValue()) / float64(of.MilliValue()) * 100, true
+}
+
+// addResources sums the requests and limits of the resource requirements;
+// a missing value makes the total unknown.
+func (u *Usage) addResources(rr corev1.ResourceRequirements, first bool) {
+	add := func(dst **resource.Quantity, rl corev1.ResourceList, name corev1.ResourceName) {
+		q, ok := rl[name]
+		switch {
+		case !ok:
+			*dst = nil
+		case first:
+			c := q.DeepCopy()
+			*dst = &c
+		case *dst != nil:
+			(*dst).Add(q)
+		}
+	}
+	add(&u.CPURequest, rr.Requests, corev1.ResourceCPU)
+	add(&u.CPULimit, rr.Limits, corev1.ResourceCPU)
+	add(&u.MemoryRequest, rr.Requests, corev1.ResourceMemory)
+	add(&u.MemoryLimit, rr.Limits, corev1.ResourceMemory)
+}
@@ -0,0 +1,64 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	coreclient "k8s.io/client-go/kubernetes/typed/core/v1"
+)
+
+const (
+	// DefaultContainerAnnotationName is the pod annotation naming the
+	// container to use when none is specified.
+	DefaultContainerAnnotationName = "kubectl.kubernetes.io/default-container"
+)
+
+// AttachablePodForObject returns the pod the object refers to: the object
+// itself when it is a pod, otherwise the first pod selected by the workload
+// (see SelectorsForObject) preferring the running and ready ones.
+func AttachablePodForObject(client coreclient.PodsGetter, object runtime.Object, timeout time.Duration) (*corev1.Pod, error) {
+	return AttachablePodForObjectContext(context.Background(), client, object, timeout)
+}
+
+// AttachablePodForObjectContext is like AttachablePodForObject
+// but stops when the context is cancelled.
+func AttachablePodForObjectContext(ctx context.Context, client coreclient.PodsGetter, object runtime.Object, timeout time.Duration) (*corev1.Pod, error) {
+	if pod, ok := object.(*corev1.Pod); ok {
+		return pod, nil
+	}
+
+	namespace, selector, err := SelectorsForObject(object)
+	if err != nil {
+		return nil, fmt.Errorf("cannot attach to %T: %v", object, err)
+	}
+
+	sortBy := func(pods []*corev1.Pod) sort.Interface { return sort.Reverse(ActivePods(pods)) }
+	pod, _, err := GetFirstPodContext(ctx, client, namespace, selector.String(), timeout, sortBy)
+	return pod, err
+}
+
+// ContainerToAttach returns the named container of the pod looking also at
+// the init and ephemeral containers. If the name is empty, the container
+// named by the default-container annotation or the first one is returned.
+func ContainerToAttach(pod *corev1.Pod, name string) (*corev1.Container, error) {
+	if len(name) == 0 {
+		name = pod.Annotations[DefaultContainerAnnotationName]
+	}
+	if len(name) == 0 {
+		if len(pod.Spec.Containers) == 0 {
+			return nil, fmt.Errorf("pod %s/%s has no containers", pod.Namespace, pod.Name)
+		}
+		return &pod.Spec.Containers[0], nil
+	}
+
+	container, _ := FindContainerByName(pod, name)
+	if container == nil {
+		return nil, fmt.Errorf("container %s not found in pod %s (valid containers: %s)",
+			name, pod.Name, AllContainerNames(pod))
+	}
+	return container, nil
+}
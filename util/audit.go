@@ -0,0 +1,198 @@
+package util
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AuditRecord describes a request sent to the API server.
+type AuditRecord struct {
+	Timestamp   time.Time     `json:"timestamp"`
+	Verb        string        `json:"verb"`
+	Method      string        `json:"method"`
+	Path        string        `json:"path"`
+	Group       string        `json:"group,omitempty"`
+	Version     string        `json:"version,omitempty"`
+	Resource    string        `json:"resource,omitempty"`
+	Subresource string        `json:"subresource,omitempty"`
+	Namespace   string        `json:"namespace,omitempty"`
+	Name        string        `json:"name,omitempty"`
+	DryRun      bool          `json:"dryRun,omitempty"`
+	Status      int           `json:"status,omitempty"`
+	Latency     time.Duration `json:"latency"`
+	Error       string        `json:"error,omitempty"`
+}
+
+// AuditSink receives the audit records.
+type AuditSink interface {
+	Record(AuditRecord) error
+}
+
+// WithAuditSink records every request sent by the clients of the Factory
+// to the sink. Sink errors never fail the requests.
+func WithAuditSink(sink AuditSink) FactoryOption {
+	return WithWrapTransport(func(rt http.RoundTripper) http.RoundTripper {
+		return &auditRoundTripper{delegate: rt, sink: sink}
+	})
+}
+
+type auditRoundTripper struct {
+	delegate http.RoundTripper
+	sink     AuditSink
+}
+
+func (rt *auditRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	rec := newAuditRecord(req)
+
+	start := time.Now()
+	resp, err := rt.delegate.RoundTrip(req)
+	rec.Latency = time.Since(start)
+
+	if err != nil {
+		rec.Error = err.Error()
+	}
+	if resp != nil {
+		rec.Status = resp.StatusCode
+	}
+
+	rt.sink.Record(rec)
+
+	return resp, err
+}
+
+func (rt *auditRoundTripper) WrappedRoundTripper() http.RoundTripper {
+	return rt.delegate
+}
+
+// newAuditRecord fills the record parsing the API path:
+//
+//	/api/{version}/namespaces/{namespace}/{resource}/{name}/{subresource}
+//	/apis/{group}/{version}/namespaces/{namespace}/{resource}/{name}/{subresource}
+func newAuditRecord(req *http.Request) AuditRecord {
+	rec := AuditRecord{
+		Timestamp: time.Now(),
+		Method:    req.Method,
+		Path:      req.URL.Path,
+	}
+
+	q := req.URL.Query()
+	rec.DryRun = len(q["dryRun"]) > 0
+
+	parts := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	switch {
+	case len(parts) >= 2 && parts[0] == "api":
+		rec.Version, parts = parts[1], parts[2:]
+	case len(parts) >= 3 && parts[0] == "apis":
+		rec.Group, rec.Version, parts = parts[1], parts[2], parts[3:]
+	default:
+		// non resource URL (e.g. /version, /healthz)
+		rec.Verb = strings.ToLower(req.Method)
+		return rec
+	}
+
+	if len(parts) >= 2 && parts[0] == "namespaces" {
+		if len(parts) == 2 {
+			// the namespace object itself
+			rec.Resource, rec.Name = "namespaces", parts[1]
+			parts = nil
+		} else {
+			rec.Namespace, parts = parts[1], parts[2:]
+		}
+	}
+	if len(parts) > 0 {
+		rec.Resource = parts[0]
+	}
+	if len(parts) > 1 {
+		rec.Name = parts[1]
+	}
+	if len(parts) > 2 {
+		rec.Subresource = strings.Join(parts[2:], "/")
+	}
+
+	switch req.Method {
+	case http.MethodGet, http.MethodHead:
+		switch {
+		case q.Get("watch") == "true" || q.Get("watch") == "1":
+			rec.Verb = "watch"
+		case len(rec.Name) == 0:
+			rec.Verb = "list"
+		default:
+			rec.Verb = "get"
+		}
+	case http.MethodPost:
+		rec.Verb = "create"
+	case http.MethodPut:
+		rec.Verb = "update"
+	case http.MethodPatch:
+		rec.Verb = "patch"
+	case http.MethodDelete:
+		rec.Verb = "delete"
+		if len(rec.Name) == 0 {
+			rec.Verb = "deletecollection"
+		}
+	default:
+		rec.Verb = strings.ToLower(req.Method)
+	}
+
+	return rec
+}
+
+// MemoryAuditSink keeps the audit records in memory.
+type MemoryAuditSink struct {
+	mu      sync.Mutex
+	records []AuditRecord
+}
+
+func (s *MemoryAuditSink) Record(rec AuditRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records = append(s.records, rec)
+	return nil
+}
+
+// Records returns a copy of the collected records.
+func (s *MemoryAuditSink) Records() []AuditRecord {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]AuditRecord(nil), s.records...)
+}
+
+// JSONLAuditSink writes each audit record as a JSON line.
+type JSONLAuditSink struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+	c   io.Closer
+}
+
+// NewJSONLAuditSink returns a sink writing JSON lines to w.
+func NewJSONLAuditSink(w io.Writer) *JSONLAuditSink {
+	return &JSONLAuditSink{enc: json.NewEncoder(w)}
+}
+
+// NewJSONLAuditFileSink returns a sink appending JSON lines to the named file.
+func NewJSONLAuditFileSink(filename string) (*JSONLAuditSink, error) {
+	fp, err := os.OpenFile(filename, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return &JSONLAuditSink{enc: json.NewEncoder(fp), c: fp}, nil
+}
+
+func (s *JSONLAuditSink) Record(rec AuditRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.enc.Encode(rec)
+}
+
+// Close closes the underlying file, if any.
+func (s *JSONLAuditSink) Close() error {
+	if s.c == nil {
+		return nil
+	}
+	return s.c.Close()
+}
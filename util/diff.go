@@ -0,0 +1,133 @@
+package util
+
+import (
+	"fmt"
+	"strings"
+)
+
+// UnifiedDiff returns a line oriented diff between a and b in the unified
+// format (with 3 lines of context), or an empty string if they are equal.
+func UnifiedDiff(fromName, toName, a, b string) string {
+	if a == b {
+		return ""
+	}
+
+	x, y := splitLines(a), splitLines(b)
+	ops := diffLines(x, y)
+
+	const context = 3
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", fromName, toName)
+
+	for i := 0; i < len(ops); {
+		// skip equal lines not adjacent to a change
+		if ops[i].kind == ' ' {
+			i++
+			continue
+		}
+
+		start := i - context
+		if start < 0 {
+			start = 0
+		}
+		// extend the hunk while changes are close enough
+		end := i
+		for end < len(ops) {
+			if ops[end].kind != ' ' {
+				end++
+				continue
+			}
+			next := end
+			for next < len(ops) && ops[next].kind == ' ' {
+				next++
+			}
+			if next == len(ops) || next-end > 2*context {
+				break
+			}
+			end = next
+		}
+		stop := end + context
+		if stop > len(ops) {
+			stop = len(ops)
+		}
+
+		fromLine, fromCount, toLine, toCount := ops[start].x+1, 0, ops[start].y+1, 0
+		for _, op := range ops[start:stop] {
+			if op.kind != '+' {
+				fromCount++
+			}
+			if op.kind != '-' {
+				toCount++
+			}
+		}
+		fmt.Fprintf(&sb, "@@ -%d,%d +%d,%d @@\n", fromLine, fromCount, toLine, toCount)
+		for _, op := range ops[start:stop] {
+			sb.WriteByte(op.kind)
+			sb.WriteString(op.text)
+			sb.WriteByte('\n')
+		}
+
+		i = stop
+	}
+
+	return sb.String()
+}
+
+type diffOp struct {
+	kind byte // ' ', '-' or '+'
+	text string
+	x, y int // line indexes in the old and new text
+}
+
+// diffLines computes the edit script using the longest common subsequence.
+func diffLines(x, y []string) []diffOp {
+	n, m := len(x), len(y)
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if x[i] == y[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	ops := make([]diffOp, 0, n+m)
+	i, j := 0, 0
+	for i < n && j < m {
+		switch {
+		case x[i] == y[j]:
+			ops = append(ops, diffOp{kind: ' ', text: x[i], x: i, y: j})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, diffOp{kind: '-', text: x[i], x: i, y: j})
+			i++
+		default:
+			ops = append(ops, diffOp{kind: '+', text: y[j], x: i, y: j})
+			j++
+		}
+	}
+	for ; i < n; i++ {
+		ops = append(ops, diffOp{kind: '-', text: x[i], x: i, y: j})
+	}
+	for ; j < m; j++ {
+		ops = append(ops, diffOp{kind: '+', text: y[j], x: i, y: j})
+	}
+	return ops
+}
+
+func splitLines(s string) []string {
+	s = strings.TrimSuffix(s, "\n")
+	if len(s) == 0 {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
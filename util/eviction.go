@@ -0,0 +1,77 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	policyv1 "k8s.io/api/policy/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+)
+
+// EvictionBlockedError is returned by EvictPod when the eviction is
+// refused (HTTP 429) because it would violate a PodDisruptionBudget.
+type EvictionBlockedError struct {
+	Namespace string
+	Name      string
+	// RetryAfter is the delay suggested by the server before trying again.
+	RetryAfter time.Duration
+	Err        error
+}
+
+func (e *EvictionBlockedError) Error() string {
+	return fmt.Sprintf("cannot evict pod %s/%s as it would violate the pod's disruption budget (retry after %s): %v",
+		e.Namespace, e.Name, e.RetryAfter, e.Err)
+}
+
+func (e *EvictionBlockedError) Unwrap() error {
+	return e.Err
+}
+
+// EvictPod evicts the named pod using the policy/v1 Eviction subresource,
+// so that PodDisruptionBudgets are respected.
+//
+// A negative gracePeriod means the pod's default termination grace period is used.
+// If a PodDisruptionBudget blocks the eviction, an *EvictionBlockedError is returned.
+func EvictPod(ctx context.Context, f Factory, namespace, name string, gracePeriod int64) error {
+	cli, err := f.KubernetesClientSet()
+	if err != nil {
+		return err
+	}
+	return EvictPodWithClient(ctx, cli, namespace, name, "", gracePeriod)
+}
+
+// EvictPodWithClient is like EvictPod but uses the given client. A non
+// empty uid is set as the precondition of the eviction, so that a pod
+// recreated with the same name (e.g. by a StatefulSet) is not evicted.
+func EvictPodWithClient(ctx context.Context, cli kubernetes.Interface, namespace, name string, uid types.UID, gracePeriod int64) error {
+	deleteOptions := &metav1.DeleteOptions{}
+	if gracePeriod >= 0 {
+		deleteOptions.GracePeriodSeconds = &gracePeriod
+	}
+	if len(uid) > 0 {
+		deleteOptions.Preconditions = &metav1.Preconditions{UID: &uid}
+	}
+
+	eviction := &policyv1.Eviction{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		DeleteOptions: deleteOptions,
+	}
+
+	err := cli.PolicyV1().Evictions(namespace).Evict(ctx, eviction)
+	if apierrors.IsTooManyRequests(err) {
+		blocked := &EvictionBlockedError{Namespace: namespace, Name: name, Err: err}
+		if seconds, ok := apierrors.SuggestsClientDelay(err); ok {
+			blocked.RetryAfter = time.Duration(seconds) * time.Second
+		}
+		return blocked
+	}
+
+	return err
+}
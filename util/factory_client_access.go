@@ -3,6 +3,7 @@
 package util
 
 import (
+	"net/http"
 	"path/filepath"
 	"regexp"
 	"strings"
@@ -15,12 +16,15 @@ import (
 	"k8s.io/cli-runtime/pkg/resource"
 	"k8s.io/client-go/discovery"
 	diskcached "k8s.io/client-go/discovery/cached/disk"
+	"k8s.io/client-go/discovery/cached/memory"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/metadata"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/restmapper"
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/client-go/util/homedir"
+	metricsclientset "k8s.io/metrics/pkg/client/clientset/versioned"
 
 	"github.com/lucasepe/kube/scheme"
 )
@@ -34,14 +38,22 @@ var overlyCautiousIllegalFileCharacters = regexp.MustCompile(`[^(\w/\.)]`)
 type factoryImpl struct {
 	KubeConfig string
 	Context    string
+
+	wrappers        []func(http.RoundTripper) http.RoundTripper
+	clientConfig    clientcmd.ClientConfig
+	memoryDiscovery bool
 }
 
-func NewFactory(context, kubeconfig string) Factory {
+func NewFactory(context, kubeconfig string, opts ...FactoryOption) Factory {
 	fi := &factoryImpl{
 		KubeConfig: kubeconfig,
 		Context:    context,
 	}
 
+	for _, opt := range opts {
+		opt(fi)
+	}
+
 	return fi
 }
 
@@ -67,6 +79,10 @@ func (f *factoryImpl) ToRESTConfig() (*rest.Config, error) {
 		config.NegotiatedSerializer = scheme.Codecs.WithoutConversion()
 	}
 
+	for _, fn := range f.wrappers {
+		config.Wrap(fn)
+	}
+
 	rest.SetKubernetesDefaults(config)
 	return config, nil
 }
@@ -94,6 +110,15 @@ func (f *factoryImpl) ToDiscoveryClient() (discovery.CachedDiscoveryInterface, e
 		return nil, err
 	}
 	factory.Burst = 100
+
+	if f.memoryDiscovery {
+		discoveryClient, err := discovery.NewDiscoveryClientForConfig(factory)
+		if err != nil {
+			return nil, err
+		}
+		return memory.NewMemCacheClient(discoveryClient), nil
+	}
+
 	defaultHTTPCacheDir := filepath.Join(homedir.HomeDir(), ".kube", "http-cache")
 
 	// takes the parentDir and the host and comes up with a "usually non-colliding" name for the discoveryCacheDir
@@ -114,6 +139,10 @@ func (f *factoryImpl) ToDiscoveryClient() (discovery.CachedDiscoveryInterface, e
 // 4. Uses $HOME/.kube/factory
 // It's required to implement the interface genericclioptions.RESTClientGetter
 func (f *factoryImpl) ToRawKubeConfigLoader() clientcmd.ClientConfig {
+	if f.clientConfig != nil {
+		return f.clientConfig
+	}
+
 	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
 	loadingRules.DefaultClientConfig = &clientcmd.DefaultClientConfig
 	if len(f.KubeConfig) != 0 {
@@ -145,6 +174,26 @@ func (f *factoryImpl) DynamicClient() (dynamic.Interface, error) {
 	return dynamic.NewForConfig(clientConfig)
 }
 
+// MetadataClient returns a client that reads only the metadata of the
+// objects, built from the REST config of the factory.
+func MetadataClient(f Factory) (metadata.Interface, error) {
+	clientConfig, err := f.ToRESTConfig()
+	if err != nil {
+		return nil, err
+	}
+	return metadata.NewForConfig(clientConfig)
+}
+
+// MetricsClientSet gives you back a clientset for the metrics.k8s.io API,
+// built from the REST config of the factory.
+func MetricsClientSet(f Factory) (*metricsclientset.Clientset, error) {
+	clientConfig, err := f.ToRESTConfig()
+	if err != nil {
+		return nil, err
+	}
+	return metricsclientset.NewForConfig(clientConfig)
+}
+
 // NewBuilder returns a new resource builder for structured api objects.
 // It's required to implement the Factory interface
 func (f *factoryImpl) NewBuilder() *resource.Builder {
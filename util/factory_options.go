@@ -0,0 +1,36 @@
+package util
+
+import (
+	"net/http"
+
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// FactoryOption customizes the Factory returned by NewFactory.
+type FactoryOption func(*factoryImpl)
+
+// WithWrapTransport adds a wrapper around the HTTP transport used by
+// every client built by the Factory. Wrappers are applied in order,
+// so the last one sees the request first.
+func WithWrapTransport(fn func(http.RoundTripper) http.RoundTripper) FactoryOption {
+	return func(f *factoryImpl) {
+		f.wrappers = append(f.wrappers, fn)
+	}
+}
+
+// WithClientConfig makes the Factory use the given client config instead
+// of loading the kubeconfig from disk (the context and kubeconfig arguments
+// of NewFactory are ignored).
+func WithClientConfig(cc clientcmd.ClientConfig) FactoryOption {
+	return func(f *factoryImpl) {
+		f.clientConfig = cc
+	}
+}
+
+// WithMemoryDiscoveryCache keeps the discovery information in memory
+// instead of caching it under $HOME/.kube/cache.
+func WithMemoryDiscoveryCache() FactoryOption {
+	return func(f *factoryImpl) {
+		f.memoryDiscovery = true
+	}
+}
@@ -0,0 +1,127 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"golang.org/x/sync/errgroup"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+const (
+	defaultFanOutConcurrency = 5
+)
+
+// FactorySet holds one Factory per cluster context, so that the same
+// operation can be executed against a fleet of clusters.
+type FactorySet struct {
+	factories map[string]Factory
+}
+
+// NewFactorySet returns a FactorySet with one Factory for each context
+// found in the kubeconfig (resolved using the same rules of NewFactory).
+func NewFactorySet(kubeconfig string) (*FactorySet, error) {
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	if len(kubeconfig) != 0 {
+		loadingRules.ExplicitPath = kubeconfig
+	}
+
+	raw, err := loadingRules.Load()
+	if err != nil {
+		return nil, err
+	}
+	if len(raw.Contexts) == 0 {
+		return nil, fmt.Errorf("no contexts found in kubeconfig")
+	}
+
+	set := &FactorySet{factories: make(map[string]Factory, len(raw.Contexts))}
+	for name := range raw.Contexts {
+		set.factories[name] = NewFactory(name, kubeconfig)
+	}
+
+	return set, nil
+}
+
+// NewFactorySetFromMap returns a FactorySet using the provided factories
+// keyed by cluster name.
+func NewFactorySetFromMap(factories map[string]Factory) *FactorySet {
+	set := &FactorySet{factories: make(map[string]Factory, len(factories))}
+	for name, f := range factories {
+		set.factories[name] = f
+	}
+	return set
+}
+
+// Names returns the sorted cluster names of the set.
+func (s *FactorySet) Names() []string {
+	names := make([]string, 0, len(s.factories))
+	for name := range s.factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Get returns the Factory for the named cluster.
+func (s *FactorySet) Get(name string) (Factory, bool) {
+	f, ok := s.factories[name]
+	return f, ok
+}
+
+// Len returns the number of clusters in the set.
+func (s *FactorySet) Len() int {
+	return len(s.factories)
+}
+
+// ClusterResult is the outcome of an operation executed against a single cluster.
+type ClusterResult[T any] struct {
+	Cluster string
+	Value   T
+	Err     error
+}
+
+// FanOut executes fn against every cluster of the set running at most
+// concurrency operations at the same time (5 if concurrency <= 0).
+//
+// A failure on one cluster does not stop the others; the results are
+// returned in the same order of Names().
+func FanOut[T any](ctx context.Context, s *FactorySet, concurrency int, fn func(ctx context.Context, cluster string, f Factory) (T, error)) []ClusterResult[T] {
+	if concurrency <= 0 {
+		concurrency = defaultFanOutConcurrency
+	}
+
+	names := s.Names()
+	results := make([]ClusterResult[T], len(names))
+
+	g := new(errgroup.Group)
+	g.SetLimit(concurrency)
+	for i, name := range names {
+		i, name := i, name
+		g.Go(func() error {
+			results[i].Cluster = name
+			if err := ctx.Err(); err != nil {
+				results[i].Err = err
+				return nil
+			}
+			results[i].Value, results[i].Err = fn(ctx, name, s.factories[name])
+			return nil
+		})
+	}
+	g.Wait()
+
+	return results
+}
+
+// AggregateErrors collects the per-cluster errors in a single error,
+// prefixing each one with the cluster name. Returns nil if no operation failed.
+func AggregateErrors[T any](results []ClusterResult[T]) error {
+	errs := []error{}
+	for _, res := range results {
+		if res.Err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", res.Cluster, res.Err))
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
@@ -0,0 +1,94 @@
+package util
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	runtimeresource "k8s.io/cli-runtime/pkg/resource"
+)
+
+const (
+	// MirrorPodAnnotationKey marks the static pods mirrored by the kubelet.
+	MirrorPodAnnotationKey = "kubernetes.io/config.mirror"
+)
+
+// PodsOnNodeOpts is a set of options that allows you to list the pods scheduled on a node.
+type PodsOnNodeOpts struct {
+	// Namespace restricts the listing to a namespace; empty means all namespaces.
+	Namespace     string
+	LabelSelector string
+	ChunkSize     int64
+
+	// SkipDaemonSetPods excludes the pods controlled by a DaemonSet.
+	SkipDaemonSetPods bool
+	// SkipMirrorPods excludes the static pods mirrored by the kubelet.
+	SkipMirrorPods bool
+	// SkipTerminated excludes the pods in Succeeded or Failed phase.
+	SkipTerminated bool
+}
+
+// PodsOnNode returns the pods bound to the named node using
+// the spec.nodeName field selector and chunked list requests.
+func PodsOnNode(ctx context.Context, f Factory, nodeName string, o PodsOnNodeOpts) ([]corev1.Pod, error) {
+	if o.ChunkSize <= 0 {
+		o.ChunkSize = DefaultChunkSize
+	}
+
+	cli, err := f.KubernetesClientSet()
+	if err != nil {
+		return nil, err
+	}
+
+	listOptions := metav1.ListOptions{
+		Limit:         o.ChunkSize,
+		LabelSelector: o.LabelSelector,
+		FieldSelector: fields.OneTermEqualSelector("spec.nodeName", nodeName).String(),
+	}
+
+	pods := []corev1.Pod{}
+	err = runtimeresource.FollowContinue(&listOptions,
+		func(options metav1.ListOptions) (runtime.Object, error) {
+			list, err := cli.CoreV1().Pods(o.Namespace).List(ctx, options)
+			if err != nil {
+				return nil, runtimeresource.EnhanceListError(err, options, "pods")
+			}
+			for _, pod := range list.Items {
+				if o.SkipDaemonSetPods && IsDaemonSetPod(&pod) {
+					continue
+				}
+				if o.SkipMirrorPods && IsMirrorPod(&pod) {
+					continue
+				}
+				if o.SkipTerminated && IsPodTerminated(&pod) {
+					continue
+				}
+				pods = append(pods, pod)
+			}
+			return list, nil
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	return pods, nil
+}
+
+// IsMirrorPod returns true if the pod is the API mirror of a kubelet static pod.
+func IsMirrorPod(pod *corev1.Pod) bool {
+	_, ok := pod.Annotations[MirrorPodAnnotationKey]
+	return ok
+}
+
+// IsDaemonSetPod returns true if the pod is controlled by a DaemonSet.
+func IsDaemonSetPod(pod *corev1.Pod) bool {
+	ref := metav1.GetControllerOf(pod)
+	return ref != nil && ref.Kind == "DaemonSet"
+}
+
+// IsPodTerminated returns true if the pod has completed, successfully or not.
+func IsPodTerminated(pod *corev1.Pod) bool {
+	return pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed
+}
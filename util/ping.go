@@ -0,0 +1,138 @@
+package util
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http/httptrace"
+	"net/url"
+	"sync"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/version"
+)
+
+// PingErrorKind classifies the reason a Ping failed.
+type PingErrorKind string
+
+const (
+	// PingOK means both endpoints answered successfully.
+	PingOK PingErrorKind = ""
+	// PingAuthError means the server answered but rejected the credentials (401/403).
+	PingAuthError PingErrorKind = "Auth"
+	// PingNetworkError means the server could not be reached (DNS, dial, TLS, timeout).
+	PingNetworkError PingErrorKind = "Network"
+	// PingServerError means the server answered with an unexpected error.
+	PingServerError PingErrorKind = "Server"
+)
+
+// PingResult is the outcome of probing the API server.
+type PingResult struct {
+	// Host is the API server URL that was probed.
+	Host string
+	// Ready is true when /readyz answered "ok".
+	Ready bool
+	// Version is the server version reported by /version, if reachable.
+	Version *version.Info
+	// Latency is the round-trip time of the /version request.
+	Latency time.Duration
+	// TLSHandshake is the time spent in the TLS handshake; zero when
+	// the connection was reused or the server is plain HTTP.
+	TLSHandshake time.Duration
+	// ErrorKind classifies Err.
+	ErrorKind PingErrorKind
+	// Err is the first error encountered, if any.
+	Err error
+}
+
+// OK returns true if the API server is reachable, ready and accepted the credentials.
+func (r PingResult) OK() bool {
+	return r.Err == nil && r.Ready
+}
+
+// Ping checks the /readyz and /version endpoints of the API server
+// measuring round-trip latency and TLS handshake time.
+//
+// Errors are never returned directly; they are reported in the result
+// together with their classification, so the caller can tell apart
+// credentials problems from connectivity problems.
+func Ping(ctx context.Context, f Factory) PingResult {
+	res := PingResult{}
+
+	cfg, err := f.ToRESTConfig()
+	if err != nil {
+		res.Err, res.ErrorKind = err, PingServerError
+		return res
+	}
+	res.Host = cfg.Host
+
+	client, err := f.RESTClient()
+	if err != nil {
+		res.Err, res.ErrorKind = err, PingServerError
+		return res
+	}
+
+	var (
+		mu             sync.Mutex
+		handshakeStart time.Time
+	)
+	trace := &httptrace.ClientTrace{
+		TLSHandshakeStart: func() {
+			mu.Lock()
+			handshakeStart = time.Now()
+			mu.Unlock()
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			mu.Lock()
+			if !handshakeStart.IsZero() {
+				res.TLSHandshake += time.Since(handshakeStart)
+			}
+			mu.Unlock()
+		},
+	}
+	tctx := httptrace.WithClientTrace(ctx, trace)
+
+	start := time.Now()
+	body, err := client.Get().AbsPath("/version").Do(tctx).Raw()
+	res.Latency = time.Since(start)
+	if err != nil {
+		res.Err, res.ErrorKind = err, classifyPingError(err)
+		return res
+	}
+
+	var info version.Info
+	if err := json.Unmarshal(body, &info); err != nil {
+		res.Err, res.ErrorKind = err, PingServerError
+		return res
+	}
+	res.Version = &info
+
+	body, err = client.Get().AbsPath("/readyz").Do(tctx).Raw()
+	if err != nil {
+		res.Err, res.ErrorKind = err, classifyPingError(err)
+		return res
+	}
+	res.Ready = string(body) == "ok"
+
+	return res
+}
+
+func classifyPingError(err error) PingErrorKind {
+	if apierrors.IsUnauthorized(err) || apierrors.IsForbidden(err) {
+		return PingAuthError
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return PingNetworkError
+	}
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return PingNetworkError
+	}
+
+	return PingServerError
+}
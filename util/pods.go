@@ -18,9 +18,14 @@ import (
 // GetFirstPod returns a pod matching the namespace and label selector
 // and the number of all pods that match the label selector.
 func GetFirstPod(client coreclient.PodsGetter, namespace string, selector string, timeout time.Duration, sortBy func([]*corev1.Pod) sort.Interface) (*corev1.Pod, int, error) {
+	return GetFirstPodContext(context.Background(), client, namespace, selector, timeout, sortBy)
+}
+
+// GetFirstPodContext is like GetFirstPod but stops when the context is cancelled.
+func GetFirstPodContext(ctx context.Context, client coreclient.PodsGetter, namespace string, selector string, timeout time.Duration, sortBy func([]*corev1.Pod) sort.Interface) (*corev1.Pod, int, error) {
 	options := metav1.ListOptions{LabelSelector: selector}
 
-	podList, err := client.Pods(namespace).List(context.TODO(), options)
+	podList, err := client.Pods(namespace).List(ctx, options)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -36,7 +41,7 @@ func GetFirstPod(client coreclient.PodsGetter, namespace string, selector string
 
 	// Watch until we observe a pod
 	options.ResourceVersion = podList.ResourceVersion
-	w, err := client.Pods(namespace).Watch(context.TODO(), options)
+	w, err := client.Pods(namespace).Watch(ctx, options)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -46,7 +51,7 @@ func GetFirstPod(client coreclient.PodsGetter, namespace string, selector string
 		return event.Type == watch.Added || event.Type == watch.Modified, nil
 	}
 
-	ctx, cancel := watchtools.ContextWithOptionalTimeout(context.Background(), timeout)
+	ctx, cancel := watchtools.ContextWithOptionalTimeout(ctx, timeout)
 	defer cancel()
 	event, err := watchtools.UntilWithoutRetry(ctx, w, condition)
 	if err != nil {
@@ -59,6 +64,16 @@ func GetFirstPod(client coreclient.PodsGetter, namespace string, selector string
 	return pod, 1, nil
 }
 
+// GetFirstPodBy is like GetFirstPod but picks the pod using the named sort strategy
+// (an empty strategy means SortByLogging).
+func GetFirstPodBy(client coreclient.PodsGetter, namespace string, selector string, timeout time.Duration, strategy PodSortStrategy) (*corev1.Pod, int, error) {
+	sortBy, err := SortFuncFor(strategy)
+	if err != nil {
+		return nil, 0, err
+	}
+	return GetFirstPod(client, namespace, selector, timeout, sortBy)
+}
+
 func AllContainerNames(pod *corev1.Pod) string {
 	var containers []string
 	for _, container := range pod.Spec.Containers {
@@ -192,45 +207,18 @@ func (s ByLogging) Less(i, j int) bool {
 }
 
 // ActivePods type allows custom sorting of pods so a controller can pick the best ones to delete.
+// The order is defined by ActivePodsComparators.
 type ActivePods []*corev1.Pod
 
 func (s ActivePods) Len() int      { return len(s) }
 func (s ActivePods) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
 
 func (s ActivePods) Less(i, j int) bool {
-	// 1. Unassigned < assigned
-	// If only one of the pods is unassigned, the unassigned one is smaller
-	if s[i].Spec.NodeName != s[j].Spec.NodeName && (len(s[i].Spec.NodeName) == 0 || len(s[j].Spec.NodeName) == 0) {
-		return len(s[i].Spec.NodeName) == 0
-	}
-	// 2. PodPending < PodUnknown < PodRunning
-	m := map[corev1.PodPhase]int{corev1.PodPending: 0, corev1.PodUnknown: 1, corev1.PodRunning: 2}
-	if m[s[i].Status.Phase] != m[s[j].Status.Phase] {
-		return m[s[i].Status.Phase] < m[s[j].Status.Phase]
-	}
-	// 3. Not ready < ready
-	// If only one of the pods is not ready, the not ready one is smaller
-	if IsPodReady(s[i]) != IsPodReady(s[j]) {
-		return !IsPodReady(s[i])
-	}
-	// TODO: take availability into account when we push minReadySeconds information from deployment into pods,
-	//       see https://github.com/kubernetes/kubernetes/issues/22065
-	// 4. Been ready for empty time < less time < more time
-	// If both pods are ready, the latest ready one is smaller
-	if IsPodReady(s[i]) && IsPodReady(s[j]) && !podReadyTime(s[i]).Equal(podReadyTime(s[j])) {
-		return afterOrZero(podReadyTime(s[i]), podReadyTime(s[j]))
-	}
-	// 5. Pods with containers with higher restart counts < lower restart counts
-	if maxContainerRestarts(s[i]) != maxContainerRestarts(s[j]) {
-		return maxContainerRestarts(s[i]) > maxContainerRestarts(s[j])
-	}
-	// 6. Empty creation time pods < newer pods < older pods
-	if !s[i].CreationTimestamp.Equal(&s[j].CreationTimestamp) {
-		return afterOrZero(&s[i].CreationTimestamp, &s[j].CreationTimestamp)
-	}
-	return false
+	return comparePods(activePodsComparators, s[i], s[j]) < 0
 }
 
+var activePodsComparators = ActivePodsComparators()
+
 // afterOrZero checks if time t1 is after time t2; if one of them
 // is zero, the zero time is seen as after non-zero time.
 func afterOrZero(t1, t2 *metav1.Time) bool {
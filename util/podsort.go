@@ -0,0 +1,242 @@
+package util
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// PodDeletionCostAnnotation is the annotation controllers use to
+	// prefer which pods to remove first on scale down.
+	PodDeletionCostAnnotation = "controller.kubernetes.io/pod-deletion-cost"
+)
+
+// PodCompareFunc compares two pods returning a negative number if p1
+// sorts before p2, a positive number if p2 sorts before p1 and zero
+// if the comparator cannot tell them apart.
+type PodCompareFunc func(p1, p2 *corev1.Pod) int
+
+// PodSorter sorts pods applying a chain of comparators:
+// the first comparator that tells two pods apart decides their order.
+type PodSorter struct {
+	Pods []*corev1.Pod
+	By   []PodCompareFunc
+}
+
+func (s PodSorter) Len() int      { return len(s.Pods) }
+func (s PodSorter) Swap(i, j int) { s.Pods[i], s.Pods[j] = s.Pods[j], s.Pods[i] }
+
+func (s PodSorter) Less(i, j int) bool {
+	return comparePods(s.By, s.Pods[i], s.Pods[j]) < 0
+}
+
+func comparePods(chain []PodCompareFunc, p1, p2 *corev1.Pod) int {
+	for _, cmp := range chain {
+		if res := cmp(p1, p2); res != 0 {
+			return res
+		}
+	}
+	return 0
+}
+
+// ActivePodsComparators returns the comparator chain used by ActivePods,
+// which mirrors the order controllers use to pick the pods to delete first:
+//
+//  1. unassigned < assigned
+//  2. PodPending < PodUnknown < PodRunning
+//  3. not ready < ready
+//  4. lower priority < higher priority
+//  5. lower pod-deletion-cost < higher pod-deletion-cost
+//  6. been ready for empty time < less time < more time
+//  7. higher restart counts < lower restart counts
+//  8. empty creation time < newer < older
+//
+// The returned slice is a copy, callers can reorder or extend it.
+func ActivePodsComparators() []PodCompareFunc {
+	return []PodCompareFunc{
+		CompareUnassignedFirst,
+		ComparePhaseNotRunningFirst,
+		CompareNotReadyFirst,
+		CompareLowerPriorityFirst,
+		CompareLowerDeletionCostFirst,
+		CompareReadyMoreRecentlyFirst,
+		CompareMoreRestartsFirst,
+		CompareNewerFirst,
+	}
+}
+
+// CompareUnassignedFirst sorts the pods not yet bound to a node first.
+func CompareUnassignedFirst(p1, p2 *corev1.Pod) int {
+	if p1.Spec.NodeName != p2.Spec.NodeName && (len(p1.Spec.NodeName) == 0 || len(p2.Spec.NodeName) == 0) {
+		if len(p1.Spec.NodeName) == 0 {
+			return -1
+		}
+		return 1
+	}
+	return 0
+}
+
+// ComparePhaseNotRunningFirst sorts PodPending < PodUnknown < PodRunning.
+func ComparePhaseNotRunningFirst(p1, p2 *corev1.Pod) int {
+	m := map[corev1.PodPhase]int{corev1.PodPending: 0, corev1.PodUnknown: 1, corev1.PodRunning: 2}
+	return m[p1.Status.Phase] - m[p2.Status.Phase]
+}
+
+// CompareNotReadyFirst sorts the not ready pods first.
+func CompareNotReadyFirst(p1, p2 *corev1.Pod) int {
+	r1, r2 := IsPodReady(p1), IsPodReady(p2)
+	if r1 == r2 {
+		return 0
+	}
+	if !r1 {
+		return -1
+	}
+	return 1
+}
+
+// CompareLowerPriorityFirst sorts the pods with a lower priority first.
+func CompareLowerPriorityFirst(p1, p2 *corev1.Pod) int {
+	return compareInt32(podPriority(p1), podPriority(p2))
+}
+
+// CompareLowerDeletionCostFirst sorts the pods with a lower
+// controller.kubernetes.io/pod-deletion-cost annotation first.
+func CompareLowerDeletionCostFirst(p1, p2 *corev1.Pod) int {
+	return compareInt32(PodDeletionCost(p1), PodDeletionCost(p2))
+}
+
+// CompareReadyMoreRecentlyFirst sorts, among ready pods, the ones
+// that have been ready for less time first.
+func CompareReadyMoreRecentlyFirst(p1, p2 *corev1.Pod) int {
+	if !IsPodReady(p1) || !IsPodReady(p2) || podReadyTime(p1).Equal(podReadyTime(p2)) {
+		return 0
+	}
+	if afterOrZero(podReadyTime(p1), podReadyTime(p2)) {
+		return -1
+	}
+	return 1
+}
+
+// CompareMoreRestartsFirst sorts the pods with higher container restart counts first.
+func CompareMoreRestartsFirst(p1, p2 *corev1.Pod) int {
+	return maxContainerRestarts(p2) - maxContainerRestarts(p1)
+}
+
+// CompareNewerFirst sorts empty creation time < newer pods < older pods.
+func CompareNewerFirst(p1, p2 *corev1.Pod) int {
+	if p1.CreationTimestamp.Equal(&p2.CreationTimestamp) {
+		return 0
+	}
+	if afterOrZero(&p1.CreationTimestamp, &p2.CreationTimestamp) {
+		return -1
+	}
+	return 1
+}
+
+// PodDeletionCost returns the value of the pod-deletion-cost
+// annotation, or zero if missing or invalid.
+func PodDeletionCost(pod *corev1.Pod) int32 {
+	v, ok := pod.Annotations[PodDeletionCostAnnotation]
+	if !ok {
+		return 0
+	}
+	cost, err := strconv.ParseInt(v, 10, 32)
+	if err != nil {
+		return 0
+	}
+	return int32(cost)
+}
+
+func podPriority(pod *corev1.Pod) int32 {
+	if pod.Spec.Priority == nil {
+		return 0
+	}
+	return *pod.Spec.Priority
+}
+
+func compareInt32(a, b int32) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// ByRestarts sorts pods by their highest container restart count, fewest restarts first.
+type ByRestarts []*corev1.Pod
+
+func (s ByRestarts) Len() int      { return len(s) }
+func (s ByRestarts) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s ByRestarts) Less(i, j int) bool {
+	return maxContainerRestarts(s[i]) < maxContainerRestarts(s[j])
+}
+
+// ByAge sorts pods by creation time, oldest first.
+type ByAge []*corev1.Pod
+
+func (s ByAge) Len() int      { return len(s) }
+func (s ByAge) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s ByAge) Less(i, j int) bool {
+	return CompareNewerFirst(s[j], s[i]) < 0
+}
+
+// ByName sorts pods by namespace and name.
+type ByName []*corev1.Pod
+
+func (s ByName) Len() int      { return len(s) }
+func (s ByName) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s ByName) Less(i, j int) bool {
+	if s[i].Namespace != s[j].Namespace {
+		return s[i].Namespace < s[j].Namespace
+	}
+	return s[i].Name < s[j].Name
+}
+
+// ByReadySince sorts ready pods first, the ones ready for the longest time first.
+type ByReadySince []*corev1.Pod
+
+func (s ByReadySince) Len() int      { return len(s) }
+func (s ByReadySince) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s ByReadySince) Less(i, j int) bool {
+	return comparePods([]PodCompareFunc{
+		func(p1, p2 *corev1.Pod) int { return CompareNotReadyFirst(p2, p1) },
+		func(p1, p2 *corev1.Pod) int { return CompareReadyMoreRecentlyFirst(p2, p1) },
+	}, s[i], s[j]) < 0
+}
+
+// PodSortStrategy names one of the ready-made pod orderings.
+type PodSortStrategy string
+
+const (
+	SortByLogging    PodSortStrategy = "logging"
+	SortByActive     PodSortStrategy = "active"
+	SortByRestarts   PodSortStrategy = "restarts"
+	SortByAge        PodSortStrategy = "age"
+	SortByName       PodSortStrategy = "name"
+	SortByReadySince PodSortStrategy = "ready-since"
+)
+
+// SortFuncFor returns the sort function for the named strategy,
+// in the form accepted by GetFirstPod.
+func SortFuncFor(strategy PodSortStrategy) (func([]*corev1.Pod) sort.Interface, error) {
+	switch strategy {
+	case SortByLogging, "":
+		return func(pods []*corev1.Pod) sort.Interface { return ByLogging(pods) }, nil
+	case SortByActive:
+		return func(pods []*corev1.Pod) sort.Interface { return ActivePods(pods) }, nil
+	case SortByRestarts:
+		return func(pods []*corev1.Pod) sort.Interface { return ByRestarts(pods) }, nil
+	case SortByAge:
+		return func(pods []*corev1.Pod) sort.Interface { return ByAge(pods) }, nil
+	case SortByName:
+		return func(pods []*corev1.Pod) sort.Interface { return ByName(pods) }, nil
+	case SortByReadySince:
+		return func(pods []*corev1.Pod) sort.Interface { return ByReadySince(pods) }, nil
+	}
+	return nil, fmt.Errorf("unknown pod sort strategy %q", strategy)
+}
@@ -0,0 +1,36 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// RawRequest performs a request to an arbitrary path of the API server
+// (as kubectl get --raw does) and returns the response body. The path
+// may include a query string (e.g. "/readyz?verbose"); body is sent as
+// is and can be nil. Non 2xx responses are returned as API errors.
+func RawRequest(ctx context.Context, f Factory, method, path string, body io.Reader) ([]byte, error) {
+	method = strings.ToUpper(method)
+	switch method {
+	case http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete:
+	default:
+		return nil, fmt.Errorf("unsupported method %q", method)
+	}
+	if !strings.HasPrefix(path, "/") {
+		return nil, fmt.Errorf("the path must be absolute (e.g. /apis/metrics.k8s.io/v1beta1)")
+	}
+
+	client, err := f.RESTClient()
+	if err != nil {
+		return nil, err
+	}
+
+	req := client.Verb(method).RequestURI(path)
+	if body != nil {
+		req = req.Body(body)
+	}
+	return req.Do(ctx).Raw()
+}
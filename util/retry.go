@@ -0,0 +1,129 @@
+package util
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// RetryPolicy tells which failed requests are retried and how long to wait.
+type RetryPolicy struct {
+	// MaxRetries is the max number of retries of a request (default 5).
+	MaxRetries int
+	// InitialBackoff is the wait before the first retry, doubled at
+	// every attempt up to MaxBackoff (default 500ms and 30s). The
+	// Retry-After header sent by the server takes precedence.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// RetryNonIdempotent retries also the POST and PATCH requests failed
+	// with a 5xx status; by default only the 429 responses are retried
+	// for them, since the server did not process the request.
+	RetryNonIdempotent bool
+}
+
+// DefaultRetryPolicy is the policy used by WithRetry when none is given.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxRetries:     5,
+	InitialBackoff: 500 * time.Millisecond,
+	MaxBackoff:     30 * time.Second,
+}
+
+// WithRetry retries the requests throttled by the API server (429) or
+// failed with a 500, 502, 503 or 504 status, with exponential backoff.
+func WithRetry(policy RetryPolicy) FactoryOption {
+	if policy.MaxRetries <= 0 {
+		policy.MaxRetries = DefaultRetryPolicy.MaxRetries
+	}
+	if policy.InitialBackoff <= 0 {
+		policy.InitialBackoff = DefaultRetryPolicy.InitialBackoff
+	}
+	if policy.MaxBackoff <= 0 {
+		policy.MaxBackoff = DefaultRetryPolicy.MaxBackoff
+	}
+
+	return WithWrapTransport(func(rt http.RoundTripper) http.RoundTripper {
+		return &retryRoundTripper{delegate: rt, policy: policy}
+	})
+}
+
+type retryRoundTripper struct {
+	delegate http.RoundTripper
+	policy   RetryPolicy
+}
+
+func (rt *retryRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	backoff := rt.policy.InitialBackoff
+	for attempt := 0; ; attempt++ {
+		resp, err := rt.delegate.RoundTrip(req)
+		if err != nil || attempt >= rt.policy.MaxRetries || !rt.retriable(req, resp) {
+			return resp, err
+		}
+
+		// the body must be sent again
+		if req.Body != nil {
+			if req.GetBody == nil {
+				return resp, err
+			}
+			body, berr := req.GetBody()
+			if berr != nil {
+				return resp, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+
+		delay := retryAfter(resp)
+		if delay <= 0 {
+			delay = wait.Jitter(backoff, 0.2)
+			if backoff *= 2; backoff > rt.policy.MaxBackoff {
+				backoff = rt.policy.MaxBackoff
+			}
+		}
+
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+
+		t := time.NewTimer(delay)
+		select {
+		case <-req.Context().Done():
+			t.Stop()
+			return nil, req.Context().Err()
+		case <-t.C:
+		}
+	}
+}
+
+func (rt *retryRoundTripper) WrappedRoundTripper() http.RoundTripper {
+	return rt.delegate
+}
+
+func (rt *retryRoundTripper) retriable(req *http.Request, resp *http.Response) bool {
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests:
+		return true
+	case http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		if rt.policy.RetryNonIdempotent {
+			return true
+		}
+		switch req.Method {
+		case http.MethodPost, http.MethodPatch:
+			return false
+		}
+		return true
+	}
+	return false
+}
+
+// retryAfter returns the wait asked by the server with the
+// Retry-After header (in seconds), or zero if missing.
+func retryAfter(resp *http.Response) time.Duration {
+	secs, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || secs <= 0 {
+		return 0
+	}
+	return time.Duration(secs) * time.Second
+}
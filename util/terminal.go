@@ -0,0 +1,86 @@
+package util
+
+import (
+	"context"
+	"io"
+	"os"
+	"time"
+
+	"golang.org/x/term"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+const (
+	terminalResizePollInterval = 250 * time.Millisecond
+)
+
+// RawTerminal puts the terminal behind in in raw mode, returning the
+// function that restores its previous state. If in is not a terminal,
+// nothing is done and the returned function is a no-op.
+func RawTerminal(in io.Reader) (restore func(), err error) {
+	fp, ok := in.(*os.File)
+	if !ok || !term.IsTerminal(int(fp.Fd())) {
+		return func() {}, nil
+	}
+
+	state, err := term.MakeRaw(int(fp.Fd()))
+	if err != nil {
+		return nil, err
+	}
+	return func() { term.Restore(int(fp.Fd()), state) }, nil
+}
+
+// NewTerminalSizeQueue returns a queue that notifies the size changes of the
+// terminal behind out (polling it, so that it works on every platform) until
+// the context is done. Returns nil if out is not a terminal.
+func NewTerminalSizeQueue(ctx context.Context, out io.Writer) remotecommand.TerminalSizeQueue {
+	fp, ok := out.(*os.File)
+	if !ok || !term.IsTerminal(int(fp.Fd())) {
+		return nil
+	}
+
+	q := &terminalSizeQueue{ch: make(chan remotecommand.TerminalSize, 1)}
+	go q.monitor(ctx, int(fp.Fd()))
+	return q
+}
+
+type terminalSizeQueue struct {
+	ch chan remotecommand.TerminalSize
+}
+
+// Next returns the new terminal size or nil when the queue is stopped.
+func (q *terminalSizeQueue) Next() *remotecommand.TerminalSize {
+	size, ok := <-q.ch
+	if !ok {
+		return nil
+	}
+	return &size
+}
+
+func (q *terminalSizeQueue) monitor(ctx context.Context, fd int) {
+	defer close(q.ch)
+
+	ticker := time.NewTicker(terminalResizePollInterval)
+	defer ticker.Stop()
+
+	last := remotecommand.TerminalSize{}
+	for {
+		if w, h, err := term.GetSize(fd); err == nil {
+			size := remotecommand.TerminalSize{Width: uint16(w), Height: uint16(h)}
+			if size != last {
+				last = size
+				select {
+				case q.ch <- size:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package util
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// resourceTypeRegexp matches a lowercased resource type optionally qualified
+// with version and group (e.g. "pods", "deployments.apps", "jobs.v1.batch").
+var resourceTypeRegexp = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`)
+
+// ValidationError describes an invalid user input before any API call is made.
+type ValidationError struct {
+	// Input is the value being validated.
+	Input string
+	// Position is the byte offset of the error in a selector, or the
+	// index of the offending argument for resource arguments.
+	Position int
+	// Message describes the problem.
+	Message string
+	// Suggestion is an optional hint to fix the input.
+	Suggestion string
+}
+
+func (e *ValidationError) Error() string {
+	msg := fmt.Sprintf("%s (at position %d in %q)", e.Message, e.Position, e.Input)
+	if len(e.Suggestion) > 0 {
+		msg = fmt.Sprintf("%s: %s", msg, e.Suggestion)
+	}
+	return msg
+}
+
+// ValidateLabelSelector parses the label selector returning a *ValidationError
+// that points to the offending requirement.
+func ValidateLabelSelector(s string) error {
+	if _, err := labels.Parse(s); err == nil {
+		return nil
+	}
+
+	for _, term := range splitSelectorTerms(s) {
+		if _, err := labels.Parse(term.text); err != nil {
+			return &ValidationError{
+				Input:      s,
+				Position:   term.offset,
+				Message:    fmt.Sprintf("invalid requirement %q: %v", strings.TrimSpace(term.text), err),
+				Suggestion: suggestSelectorFix(term.text),
+			}
+		}
+	}
+
+	// The single terms are valid but the whole selector is not
+	// (e.g. unbalanced parentheses spanning multiple terms).
+	_, err := labels.Parse(s)
+	return &ValidationError{
+		Input:      s,
+		Position:   0,
+		Message:    err.Error(),
+		Suggestion: suggestSelectorFix(s),
+	}
+}
+
+type selectorTerm struct {
+	text   string
+	offset int
+}
+
+// splitSelectorTerms splits the selector on the commas that
+// are not enclosed in parentheses, keeping track of the offsets.
+func splitSelectorTerms(s string) []selectorTerm {
+	terms := []selectorTerm{}
+	depth, start := 0, 0
+	for i, c := range s {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				terms = append(terms, selectorTerm{text: s[start:i], offset: start})
+				start = i + 1
+			}
+		}
+	}
+	terms = append(terms, selectorTerm{text: s[start:], offset: start})
+	return terms
+}
+
+func suggestSelectorFix(term string) string {
+	t := strings.TrimSpace(term)
+	switch {
+	case len(t) == 0:
+		return "remove the empty requirement (check for duplicated or trailing commas)"
+	case strings.Count(t, "(") != strings.Count(t, ")"):
+		return "check that parentheses are balanced"
+	case strings.Contains(t, ":") && !strings.ContainsAny(t, "=!"):
+		return fmt.Sprintf("use '=' instead of ':' (e.g. %q)", strings.Replace(t, ":", "=", 1))
+	case strings.HasSuffix(t, "=") || strings.HasSuffix(t, "!="):
+		return "an equality requirement needs a value (use 'key' or '!key' to test for existence)"
+	}
+
+	fields := strings.Fields(t)
+	if len(fields) >= 2 {
+		op := strings.ToLower(fields[1])
+		if (op == "in" || op == "notin") && !strings.Contains(t, "(") {
+			return fmt.Sprintf("set values must be enclosed in parentheses (e.g. \"%s %s (%s)\")",
+				fields[0], op, strings.Join(fields[2:], ","))
+		}
+	}
+
+	key := t
+	if idx := strings.IndexAny(t, "=!<> "); idx > 0 {
+		key = t[:idx]
+	}
+	key = strings.TrimPrefix(key, "!")
+	if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+		return fmt.Sprintf("label key %q is not valid: %s", key, strings.Join(errs, "; "))
+	}
+
+	return ""
+}
+
+// ValidateResourceArgs checks that the arguments follow one of the forms
+// accepted by the resource builder ("type", "type1,type2", "type name1 name2",
+// "type/name type/name"), returning a *ValidationError for the first bad argument.
+func ValidateResourceArgs(args []string) error {
+	if len(args) == 0 {
+		return &ValidationError{
+			Message:    "you must specify the type of resource",
+			Suggestion: "use the apiresources package to list the available resource types",
+		}
+	}
+
+	input := strings.Join(args, " ")
+	slashForm := strings.Contains(args[0], "/")
+	for i, arg := range args {
+		if strings.Contains(arg, "/") != slashForm {
+			msg := "cannot mix 'type/name' and 'type name' forms"
+			hint := fmt.Sprintf("use %q for every argument", args[0][:strings.Index(args[0], "/")+1]+"<name>")
+			if !slashForm {
+				hint = "pass either a resource type followed by names or only 'type/name' arguments"
+			}
+			return &ValidationError{Input: input, Position: i, Message: msg, Suggestion: hint}
+		}
+
+		if slashForm {
+			parts := strings.SplitN(arg, "/", 2)
+			if err := validateResourceType(parts[0]); err != nil {
+				return &ValidationError{Input: input, Position: i, Message: err.Error(), Suggestion: suggestResourceType(parts[0])}
+			}
+			if len(parts[1]) == 0 {
+				return &ValidationError{Input: input, Position: i,
+					Message:    fmt.Sprintf("missing resource name in %q", arg),
+					Suggestion: fmt.Sprintf("use %q to select all the resources of that type", parts[0])}
+			}
+			if strings.Contains(parts[1], "/") {
+				return &ValidationError{Input: input, Position: i,
+					Message: fmt.Sprintf("resource name %q cannot contain '/'", parts[1])}
+			}
+			continue
+		}
+
+		if i == 0 {
+			for _, typ := range strings.Split(arg, ",") {
+				if err := validateResourceType(typ); err != nil {
+					return &ValidationError{Input: input, Position: i, Message: err.Error(), Suggestion: suggestResourceType(typ)}
+				}
+			}
+			continue
+		}
+
+		if strings.Contains(args[0], ",") {
+			return &ValidationError{Input: input, Position: i,
+				Message:    "resource names cannot be used with multiple resource types",
+				Suggestion: "use the 'type/name' form for each object instead"}
+		}
+		if len(arg) == 0 {
+			return &ValidationError{Input: input, Position: i, Message: "resource name cannot be empty"}
+		}
+	}
+
+	return nil
+}
+
+func validateResourceType(typ string) error {
+	if len(typ) == 0 {
+		return fmt.Errorf("resource type cannot be empty")
+	}
+	// kinds are accepted too (e.g. "Pod", "Deployment.apps")
+	if !resourceTypeRegexp.MatchString(strings.ToLower(typ)) {
+		return fmt.Errorf("invalid resource type %q", typ)
+	}
+	return nil
+}
+
+func suggestResourceType(typ string) string {
+	if len(typ) == 0 {
+		return "check for duplicated or trailing commas and slashes"
+	}
+	return "resource types may contain only letters, digits, '-' and '.'"
+}
@@ -0,0 +1,25 @@
+package util
+
+import "testing"
+
+func TestValidateResourceArgs(t *testing.T) {
+	tests := []struct {
+		args []string
+		ok   bool
+	}{
+		{[]string{"pods"}, true},
+		{[]string{"Pod"}, true},
+		{[]string{"Deployment.apps/web"}, true},
+		{[]string{"deployments.apps,services", "web"}, false},
+		{[]string{"pods", "web", "db"}, true},
+		{[]string{"pods/web", "db"}, false},
+		{[]string{"pods/"}, false},
+		{[]string{"pods,,services"}, false},
+		{[]string{"pod_s"}, false},
+	}
+	for _, tt := range tests {
+		if err := ValidateResourceArgs(tt.args); (err == nil) != tt.ok {
+			t.Errorf("%v: unexpected validation result: %v", tt.args, err)
+		}
+	}
+}
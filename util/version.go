@@ -0,0 +1,79 @@
+package util
+
+import (
+	"fmt"
+
+	utilversion "k8s.io/apimachinery/pkg/util/version"
+	"k8s.io/apimachinery/pkg/version"
+)
+
+// MaxSupportedMinorSkew is the max number of minor versions a client
+// may differ from the API server (the kubectl version skew policy).
+const MaxSupportedMinorSkew = 1
+
+// VersionSkew is the difference between the client and the server versions.
+type VersionSkew struct {
+	Client *utilversion.Version
+	Server *utilversion.Version
+	// Minors is the client minor version minus the server minor
+	// version (positive if the client is newer).
+	Minors int
+	// Supported is false if the major versions differ or the
+	// minor versions differ by more than MaxSupportedMinorSkew.
+	Supported bool
+}
+
+// Warning returns a kubectl style warning if the skew is not supported,
+// otherwise an empty string.
+func (s *VersionSkew) Warning() string {
+	if s.Supported {
+		return ""
+	}
+	return fmt.Sprintf("version difference between client (%d.%d) and server (%d.%d) exceeds the supported minor version skew of +/-%d",
+		s.Client.Major(), s.Client.Minor(), s.Server.Major(), s.Server.Minor(), MaxSupportedMinorSkew)
+}
+
+// ServerVersion returns the version of the API server.
+func ServerVersion(f Factory) (*version.Info, error) {
+	dc, err := f.ToDiscoveryClient()
+	if err != nil {
+		return nil, err
+	}
+	return dc.ServerVersion()
+}
+
+// CheckSkew compares the client version (e.g. "v1.25.4") with the
+// version of the API server.
+func CheckSkew(f Factory, clientVersion string) (*VersionSkew, error) {
+	info, err := ServerVersion(f)
+	if err != nil {
+		return nil, err
+	}
+	return ComputeSkew(clientVersion, info.GitVersion)
+}
+
+// ComputeSkew compares the client and the server versions; vendor
+// suffixes (e.g. "v1.25.4-gke.100") are ignored.
+func ComputeSkew(clientVersion, serverVersion string) (*VersionSkew, error) {
+	client, err := utilversion.ParseGeneric(clientVersion)
+	if err != nil {
+		return nil, fmt.Errorf("invalid client version %q: %w", clientVersion, err)
+	}
+	server, err := utilversion.ParseGeneric(serverVersion)
+	if err != nil {
+		return nil, fmt.Errorf("invalid server version %q: %w", serverVersion, err)
+	}
+
+	res := &VersionSkew{
+		Client: client,
+		Server: server,
+		Minors: int(client.Minor()) - int(server.Minor()),
+	}
+	abs := res.Minors
+	if abs < 0 {
+		abs = -abs
+	}
+	res.Supported = client.Major() == server.Major() && abs <= MaxSupportedMinorSkew
+
+	return res, nil
+}
@@ -0,0 +1,99 @@
+package wait
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/util/jsonpath"
+)
+
+// Condition checks if an object satisfies a condition.
+type Condition func(obj *unstructured.Unstructured) (bool, error)
+
+// ParseCondition parses a condition in the kubectl wait --for syntax:
+//
+//	delete
+//	condition=Ready (or condition=Ready=False)
+//	jsonpath={.status.phase}=Running (or jsonpath={.status.readyReplicas} for any value)
+//
+// A nil Condition is returned for delete.
+func ParseCondition(s string) (Condition, error) {
+	switch {
+	case strings.EqualFold(s, "delete"):
+		return nil, nil
+
+	case strings.HasPrefix(s, "condition="):
+		spec := strings.TrimPrefix(s, "condition=")
+		name, value := spec, "True"
+		if i := strings.Index(spec, "="); i >= 0 {
+			name, value = spec[:i], spec[i+1:]
+		}
+		if len(name) == 0 {
+			return nil, fmt.Errorf("condition name cannot be empty in %q", s)
+		}
+		return ConditionStatus(name, value), nil
+
+	case strings.HasPrefix(s, "jsonpath="):
+		spec := strings.TrimPrefix(s, "jsonpath=")
+		expr, value, hasValue := spec, "", false
+		if i := strings.LastIndex(spec, "}="); i >= 0 {
+			expr, value, hasValue = spec[:i+1], spec[i+2:], true
+		}
+		return JSONPath(expr, value, hasValue)
+	}
+
+	return nil, fmt.Errorf("unrecognized condition %q (use delete, condition=<name>[=<value>] or jsonpath=<expr>[=<value>])", s)
+}
+
+// ConditionStatus is met when the object has the named condition (case insensitive)
+// with the given status. Conditions observed for an older generation are ignored.
+func ConditionStatus(name, status string) Condition {
+	return func(obj *unstructured.Unstructured) (bool, error) {
+		conditions, _, err := unstructured.NestedSlice(obj.Object, "status", "conditions")
+		if err != nil {
+			return false, err
+		}
+		for _, c := range conditions {
+			cm, ok := c.(map[string]interface{})
+			if !ok || !strings.EqualFold(fmt.Sprint(cm["type"]), name) {
+				continue
+			}
+			if og, ok := cm["observedGeneration"].(int64); ok && og < obj.GetGeneration() {
+				return false, nil
+			}
+			return strings.EqualFold(fmt.Sprint(cm["status"]), status), nil
+		}
+		return false, nil
+	}
+}
+
+// JSONPath is met when the expression evaluates to the value or, if
+// hasValue is false, when it evaluates to anything.
+func JSONPath(expr, value string, hasValue bool) (Condition, error) {
+	if !strings.HasPrefix(expr, "{") {
+		expr = "{" + expr + "}"
+	}
+
+	jp := jsonpath.New("wait").AllowMissingKeys(true)
+	if err := jp.Parse(expr); err != nil {
+		return nil, fmt.Errorf("invalid jsonpath expression %q: %w", expr, err)
+	}
+
+	return func(obj *unstructured.Unstructured) (bool, error) {
+		results, err := jp.FindResults(obj.Object)
+		if err != nil {
+			return false, err
+		}
+		if len(results) == 0 || len(results[0]) == 0 {
+			return false, nil
+		}
+		if len(results) > 1 || len(results[0]) > 1 {
+			return false, fmt.Errorf("jsonpath %s must return a single value", expr)
+		}
+		if !hasValue {
+			return true, nil
+		}
+		return fmt.Sprint(results[0][0].Interface()) == value, nil
+	}, nil
+}
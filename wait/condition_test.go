@@ -0,0 +1,96 @@
+package wait
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func deployment(generation int64, status map[string]interface{}) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata": map[string]interface{}{
+			"name":       "web",
+			"namespace":  "default",
+			"generation": generation,
+		},
+		"status": status,
+	}}
+	return obj
+}
+
+func available(status string, observedGeneration int64) map[string]interface{} {
+	return map[string]interface{}{
+		"readyReplicas": int64(3),
+		"conditions": []interface{}{
+			map[string]interface{}{"type": "Progressing", "status": "True"},
+			map[string]interface{}{"type": "Available", "status": status, "observedGeneration": observedGeneration},
+		},
+	}
+}
+
+func TestParseCondition(t *testing.T) {
+	tests := []struct {
+		spec string
+		obj  *unstructured.Unstructured
+		met  bool
+	}{
+		{"condition=Available", deployment(1, available("True", 1)), true},
+		{"condition=available", deployment(1, available("True", 1)), true},
+		{"condition=Available=False", deployment(1, available("True", 1)), false},
+		{"condition=Available=false", deployment(1, available("False", 1)), true},
+		{"condition=Available", deployment(2, available("True", 1)), false},
+		{"condition=Missing", deployment(1, available("True", 1)), false},
+		{"jsonpath={.status.readyReplicas}=3", deployment(1, available("True", 1)), true},
+		{"jsonpath={.status.readyReplicas}=2", deployment(1, available("True", 1)), false},
+		{"jsonpath=.status.readyReplicas", deployment(1, available("True", 1)), true},
+		{"jsonpath={.status.replicas}", deployment(1, available("True", 1)), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			cond, err := ParseCondition(tt.spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			met, err := cond(tt.obj)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if met != tt.met {
+				t.Fatalf("expected %t, got %t", tt.met, met)
+			}
+		})
+	}
+}
+
+func TestParseConditionDelete(t *testing.T) {
+	for _, spec := range []string{"delete", "Delete"} {
+		cond, err := ParseCondition(spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cond != nil {
+			t.Fatalf("expected a nil condition for %q", spec)
+		}
+	}
+}
+
+func TestParseConditionInvalid(t *testing.T) {
+	for _, spec := range []string{"", "ready", "condition=", "condition==True", "jsonpath={.status[}"} {
+		if _, err := ParseCondition(spec); err == nil {
+			t.Errorf("expected an error for %q", spec)
+		}
+	}
+}
+
+func TestJSONPathMultipleValues(t *testing.T) {
+	cond, err := JSONPath("{.status.conditions[*].type}", "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cond(deployment(1, available("True", 1))); err == nil {
+		t.Fatal("expected an error for multiple values")
+	}
+}
@@ -0,0 +1,66 @@
+package wait
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	kubeutil "github.com/lucasepe/kube/util"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// DeletionTimeoutError is returned (per object) when an object still
+// exists at the end of a deletion wait. It reports what is keeping
+// the object alive, so that callers can surface an actionable error.
+type DeletionTimeoutError struct {
+	Kind      string
+	Namespace string
+	Name      string
+	// DeletionTimestamp is nil if the object was never marked for deletion.
+	DeletionTimestamp *time.Time
+	// Finalizers are the finalizers still blocking the deletion.
+	Finalizers []string
+}
+
+func (e *DeletionTimeoutError) Error() string {
+	name := e.Name
+	if len(e.Namespace) > 0 {
+		name = e.Namespace + "/" + e.Name
+	}
+
+	if e.DeletionTimestamp == nil {
+		return fmt.Sprintf("timed out waiting for the deletion of %s %s: object is not marked for deletion", e.Kind, name)
+	}
+	if len(e.Finalizers) == 0 {
+		return fmt.Sprintf("timed out waiting for the deletion of %s %s (marked for deletion at %s)",
+			e.Kind, name, e.DeletionTimestamp.Format(time.RFC3339))
+	}
+	return fmt.Sprintf("timed out waiting for the deletion of %s %s (marked for deletion at %s): blocked by finalizers [%s]",
+		e.Kind, name, e.DeletionTimestamp.Format(time.RFC3339), strings.Join(e.Finalizers, ", "))
+}
+
+// ForDeletion watches the selected objects until they disappear, the
+// timeout expires or the context is cancelled. Objects already gone
+// are reported as met. For each object still present at the end, the
+// result error is a *DeletionTimeoutError listing its pending finalizers.
+func ForDeletion(ctx context.Context, f kubeutil.Factory, o Opts) ([]Result, error) {
+	o.For = "delete"
+	return Do(ctx, f, o)
+}
+
+// deletionTimeoutError describes the last observed state of an
+// object that was not deleted in time.
+func deletionTimeoutError(kind string, obj *unstructured.Unstructured) *DeletionTimeoutError {
+	err := &DeletionTimeoutError{
+		Kind:       kind,
+		Namespace:  obj.GetNamespace(),
+		Name:       obj.GetName(),
+		Finalizers: obj.GetFinalizers(),
+	}
+	if ts := obj.GetDeletionTimestamp(); ts != nil {
+		t := ts.Time
+		err.DeletionTimestamp = &t
+	}
+	return err
+}
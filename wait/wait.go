@@ -0,0 +1,195 @@
+package wait
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	kubeutil "github.com/lucasepe/kube/util"
+	"golang.org/x/sync/errgroup"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/client-go/tools/cache"
+	watchtools "k8s.io/client-go/tools/watch"
+)
+
+const (
+	defaultTimeout     = 30 * time.Second
+	defaultConcurrency = 10
+)
+
+// Opts is a set of options that allows you to wait for conditions on resources.
+type Opts struct {
+	Resources     []string
+	LabelSelector string
+	FieldSelector string
+	AllNamespaces bool
+	Namespace     string
+
+	// For is the condition to wait for (see ParseCondition).
+	For string
+	// Timeout is the max time to wait for all the objects (default 30s).
+	Timeout time.Duration
+}
+
+// Result is the outcome of the wait for a single object.
+type Result struct {
+	Namespace string
+	Name      string
+	Kind      string
+	// Met is true if the condition was satisfied.
+	Met bool
+	Err error
+	// Object is the last observed state (nil if deleted).
+	// On deletion timeouts its finalizers are the ones still pending.
+	Object *unstructured.Unstructured
+}
+
+// Do watches the selected objects until the condition is met by all of them,
+// the timeout expires or the context is cancelled. The returned error
+// aggregates the failures of the single objects.
+func Do(ctx context.Context, f kubeutil.Factory, o Opts) ([]Result, error) {
+	if o.Timeout <= 0 {
+		o.Timeout = defaultTimeout
+	}
+	cond, err := ParseCondition(o.For)
+	if err != nil {
+		return nil, err
+	}
+	if !o.AllNamespaces && len(o.Namespace) == 0 {
+		ns, _, err := f.ToRawKubeConfigLoader().Namespace()
+		if err != nil {
+			return nil, err
+		}
+		o.Namespace = ns
+	}
+
+	r := f.NewBuilder().
+		Unstructured().
+		ContinueOnError().
+		NamespaceParam(o.Namespace).DefaultNamespace().AllNamespaces(o.AllNamespaces).
+		LabelSelectorParam(o.LabelSelector).
+		FieldSelectorParam(o.FieldSelector).
+		ResourceTypeOrNameArgs(true, o.Resources...).
+		Flatten().
+		Do()
+	if cond == nil {
+		// already deleted objects satisfy the condition
+		r.IgnoreErrors(apierrors.IsNotFound)
+	}
+	infos, err := r.Infos()
+	if err != nil {
+		return nil, err
+	}
+	if len(infos) == 0 && cond != nil {
+		return nil, fmt.Errorf("no matching resources found")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, o.Timeout)
+	defer cancel()
+
+	results := make([]Result, len(infos))
+	g := new(errgroup.Group)
+	g.SetLimit(defaultConcurrency)
+	for i, info := range infos {
+		i, info := i, info
+		g.Go(func() error {
+			results[i] = waitFor(ctx, info, cond)
+			return nil
+		})
+	}
+	g.Wait()
+
+	errs := []error{}
+	for _, res := range results {
+		if res.Err != nil {
+			errs = append(errs, res.Err)
+		}
+	}
+	return results, utilerrors.NewAggregate(errs)
+}
+
+// waitFor watches the object until the condition is met (or until
+// it is deleted if the condition is nil).
+func waitFor(ctx context.Context, info *resource.Info, cond Condition) Result {
+	res := Result{
+		Namespace: info.Namespace,
+		Name:      info.Name,
+		Kind:      info.Mapping.GroupVersionKind.Kind,
+	}
+
+	uid := ""
+	if obj, ok := info.Object.(*unstructured.Unstructured); ok {
+		res.Object = obj
+		uid = string(obj.GetUID())
+	}
+
+	helper := resource.NewHelper(info.Client, info.Mapping)
+	fieldSelector := fields.OneTermEqualSelector("metadata.name", info.Name).String()
+	lw := &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			options.FieldSelector = fieldSelector
+			return helper.List(info.Namespace, "", &options)
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			options.FieldSelector = fieldSelector
+			return helper.Watch(info.Namespace, "", &options)
+		},
+	}
+
+	check := func(obj interface{}) (bool, error) {
+		u, ok := obj.(*unstructured.Unstructured)
+		if !ok {
+			return false, nil
+		}
+		res.Object = u
+		if cond == nil {
+			return false, nil
+		}
+		return cond(u)
+	}
+
+	precondition := func(store cache.Store) (bool, error) {
+		for _, obj := range store.List() {
+			if u, ok := obj.(*unstructured.Unstructured); ok && (len(uid) == 0 || string(u.GetUID()) == uid) {
+				return check(u)
+			}
+		}
+		if cond == nil {
+			res.Object = nil
+			return true, nil
+		}
+		return false, nil
+	}
+
+	_, err := watchtools.UntilWithSync(ctx, lw, &unstructured.Unstructured{}, precondition, func(ev watch.Event) (bool, error) {
+		if ev.Type == watch.Deleted {
+			if cond == nil {
+				res.Object = nil
+				return true, nil
+			}
+			return false, fmt.Errorf("%s %s was deleted", res.Kind, info.ObjectName())
+		}
+		return check(ev.Object)
+	})
+	if err != nil {
+		if err == watchtools.ErrWatchClosed || ctx.Err() != nil {
+			if cond == nil && res.Object != nil {
+				err = deletionTimeoutError(res.Kind, res.Object)
+			} else {
+				err = fmt.Errorf("timed out waiting for the condition on %s", info.ObjectName())
+			}
+		}
+		res.Err = err
+		return res
+	}
+
+	res.Met = true
+	return res
+}
@@ -0,0 +1,171 @@
+package wait
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/client-go/rest/fake"
+)
+
+// deploymentInfo returns the info of the web deployment served by a fake
+// client: the list returns listed (if not nil) and the watch sends events.
+func deploymentInfo(t *testing.T, listed *unstructured.Unstructured, events ...string) *resource.Info {
+	t.Helper()
+
+	items := "[]"
+	if listed != nil {
+		dat, err := listed.MarshalJSON()
+		if err != nil {
+			t.Fatal(err)
+		}
+		items = "[" + string(dat) + "]"
+	}
+
+	client := &fake.RESTClient{
+		GroupVersion:         appsv1.SchemeGroupVersion,
+		NegotiatedSerializer: resource.UnstructuredPlusDefaultContentConfig().NegotiatedSerializer,
+		Client: fake.CreateHTTPClient(func(req *http.Request) (*http.Response, error) {
+			if fs := req.URL.Query().Get("fieldSelector"); fs != "metadata.name=web" {
+				t.Errorf("unexpected field selector %q", fs)
+			}
+
+			body := fmt.Sprintf(`{"apiVersion":"apps/v1","kind":"DeploymentList","metadata":{"resourceVersion":"1"},"items":%s}`, items)
+			if req.URL.Query().Get("watch") == "true" {
+				body = strings.Join(events, "\n")
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{"Content-Type": []string{runtime.ContentTypeJSON}},
+				Body:       io.NopCloser(bytes.NewReader([]byte(body))),
+			}, nil
+		}),
+	}
+
+	info := &resource.Info{
+		Client:    client,
+		Namespace: "default",
+		Name:      "web",
+		Mapping: &meta.RESTMapping{
+			Resource:         appsv1.SchemeGroupVersion.WithResource("deployments"),
+			GroupVersionKind: appsv1.SchemeGroupVersion.WithKind("Deployment"),
+			Scope:            meta.RESTScopeNamespace,
+		},
+	}
+	if listed != nil {
+		info.Object = listed
+	}
+	return info
+}
+
+func event(t *testing.T, typ string, obj *unstructured.Unstructured) string {
+	t.Helper()
+	obj.SetResourceVersion("2")
+	dat, err := obj.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fmt.Sprintf(`{"type":%q,"object":%s}`, typ, dat)
+}
+
+func TestWaitForAlreadyMet(t *testing.T) {
+	info := deploymentInfo(t, deployment(1, available("True", 1)))
+
+	res := waitFor(context.Background(), info, ConditionStatus("Available", "True"))
+	if !res.Met || res.Err != nil {
+		t.Fatalf("expected the condition to be met, got %+v", res)
+	}
+	if res.Kind != "Deployment" || res.Namespace != "default" || res.Name != "web" {
+		t.Fatalf("unexpected result %+v", res)
+	}
+}
+
+func TestWaitForEvent(t *testing.T) {
+	info := deploymentInfo(t, deployment(1, available("False", 1)),
+		event(t, "MODIFIED", deployment(1, available("True", 1))))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	res := waitFor(ctx, info, ConditionStatus("Available", "True"))
+	if !res.Met || res.Err != nil {
+		t.Fatalf("expected the condition to be met, got %+v", res)
+	}
+	if met, _ := ConditionStatus("Available", "True")(res.Object); !met {
+		t.Fatalf("expected the last observed object, got %v", res.Object)
+	}
+}
+
+func TestWaitForDeletedObject(t *testing.T) {
+	info := deploymentInfo(t, deployment(1, available("False", 1)),
+		event(t, "DELETED", deployment(1, available("False", 1))))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	res := waitFor(ctx, info, ConditionStatus("Available", "True"))
+	if res.Met || res.Err == nil || !strings.Contains(res.Err.Error(), "was deleted") {
+		t.Fatalf("expected the deletion to fail the wait, got %+v", res)
+	}
+}
+
+func TestWaitForTimeout(t *testing.T) {
+	info := deploymentInfo(t, deployment(1, available("False", 1)))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	res := waitFor(ctx, info, ConditionStatus("Available", "True"))
+	if res.Met || res.Err == nil || !strings.Contains(res.Err.Error(), "timed out") {
+		t.Fatalf("expected a timeout, got %+v", res)
+	}
+}
+
+func TestWaitForDeletion(t *testing.T) {
+	obj := deployment(1, nil)
+	info := deploymentInfo(t, obj, event(t, "DELETED", obj.DeepCopy()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	res := waitFor(ctx, info, nil)
+	if !res.Met || res.Err != nil || res.Object != nil {
+		t.Fatalf("expected the object to be deleted, got %+v", res)
+	}
+
+	res = waitFor(ctx, deploymentInfo(t, nil), nil)
+	if !res.Met || res.Err != nil {
+		t.Fatalf("expected a missing object to satisfy the deletion, got %+v", res)
+	}
+}
+
+func TestWaitForDeletionTimeout(t *testing.T) {
+	obj := deployment(1, nil)
+	obj.SetFinalizers([]string{"example.com/cleanup"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	res := waitFor(ctx, deploymentInfo(t, obj), nil)
+	var dte *DeletionTimeoutError
+	if !errors.As(res.Err, &dte) {
+		t.Fatalf("expected a *DeletionTimeoutError, got %v", res.Err)
+	}
+	if dte.DeletionTimestamp != nil || len(dte.Finalizers) != 1 {
+		t.Fatalf("unexpected deletion error %+v", dte)
+	}
+	if !strings.Contains(dte.Error(), "not marked for deletion") {
+		t.Fatalf("unexpected message %q", dte.Error())
+	}
+}